@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/supermancell/okex-buddy/internal/apispec"
+)
+
+// apispec writes the OpenAPI 3 document of the HTTP API and wshub messages.
+//
+//	go run ./cmd/apispec -out api/openapi.json
+func main() {
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	data, err := json.MarshalIndent(apispec.Document(), "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal API spec: %v", err)
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		log.Fatalf("Failed to write API spec: %v", err)
+	}
+}
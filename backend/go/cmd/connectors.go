@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/supermancell/okex-buddy/internal/config"
 	httpserver "github.com/supermancell/okex-buddy/internal/http"
@@ -9,14 +10,16 @@ import (
 	"github.com/supermancell/okex-buddy/internal/mongodb"
 	"github.com/supermancell/okex-buddy/internal/orderbook"
 	"github.com/supermancell/okex-buddy/internal/redisclient"
+	"github.com/supermancell/okex-buddy/internal/rest"
 	"github.com/supermancell/okex-buddy/internal/signal"
+	"github.com/supermancell/okex-buddy/internal/trade"
 	"github.com/supermancell/okex-buddy/internal/ws"
 )
 
 // ConnectPublicWebSocket connects to the public WebSocket endpoint
-func ConnectPublicWebSocket(cfg config.AppConfig, obManager *orderbook.Manager) *ws.PublicClient {
+func ConnectPublicWebSocket(cfg config.AppConfig, obManager *orderbook.Manager, mongoClient *mongodb.Client, tradeBatcher *trade.Batcher) *ws.PublicClient {
 	log.Printf("Public WebSocket is enabled, connecting to: %s", cfg.OKEX.PublicWSURL)
-	messageHandler := handler.NewPublicMessageHandler(obManager)
+	messageHandler := handler.NewPublicMessageHandler(obManager, tradeBatcher)
 
 	var wsClient *ws.PublicClient
 	if cfg.OKEX.UseProxy {
@@ -26,6 +29,69 @@ func ConnectPublicWebSocket(cfg config.AppConfig, obManager *orderbook.Manager)
 		log.Println("Proxy disabled, connecting directly")
 		wsClient = ws.NewPublicClient(cfg.OKEX.PublicWSURL, messageHandler)
 	}
+	wsClient.SetCompression(cfg.OKEX.PublicWSCompression)
+	wsClient.SetWriteTimeout(time.Duration(cfg.OKEX.WSWriteTimeoutMs) * time.Millisecond)
+	wsClient.SetSubscribeInterval(time.Duration(cfg.OKEX.SubscribeIntervalMs) * time.Millisecond)
+	wsClient.SetSubscribeBatchSize(cfg.OKEX.SubscribeBatchSize)
+	wsClient.SetMessageQueueSize(cfg.OKEX.PublicMessageQueueSize)
+	if cfg.OKEX.CapturePath != "" {
+		captureFile, err := ws.NewCaptureFile(cfg.OKEX.CapturePath, int64(cfg.OKEX.CaptureMaxMB)*1024*1024)
+		if err != nil {
+			log.Printf("Failed to open capture file, raw frames are not captured: %v", err)
+		} else {
+			wsClient.SetCapture(captureFile)
+			log.Printf("Capturing raw public frames to %s", cfg.OKEX.CapturePath)
+		}
+	}
+
+	if cfg.OKEX.EnableBooksL2TBT {
+		enableBooksL2TBT(cfg, wsClient, mongoClient)
+	}
+	if tradeBatcher != nil || cfg.Analysis.EnableTradePressure {
+		wsClient.SetDefaultChannels(append(wsClient.DefaultChannels(), config.TradesChannel))
+	}
+	swapChannels := wsClient.DefaultChannels()
+	futuresChannels := wsClient.DefaultChannels()
+	if cfg.OKEX.EnableFundingRate {
+		// Only perpetual swaps have a funding rate
+		swapChannels = append(swapChannels, config.FundingRateChannel)
+	}
+	if cfg.OKEX.EnableOpenInterest {
+		// Open interest only exists for derivatives
+		swapChannels = append(swapChannels, config.OpenInterestChannel)
+		futuresChannels = append(futuresChannels, config.OpenInterestChannel)
+	}
+	if cfg.OKEX.EnableMarkIndexPrice {
+		// The index-tickers arg is rewritten to the instrument's index when subscribing
+		swapChannels = append(swapChannels, config.MarkPriceChannel, config.IndexTickersChannel)
+		futuresChannels = append(futuresChannels, config.MarkPriceChannel, config.IndexTickersChannel)
+	}
+	if cfg.OKEX.EnableFundingRate || cfg.OKEX.EnableOpenInterest || cfg.OKEX.EnableMarkIndexPrice {
+		wsClient.SetInstTypeChannels("SWAP", swapChannels)
+	}
+	if cfg.OKEX.EnableOpenInterest || cfg.OKEX.EnableMarkIndexPrice {
+		wsClient.SetInstTypeChannels("FUTURES", futuresChannels)
+	}
+
+	// A lost books message or a crossed book leaves the book inconsistent; resubscribe to get a fresh snapshot
+	obManager.SetSequenceGapHandler(func(instID string) {
+		go func() {
+			log.Printf("Order book of %s dropped, resubscribing", instID)
+			obManager.ResetWindows(instID)
+			if err := wsClient.Resubscribe(instID); err != nil {
+				log.Printf("Failed to resubscribe %s: %v", instID, err)
+			}
+		}()
+	})
+
+	// Books are not continuous across a reconnect; drop them and their analysis history and
+	// wait for the new snapshots
+	wsClient.SetReconnectHandler(func(instIDs []string) {
+		obManager.MarkAwaitingSnapshot(instIDs)
+		for _, instID := range instIDs {
+			obManager.ResetWindows(instID)
+		}
+	})
 
 	if err := wsClient.Connect(); err != nil {
 		log.Printf("Failed to connect to OKEx WebSocket: %v", err)
@@ -37,6 +103,50 @@ func ConnectPublicWebSocket(cfg config.AppConfig, obManager *orderbook.Manager)
 	return wsClient
 }
 
+// NewTradeBatcher creates the trade tape batcher when trades capture is enabled and MongoDB is available
+func NewTradeBatcher(cfg config.AppConfig, mongoClient *mongodb.Client) *trade.Batcher {
+	if !cfg.OKEX.EnableTradesCapture {
+		return nil
+	}
+	if mongoClient == nil {
+		log.Println("Trades capture requires MongoDB, skipping")
+		return nil
+	}
+
+	if err := mongoClient.EnsureTradeIndexes(); err != nil {
+		log.Printf("Failed to create trades index: %v", err)
+	}
+	log.Printf("Trades capture enabled (batch size %d, flush interval %dms)", cfg.MongoDB.TradeBatchSize, cfg.MongoDB.TradeFlushIntervalMs)
+	return trade.NewBatcher(mongoClient, cfg.MongoDB.TradeBatchSize, time.Duration(cfg.MongoDB.TradeFlushIntervalMs)*time.Millisecond)
+}
+
+// enableBooksL2TBT switches the public client to books-l2-tbt, logging in with the API
+// credentials from MongoDB. Falls back to books when no credentials are available.
+func enableBooksL2TBT(cfg config.AppConfig, wsClient *ws.PublicClient, mongoClient *mongodb.Client) {
+	if mongoClient == nil {
+		log.Println("books-l2-tbt requires API credentials from MongoDB, falling back to books")
+		return
+	}
+
+	apiKey, secretKey, passphrase, err := mongoClient.GetOKExConfig()
+	if err != nil {
+		log.Printf("Failed to get OKEx config for books-l2-tbt login: %v, falling back to books", err)
+		return
+	}
+
+	httpProxyAddr := ""
+	if cfg.OKEX.UseProxy {
+		httpProxyAddr = cfg.OKEX.HTTPProxyAddr
+	}
+	wsClient.SetLogin(ws.OKExConfig{
+		APIKey:     apiKey,
+		SecretKey:  secretKey,
+		Passphrase: passphrase,
+	}, httpProxyAddr)
+	wsClient.SetDefaultChannels([]string{config.BooksL2TBTChannel, config.TickerChannel})
+	log.Println("books-l2-tbt enabled, public WebSocket will log in before subscribing")
+}
+
 // ConnectBusinessWebSocket connects to the business WebSocket endpoint
 func ConnectBusinessWebSocket(cfg config.AppConfig, mongoClient *mongodb.Client) *ws.BusinessClient {
 	log.Printf("Business WebSocket is enabled, connecting to: %s", cfg.OKEX.BusinessWSURL)
@@ -50,6 +160,9 @@ func ConnectBusinessWebSocket(cfg config.AppConfig, mongoClient *mongodb.Client)
 		log.Println("Business WebSocket proxy disabled, connecting directly")
 		businessWsClient = ws.NewBusinessClient(cfg.OKEX.BusinessWSURL, businessMessageHandler)
 	}
+	businessWsClient.SetCompression(cfg.OKEX.BusinessWSCompression)
+	businessWsClient.SetWriteTimeout(time.Duration(cfg.OKEX.WSWriteTimeoutMs) * time.Millisecond)
+	businessWsClient.SetSubscribeInterval(time.Duration(cfg.OKEX.SubscribeIntervalMs) * time.Millisecond)
 
 	log.Println("Attempting to connect to Business WebSocket...")
 	if err := businessWsClient.Connect(); err != nil {
@@ -90,12 +203,22 @@ func ConnectPrivateWebSocket(cfg config.AppConfig, mongoClient *mongodb.Client,
 	orderProcessor := signal.NewOrderProcessor(nil, mongoClient)
 	msgHandler := handler.NewPrivateMessageHandler(mongoClient, orderProcessor)
 
+	privateURL := cfg.OKEX.TradingPrivateWSURL()
+	if cfg.OKEX.DemoTrading {
+		log.Printf("Demo trading enabled, private WebSocket uses %s", privateURL)
+	}
+
 	var privateClient *ws.PrivateClient
 	if cfg.OKEX.UseProxy {
-		privateClient = ws.NewPrivateClientWithDualProxy(cfg.OKEX.PrivateWSURL, msgHandler, true, cfg.OKEX.ProxyAddr, cfg.OKEX.HTTPProxyAddr, privateConfig)
+		privateClient = ws.NewPrivateClientWithDualProxy(privateURL, msgHandler, true, cfg.OKEX.ProxyAddr, cfg.OKEX.HTTPProxyAddr, privateConfig)
 	} else {
-		privateClient = ws.NewPrivateClient(cfg.OKEX.PrivateWSURL, msgHandler, privateConfig)
+		privateClient = ws.NewPrivateClient(privateURL, msgHandler, privateConfig)
 	}
+	privateClient.SetCompression(cfg.OKEX.PrivateWSCompression)
+	privateClient.SetWriteTimeout(time.Duration(cfg.OKEX.WSWriteTimeoutMs) * time.Millisecond)
+	privateClient.SetSubscribeInterval(time.Duration(cfg.OKEX.SubscribeIntervalMs) * time.Millisecond)
+	privateClient.SetSimulatedTrading(cfg.OKEX.DemoTrading)
+	privateClient.SetTimeSyncInterval(time.Duration(cfg.OKEX.TimeSyncIntervalSec) * time.Second)
 
 	orderProcessor = signal.NewOrderProcessor(privateClient, mongoClient)
 
@@ -125,3 +248,27 @@ func ConnectPrivateWebSocket(cfg config.AppConfig, mongoClient *mongodb.Client,
 
 	return privateClient
 }
+
+// NewRESTClient creates the OKEx REST client used as an order placement fallback
+func NewRESTClient(cfg config.AppConfig, mongoClient *mongodb.Client) *rest.Client {
+	apiKey, secretKey, passphrase, err := mongoClient.GetOKExConfig()
+	if err != nil {
+		log.Printf("Failed to get OKEx config for REST client: %v", err)
+		return nil
+	}
+
+	restConfig := ws.OKExConfig{
+		APIKey:     apiKey,
+		SecretKey:  secretKey,
+		Passphrase: passphrase,
+	}
+
+	var restClient *rest.Client
+	if cfg.OKEX.UseProxy {
+		restClient = rest.NewClientWithProxy(cfg.OKEX.TradingRESTURL(), restConfig, cfg.OKEX.HTTPProxyAddr)
+	} else {
+		restClient = rest.NewClient(cfg.OKEX.TradingRESTURL(), restConfig)
+	}
+	restClient.SetSimulatedTrading(cfg.OKEX.DemoTrading)
+	return restClient
+}
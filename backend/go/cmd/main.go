@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/supermancell/okex-buddy/internal/config"
 	httpserver "github.com/supermancell/okex-buddy/internal/http"
@@ -14,36 +15,76 @@ import (
 	"github.com/supermancell/okex-buddy/internal/redisclient"
 	signalservice "github.com/supermancell/okex-buddy/internal/signal"
 	"github.com/supermancell/okex-buddy/internal/subscription"
+	"github.com/supermancell/okex-buddy/internal/trade"
 	"github.com/supermancell/okex-buddy/internal/ws"
 	"github.com/supermancell/okex-buddy/internal/wshub"
 )
 
 func main() {
 	cfg := config.LoadFromEnv()
+	config.SetColorMode(cfg.LogColor)
 	log.Println("OKEx Buddy - Combined WebSocket Client and API Server")
 	log.Printf("Config loaded: Redis=%s, OKEx WS=%s, API HTTP=%s\n", cfg.Redis.Addr, cfg.OKEX.PublicWSURL, cfg.APIHTTPAddr)
 	log.Printf("Proxy config: USE_PROXY=%v, PROXY_ADDR=%s", cfg.OKEX.UseProxy, cfg.OKEX.ProxyAddr)
 	log.Printf("WebSocket enable: PublicWS=%v, BusinessWS=%v, PrivateWS=%v", cfg.OKEX.EnablePublicWS, cfg.OKEX.EnableBusinessWS, cfg.OKEX.EnablePrivateWS)
 
-	redisClient, err := redisclient.NewClient(cfg.Redis.Addr, cfg.Redis.Password)
+	redisClient, err := redisclient.NewClientWithOptions(redisclient.Options{
+		Addr:         cfg.Redis.Addr,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		PoolSize:     cfg.Redis.PoolSize,
+		MinIdleConns: cfg.Redis.MinIdleConns,
+		DialTimeout:  time.Duration(cfg.Redis.DialTimeoutMs) * time.Millisecond,
+		ReadTimeout:  time.Duration(cfg.Redis.ReadTimeoutMs) * time.Millisecond,
+		WriteTimeout: time.Duration(cfg.Redis.WriteTimeoutMs) * time.Millisecond,
+		KeyPrefix:    cfg.Redis.KeyPrefix,
+	})
+	// store receives the analysis results; without Redis they only live in the Manager
+	var store redisclient.RedisStore = redisclient.NopStore{}
 	if err != nil {
 		log.Printf("Failed to connect to Redis: %v", err)
-		httpserver.SetRedisHealthy(false)
-		log.Fatalf("Cannot start service without Redis connection")
-	}
-	defer func() {
-		httpserver.SetRedisHealthy(false)
-		if err := redisClient.Close(); err != nil {
-			log.Printf("Failed to close Redis client: %v", err)
+		if cfg.Redis.Required {
+			httpserver.SetRedisHealthy(false)
+			log.Fatalf("Cannot start service without Redis connection")
+		}
+		// Like MongoDB when not configured, Redis stays healthy in probes while it is not used
+		redisClient = nil
+		log.Println(config.Colorf(config.Yellow, "Running without Redis (REDIS_REQUIRED=false): analyses are kept in memory only, subscribing %v", cfg.Redis.StaticTradingPairs))
+	} else {
+		store = redisClient
+		defer func() {
+			httpserver.SetRedisHealthy(false)
+			if err := redisClient.Close(); err != nil {
+				log.Printf("Failed to close Redis client: %v", err)
+			}
+		}()
+		log.Println("Connected to Redis")
+		if prefix := redisClient.KeyPrefix(); prefix != "" {
+			log.Printf("Redis key prefix: %q", prefix)
 		}
-	}()
-	log.Println("Connected to Redis")
+		redisClient.SetRetryPolicy(cfg.Redis.MaxRetries, time.Duration(cfg.Redis.RetryBaseDelayMs)*time.Millisecond)
+		redisClient.SetOpTimeout(time.Duration(cfg.Redis.OpTimeoutMs) * time.Millisecond)
+		redisClient.SetOrderBookHistorySize(cfg.Redis.OrderBookHistorySize)
+		redisClient.SetSentimentHistorySize(cfg.Redis.SentimentHistorySize)
+		httpserver.SetSentimentHistoryReader(func(ctx context.Context, instID string, from, to int64) ([]redisclient.SentimentHistoryPoint, error) {
+			return redisClient.WithContext(ctx).GetSentimentHistory(instID, from, to)
+		})
+	}
 
 	var mongoClient *mongodb.Client
 	if cfg.MongoDB.Addr != "" {
-		mongoClient, err = mongodb.NewClient(cfg.MongoDB.Addr, cfg.MongoDB.Database)
+		mongoClient, err = mongodb.NewClientWithOptions(mongodb.Options{
+			Addr:                   cfg.MongoDB.Addr,
+			Database:               cfg.MongoDB.Database,
+			ConnectAttempts:        cfg.MongoDB.ConnectAttempts,
+			RetryInterval:          time.Duration(cfg.MongoDB.ConnectRetryIntervalMs) * time.Millisecond,
+			MaxPoolSize:            uint64(max(cfg.MongoDB.MaxPoolSize, 0)),
+			ConnectTimeout:         time.Duration(cfg.MongoDB.ConnectTimeoutMs) * time.Millisecond,
+			ServerSelectionTimeout: time.Duration(cfg.MongoDB.ConnectTimeoutMs) * time.Millisecond,
+		})
 		if err != nil {
 			log.Printf("Failed to connect to MongoDB: %v", err)
+			httpserver.SetMongoHealthy(false)
 		} else {
 			defer func() {
 				if err := mongoClient.Close(); err != nil {
@@ -51,14 +92,49 @@ func main() {
 				}
 			}()
 			log.Println("Connected to MongoDB")
+			if err := mongoClient.EnsurePrivateDataIndexes(); err != nil {
+				log.Printf("Failed to create MongoDB indexes: %v", err)
+			}
+			httpserver.SetPrivateDataStore(mongoClient)
+			if err := mongoClient.EnsureCandlestickIndexes(); err != nil {
+				log.Printf("Failed to create candlesticks index: %v", err)
+			}
+			httpserver.SetCandleStore(mongoClient)
+		}
+	}
+
+	if redisClient != nil && cfg.Redis.PersistAnalysisConfig {
+		// Parameters tuned through PUT /api/config/analysis survive restarts
+		saved, found, err := redisClient.LoadAnalysisConfig(cfg.Analysis)
+		switch {
+		case err != nil:
+			log.Printf("Failed to load saved analysis config, using env values: %v", err)
+		case found && saved.Validate() != nil:
+			log.Printf("Ignoring saved analysis config: %v", saved.Validate())
+		case found:
+			cfg.Analysis = saved
+			log.Println("Loaded analysis config saved in Redis")
 		}
 	}
+	analysisSettings := config.NewAnalysisSettings(cfg.Analysis)
 
 	obManager := orderbook.NewManager()
+	configureAnalysis(obManager, cfg.Analysis)
+	obManager.SetVerifyChecksum(cfg.OKEX.VerifyBooksChecksum)
 
+	httpserver.SetAnalysisSettings(analysisSettings, func(analysis config.AnalysisConfig) error {
+		configureAnalysis(obManager, analysis)
+		if redisClient != nil && cfg.Redis.PersistAnalysisConfig {
+			return redisClient.SaveAnalysisConfig(analysis)
+		}
+		return nil
+	})
+
+	var tradeBatcher *trade.Batcher
 	var wsClient *ws.PublicClient
 	if cfg.OKEX.EnablePublicWS {
-		wsClient = ConnectPublicWebSocket(cfg, obManager)
+		tradeBatcher = NewTradeBatcher(cfg, mongoClient)
+		wsClient = ConnectPublicWebSocket(cfg, obManager, mongoClient, tradeBatcher)
 		defer func() {
 			if wsClient != nil {
 				httpserver.SetWSHealthy(false)
@@ -80,37 +156,73 @@ func main() {
 	}
 
 	var privateWsClient *ws.PrivateClient
-	if mongoClient != nil && cfg.OKEX.EnablePrivateWS {
+	var stopSignalConsumer func()
+	if mongoClient != nil && redisClient != nil && cfg.OKEX.EnablePrivateWS {
 		privateWsClient = ConnectPrivateWebSocket(cfg, mongoClient, redisClient)
 		if privateWsClient != nil {
 			defer privateWsClient.Close()
+		}
 
-			if cfg.OKEX.EnablePrivateWS {
-				signalservice.StartSignalConsumer(redisClient, mongoClient, privateWsClient)
-			}
+		// Orders fall back to REST while the private socket is down or unauthenticated
+		restClient := NewRESTClient(cfg, mongoClient)
+		if privateWsClient != nil || restClient != nil {
+			stopSignalConsumer = signalservice.StartSignalConsumer(redisClient, mongoClient, privateWsClient, restClient)
+		} else {
+			log.Println("Trading signal consumer skipped because no OKEx credentials are available")
 		}
+	} else if mongoClient != nil && cfg.OKEX.EnablePrivateWS {
+		log.Println("Private WebSocket skipped because trading signals need Redis")
 	} else if mongoClient != nil {
 		log.Println("Private WebSocket is disabled, skipping connection")
 	}
 
-	hub := wshub.NewHub()
+	hub := wshub.NewHubWithOptions(wshub.HubOptions{
+		ReadBufferSize:   cfg.WSHub.ReadBufferSize,
+		WriteBufferSize:  cfg.WSHub.WriteBufferSize,
+		HandshakeTimeout: time.Duration(cfg.WSHub.HandshakeTimeoutMs) * time.Millisecond,
+	})
 	go hub.Run()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if redisClient != nil {
+		redisClient.StartHealthPinger(ctx, time.Duration(cfg.Redis.HealthCheckIntervalSec)*time.Second, httpserver.SetRedisHealthy)
+	}
+
+	if tradeBatcher != nil {
+		go tradeBatcher.Run(ctx)
+	}
+
 	if wsClient != nil {
-		go orderbook.StartOrderBookProcessor(ctx, wsClient, obManager, redisClient, cfg)
+		go orderbook.StartOrderBookProcessor(ctx, obManager, store, cfg, analysisSettings)
+	}
+
+	if wsClient != nil && mongoClient != nil && cfg.MongoDB.OrderBookSnapshotIntervalSec > 0 {
+		if err := mongoClient.EnsureOrderBookSnapshotIndexes(); err != nil {
+			log.Printf("Failed to create orderbook_snapshots index: %v", err)
+		}
+		interval := time.Duration(cfg.MongoDB.OrderBookSnapshotIntervalSec) * time.Second
+		go orderbook.StartSnapshotArchiver(ctx, obManager, mongoClient, interval, obManager.Instruments)
 	}
 
 	var subManager *subscription.SubscriptionManager
 	if wsClient != nil {
+		var pairsReader subscription.RedisConfigReader = subscription.StaticPairs{
+			Key:   cfg.Redis.TradingPairsKey,
+			Pairs: cfg.Redis.StaticTradingPairs,
+		}
+		if redisClient != nil {
+			pairsReader = redisClient
+		}
 		subManager = subscription.NewSubscriptionManager(
 			wsClient,
-			redisClient,
+			pairsReader,
 			cfg.Redis.TradingPairsKey,
 			cfg.Redis.PollIntervalSec,
 		)
+		subManager.SetMaxPairs(cfg.Redis.MaxTradingPairs)
+		subManager.SetPairPriority(cfg.Redis.TradingPairsPriority)
 
 		if err := subManager.Start(); err != nil {
 			log.Fatalf("Failed to start subscription manager: %v", err)
@@ -127,15 +239,50 @@ func main() {
 	}
 	if wsClient != nil {
 		monitoringData["websocket_connections"] = 1
-		monitoringData["active_pairs"] = len(wsClient.GetSubscribed())
+		monitoringData["active_pairs"] = len(wsClient.GetRequestedInstruments())
+	}
+	if redisClient != nil {
+		if err := redisClient.UpdateSystemMonitoring(monitoringData); err != nil {
+			log.Printf("Failed to update system monitoring: %v", err)
+		}
 	}
-	if err := redisClient.UpdateSystemMonitoring(monitoringData); err != nil {
-		log.Printf("Failed to update system monitoring: %v", err)
+
+	httpserver.SetProcessorStatsProvider(orderbook.GetProcessorStats)
+	httpserver.SetMessageStatsProvider(obManager.Stats)
+	httpserver.SetFillEstimator(obManager.EstimateFillPrice)
+	httpserver.SetDepthStatsProvider(obManager.GetDepthStats)
+	httpserver.SetInstrumentsProvider(func() []orderbook.InstrumentStatus {
+		if wsClient == nil {
+			return nil
+		}
+		// Unacknowledged instruments are listed too, with their unconfirmed channels
+		statuses := obManager.InstrumentStatuses(wsClient.GetRequestedInstruments())
+		unconfirmed := wsClient.GetUnconfirmedSubscriptions()
+		for i := range statuses {
+			statuses[i].UnconfirmedChannels = unconfirmed[statuses[i].InstrumentID]
+		}
+		return statuses
+	})
+	if wsClient != nil {
+		httpserver.SetDroppedMessagesProvider(wsClient.DroppedMessages)
+		httpserver.SetResyncer(func(instID string) (orderbook.InstrumentStatus, error) {
+			if len(wsClient.GetSubscribedChannels()[instID]) == 0 {
+				return orderbook.InstrumentStatus{}, httpserver.ErrInstrumentNotSubscribed
+			}
+			// Drop the book first so updates racing the resubscribe are ignored
+			obManager.MarkAwaitingSnapshot([]string{instID})
+			obManager.ResetWindows(instID)
+			if err := wsClient.Resubscribe(instID); err != nil {
+				return orderbook.InstrumentStatus{}, err
+			}
+			return obManager.InstrumentStatus(instID), nil
+		})
 	}
 
 	httpServerDone := make(chan struct{})
 	httpServerStop := make(chan struct{})
 	go httpserver.StartHTTPServer(cfg.APIHTTPAddr, httpServerDone, httpServerStop)
+	httpserver.SetStartupComplete()
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -145,11 +292,35 @@ func main() {
 
 	log.Println("Received shutdown signal...")
 	log.Println("Shutting down gracefully...")
+	if stopSignalConsumer != nil {
+		// Before closing the private socket, so no order goes out mid-shutdown
+		stopSignalConsumer()
+	}
 	cancel()
 	log.Println("Context cancelled, waiting for order book processing to stop...")
 
+	hubCtx, hubCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := hub.Shutdown(hubCtx); err != nil {
+		log.Printf("WebSocket hub shutdown did not complete: %v", err)
+	} else {
+		log.Println("WebSocket hub stopped")
+	}
+	hubCancel()
+
 	close(httpServerStop)
 	<-httpServerDone
 	log.Println("HTTP server stopped")
 	log.Println("Shutdown complete")
 }
+
+// configureAnalysis applies the analysis parameters that the order book manager keeps itself.
+// Windows already created keep their length, new ones use the new values.
+func configureAnalysis(obManager *orderbook.Manager, analysis config.AnalysisConfig) {
+	obManager.SetIcebergEnabled(analysis.EnableIceberg)
+	obManager.SetIcebergParams(analysis.IcebergWindowSeconds, analysis.IcebergMinRefills)
+	obManager.SetOFIWindow(analysis.OFIWindowSeconds)
+	obManager.SetTradePressureWindow(analysis.TradePressureWindowSeconds)
+	obManager.SetOpenInterestWindow(analysis.OpenInterestWindowSeconds)
+	obManager.SetImbalanceFlipParams(analysis.ImbalanceFlipLevels, analysis.ImbalanceFlipDeadband)
+	obManager.SetLiquidityHysteresis(analysis.LiquidityShrinkEscalateCount, analysis.LiquidityShrinkDeescalateCount)
+}
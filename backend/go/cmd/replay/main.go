@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+// replay feeds a captured NDJSON file of raw OKEx frames through the order book
+// manager and prints the computed metrics to stdout, one JSON object per books frame.
+//
+//	go run ./cmd/replay -file cmd/replay/sample.ndjson -speed 0
+//
+// With -inst and -step or -until it stops at a frame index or timestamp (ms) instead and
+// prints the instrument's book with its checksum string:
+//
+//	go run ./cmd/replay -file cmd/replay/sample.ndjson -inst BTC-USDT -step 3
+func main() {
+	file := flag.String("file", "", "path to the NDJSON capture of raw OKEx frames")
+	speed := flag.Float64("speed", 0, "replay speed relative to original timestamps (0 = as fast as possible)")
+	instID := flag.String("inst", "", "instrument whose book is dumped at -step or -until")
+	step := flag.Int("step", 0, "dump the book after this many frames (1-based)")
+	until := flag.Int64("until", 0, "dump the book after the last frame with ts <= until (ms)")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open capture: %v", err)
+	}
+	defer f.Close()
+
+	cfg := config.LoadFromEnv()
+	// Windows and timestamps follow the capture, not the wall clock, so replays are repeatable
+	clock := orderbook.NewReplayClock()
+	replayer := orderbook.NewReplayer(orderbook.NewManagerWithClock(clock.Now), cfg.Analysis, *speed)
+	replayer.SetClock(clock)
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	if *instID != "" {
+		replayer.Load(f)
+		if *step > 0 {
+			err = replayer.StepTo(*step)
+		} else if *until > 0 {
+			_, err = replayer.StepToTimestamp(*until)
+		} else {
+			log.Fatalf("-inst requires -step or -until")
+		}
+		if err != nil {
+			log.Fatalf("Replay failed: %v", err)
+		}
+
+		dump, err := replayer.DumpBook(*instID)
+		if err != nil {
+			log.Fatalf("Failed to dump book at frame %d: %v", replayer.Frame(), err)
+		}
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(dump); err != nil {
+			log.Fatalf("Failed to write dump: %v", err)
+		}
+		return
+	}
+
+	if err := replayer.Replay(f, func(metrics orderbook.ReplayMetrics) error {
+		return encoder.Encode(metrics)
+	}); err != nil {
+		log.Fatalf("Replay failed: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+// Package apispec generates the OpenAPI 3 document describing the HTTP API and the
+// wshub WebSocket messages from their Go types, giving the frontend a contract.
+package apispec
+
+//go:generate go run ../../cmd/apispec -out ../../api/openapi.json
+
+import (
+	"net/http"
+	"strconv"
+
+	httpserver "github.com/supermancell/okex-buddy/internal/http"
+	"github.com/supermancell/okex-buddy/internal/wshub"
+)
+
+// Endpoint describes a single HTTP endpoint and its response types by status code
+type Endpoint struct {
+	Method    string
+	Path      string
+	Summary   string
+	Responses map[int]interface{}
+}
+
+// Endpoints lists every HTTP endpoint served by internal/http
+var Endpoints = []Endpoint{
+	{
+		Method:  "get",
+		Path:    "/health",
+		Summary: "WebSocket and Redis health",
+		Responses: map[int]interface{}{
+			200: httpserver.HealthCheckResponse{},
+			503: httpserver.HealthCheckResponse{},
+			405: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/livez",
+		Summary: "Liveness probe, 200 while the process is up",
+		Responses: map[int]interface{}{
+			200: httpserver.ProbeResponse{},
+			405: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/readyz",
+		Summary: "Readiness probe, 503 until startup is complete and WebSocket, Redis and MongoDB are healthy",
+		Responses: map[int]interface{}{
+			200: httpserver.ProbeResponse{},
+			503: httpserver.ProbeResponse{},
+			405: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/instruments",
+		Summary: "Subscribed instruments and their order book status",
+		Responses: map[int]interface{}{
+			200: httpserver.InstrumentsResponse{},
+			405: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "post",
+		Path:    "/api/resync/{instId}",
+		Summary: "Clear an instrument's order book and resubscribe it to get a fresh snapshot",
+		Responses: map[int]interface{}{
+			200: httpserver.InstrumentResponse{},
+			400: httpserver.ErrorResponse{},
+			404: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/orders",
+		Summary: "Recent orders, newest first; supports ?instId=, ?limit= and ?offset=",
+		Responses: map[int]interface{}{
+			200: httpserver.OrdersResponse{},
+			400: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/positions",
+		Summary: "Open positions; supports ?instId=, ?limit= and ?offset=",
+		Responses: map[int]interface{}{
+			200: httpserver.PositionsResponse{},
+			400: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/slippage/{instId}",
+		Summary: "Estimated average fill price and slippage of a market order; requires ?side=buy|sell and ?notional=",
+		Responses: map[int]interface{}{
+			200: httpserver.SlippageResponse{},
+			400: httpserver.ErrorResponse{},
+			404: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/depth/{instId}",
+		Summary: "Level count, total size and total notional of each side of the book",
+		Responses: map[int]interface{}{
+			200: httpserver.DepthStatsResponse{},
+			400: httpserver.ErrorResponse{},
+			404: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/sentiment/{instId}/history",
+		Summary: "Retained sentiment values, oldest first; supports ?from= and ?to= (unix seconds)",
+		Responses: map[int]interface{}{
+			200: httpserver.SentimentHistoryResponse{},
+			400: httpserver.ErrorResponse{},
+			404: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/candles",
+		Summary: "Recorded candlesticks of ?instId=, oldest first; supports ?bar=, ?from=, ?to= (ms) and ?limit=",
+		Responses: map[int]interface{}{
+			200: httpserver.CandlesResponse{},
+			400: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "get",
+		Path:    "/api/config/analysis",
+		Summary: "Analysis parameters currently used by the processing loop",
+		Responses: map[int]interface{}{
+			200: httpserver.AnalysisConfigResponse{},
+			405: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+	{
+		Method:  "put",
+		Path:    "/api/config/analysis",
+		Summary: "Update any subset of the analysis parameters at runtime; 400 on unknown fields or out-of-range values",
+		Responses: map[int]interface{}{
+			200: httpserver.AnalysisConfigResponse{},
+			400: httpserver.ErrorResponse{},
+			405: httpserver.ErrorResponse{},
+			500: httpserver.ErrorResponse{},
+			503: httpserver.ErrorResponse{},
+		},
+	},
+}
+
+// Document builds the OpenAPI 3 document. WebSocket messages are listed under the
+// x-websocket-messages extension, each referencing the Message envelope and its Data schema.
+func Document() map[string]interface{} {
+	b := newSchemaBuilder()
+
+	paths := make(map[string]interface{})
+	for _, endpoint := range Endpoints {
+		responses := make(map[string]interface{})
+		for status, body := range endpoint.Responses {
+			responses[statusKey(status)] = map[string]interface{}{
+				"description": statusDescription(status),
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": b.schemaOf(body)},
+				},
+			}
+		}
+
+		item, _ := paths[endpoint.Path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[endpoint.Path] = item
+		}
+		item[endpoint.Method] = map[string]interface{}{
+			"summary":   endpoint.Summary,
+			"responses": responses,
+		}
+	}
+
+	envelope := b.schemaOf(wshub.Message{})
+	messages := make([]interface{}, 0, len(wshub.MessageTypes))
+	for _, msg := range wshub.MessageTypes {
+		entry := map[string]interface{}{
+			"type":      msg.Type,
+			"direction": msg.Direction,
+			"envelope":  envelope,
+		}
+		if msg.Data != nil {
+			entry["data"] = b.schemaOf(msg.Data)
+		}
+		messages = append(messages, entry)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "OKEx Buddy API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.components,
+		},
+		"x-websocket-messages": messages,
+	}
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
+
+func statusDescription(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+	return "response"
+}
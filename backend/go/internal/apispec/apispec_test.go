@@ -0,0 +1,215 @@
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/wshub"
+)
+
+// sample returns a value of type t with every field set: one element per slice and map,
+// pointers allocated, so that every property of the schema is exercised
+func sample(t reflect.Type, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	if depth > 6 {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(sample(t.Elem(), depth+1))
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				v.Field(i).Set(sample(t.Field(i).Type, depth+1))
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.Append(reflect.MakeSlice(t, 0, 1), sample(t.Elem(), depth+1)))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		v.SetMapIndex(sample(t.Key(), depth+1), sample(t.Elem(), depth+1))
+	case reflect.String:
+		v.SetString("BTC-USDT")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(7)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(7)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.25)
+	}
+	return v
+}
+
+// validate checks a decoded JSON value against an OpenAPI 3.0 schema, resolving $ref
+// against components
+func validate(schema map[string]interface{}, components map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := components[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unresolved $ref %s", path, ref)
+		}
+		return validate(resolved, components, value, path)
+	}
+	if value == nil {
+		if schema["nullable"] == true || len(schema) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%s: null for a non-nullable %v", path, schema["type"])
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			if err := validate(sub.(map[string]interface{}), components, value, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %T, want object", path, value)
+		}
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required %s", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, field := range object {
+			sub, declared := properties[name].(map[string]interface{})
+			if !declared {
+				if additional == nil {
+					return fmt.Errorf("%s: undeclared property %s", path, name)
+				}
+				sub = additional
+			}
+			if err := validate(sub, components, field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %T, want array", path, value)
+		}
+		for i, item := range items {
+			if err := validate(schema["items"].(map[string]interface{}), components, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: %T, want string", path, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: %T, want number", path, value)
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: %v, want integer", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: %T, want boolean", path, value)
+		}
+	}
+	return nil
+}
+
+// marshalled returns v as decoded JSON
+func marshalled(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decode %T: %v", v, err)
+	}
+	return decoded
+}
+
+func TestResponsesMatchSchema(t *testing.T) {
+	b := newSchemaBuilder()
+	for _, endpoint := range Endpoints {
+		statuses := make([]int, 0, len(endpoint.Responses))
+		for status := range endpoint.Responses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+
+		for _, status := range statuses {
+			body := endpoint.Responses[status]
+			schema := b.schemaOf(body)
+			value := marshalled(t, sample(reflect.TypeOf(body), 0).Interface())
+			if err := validate(schema, b.components, value, fmt.Sprintf("%s %s %d", endpoint.Method, endpoint.Path, status)); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func TestWebSocketMessagesMatchSchema(t *testing.T) {
+	b := newSchemaBuilder()
+	envelope := b.schemaOf(wshub.Message{})
+
+	for _, msg := range wshub.MessageTypes {
+		message := wshub.Message{Type: msg.Type, InstrumentID: "BTC-USDT", Timestamp: 1717000000}
+		if msg.Data != nil {
+			data := marshalled(t, sample(reflect.TypeOf(msg.Data), 0).Interface())
+			if err := validate(b.schemaOf(msg.Data), b.components, data, msg.Type+".data"); err != nil {
+				t.Error(err)
+			}
+			message.Data = data.(map[string]interface{})
+		}
+		if err := validate(envelope, b.components, marshalled(t, message), msg.Type); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSchemaRejectsWrongShapes(t *testing.T) {
+	b := newSchemaBuilder()
+	schema := b.schemaOf(wshub.Message{})
+
+	for name, value := range map[string]interface{}{
+		"missing type":      map[string]interface{}{"timestamp": 1.0},
+		"string timestamp":  map[string]interface{}{"type": "ping", "timestamp": "now"},
+		"unknown property":  map[string]interface{}{"type": "ping", "timestamp": 1.0, "extra": true},
+		"fractional number": map[string]interface{}{"type": "ping", "timestamp": 1.5},
+	} {
+		if err := validate(schema, b.components, value, name); err == nil {
+			t.Errorf("%s: accepted %v", name, value)
+		}
+	}
+}
+
+func TestCommittedSpecIsUpToDate(t *testing.T) {
+	committed, err := os.ReadFile("../../api/openapi.json")
+	if err != nil {
+		t.Fatalf("read api/openapi.json: %v", err)
+	}
+	generated, err := json.MarshalIndent(Document(), "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(committed), generated) {
+		t.Fatal("api/openapi.json is stale, run go generate ./internal/apispec")
+	}
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// AsString returns a JSON-decoded value as a string. OKEx sends numeric fields (sz, px,
+// ordId, ...) as strings, but some payloads carry plain JSON numbers; both forms, as well
+// as json.Number from a decoder with UseNumber, are handled the same way. Missing or
+// unsupported values return "".
+func AsString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case int:
+		return strconv.Itoa(val)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAsString(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"0.015", "0.015"},
+		{json.Number("1234567890123456789"), "1234567890123456789"},
+		{0.015, "0.015"},
+		{float64(100), "100"},
+		{1e21, "1000000000000000000000"},
+		{int64(-3), "-3"},
+		{7, "7"},
+		{true, "true"},
+		{nil, ""},
+		{map[string]interface{}{"px": "1"}, ""},
+	}
+	for _, tt := range tests {
+		if got := AsString(tt.in); got != tt.want {
+			t.Errorf("AsString(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+)
+
+// AnalysisSettings holds the AnalysisConfig shared by the processing loop and the HTTP API,
+// so that thresholds can be tuned at runtime without a restart
+type AnalysisSettings struct {
+	mu  sync.RWMutex
+	cfg AnalysisConfig
+}
+
+// NewAnalysisSettings creates settings holding cfg
+func NewAnalysisSettings(cfg AnalysisConfig) *AnalysisSettings {
+	return &AnalysisSettings{cfg: cfg}
+}
+
+// Get returns a copy of the current analysis config
+func (s *AnalysisSettings) Get() AnalysisConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Set replaces the analysis config after validating it; an invalid config is not applied
+func (s *AnalysisSettings) Set(cfg AnalysisConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	return nil
+}
+
+// Validate checks that every parameter is within its valid range and returns the first
+// violation, named after the field
+func (c AnalysisConfig) Validate() error {
+	positiveInts := []struct {
+		name  string
+		value int
+	}{
+		{"SupportResistanceBinCount", c.SupportResistanceBinCount},
+		{"SupportResistanceTopN", c.SupportResistanceTopN},
+		{"SpreadZScoreWindowMinutes", c.SpreadZScoreWindowMinutes},
+		{"DepthAnomalyWindowSize", c.DepthAnomalyWindowSize},
+		{"LiquidityShrinkShortWindowSeconds", c.LiquidityShrinkShortWindowSeconds},
+		{"LiquidityShrinkLongWindowSeconds", c.LiquidityShrinkLongWindowSeconds},
+		{"LiquidityShrinkEscalateCount", c.LiquidityShrinkEscalateCount},
+		{"LiquidityShrinkDeescalateCount", c.LiquidityShrinkDeescalateCount},
+		{"IcebergWindowSeconds", c.IcebergWindowSeconds},
+		{"IcebergMinRefills", c.IcebergMinRefills},
+		{"OFIWindowSeconds", c.OFIWindowSeconds},
+		{"TradePressureWindowSeconds", c.TradePressureWindowSeconds},
+		{"OpenInterestWindowSeconds", c.OpenInterestWindowSeconds},
+		{"ImbalanceFlipLevels", c.ImbalanceFlipLevels},
+		{"AggregatedBookTopN", c.AggregatedBookTopN},
+		{"WorkerPoolSize", c.WorkerPoolSize},
+	}
+	for _, field := range positiveInts {
+		if field.value <= 0 {
+			return fmt.Errorf("%s must be > 0, got %d", field.name, field.value)
+		}
+	}
+
+	nonNegativeInts := []struct {
+		name  string
+		value int
+	}{
+		{"SupportResistanceMinDistanceTicks", c.SupportResistanceMinDistanceTicks},
+		{"TickerMaxAgeSec", c.TickerMaxAgeSec},
+		{"BookMaxAgeSec", c.BookMaxAgeSec},
+		{"CircuitBreakerThreshold", c.CircuitBreakerThreshold},
+		{"CircuitBreakerCooldownSec", c.CircuitBreakerCooldownSec},
+	}
+	for _, field := range nonNegativeInts {
+		if field.value < 0 {
+			return fmt.Errorf("%s must be >= 0, got %d", field.name, field.value)
+		}
+	}
+
+	positiveFloats := []struct {
+		name  string
+		value float64
+	}{
+		{"SupportResistanceSignificanceThreshold", c.SupportResistanceSignificanceThreshold},
+		{"DepthAnomalyPriceRangePercent", c.DepthAnomalyPriceRangePercent},
+		{"DepthAnomalyZThreshold", c.DepthAnomalyZThreshold},
+		{"LiquidityShrinkNearPriceDeltaPercent", c.LiquidityShrinkNearPriceDeltaPercent},
+	}
+	for _, field := range positiveFloats {
+		if !(field.value > 0) {
+			return fmt.Errorf("%s must be > 0, got %v", field.name, field.value)
+		}
+	}
+
+	nonNegativeFloats := []struct {
+		name  string
+		value float64
+	}{
+		{"SupportResistanceMinDistancePercent", c.SupportResistanceMinDistancePercent},
+		{"SupportResistanceMinNotional", c.SupportResistanceMinNotional},
+		{"SpreadZScoreAlertThreshold", c.SpreadZScoreAlertThreshold},
+		{"LargeOrderMinNotional", c.LargeOrderMinNotional},
+		{"LargeOrderDecayLambda", c.LargeOrderDecayLambda},
+		{"TickerDivergenceThreshold", c.TickerDivergenceThreshold},
+	}
+	for _, field := range nonNegativeFloats {
+		if !(field.value >= 0) {
+			return fmt.Errorf("%s must be >= 0, got %v", field.name, field.value)
+		}
+	}
+
+	// Fractions in [0, 1)
+	fractions := []struct {
+		name  string
+		value float64
+	}{
+		{"LargeOrderSentimentDeadzoneThreshold", c.LargeOrderSentimentDeadzoneThreshold},
+		{"ImbalanceFlipDeadband", c.ImbalanceFlipDeadband},
+	}
+	for _, field := range fractions {
+		if !(field.value >= 0 && field.value < 1) {
+			return fmt.Errorf("%s must be in [0, 1), got %v", field.name, field.value)
+		}
+	}
+
+	if !(c.LargeOrderPercentileAlpha > 0 && c.LargeOrderPercentileAlpha < 1) {
+		return fmt.Errorf("LargeOrderPercentileAlpha must be in (0, 1), got %v", c.LargeOrderPercentileAlpha)
+	}
+	// The spread history keeps 30 minutes
+	if c.SpreadZScoreWindowMinutes > 30 {
+		return fmt.Errorf("SpreadZScoreWindowMinutes must be <= 30, got %d", c.SpreadZScoreWindowMinutes)
+	}
+	if c.LiquidityShrinkLongWindowSeconds < c.LiquidityShrinkShortWindowSeconds {
+		return fmt.Errorf("LiquidityShrinkLongWindowSeconds (%d) must be >= LiquidityShrinkShortWindowSeconds (%d)",
+			c.LiquidityShrinkLongWindowSeconds, c.LiquidityShrinkShortWindowSeconds)
+	}
+	if c.LiquidityShrinkMidPriceMode != "simple" && c.LiquidityShrinkMidPriceMode != "micro" {
+		return fmt.Errorf("LiquidityShrinkMidPriceMode must be simple or micro, got %q", c.LiquidityShrinkMidPriceMode)
+	}
+	return nil
+}
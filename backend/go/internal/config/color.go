@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Log color modes for SetColorMode
+const (
+	ColorAuto   = "auto"   // color only when stderr (the log output) is a terminal
+	ColorAlways = "always" // always emit ANSI color codes
+	ColorNever  = "never"  // plain text, e.g. for log files
+)
+
+// colorEnabled is read by Colorf on every colored log line
+var colorEnabled atomic.Bool
+
+func init() {
+	colorEnabled.Store(true)
+}
+
+// ansiEscape matches ANSI SGR sequences such as Red or Reset
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// SetColorMode switches colored logging on or off. Unknown modes are treated as auto.
+func SetColorMode(mode string) {
+	switch strings.ToLower(mode) {
+	case ColorAlways:
+		colorEnabled.Store(true)
+	case ColorNever:
+		colorEnabled.Store(false)
+	default:
+		colorEnabled.Store(isTerminal(os.Stderr))
+	}
+}
+
+// ColorEnabled reports whether Colorf currently emits color codes
+func ColorEnabled() bool {
+	return colorEnabled.Load()
+}
+
+// Colorf formats a log message wrapped in color and Reset, or with every color code
+// stripped when color is disabled, so plain log files never contain escape sequences
+// 彩色日志：非终端输出时自动去掉颜色码
+func Colorf(color, format string, args ...interface{}) string {
+	msg := fmt.Sprintf(format, args...)
+	if !colorEnabled.Load() {
+		return StripColors(msg)
+	}
+	return color + msg + Reset
+}
+
+// StripColors removes ANSI color codes from s
+func StripColors(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// isTerminal reports whether f is a character device, i.e. an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
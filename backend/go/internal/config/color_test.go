@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestColorfPlainModeHasNoEscapes(t *testing.T) {
+	t.Cleanup(func() { SetColorMode(ColorAlways) })
+
+	// Arguments may carry colors of their own, e.g. a nested Colorf result
+	nested := Red + "nested" + Reset
+	format, args := "Spread alert for %s: %s, z-score=%.2f", []interface{}{"BTC-USDT", nested, 3.5}
+
+	SetColorMode(ColorNever)
+	plain := Colorf(Yellow, format, args...)
+	if strings.Contains(plain, "\x1b") {
+		t.Fatalf("plain mode emitted escapes: %q", plain)
+	}
+	if plain != "Spread alert for BTC-USDT: nested, z-score=3.50" {
+		t.Fatalf("plain mode = %q, want the message without colors", plain)
+	}
+
+	SetColorMode("ALWAYS")
+	if colored := Colorf(Yellow, format, args...); colored != Yellow+"Spread alert for BTC-USDT: "+nested+", z-score=3.50"+Reset {
+		t.Fatalf("color mode = %q, want the message wrapped in yellow", colored)
+	}
+}
+
+func TestColorAutoFollowsStderr(t *testing.T) {
+	stderr := os.Stderr
+	t.Cleanup(func() {
+		os.Stderr = stderr
+		SetColorMode(ColorAlways)
+	})
+
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+	pipeReader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pipeReader.Close()
+	defer pipeWriter.Close()
+
+	// Log files and pipes (docker, systemd, tee) are not terminals
+	for name, output := range map[string]*os.File{"file": logFile, "pipe": pipeWriter} {
+		for _, mode := range []string{ColorAuto, "", "bogus"} {
+			SetColorMode(ColorAlways)
+			os.Stderr = output
+			SetColorMode(mode)
+			if ColorEnabled() {
+				t.Errorf("mode %q with stderr to a %s enabled color", mode, name)
+			}
+			if msg := Colorf(Blue, "depth anomaly"); msg != "depth anomaly" {
+				t.Errorf("mode %q with stderr to a %s logged %q", mode, name, msg)
+			}
+		}
+	}
+}
@@ -2,13 +2,14 @@ package config
 
 import (
 	"bufio"
+	"log"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 )
 
-// 定义颜色常量
+// 定义颜色常量，日志中请通过Colorf使用，见color.go
 const (
 	Reset  = "\033[0m"
 	Red    = "\033[31m"
@@ -17,24 +18,47 @@ const (
 	Blue   = "\033[34m"
 )
 
+// SchemaVersion is written as schema_version into every Redis hash and wshub analysis_update
+// message. Bump it whenever a field is renamed, removed or changes meaning.
+const SchemaVersion = 1
+
 // RedisKey
 const (
 	TradingPairsKey      = "config:trading_pairs" //运行时会去订阅的交易对
+	AnalysisConfigKey    = "config:analysis"      //运行时调整的分析参数（JSON），见 PUT /api/config/analysis
 	OrderBookKey         = "orderbook:%s"
+	OrderBookHistoryKey  = "orderbook:history:%s" //最近N个订单簿快照（ZSET，按时间戳排序）
+	AggregatedBookKey    = "orderbook:agg:%s"     //聚合订单簿（累计数量/名义价值 + 前N档）
 	TickerKey            = "ticker:%s"
-	SupportResistanceKey = "analysis:supp_resi:%s" //支撑位和阻力位
-	SentimentKey         = "analysis:sentiment:%s" //多空情绪
-	DepthAnomalyKey      = "analysis:dept_anom:%s" //深度异常波动
-	LiquidityShrinkKey   = "analysis:liqu_shri:%s" //流动性萎缩预警
+	SupportResistanceKey = "analysis:supp_resi:%s"  //支撑位和阻力位
+	SentimentKey         = "analysis:sentiment:%s"  //多空情绪
+	SentimentHistoryKey  = "analysis:senti_hist:%s" //多空情绪历史（ZSET，按时间排序）
+	DepthAnomalyKey      = "analysis:dept_anom:%s"  //深度异常波动
+	LiquidityShrinkKey   = "analysis:liqu_shri:%s"  //流动性萎缩预警
+	IcebergKey           = "analysis:iceberg:%s"    //冰山订单候选价位
+	OFIKey               = "analysis:ofi:%s"        //订单流不平衡
+	TradePressureKey     = "analysis:trade_pres:%s" //主动买卖成交压力
+	ImbalanceFlipKey     = "analysis:obi_flip:%s"   //盘口不平衡方向反转事件（LIST，最新在前）
+	FundingRateKey       = "funding_rate:%s"        //永续合约最新资金费率
+	OpenInterestKey      = "open_interest:%s"       //合约最新持仓量及窗口内变化
 )
 
 const (
-	BooksChannel  = "books"   //订单薄频道
-	TickerChannel = "tickers" //行情频道
-	Candle1D      = "candle1D"
-	Candle4H      = "candle4H"
-	Candle1H      = "candle1H"
-	Candle15m     = "candle15m"
+	BooksChannel        = "books"          //订单薄频道
+	BooksL2TBTChannel   = "books-l2-tbt"   //400档逐笔订单簿（VIP，需要登录）
+	Books50L2TBTChannel = "books50-l2-tbt" //50档逐笔订单簿（VIP，需要登录）
+	Books5Channel       = "books5"         //5档全量推送，无checksum
+	BBOTBTChannel       = "bbo-tbt"        //1档逐笔，无checksum
+	TickerChannel       = "tickers"        //行情频道
+	TradesChannel       = "trades"         //成交频道
+	FundingRateChannel  = "funding-rate"   //资金费率频道（仅永续合约）
+	OpenInterestChannel = "open-interest"  //持仓总量频道（仅永续/交割合约）
+	MarkPriceChannel    = "mark-price"     //标记价格频道
+	IndexTickersChannel = "index-tickers"  //指数行情频道，instId为指数（如BTC-USDT）
+	Candle1D            = "candle1D"
+	Candle4H            = "candle4H"
+	Candle1H            = "candle1H"
+	Candle15m           = "candle15m"
 )
 
 // RedisConfig holds Redis connection settings.
@@ -43,25 +67,166 @@ type RedisConfig struct {
 	Password        string
 	TradingPairsKey string // Redis key for trading pairs configuration
 	PollIntervalSec int    // Polling interval for config changes in seconds
+	MaxTradingPairs int    // Cap on subscribed trading pairs, extra configured pairs are dropped
+	// Pairs kept first when the config has more than MaxTradingPairs, highest priority first;
+	// unlisted pairs follow in config order
+	TradingPairsPriority []string
+
+	HealthCheckIntervalSec int // Interval between background Redis pings in seconds
+	MaxRetries             int // Retries for failed writes (exponential backoff)
+	RetryBaseDelayMs       int // Initial backoff delay for write retries in milliseconds
+	OpTimeoutMs            int // Timeout of each Redis round trip (every retry attempt) in milliseconds, 0 disables it
+
+	DB             int // Logical database index
+	PoolSize       int // Connection pool size (0 = go-redis default)
+	MinIdleConns   int // Minimum idle connections kept in the pool
+	DialTimeoutMs  int // Dial timeout in milliseconds (0 = go-redis default)
+	ReadTimeoutMs  int // Read timeout in milliseconds (0 = go-redis default)
+	WriteTimeoutMs int // Write timeout in milliseconds (0 = go-redis default)
+
+	KeyPrefix string // Namespace prepended to every key, e.g. "dev:" (default empty)
+
+	OrderBookHistorySize int // Snapshots retained per instrument in a sorted set (0 = disabled)
+	SentimentHistorySize int // Sentiment values retained per instrument in a sorted set (0 = disabled)
+
+	// PersistAnalysisConfig saves analysis parameters changed through PUT /api/config/analysis
+	// to Redis and loads them over the env values at startup
+	PersistAnalysisConfig bool
+
+	// Required makes startup fail without Redis; when false the service runs analyses in memory
+	// only, skips every Redis write and subscribes StaticTradingPairs
+	Required           bool
+	StaticTradingPairs []string // 无Redis时订阅的交易对
 }
 
 // MongoDBConfig holds MongoDB connection settings.
 type MongoDBConfig struct {
 	Addr     string
 	Database string
+
+	ConnectAttempts        int // Connect+ping attempts at startup before giving up
+	ConnectRetryIntervalMs int // Wait before the second attempt in milliseconds, doubled after every failure
+	MaxPoolSize            int // Maximum connections in the driver pool
+	ConnectTimeoutMs       int // Dial and ping timeout in milliseconds
+
+	TradeBatchSize       int // Trades buffered before an InsertMany
+	TradeFlushIntervalMs int // Maximum time a trade waits in the buffer in milliseconds
+
+	OrderBookSnapshotIntervalSec int // Interval between full order book snapshots written to MongoDB, 0 disables it
+}
+
+// OKEx endpoint regions, see OKEX_REGION
+const (
+	RegionGlobal = ""    // ws.okx.com / www.okx.com
+	RegionAWS    = "aws" // wsaws.okx.com / aws.okx.com, lower latency from AWS
+)
+
+// OKExEndpoints are the production endpoints of a region
+type OKExEndpoints struct {
+	PublicWSURL   string
+	BusinessWSURL string
+	PrivateWSURL  string
+	RESTURL       string
+}
+
+// RegionEndpoints returns the production endpoints of a region; unknown regions fall back
+// to the global endpoints and ok is false
+func RegionEndpoints(region string) (endpoints OKExEndpoints, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(region)) {
+	case RegionAWS:
+		return OKExEndpoints{
+			PublicWSURL:   "wss://wsaws.okx.com:8443/ws/v5/public",
+			BusinessWSURL: "wss://wsaws.okx.com:8443/ws/v5/business",
+			PrivateWSURL:  "wss://wsaws.okx.com:8443/ws/v5/private",
+			RESTURL:       "https://aws.okx.com",
+		}, true
+	case RegionGlobal, "global":
+		ok = true
+	}
+	return OKExEndpoints{
+		PublicWSURL:   "wss://ws.okx.com:8443/ws/v5/public",
+		BusinessWSURL: "wss://ws.okx.com:8443/ws/v5/business",
+		PrivateWSURL:  "wss://ws.okx.com:8443/ws/v5/private",
+		RESTURL:       "https://www.okx.com",
+	}, ok
 }
 
 // OKEXConfig holds OKEx WebSocket endpoint configuration.
 type OKEXConfig struct {
-	PublicWSURL      string
-	BusinessWSURL    string
-	PrivateWSURL     string
+	// Region selects the default endpoints (RegionGlobal or RegionAWS); OKEX_WS_* and
+	// OKEX_REST_URL override them individually
+	Region string
+
+	PublicWSURL   string
+	BusinessWSURL string
+	PrivateWSURL  string
+	RESTURL       string
+
+	// DemoTrading routes private WebSocket and REST trading to the OKEx demo environment
+	DemoTrading      bool
+	DemoPrivateWSURL string
+	DemoRESTURL      string
 	UseProxy         bool
 	ProxyAddr        string
 	HTTPProxyAddr    string
 	EnablePublicWS   bool
 	EnableBusinessWS bool
 	EnablePrivateWS  bool
+
+	// permessage-deflate per endpoint, mostly useful for high-volume books on the public endpoint
+	PublicWSCompression   bool
+	BusinessWSCompression bool
+	PrivateWSCompression  bool
+
+	// WSWriteTimeoutMs bounds every write on the OKEx WebSocket connections, 0 disables the deadline
+	WSWriteTimeoutMs int
+	// SubscribeBatchSize caps the channel args per public subscribe/unsubscribe frame
+	SubscribeBatchSize int
+	// SubscribeIntervalMs is the minimum time between two subscribe requests on a connection, 0 disables it
+	SubscribeIntervalMs int
+
+	// TimeSyncIntervalSec is how often the private client re-syncs the server time offset, 0 disables it
+	TimeSyncIntervalSec int
+
+	// EnableBooksL2TBT subscribes books-l2-tbt instead of books (VIP only, logs in on the public connection)
+	EnableBooksL2TBT bool
+
+	// VerifyBooksChecksum verifies the checksum of books channels that carry one (books5/bbo-tbt never do)
+	VerifyBooksChecksum bool
+
+	// CapturePath writes every raw public message to this NDJSON file for cmd/replay, empty disables it
+	CapturePath string
+	// CaptureMaxMB rotates the capture file once it reaches this size, 0 disables rotation
+	CaptureMaxMB int
+
+	// PublicMessageQueueSize buffers raw messages between the public socket reader and the handler;
+	// messages arriving while it is full are dropped
+	PublicMessageQueueSize int
+
+	// EnableTradesCapture subscribes the trades channel and stores every trade in MongoDB
+	EnableTradesCapture bool
+	// EnableFundingRate subscribes the funding-rate channel for SWAP instruments
+	EnableFundingRate bool
+	// EnableOpenInterest subscribes the open-interest channel for SWAP and FUTURES instruments
+	EnableOpenInterest bool
+	// EnableMarkIndexPrice subscribes the mark-price and index-tickers channels for SWAP and FUTURES instruments
+	EnableMarkIndexPrice bool
+}
+
+// TradingPrivateWSURL returns the private WebSocket URL, the demo one when DemoTrading is on
+func (c OKEXConfig) TradingPrivateWSURL() string {
+	if c.DemoTrading {
+		return c.DemoPrivateWSURL
+	}
+	return c.PrivateWSURL
+}
+
+// TradingRESTURL returns the REST base URL, the demo one when DemoTrading is on
+func (c OKEXConfig) TradingRESTURL() string {
+	if c.DemoTrading {
+		return c.DemoRESTURL
+	}
+	return c.RESTURL
 }
 
 // AnalysisConfig holds configuration for analysis functions.
@@ -70,10 +235,17 @@ type AnalysisConfig struct {
 	SupportResistanceBinCount              int     // 价格区间划分数量
 	SupportResistanceSignificanceThreshold float64 // 支撑/阻力位显著性阈值
 	SupportResistanceTopN                  int     // 返回的支撑/阻力位数量
-	SupportResistanceMinDistancePercent    float64 // 支撑/阻力位之间的最小价格差异百分比
+	SupportResistanceMinDistancePercent    float64 // 支撑/阻力位之间的最小价格差异百分比（相对深度加权中间价）
+	SupportResistanceMinDistanceTicks      int     // 支撑/阻力位之间的最小价格差异（tick数），>0时优先于百分比
+	SupportResistanceMinNotional           float64 // 价格区间成为支撑/阻力位所需的最小名义价值，无区间达到时不返回任何价位
+
+	// AnalyzeSpreadZScore
+	SpreadZScoreWindowMinutes  int     // 价差Z分数的统计窗口（分钟），价差历史最多保留30分钟
+	SpreadZScoreAlertThreshold float64 // Z分数绝对值超过该阈值时输出价差告警，0为不告警
 
 	// ComputeLargeOrderDistribution
 	LargeOrderPercentileAlpha            float64 // 大额订单的百分位数阈值
+	LargeOrderMinNotional                float64 // 大额订单的最小名义价值，与百分位数阈值取较大者，0为不限制
 	LargeOrderDecayLambda                float64 // 价格距离衰减因子
 	LargeOrderSentimentDeadzoneThreshold float64 // 情绪中性区间阈值
 
@@ -86,7 +258,62 @@ type AnalysisConfig struct {
 	LiquidityShrinkNearPriceDeltaPercent float64 // 价格附近的百分比阈值
 	LiquidityShrinkShortWindowSeconds    int     // 短期趋势窗口（秒）
 	LiquidityShrinkLongWindowSeconds     int     // 长期基准窗口（秒）
-	LiquidityShrinkSlopeThreshold        float64 // 流动性下降斜率阈值
+	LiquidityShrinkSlopeThreshold        float64 // 流动性下降斜率阈值（均值流动性的百分比/分钟）
+	LiquidityShrinkMidPriceMode          string  // 价格范围中心：simple（简单中间价）或 micro（微观价格）
+	LiquidityShrinkEscalateCount         int     // 更高警告级别需连续出现的次数
+	LiquidityShrinkDeescalateCount       int     // 更低警告级别需连续出现的次数
+
+	// DetectIceberg
+	IcebergWindowSeconds int // 补单统计窗口（秒）
+	IcebergMinRefills    int // 窗口内至少补单次数才视为冰山订单
+
+	// ComputeOFI
+	OFIWindowSeconds int // 订单流不平衡累计窗口（秒）
+
+	// ComputeTradePressure
+	TradePressureWindowSeconds int // 主动买卖成交统计窗口（秒）
+
+	// GetOpenInterest
+	OpenInterestWindowSeconds int // 持仓量变化统计窗口（秒）
+
+	// DetectImbalanceFlip
+	ImbalanceFlipLevels   int     // 计算盘口不平衡使用的每方向档位数
+	ImbalanceFlipDeadband float64 // 不平衡绝对值超过该阈值才视为一方占优，避免零轴附近反复触发
+
+	// ComputeAggregatedBook
+	AggregatedBookTopN int // 聚合订单簿每方向保留的档位数量
+
+	// Ticker staleness check
+	TickerMaxAgeSec           int     // ticker超过该时长未更新则视为tickers频道已停滞（秒）
+	TickerDivergenceThreshold float64 // 订单簿最优买卖价与ticker的相对偏离超过该值时视为订单簿损坏并重新订阅，0为不检查
+
+	// Book staleness check
+	BookMaxAgeSec int // 订单簿超过该时长未更新则跳过本轮分析（秒），0为不检查
+
+	// Processing loop
+	WorkerPoolSize int // 分析协程池大小，所有交易对和分析共用
+
+	// Circuit breaker for repeatedly failing analyses
+	CircuitBreakerThreshold   int // 连续失败次数阈值，达到后暂停该分析
+	CircuitBreakerCooldownSec int // 暂停时长（秒）
+
+	// Enable flags, disabled analyses are skipped entirely
+	EnableSupportResistance bool // 支撑/阻力位
+	EnableSpreadZScore      bool // 价差Z分数（使用支撑/阻力位价差，无需开启支撑/阻力位）
+	EnableLargeOrder        bool // 大额订单分布
+	EnableDepthAnomaly      bool // 深度异常
+	EnableLiquidityShrink   bool // 流动性收缩
+	EnableIceberg           bool // 冰山订单
+	EnableOFI               bool // 订单流不平衡
+	EnableTradePressure     bool // 主动买卖成交压力（需要订阅trades频道）
+	EnableImbalanceFlip     bool // 盘口不平衡方向反转
+}
+
+// WSHubConfig holds configuration for the frontend WebSocket hub
+type WSHubConfig struct {
+	ReadBufferSize     int // Read buffer per client connection in bytes (0 = gorilla default, 4096)
+	WriteBufferSize    int // Write buffer per client connection in bytes (0 = gorilla default, 4096)
+	HandshakeTimeoutMs int // Upgrade handshake timeout in milliseconds (0 = no timeout)
 }
 
 // AppConfig aggregates all runtime configuration needed by backend services.
@@ -95,8 +322,10 @@ type AppConfig struct {
 	MongoDB           MongoDBConfig
 	OKEX              OKEXConfig
 	Analysis          AnalysisConfig
+	WSHub             WSHubConfig
 	APIHTTPAddr       string
 	FrontendDevServer string
+	LogColor          string // auto, always or never, see SetColorMode
 }
 
 // LoadFromEnv loads configuration from environment variables.
@@ -104,37 +333,112 @@ type AppConfig struct {
 func LoadFromEnv() AppConfig {
 	loadEnvFile("config/app.env")
 
+	region := getenvWithDefault("OKEX_REGION", RegionGlobal)
+	endpoints, ok := RegionEndpoints(region)
+	if !ok {
+		log.Printf("Unknown OKEX_REGION %q, using the global endpoints", region)
+	}
+
 	return AppConfig{
 		Redis: RedisConfig{
 			Addr:            getenvWithDefault("REDIS_ADDR", "localhost:6379"),
 			Password:        os.Getenv("REDIS_PASSWORD"),
 			TradingPairsKey: getenvWithDefault("REDIS_TRADING_PAIRS_KEY", "config:trading_pairs"),
 			PollIntervalSec: getenvIntWithDefault("TRADING_PAIRS_POLL_INTERVAL", 20),
+			MaxTradingPairs: getenvIntWithDefault("TRADING_PAIRS_MAX", 10),
+
+			TradingPairsPriority: getenvListWithDefault("TRADING_PAIRS_PRIORITY", nil),
+
+			HealthCheckIntervalSec: getenvIntWithDefault("REDIS_HEALTH_CHECK_INTERVAL", 5),
+			MaxRetries:             getenvIntWithDefault("REDIS_MAX_RETRIES", 3),
+			RetryBaseDelayMs:       getenvIntWithDefault("REDIS_RETRY_BASE_DELAY_MS", 100),
+			OpTimeoutMs:            getenvIntWithDefault("REDIS_OP_TIMEOUT_MS", 3000),
+
+			DB:             getenvIntWithDefault("REDIS_DB", 0),
+			PoolSize:       getenvIntWithDefault("REDIS_POOL_SIZE", 0),
+			MinIdleConns:   getenvIntWithDefault("REDIS_MIN_IDLE_CONNS", 0),
+			DialTimeoutMs:  getenvIntWithDefault("REDIS_DIAL_TIMEOUT_MS", 0),
+			ReadTimeoutMs:  getenvIntWithDefault("REDIS_READ_TIMEOUT_MS", 0),
+			WriteTimeoutMs: getenvIntWithDefault("REDIS_WRITE_TIMEOUT_MS", 0),
+
+			KeyPrefix: os.Getenv("REDIS_KEY_PREFIX"),
+
+			OrderBookHistorySize: getenvIntWithDefault("REDIS_ORDERBOOK_HISTORY_SIZE", 0),
+			SentimentHistorySize: getenvIntWithDefault("REDIS_SENTIMENT_HISTORY_SIZE", 3600),
+
+			PersistAnalysisConfig: getenvBoolWithDefault("REDIS_PERSIST_ANALYSIS_CONFIG", false),
+
+			Required:           getenvBoolWithDefault("REDIS_REQUIRED", true),
+			StaticTradingPairs: getenvListWithDefault("TRADING_PAIRS", nil),
 		},
 		MongoDB: MongoDBConfig{
 			Addr:     getenvWithDefault("MONGODB_ADDR", "mongodb://127.0.0.1:27017"),
 			Database: getenvWithDefault("MONGODB_DATABASE", "technical_analysis"),
+
+			ConnectAttempts:        getenvIntWithDefault("MONGODB_CONNECT_ATTEMPTS", 5),
+			ConnectRetryIntervalMs: getenvIntWithDefault("MONGODB_CONNECT_RETRY_INTERVAL_MS", 1000),
+			MaxPoolSize:            getenvIntWithDefault("MONGODB_MAX_POOL_SIZE", 50),
+			ConnectTimeoutMs:       getenvIntWithDefault("MONGODB_CONNECT_TIMEOUT_MS", 10000),
+
+			TradeBatchSize:       getenvIntWithDefault("MONGODB_TRADE_BATCH_SIZE", 200),
+			TradeFlushIntervalMs: getenvIntWithDefault("MONGODB_TRADE_FLUSH_INTERVAL_MS", 1000),
+
+			OrderBookSnapshotIntervalSec: getenvIntWithDefault("MONGODB_ORDERBOOK_SNAPSHOT_INTERVAL_SEC", 0),
 		},
 		OKEX: OKEXConfig{
-			PublicWSURL:      getenvWithDefault("OKEX_WS_PUBLIC", "wss://ws.okx.com:8443/ws/v5/public"),
-			BusinessWSURL:    getenvWithDefault("OKEX_WS_BUSINESS", "wss://ws.okx.com:8443/ws/v5/business"),
-			PrivateWSURL:     getenvWithDefault("OKEX_WS_PRIVATE", "wss://ws.okx.com:8443/ws/v5/private"),
+			Region:        region,
+			PublicWSURL:   getenvWithDefault("OKEX_WS_PUBLIC", endpoints.PublicWSURL),
+			BusinessWSURL: getenvWithDefault("OKEX_WS_BUSINESS", endpoints.BusinessWSURL),
+			PrivateWSURL:  getenvWithDefault("OKEX_WS_PRIVATE", endpoints.PrivateWSURL),
+			RESTURL:       getenvWithDefault("OKEX_REST_URL", endpoints.RESTURL),
+
+			DemoTrading:      getenvBoolWithDefault("OKEX_DEMO_TRADING", false),
+			DemoPrivateWSURL: getenvWithDefault("OKEX_WS_PRIVATE_DEMO", "wss://wspap.okx.com:8443/ws/v5/private"),
+			DemoRESTURL:      getenvWithDefault("OKEX_REST_URL_DEMO", "https://www.okx.com"),
 			UseProxy:         getenvBoolWithDefault("USE_PROXY", true),
 			ProxyAddr:        getenvWithDefault("PROXY_ADDR", "127.0.0.1:4781"),
 			HTTPProxyAddr:    getenvWithDefault("HTTP_PROXY_ADDR", "127.0.0.1:4780"),
 			EnablePublicWS:   getenvBoolWithDefault("ENABLE_PUBLIC_WS", false),
 			EnableBusinessWS: getenvBoolWithDefault("ENABLE_BUSINESS_WS", true),
 			EnablePrivateWS:  getenvBoolWithDefault("ENABLE_PRIVATE_WS", false),
+
+			PublicWSCompression:   getenvBoolWithDefault("OKEX_WS_PUBLIC_COMPRESSION", true),
+			BusinessWSCompression: getenvBoolWithDefault("OKEX_WS_BUSINESS_COMPRESSION", false),
+			PrivateWSCompression:  getenvBoolWithDefault("OKEX_WS_PRIVATE_COMPRESSION", false),
+
+			WSWriteTimeoutMs:    getenvIntWithDefault("OKEX_WS_WRITE_TIMEOUT_MS", 10000),
+			SubscribeBatchSize:  getenvIntWithDefault("OKEX_SUBSCRIBE_BATCH_SIZE", 100),
+			SubscribeIntervalMs: getenvIntWithDefault("OKEX_SUBSCRIBE_INTERVAL_MS", 400),
+			TimeSyncIntervalSec: getenvIntWithDefault("OKEX_TIME_SYNC_INTERVAL_SEC", 300),
+
+			EnableBooksL2TBT:     getenvBoolWithDefault("OKEX_BOOKS_L2_TBT", false),
+			VerifyBooksChecksum:  getenvBoolWithDefault("OKEX_BOOKS_VERIFY_CHECKSUM", true),
+			EnableTradesCapture:  getenvBoolWithDefault("OKEX_TRADES_CAPTURE", false),
+			EnableFundingRate:    getenvBoolWithDefault("OKEX_FUNDING_RATE", true),
+			EnableOpenInterest:   getenvBoolWithDefault("OKEX_OPEN_INTEREST", true),
+			EnableMarkIndexPrice: getenvBoolWithDefault("OKEX_MARK_INDEX_PRICE", true),
+
+			PublicMessageQueueSize: getenvIntWithDefault("OKEX_WS_PUBLIC_MESSAGE_QUEUE_SIZE", 1024),
+
+			CapturePath:  os.Getenv("OKEX_WS_CAPTURE_PATH"),
+			CaptureMaxMB: getenvIntWithDefault("OKEX_WS_CAPTURE_MAX_MB", 100),
 		},
 		Analysis: AnalysisConfig{
 			// ComputeSupportResistance
 			SupportResistanceBinCount:              getenvIntWithDefault("SUPPORT_RESISTANCE_BIN_COUNT", 50),
 			SupportResistanceSignificanceThreshold: getenvFloat64WithDefault("SUPPORT_RESISTANCE_SIGNIFICANCE_THRESHOLD", 1.5),
-			SupportResistanceTopN:                  getenvIntWithDefault("SUPPORT_RESISTANCE_TOP_N", 2),
+			SupportResistanceTopN:                  getenvIntWithDefault("SUPPORT_RESISTANCE_TOP_N", 5),
 			SupportResistanceMinDistancePercent:    getenvFloat64WithDefault("SUPPORT_RESISTANCE_MIN_DISTANCE_PERCENT", 0.5),
+			SupportResistanceMinDistanceTicks:      getenvIntWithDefault("SUPPORT_RESISTANCE_MIN_DISTANCE_TICKS", 0),
+			SupportResistanceMinNotional:           getenvFloat64WithDefault("SUPPORT_RESISTANCE_MIN_NOTIONAL", 10000),
+
+			// AnalyzeSpreadZScore
+			SpreadZScoreWindowMinutes:  getenvIntWithDefault("SPREAD_ZSCORE_WINDOW_MINUTES", 5),
+			SpreadZScoreAlertThreshold: getenvFloat64WithDefault("SPREAD_ZSCORE_ALERT_THRESHOLD", 2.5),
 
 			// ComputeLargeOrderDistribution
 			LargeOrderPercentileAlpha:            getenvFloat64WithDefault("LARGE_ORDER_PERCENTILE_ALPHA", 0.95),
+			LargeOrderMinNotional:                getenvFloat64WithDefault("LARGE_ORDER_MIN_NOTIONAL", 10000),
 			LargeOrderDecayLambda:                getenvFloat64WithDefault("LARGE_ORDER_DECAY_LAMBDA", 5.0),
 			LargeOrderSentimentDeadzoneThreshold: getenvFloat64WithDefault("LARGE_ORDER_SENTIMENT_DEADZONE_THRESHOLD", 0.3),
 
@@ -147,10 +451,65 @@ func LoadFromEnv() AppConfig {
 			LiquidityShrinkNearPriceDeltaPercent: getenvFloat64WithDefault("LIQUIDITY_SHRINK_NEAR_PRICE_DELTA_PERCENT", 0.5),
 			LiquidityShrinkShortWindowSeconds:    getenvIntWithDefault("LIQUIDITY_SHRINK_SHORT_WINDOW_SECONDS", 30),
 			LiquidityShrinkLongWindowSeconds:     getenvIntWithDefault("LIQUIDITY_SHRINK_LONG_WINDOW_SECONDS", 1800),
-			LiquidityShrinkSlopeThreshold:        getenvFloat64WithDefault("LIQUIDITY_SHRINK_SLOPE_THRESHOLD", -0.01),
+			LiquidityShrinkSlopeThreshold:        getenvFloat64WithDefault("LIQUIDITY_SHRINK_SLOPE_THRESHOLD", -2),
+			LiquidityShrinkMidPriceMode:          getenvWithDefault("LIQUIDITY_SHRINK_MID_PRICE_MODE", "simple"),
+			LiquidityShrinkEscalateCount:         getenvIntWithDefault("LIQUIDITY_SHRINK_ESCALATE_COUNT", 2),
+			LiquidityShrinkDeescalateCount:       getenvIntWithDefault("LIQUIDITY_SHRINK_DEESCALATE_COUNT", 5),
+
+			// DetectIceberg
+			IcebergWindowSeconds: getenvIntWithDefault("ICEBERG_WINDOW_SECONDS", 60),
+			IcebergMinRefills:    getenvIntWithDefault("ICEBERG_MIN_REFILLS", 3),
+
+			// ComputeOFI
+			OFIWindowSeconds: getenvIntWithDefault("OFI_WINDOW_SECONDS", 60),
+
+			// ComputeTradePressure
+			TradePressureWindowSeconds: getenvIntWithDefault("TRADE_PRESSURE_WINDOW_SECONDS", 60),
+
+			// GetOpenInterest
+			OpenInterestWindowSeconds: getenvIntWithDefault("OPEN_INTEREST_WINDOW_SECONDS", 300),
+
+			// DetectImbalanceFlip
+			ImbalanceFlipLevels:   getenvIntWithDefault("IMBALANCE_FLIP_LEVELS", 5),
+			ImbalanceFlipDeadband: getenvFloat64WithDefault("IMBALANCE_FLIP_DEADBAND", 0.2),
+
+			// ComputeAggregatedBook
+			AggregatedBookTopN: getenvIntWithDefault("AGGREGATED_BOOK_TOP_N", 20),
+
+			// Ticker staleness check
+			TickerMaxAgeSec:           getenvIntWithDefault("TICKER_MAX_AGE_SEC", 30),
+			TickerDivergenceThreshold: getenvFloat64WithDefault("TICKER_DIVERGENCE_THRESHOLD", 0.005),
+
+			// Book staleness check
+			BookMaxAgeSec: getenvIntWithDefault("BOOK_MAX_AGE_SEC", 60),
+
+			// Processing loop
+			// ANALYSIS_MAX_CONCURRENT_INSTRUMENTS is the former name of the setting
+			WorkerPoolSize: getenvIntWithDefault("ANALYSIS_WORKER_POOL_SIZE", getenvIntWithDefault("ANALYSIS_MAX_CONCURRENT_INSTRUMENTS", 10)),
+
+			// Circuit breaker
+			CircuitBreakerThreshold:   getenvIntWithDefault("ANALYSIS_CIRCUIT_BREAKER_THRESHOLD", 5),
+			CircuitBreakerCooldownSec: getenvIntWithDefault("ANALYSIS_CIRCUIT_BREAKER_COOLDOWN_SEC", 60),
+
+			// Enable flags
+			EnableSupportResistance: getenvBoolWithDefault("ANALYSIS_ENABLE_SUPPORT_RESISTANCE", true),
+			EnableSpreadZScore:      getenvBoolWithDefault("ANALYSIS_ENABLE_SPREAD_ZSCORE", true),
+			EnableLargeOrder:        getenvBoolWithDefault("ANALYSIS_ENABLE_LARGE_ORDER", true),
+			EnableDepthAnomaly:      getenvBoolWithDefault("ANALYSIS_ENABLE_DEPTH_ANOMALY", true),
+			EnableLiquidityShrink:   getenvBoolWithDefault("ANALYSIS_ENABLE_LIQUIDITY_SHRINK", true),
+			EnableIceberg:           getenvBoolWithDefault("ANALYSIS_ENABLE_ICEBERG", true),
+			EnableOFI:               getenvBoolWithDefault("ANALYSIS_ENABLE_OFI", true),
+			EnableTradePressure:     getenvBoolWithDefault("ANALYSIS_ENABLE_TRADE_PRESSURE", false),
+			EnableImbalanceFlip:     getenvBoolWithDefault("ANALYSIS_ENABLE_IMBALANCE_FLIP", true),
+		},
+		WSHub: WSHubConfig{
+			ReadBufferSize:     getenvIntWithDefault("WSHUB_READ_BUFFER_SIZE", 0),
+			WriteBufferSize:    getenvIntWithDefault("WSHUB_WRITE_BUFFER_SIZE", 0),
+			HandshakeTimeoutMs: getenvIntWithDefault("WSHUB_HANDSHAKE_TIMEOUT_MS", 10000),
 		},
 		APIHTTPAddr:       getenvWithDefault("API_HTTP_ADDR", "0.0.0.0:8080"),
 		FrontendDevServer: os.Getenv("FRONTEND_DEV_SERVER"),
+		LogColor:          getenvWithDefault("LOG_COLOR", ColorAuto),
 	}
 }
 
@@ -234,6 +593,21 @@ func getenvBoolWithDefault(key string, def bool) bool {
 	return def
 }
 
+// getenvListWithDefault splits a comma separated value, dropping blank entries
+func getenvListWithDefault(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getenvFloat64WithDefault(key string, def float64) float64 {
 	if v := os.Getenv(key); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// clearOKExEnv unsets the endpoint and proxy variables for the test
+func clearOKExEnv(t *testing.T) {
+	for _, key := range []string{
+		"OKEX_REGION", "OKEX_WS_PUBLIC", "OKEX_WS_BUSINESS", "OKEX_WS_PRIVATE", "OKEX_REST_URL",
+		"OKEX_DEMO_TRADING", "USE_PROXY", "PROXY_ADDR", "HTTP_PROXY_ADDR",
+	} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestRegionSelectsEndpoints(t *testing.T) {
+	global := OKExEndpoints{
+		PublicWSURL:   "wss://ws.okx.com:8443/ws/v5/public",
+		BusinessWSURL: "wss://ws.okx.com:8443/ws/v5/business",
+		PrivateWSURL:  "wss://ws.okx.com:8443/ws/v5/private",
+		RESTURL:       "https://www.okx.com",
+	}
+	aws := OKExEndpoints{
+		PublicWSURL:   "wss://wsaws.okx.com:8443/ws/v5/public",
+		BusinessWSURL: "wss://wsaws.okx.com:8443/ws/v5/business",
+		PrivateWSURL:  "wss://wsaws.okx.com:8443/ws/v5/private",
+		RESTURL:       "https://aws.okx.com",
+	}
+
+	for region, want := range map[string]OKExEndpoints{
+		"":       global,
+		"global": global,
+		"aws":    aws,
+		" AWS ":  aws,
+		"mars":   global,
+	} {
+		clearOKExEnv(t)
+		t.Setenv("OKEX_REGION", region)
+		var logs bytes.Buffer
+		previous := log.Writer()
+		log.SetOutput(&logs)
+		okex := LoadFromEnv().OKEX
+		log.SetOutput(previous)
+
+		got := OKExEndpoints{okex.PublicWSURL, okex.BusinessWSURL, okex.PrivateWSURL, okex.RESTURL}
+		if got != want {
+			t.Errorf("region %q: endpoints %+v, want %+v", region, got, want)
+		}
+		if warned := strings.Contains(logs.String(), "Unknown OKEX_REGION"); warned != (region == "mars") {
+			t.Errorf("region %q: unknown region warning = %v", region, warned)
+		}
+	}
+}
+
+func TestExplicitEndpointsOverrideRegion(t *testing.T) {
+	clearOKExEnv(t)
+	t.Setenv("OKEX_REGION", "aws")
+	t.Setenv("OKEX_WS_BUSINESS", "wss://127.0.0.1:9443/ws/v5/business")
+	t.Setenv("OKEX_DEMO_TRADING", "true")
+
+	okex := LoadFromEnv().OKEX
+	if okex.BusinessWSURL != "wss://127.0.0.1:9443/ws/v5/business" {
+		t.Fatalf("business URL %q, want the explicit one", okex.BusinessWSURL)
+	}
+	if okex.PublicWSURL != "wss://wsaws.okx.com:8443/ws/v5/public" {
+		t.Fatalf("public URL %q, want the aws one", okex.PublicWSURL)
+	}
+	// Demo trading has its own endpoints whatever the region
+	if okex.TradingPrivateWSURL() != okex.DemoPrivateWSURL || okex.TradingRESTURL() != okex.DemoRESTURL {
+		t.Fatalf("demo trading uses %s and %s", okex.TradingPrivateWSURL(), okex.TradingRESTURL())
+	}
+}
+
+func TestProxyFieldsLoad(t *testing.T) {
+	clearOKExEnv(t)
+	okex := LoadFromEnv().OKEX
+	if !okex.UseProxy || okex.ProxyAddr != "127.0.0.1:4781" || okex.HTTPProxyAddr != "127.0.0.1:4780" {
+		t.Fatalf("defaults: use=%v socks=%q http=%q", okex.UseProxy, okex.ProxyAddr, okex.HTTPProxyAddr)
+	}
+
+	t.Setenv("USE_PROXY", "false")
+	t.Setenv("PROXY_ADDR", "10.0.0.2:1080")
+	t.Setenv("HTTP_PROXY_ADDR", "10.0.0.2:3128")
+	okex = LoadFromEnv().OKEX
+	if okex.UseProxy || okex.ProxyAddr != "10.0.0.2:1080" || okex.HTTPProxyAddr != "10.0.0.2:3128" {
+		t.Fatalf("from env: use=%v socks=%q http=%q", okex.UseProxy, okex.ProxyAddr, okex.HTTPProxyAddr)
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -37,8 +38,11 @@ func NewPrivateMessageHandler(mongoClient *mongodb.Client, orderProcessor *signa
 
 // handlePrivateMessage processes private WebSocket messages without creating a handler object
 func handlePrivateMessage(mongoClient *mongodb.Client, message []byte) error {
+	// UseNumber keeps numeric ids such as ordId exact instead of rounding them through float64
 	var msg map[string]interface{}
-	if err := json.Unmarshal(message, &msg); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+	if err := decoder.Decode(&msg); err != nil {
 		return fmt.Errorf("failed to unmarshal message: %w", err)
 	}
 
@@ -71,6 +75,7 @@ func handlePrivateMessage(mongoClient *mongodb.Client, message []byte) error {
 
 // handleOrders processes order channel data
 func handleOrders(mongoClient *mongodb.Client, data []interface{}) error {
+	orders := make([]*mongodb.Order, 0, len(data))
 	for _, item := range data {
 		orderMap, ok := item.(map[string]interface{})
 		if !ok {
@@ -83,9 +88,11 @@ func handleOrders(mongoClient *mongodb.Client, data []interface{}) error {
 			continue
 		}
 
-		if err := mongoClient.InsertOrder(order); err != nil {
-			log.Printf("Failed to insert order: %v", err)
-		}
+		orders = append(orders, order)
+	}
+
+	if err := mongoClient.InsertOrdersBulk(orders); err != nil {
+		log.Printf("Failed to insert %d orders: %v", len(orders), err)
 	}
 	return nil
 }
@@ -93,99 +100,30 @@ func handleOrders(mongoClient *mongodb.Client, data []interface{}) error {
 // parseOrder parses order data from WebSocket message
 func parseOrder(orderMap map[string]interface{}) (*mongodb.Order, error) {
 	order := &mongodb.Order{
-		Timestamp: time.Now().UnixMilli(),
-	}
-
-	if instID, ok := orderMap["instId"].(string); ok {
-		order.InstID = instID
-	}
-
-	if ordID, ok := orderMap["ordId"].(string); ok {
-		order.OrdID = ordID
-	}
-
-	if clOrdID, ok := orderMap["clOrdId"].(string); ok {
-		order.ClOrdID = clOrdID
-	}
-
-	if tag, ok := orderMap["tag"].(string); ok {
-		order.Tag = tag
-	}
-
-	if side, ok := orderMap["side"].(string); ok {
-		order.Side = side
-	}
-
-	if ordType, ok := orderMap["ordType"].(string); ok {
-		order.OrdType = ordType
-	}
-
-	if posSide, ok := orderMap["posSide"].(string); ok {
-		order.PosSide = posSide
-	}
-
-	if state, ok := orderMap["state"].(string); ok {
-		order.State = state
-	}
-
-	if sz, ok := orderMap["sz"].(string); ok {
-		order.Sz = sz
-	}
-
-	if px, ok := orderMap["px"].(string); ok {
-		order.Px = px
-	}
-
-	if lever, ok := orderMap["lever"].(string); ok {
-		order.Lever = lever
-	}
-
-	if tm, ok := orderMap["tm"].(string); ok {
-		order.Tm = tm
-	}
-
-	if cTime, ok := orderMap["cTime"].(string); ok {
-		order.CTime = cTime
-	}
-
-	if uTime, ok := orderMap["uTime"].(string); ok {
-		order.UTime = uTime
-	}
-
-	if reqID, ok := orderMap["reqId"].(string); ok {
-		order.ReqID = reqID
-	}
-
-	if fee, ok := orderMap["fee"].(string); ok {
-		order.Fee = fee
-	}
-
-	if fillSz, ok := orderMap["accFillSz"].(string); ok {
-		order.FillSz = fillSz
-	}
-
-	if fillPx, ok := orderMap["avgPx"].(string); ok {
-		order.FillPx = fillPx
-	}
-
-	if fillTime, ok := orderMap["fillTime"].(string); ok {
-		order.FillTime = fillTime
-	}
-
-	if fillNotionalUsd, ok := orderMap["fillNotionalUsd"].(string); ok {
-		order.FillNotionalUSD = fillNotionalUsd
-	}
-
-	if pnl, ok := orderMap["pnl"].(string); ok {
-		order.Pnl = pnl
-	}
-
-	if pnlRatio, ok := orderMap["pnlRatio"].(string); ok {
-		order.PnlRatio = pnlRatio
-	}
-
-	if category, ok := orderMap["category"].(string); ok {
-		order.Category = category
+		InstID:          common.AsString(orderMap["instId"]),
+		OrdID:           common.AsString(orderMap["ordId"]),
+		ClOrdID:         common.AsString(orderMap["clOrdId"]),
+		Tag:             common.AsString(orderMap["tag"]),
+		Side:            common.AsString(orderMap["side"]),
+		OrdType:         common.AsString(orderMap["ordType"]),
+		PosSide:         common.AsString(orderMap["posSide"]),
+		State:           common.AsString(orderMap["state"]),
+		Sz:              common.AsString(orderMap["sz"]),
+		Px:              common.AsString(orderMap["px"]),
+		Lever:           common.AsString(orderMap["lever"]),
+		Tm:              common.AsString(orderMap["tm"]),
+		CTime:           common.AsString(orderMap["cTime"]),
+		UTime:           common.AsString(orderMap["uTime"]),
+		ReqID:           common.AsString(orderMap["reqId"]),
+		Fee:             common.AsString(orderMap["fee"]),
+		FillSz:          common.AsString(orderMap["accFillSz"]),
+		FillPx:          common.AsString(orderMap["avgPx"]),
+		FillTime:        common.AsString(orderMap["fillTime"]),
+		FillNotionalUSD: common.AsString(orderMap["fillNotionalUsd"]),
+		Pnl:             common.AsString(orderMap["pnl"]),
+		PnlRatio:        common.AsString(orderMap["pnlRatio"]),
+		Category:        common.AsString(orderMap["category"]),
+		Timestamp:       time.Now().UnixMilli(),
 	}
 
 	order.ID = order.OrdID
@@ -195,6 +133,7 @@ func parseOrder(orderMap map[string]interface{}) (*mongodb.Order, error) {
 
 // handlePositions processes position channel data
 func handlePositions(mongoClient *mongodb.Client, data []interface{}) error {
+	positions := make([]*mongodb.Position, 0, len(data))
 	for _, item := range data {
 		posMap, ok := item.(map[string]interface{})
 		if !ok {
@@ -207,9 +146,11 @@ func handlePositions(mongoClient *mongodb.Client, data []interface{}) error {
 			continue
 		}
 
-		if err := mongoClient.InsertPosition(pos); err != nil {
-			log.Printf("Failed to insert position: %v", err)
-		}
+		positions = append(positions, pos)
+	}
+
+	if err := mongoClient.InsertPositionsBulk(positions); err != nil {
+		log.Printf("Failed to insert %d positions: %v", len(positions), err)
 	}
 	return nil
 }
@@ -217,83 +158,26 @@ func handlePositions(mongoClient *mongodb.Client, data []interface{}) error {
 // parsePosition parses position data from WebSocket message
 func parsePosition(posMap map[string]interface{}) (*mongodb.Position, error) {
 	position := &mongodb.Position{
-		Timestamp: time.Now().UnixMilli(),
-	}
-
-	if instID, ok := posMap["instId"].(string); ok {
-		position.InstID = instID
-	}
-
-	if mgnMode, ok := posMap["mgnMode"].(string); ok {
-		position.MgnMode = mgnMode
-	}
-
-	if posID, ok := posMap["posId"].(string); ok {
-		position.PosID = posID
-	}
-
-	if posSide, ok := posMap["posSide"].(string); ok {
-		position.PosSide = posSide
-	}
-
-	if pos, ok := posMap["pos"].(string); ok {
-		position.Pos = pos
-	}
-
-	if baseBal, ok := posMap["baseBal"].(string); ok {
-		position.BaseBal = baseBal
-	}
-
-	if quoteBal, ok := posMap["quoteBal"].(string); ok {
-		position.QuoteBal = quoteBal
-	}
-
-	if posCcy, ok := posMap["posCcy"].(string); ok {
-		position.PosCcy = posCcy
-	}
-
-	if pnlRatio, ok := posMap["pnlRatio"].(string); ok {
-		position.PnlRatio = pnlRatio
-	}
-
-	if upl, ok := posMap["upl"].(string); ok {
-		position.Upl = upl
-	}
-
-	if uplRatio, ok := posMap["uplRatio"].(string); ok {
-		position.UplRatio = uplRatio
-	}
-
-	if lever, ok := posMap["lever"].(string); ok {
-		position.Lever = lever
-	}
-
-	if liqPx, ok := posMap["liqPx"].(string); ok {
-		position.LiqPx = liqPx
-	}
-
-	if markPx, ok := posMap["markPx"].(string); ok {
-		position.MarkPx = markPx
-	}
-
-	if cTime, ok := posMap["cTime"].(string); ok {
-		position.CTime = cTime
-	}
-
-	if uTime, ok := posMap["uTime"].(string); ok {
-		position.UTime = uTime
-	}
-
-	if adl, ok := posMap["adl"].(string); ok {
-		position.ADL = adl
-	}
-
-	if notionalUSD, ok := posMap["notionalUsd"].(string); ok {
-		position.NotionalUSD = notionalUSD
-	}
-
-	if last, ok := posMap["last"].(string); ok {
-		position.Last = last
+		InstID:      common.AsString(posMap["instId"]),
+		MgnMode:     common.AsString(posMap["mgnMode"]),
+		PosID:       common.AsString(posMap["posId"]),
+		PosSide:     common.AsString(posMap["posSide"]),
+		Pos:         common.AsString(posMap["pos"]),
+		BaseBal:     common.AsString(posMap["baseBal"]),
+		QuoteBal:    common.AsString(posMap["quoteBal"]),
+		PosCcy:      common.AsString(posMap["posCcy"]),
+		PnlRatio:    common.AsString(posMap["pnlRatio"]),
+		Upl:         common.AsString(posMap["upl"]),
+		UplRatio:    common.AsString(posMap["uplRatio"]),
+		Lever:       common.AsString(posMap["lever"]),
+		LiqPx:       common.AsString(posMap["liqPx"]),
+		MarkPx:      common.AsString(posMap["markPx"]),
+		CTime:       common.AsString(posMap["cTime"]),
+		UTime:       common.AsString(posMap["uTime"]),
+		ADL:         common.AsString(posMap["adl"]),
+		NotionalUSD: common.AsString(posMap["notionalUsd"]),
+		Last:        common.AsString(posMap["last"]),
+		Timestamp:   time.Now().UnixMilli(),
 	}
 
 	position.ID = fmt.Sprintf("%s_%s", position.InstID, position.PosID)
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decodeData decodes the first data item of a private channel push the way
+// handlePrivateMessage does
+func decodeData(t *testing.T, message string) map[string]interface{} {
+	t.Helper()
+
+	var msg map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(message)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&msg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return msg["data"].([]interface{})[0].(map[string]interface{})
+}
+
+// assertFields compares the named string fields of a parsed struct
+func assertFields(t *testing.T, parsed interface{}, want map[string]string) {
+	t.Helper()
+
+	v := reflect.ValueOf(parsed).Elem()
+	for field, value := range want {
+		if got := v.FieldByName(field).String(); got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+}
+
+func TestParseOrderMixedStringAndNumberFields(t *testing.T) {
+	order, err := parseOrder(decodeData(t, `{"arg":{"channel":"orders"},"data":[{
+		"instId":"BTC-USDT-SWAP","ordId":1234567890123456789,"clOrdId":"sig42","tag":"",
+		"side":"buy","ordType":"limit","posSide":"long","state":"filled",
+		"sz":0.015,"px":"43250.1","lever":10,"tm":"cross",
+		"cTime":1717000000000,"uTime":"1717000000500","reqId":"r1",
+		"fee":-0.0025,"accFillSz":"0.015","avgPx":43250.1,"fillTime":1717000000400,
+		"fillNotionalUsd":"648.75","pnl":0,"pnlRatio":"0","category":"normal"}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	assertFields(t, order, map[string]string{
+		"ID": "1234567890123456789", "OrdID": "1234567890123456789",
+		"InstID": "BTC-USDT-SWAP", "ClOrdID": "sig42", "Side": "buy", "OrdType": "limit",
+		"PosSide": "long", "State": "filled", "Tm": "cross", "ReqID": "r1", "Category": "normal",
+		"Sz": "0.015", "Px": "43250.1", "Lever": "10", "CTime": "1717000000000", "UTime": "1717000000500",
+		"Fee": "-0.0025", "FillSz": "0.015", "FillPx": "43250.1", "FillTime": "1717000000400",
+		"FillNotionalUSD": "648.75", "Pnl": "0", "PnlRatio": "0",
+	})
+}
+
+func TestParsePositionMixedStringAndNumberFields(t *testing.T) {
+	position, err := parsePosition(decodeData(t, `{"arg":{"channel":"positions"},"data":[{
+		"instId":"ETH-USDT-SWAP","mgnMode":"isolated","posId":987654321098765432,"posSide":"short",
+		"pos":-3,"baseBal":"","quoteBal":0,"posCcy":"","pnlRatio":"-0.12","upl":-12.5,"uplRatio":-0.12,
+		"lever":"5","liqPx":3890.25,"markPx":"3412.8","cTime":"1717000000000","uTime":1717000009000,
+		"adl":2,"notionalUsd":1023.84,"last":3412.7}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	assertFields(t, position, map[string]string{
+		"InstID": "ETH-USDT-SWAP", "MgnMode": "isolated", "PosID": "987654321098765432", "PosSide": "short",
+		"Pos": "-3", "QuoteBal": "0", "PnlRatio": "-0.12", "Upl": "-12.5", "UplRatio": "-0.12",
+		"Lever": "5", "LiqPx": "3890.25", "MarkPx": "3412.8", "CTime": "1717000000000", "UTime": "1717000009000",
+		"ADL": "2", "NotionalUSD": "1023.84", "Last": "3412.7",
+	})
+}
@@ -1,16 +1,32 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/supermancell/okex-buddy/internal/common"
 	"github.com/supermancell/okex-buddy/internal/orderbook"
+	"github.com/supermancell/okex-buddy/internal/trade"
 )
 
-// NewPublicMessageHandler creates a message handler for public WebSocket
-func NewPublicMessageHandler(obManager *orderbook.Manager) common.MessageHandler {
+// NewPublicMessageHandler creates a message handler for public WebSocket.
+// tradeBatcher may be nil when the trade tape is not captured; trades also feed the
+// Manager's trade pressure window.
+func NewPublicMessageHandler(obManager *orderbook.Manager, tradeBatcher *trade.Batcher) common.MessageHandler {
 	return func(msg []byte) error {
+		if tradeBatcher != nil && trade.IsTradesMessage(msg) {
+			trades, err := trade.ParseTrades(msg)
+			if err != nil {
+				return fmt.Errorf("failed to parse trades message: %w", err)
+			}
+			tradeBatcher.Add(trades...)
+		}
+
 		if err := obManager.ProcessMessage(msg); err != nil {
+			if errors.Is(err, orderbook.ErrUnknownFrame) {
+				// Already logged (rate-limited) by the Manager
+				return nil
+			}
 			return fmt.Errorf("failed to process message: %w", err)
 		}
 		return nil
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// analysisConfigPath reads (GET) and updates (PUT) the analysis parameters at runtime
+const analysisConfigPath = "/api/config/analysis"
+
+// maxAnalysisConfigBody bounds the body of PUT /api/config/analysis
+const maxAnalysisConfigBody = 64 * 1024
+
+// AnalysisConfigUpdated is called after PUT /api/config/analysis applied a new config, e.g. to
+// reconfigure the order book manager and persist the config. An error is reported to the caller
+// but does not roll back the change.
+type AnalysisConfigUpdated func(cfg config.AnalysisConfig) error
+
+// analysisConfigSource is the settings served by /api/config/analysis and the update callback
+type analysisConfigSource struct {
+	settings *config.AnalysisSettings
+	updated  AnalysisConfigUpdated
+}
+
+var analysisConfig atomic.Value // analysisConfigSource
+
+// analysisConfigUpdateMu serializes PUT /api/config/analysis, so concurrent partial updates
+// don't overwrite each other's fields and are applied and persisted in order
+var analysisConfigUpdateMu sync.Mutex
+
+// SetAnalysisSettings sets the settings read and updated by /api/config/analysis. updated may be nil.
+func SetAnalysisSettings(settings *config.AnalysisSettings, updated AnalysisConfigUpdated) {
+	analysisConfig.Store(analysisConfigSource{settings: settings, updated: updated})
+}
+
+// AnalysisConfigResponse is the response of GET and PUT /api/config/analysis
+type AnalysisConfigResponse struct {
+	Code    int                   `json:"code"`
+	Message string                `json:"message"`
+	Data    config.AnalysisConfig `json:"data"`
+}
+
+// handleAnalysisConfig returns the current analysis parameters on GET. PUT takes a JSON object
+// with any subset of the fields (named as in config.AnalysisConfig), applies it over the
+// current values and returns the result; unknown fields and out-of-range values are rejected
+// with 400 and leave the config unchanged. Changes apply from the next processing cycle; window
+// lengths only apply to windows created afterwards, WorkerPoolSize and CircuitBreaker* need a restart.
+func handleAnalysisConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	source, ok := analysisConfig.Load().(analysisConfigSource)
+	if !ok || source.settings == nil {
+		writeError(w, http.StatusServiceUnavailable, "analysis config is not available")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		analysisConfigUpdateMu.Lock()
+		defer analysisConfigUpdateMu.Unlock()
+
+		cfg := source.settings.Get()
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAnalysisConfigBody))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&cfg); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid analysis config: "+err.Error())
+			return
+		}
+		if err := source.settings.Set(cfg); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid analysis config: "+err.Error())
+			return
+		}
+		log.Printf("Analysis config updated through %s", analysisConfigPath)
+
+		if source.updated != nil {
+			if err := source.updated(cfg); err != nil {
+				log.Printf("Failed to apply analysis config update: %v", err)
+				writeError(w, http.StatusInternalServerError, "analysis config applied, but not fully: "+err.Error())
+				return
+			}
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	json.NewEncoder(w).Encode(AnalysisConfigResponse{
+		Code:    200,
+		Message: "success",
+		Data:    source.settings.Get(),
+	})
+}
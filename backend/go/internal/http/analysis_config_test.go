@@ -0,0 +1,76 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func putAnalysisConfig(body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handleAnalysisConfig(rec, httptest.NewRequest(http.MethodPut, analysisConfigPath, strings.NewReader(body)))
+	return rec
+}
+
+func TestAnalysisConfigRejectsInvalidUpdate(t *testing.T) {
+	settings := config.NewAnalysisSettings(config.LoadFromEnv().Analysis)
+	SetAnalysisSettings(settings, nil)
+	before := settings.Get()
+
+	if rec := putAnalysisConfig(`{"SpreadZScoreWindowMinutes": 31}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("out-of-range value: status %d, want 400", rec.Code)
+	}
+	if rec := putAnalysisConfig(`{"NoSuchField": 1}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown field: status %d, want 400", rec.Code)
+	}
+	if settings.Get() != before {
+		t.Fatal("rejected update changed the config")
+	}
+}
+
+func TestAnalysisConfigConcurrentUpdates(t *testing.T) {
+	initial := config.LoadFromEnv().Analysis
+	initial.SupportResistanceTopN = 2
+	initial.SpreadZScoreWindowMinutes = 5
+	settings := config.NewAnalysisSettings(initial)
+
+	var inFlight, overlapped int32
+	SetAnalysisSettings(settings, func(config.AnalysisConfig) error {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.StoreInt32(&overlapped, 1)
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	})
+
+	// Two partial updates of different fields; neither may be lost
+	var wg sync.WaitGroup
+	for _, body := range []string{`{"SupportResistanceTopN": 4}`, `{"SpreadZScoreWindowMinutes": 10}`} {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(body string) {
+				defer wg.Done()
+				if rec := putAnalysisConfig(body); rec.Code != http.StatusOK {
+					t.Errorf("PUT %s: status %d: %s", body, rec.Code, rec.Body.String())
+				}
+			}(body)
+		}
+	}
+	wg.Wait()
+
+	got := settings.Get()
+	if got.SupportResistanceTopN != 4 || got.SpreadZScoreWindowMinutes != 10 {
+		t.Fatalf("lost update: SupportResistanceTopN=%d SpreadZScoreWindowMinutes=%d, want 4 and 10",
+			got.SupportResistanceTopN, got.SpreadZScoreWindowMinutes)
+	}
+	if overlapped != 0 {
+		t.Fatal("update callbacks ran concurrently")
+	}
+}
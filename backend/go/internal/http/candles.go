@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// CandleStore reads the candlesticks recorded from the business WebSocket
+type CandleStore interface {
+	GetCandlesticks(instID, bar string, from, to int64, limit int) ([]mongodb.Candlestick, error)
+}
+
+var candleStore atomic.Value // *CandleStore
+
+// SetCandleStore sets the source used by GET /api/candles
+func SetCandleStore(store CandleStore) {
+	candleStore.Store(&store)
+}
+
+// loadCandleStore returns the configured store, or nil when MongoDB is unavailable
+func loadCandleStore() CandleStore {
+	if store, ok := candleStore.Load().(*CandleStore); ok && store != nil {
+		return *store
+	}
+	return nil
+}
+
+// handleCandles lists the candlesticks of ?instId=, oldest first, optionally filtered by
+// ?bar= and by ?from= / ?to= (ms). ?limit= caps the result; page forward with from.
+func handleCandles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	instID := query.Get("instId")
+	if instID == "" {
+		writeError(w, http.StatusBadRequest, "instId is required")
+		return
+	}
+
+	limit := defaultPageLimit
+	if v := query.Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(parsed, maxPageLimit)
+	}
+
+	var from, to int64
+	for _, param := range []struct {
+		name string
+		dst  *int64
+	}{{"from", &from}, {"to", &to}} {
+		v := query.Get(param.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || value < 0 {
+			writeError(w, http.StatusBadRequest, param.name+" must be a non-negative timestamp in milliseconds")
+			return
+		}
+		*param.dst = value
+	}
+
+	store := loadCandleStore()
+	if store == nil {
+		writeError(w, http.StatusServiceUnavailable, "MongoDB is not available")
+		return
+	}
+
+	candles, err := store.GetCandlesticks(instID, query.Get("bar"), from, to, limit)
+	if err != nil {
+		log.Printf("Failed to query candlesticks: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to query candlesticks")
+		return
+	}
+
+	json.NewEncoder(w).Encode(CandlesResponse{
+		Code:    200,
+		Message: "success",
+		Data:    candles,
+	})
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// memoryCandles answers GetCandlesticks from a slice with the semantics of the Mongo query
+type memoryCandles struct {
+	candles []mongodb.Candlestick
+	err     error
+}
+
+func (s memoryCandles) GetCandlesticks(instID, bar string, from, to int64, limit int) ([]mongodb.Candlestick, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	out := []mongodb.Candlestick{}
+	for _, c := range s.candles {
+		if c.InstrumentID != instID || (bar != "" && c.Bar != bar) ||
+			(from > 0 && c.Timestamp < from) || (to > 0 && c.Timestamp > to) {
+			continue
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func TestCandlesEndpointFiltersAndPages(t *testing.T) {
+	const hour = int64(3600000)
+	store := memoryCandles{}
+	// Stored newest first to check the endpoint serves them in time order
+	for i := int64(5); i >= 0; i-- {
+		store.candles = append(store.candles,
+			mongodb.Candlestick{InstrumentID: "BTC-USDT", Bar: "1H", Timestamp: 1717000000000 + i*hour, Close: float64(100 + i)},
+			mongodb.Candlestick{InstrumentID: "BTC-USDT", Bar: "4H", Timestamp: 1717000000000 + i*hour},
+			mongodb.Candlestick{InstrumentID: "ETH-USDT", Bar: "1H", Timestamp: 1717000000000 + i*hour})
+	}
+	SetCandleStore(store)
+	t.Cleanup(func() { SetCandleStore(nil) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/candles", handleCandles)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	get := func(query string) []mongodb.Candlestick {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/api/candles?" + query)
+		if err != nil {
+			t.Fatalf("GET %s: %v", query, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status %d", query, resp.StatusCode)
+		}
+		var body CandlesResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("GET %s: decode: %v", query, err)
+		}
+		return body.Data
+	}
+
+	if got := get("instId=BTC-USDT"); len(got) != 12 {
+		t.Fatalf("all bars: %d candles, want 12", len(got))
+	}
+
+	hourly := get("instId=BTC-USDT&bar=1H&from=1717003600000&to=1717014400000")
+	if len(hourly) != 4 {
+		t.Fatalf("1H in range: %d candles, want 4", len(hourly))
+	}
+	for i, c := range hourly {
+		if c.Bar != "1H" || c.InstrumentID != "BTC-USDT" || c.Timestamp != 1717000000000+int64(i+1)*hour {
+			t.Fatalf("candle %d = %+v", i, c)
+		}
+	}
+
+	// Paging forward from the last timestamp + 1
+	first := get("instId=BTC-USDT&bar=1H&limit=4")
+	rest := get("instId=BTC-USDT&bar=1H&limit=4&from=" + strconv.FormatInt(first[len(first)-1].Timestamp+1, 10))
+	if len(first) != 4 || len(rest) != 2 || rest[0].Close != 104 || rest[1].Close != 105 {
+		t.Fatalf("pages %+v then %+v", first, rest)
+	}
+}
+
+func TestCandlesEndpointErrors(t *testing.T) {
+	SetCandleStore(nil)
+	t.Cleanup(func() { SetCandleStore(nil) })
+
+	status := func(method, url string) int {
+		rec := httptest.NewRecorder()
+		handleCandles(rec, httptest.NewRequest(method, url, nil))
+		return rec.Code
+	}
+
+	if got := status(http.MethodGet, "/api/candles?instId=BTC-USDT"); got != http.StatusServiceUnavailable {
+		t.Fatalf("without MongoDB: status %d, want 503", got)
+	}
+
+	SetCandleStore(memoryCandles{err: errors.New("connection reset")})
+	for url, want := range map[string]int{
+		"/api/candles":                             http.StatusBadRequest,
+		"/api/candles?instId=BTC-USDT&limit=0":     http.StatusBadRequest,
+		"/api/candles?instId=BTC-USDT&limit=ten":   http.StatusBadRequest,
+		"/api/candles?instId=BTC-USDT&from=-1":     http.StatusBadRequest,
+		"/api/candles?instId=BTC-USDT&to=tomorrow": http.StatusBadRequest,
+		"/api/candles?instId=BTC-USDT":             http.StatusInternalServerError,
+	} {
+		if got := status(http.MethodGet, url); got != want {
+			t.Errorf("%s: status %d, want %d", url, got, want)
+		}
+	}
+	if got := status(http.MethodPost, "/api/candles?instId=BTC-USDT"); got != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: status %d, want 405", got)
+	}
+}
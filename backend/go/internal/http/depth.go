@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+// depthPathPrefix is followed by the instrument ID, e.g. /api/depth/BTC-USDT
+const depthPathPrefix = "/api/depth/"
+
+// DepthStatsProvider returns per-side aggregates of a book, see orderbook.Manager.GetDepthStats
+type DepthStatsProvider func(instID string) (*orderbook.DepthStats, error)
+
+var depthStatsProvider atomic.Value // DepthStatsProvider
+
+// SetDepthStatsProvider sets the source used by GET /api/depth/{instId}
+func SetDepthStatsProvider(provider DepthStatsProvider) {
+	depthStatsProvider.Store(provider)
+}
+
+// DepthStatsResponse is the response of GET /api/depth/{instId}
+type DepthStatsResponse struct {
+	Code    int                   `json:"code"`
+	Message string                `json:"message"`
+	Data    *orderbook.DepthStats `json:"data"`
+}
+
+// handleDepth returns the level count, total size and total notional of each side of a book
+func handleDepth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID := strings.TrimPrefix(r.URL.Path, depthPathPrefix)
+	if instID == "" || strings.Contains(instID, "/") {
+		writeError(w, http.StatusBadRequest, "instrument ID is required")
+		return
+	}
+
+	provider, ok := depthStatsProvider.Load().(DepthStatsProvider)
+	if !ok || provider == nil {
+		writeError(w, http.StatusServiceUnavailable, "order books are not available")
+		return
+	}
+
+	stats, err := provider(instID)
+	if errors.Is(err, orderbook.ErrBookNotFound) {
+		writeError(w, http.StatusNotFound, "no order book for "+instID)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to compute depth stats for %s: %v", instID, err)
+		writeError(w, http.StatusInternalServerError, "failed to compute depth stats")
+		return
+	}
+
+	json.NewEncoder(w).Encode(DepthStatsResponse{
+		Code:    200,
+		Message: "success",
+		Data:    stats,
+	})
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+func TestHealthReportsMessageCounters(t *testing.T) {
+	m := orderbook.NewManager()
+	m.SetVerifyChecksum(false)
+	for _, msg := range []string{
+		`{"action":"snapshot","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{"asks":[["101","1","0","1"]],"bids":[["100","1","0","1"]],"ts":"1717000000000"}]}`,
+		`{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":[{"instId":"BTC-USDT","bidPx":"100","askPx":"101","last":"100.5","ts":"1717000000000"}]}`,
+	} {
+		if err := m.ProcessMessage([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous, _ := messageStatsProvider.Load().(MessageStatsProvider)
+	SetMessageStatsProvider(m.Stats)
+	t.Cleanup(func() { SetMessageStatsProvider(previous) })
+
+	rec := httptest.NewRecorder()
+	handleHealthCheck(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var body struct {
+		Data struct {
+			Messages map[string]orderbook.MessageStats `json:"messages"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := body.Data.Messages["BTC-USDT"]; got != (orderbook.MessageStats{Snapshots: 1, Tickers: 1}) {
+		t.Fatalf("health messages %+v, want 1 snapshot and 1 ticker for BTC-USDT", body.Data.Messages)
+	}
+}
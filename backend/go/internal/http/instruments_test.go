@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+func getInstruments(t *testing.T) []map[string]interface{} {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handleInstruments(rec, httptest.NewRequest(http.MethodGet, "/api/instruments", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+	if resp.Data == nil {
+		t.Fatalf("data is not an array: %s", rec.Body.String())
+	}
+	return resp.Data
+}
+
+func TestInstrumentsReflectBookState(t *testing.T) {
+	m := orderbook.NewManager()
+	messages := []string{
+		// books5 carries no checksum, so the book counts as verified
+		`{"action":"snapshot","arg":{"channel":"books5","instId":"BTC-USDT"},"data":[{
+"asks":[["100.5","2","0","1"],["101","30","0","1"]],"bids":[["100","1","0","1"],["99.5","4","0","1"],["99","2","0","1"]],
+"ts":"1717000000000","checksum":0}]}`,
+		// a wrong checksum on the books channel is flagged
+		`{"action":"snapshot","arg":{"channel":"books","instId":"ETH-USDT"},"data":[{
+"asks":[["2001","1","0","1"]],"bids":[["2000","1","0","1"]],"ts":"1717000001000","checksum":12345}]}`,
+	}
+	for _, msg := range messages {
+		if err := m.ProcessMessage([]byte(msg)); err != nil {
+			t.Fatalf("process: %v", err)
+		}
+	}
+	if _, _, _, err := m.ComputeLargeOrderDistribution("BTC-USDT", 0.5, 0, 0, 0); err != nil {
+		t.Fatalf("large orders: %v", err)
+	}
+
+	SetInstrumentsProvider(func() []orderbook.InstrumentStatus {
+		return m.InstrumentStatuses([]string{"SOL-USDT", "BTC-USDT"})
+	})
+	defer SetInstrumentsProvider(nil)
+
+	got := getInstruments(t)
+	if len(got) != 3 {
+		t.Fatalf("%d instruments, want BTC-USDT, ETH-USDT and SOL-USDT: %v", len(got), got)
+	}
+	btc, eth, sol := got[0], got[1], got[2]
+
+	if btc["instrument_id"] != "BTC-USDT" || btc["has_book"] != true || btc["checksum_ok"] != true ||
+		btc["ask_levels"] != 2.0 || btc["bid_levels"] != 3.0 || btc["book_timestamp"] != 1717000000000.0 {
+		t.Errorf("BTC-USDT = %v", btc)
+	}
+	if _, ok := btc["sentiment"].(float64); !ok {
+		t.Errorf("BTC-USDT sentiment = %v, want the computed value", btc["sentiment"])
+	}
+
+	if eth["instrument_id"] != "ETH-USDT" || eth["has_book"] != true || eth["checksum_ok"] != false {
+		t.Errorf("ETH-USDT = %v, want a book with a failed checksum", eth)
+	}
+	if eth["sentiment"] != nil {
+		t.Errorf("ETH-USDT sentiment = %v before any computation, want null", eth["sentiment"])
+	}
+
+	if sol["instrument_id"] != "SOL-USDT" || sol["has_book"] != false || sol["bid_levels"] != 0.0 {
+		t.Errorf("SOL-USDT = %v, want subscribed without a book", sol)
+	}
+}
+
+func TestInstrumentsEmptyArray(t *testing.T) {
+	SetInstrumentsProvider(func() []orderbook.InstrumentStatus { return nil })
+	defer SetInstrumentsProvider(nil)
+
+	if got := getInstruments(t); len(got) != 0 {
+		t.Fatalf("instruments = %v, want none", got)
+	}
+}
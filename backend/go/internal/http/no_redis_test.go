@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+	"github.com/supermancell/okex-buddy/internal/redisclient"
+)
+
+const noRedisSnapshot = `{"action":"snapshot","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{
+"asks":[["100.5","2","0","1"],["101","3","0","1"]],
+"bids":[["100","1","0","1"],["99.5","4","0","1"]],"ts":"1717000000000"}]}`
+
+// With REDIS_REQUIRED=false the processing loop runs on a NopStore and the HTTP reads are
+// served from the Manager
+func TestProcessingWithoutRedis(t *testing.T) {
+	obManager := orderbook.NewManager()
+	obManager.SetVerifyChecksum(false)
+	if err := obManager.ProcessMessage([]byte(noRedisSnapshot)); err != nil {
+		t.Fatalf("process snapshot: %v", err)
+	}
+
+	cfg := config.LoadFromEnv()
+	cfg.Redis.PollIntervalSec = 1
+	cfg.Analysis.BookMaxAgeSec = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cycles := orderbook.GetProcessorStats().Cycles
+	go orderbook.StartOrderBookProcessor(ctx, obManager, redisclient.NopStore{}, cfg, nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for orderbook.GetProcessorStats().Cycles == cycles {
+		if time.Now().After(deadline) {
+			t.Fatal("no processing cycle ran on the no-op store")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	SetDepthStatsProvider(obManager.GetDepthStats)
+	rec := httptest.NewRecorder()
+	handleDepth(rec, httptest.NewRequest(http.MethodGet, depthPathPrefix+"BTC-USDT", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("depth status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp DepthStatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.BidLevels != 2 || resp.Data.AskTotalSize != 5 {
+		t.Fatalf("depth = %+v, want 2 bid levels and 5 ask size", resp.Data)
+	}
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// PrivateDataStore reads the orders and positions recorded from the private WebSocket
+type PrivateDataStore interface {
+	GetRecentOrders(instID string, limit, skip int) ([]mongodb.Order, error)
+	GetOpenPositions(instID string, limit, skip int) ([]mongodb.Position, error)
+}
+
+var privateDataStore atomic.Value // PrivateDataStore
+
+// SetPrivateDataStore sets the source used by GET /api/orders and GET /api/positions
+func SetPrivateDataStore(store PrivateDataStore) {
+	privateDataStore.Store(&store)
+}
+
+// loadPrivateDataStore returns the configured store, or nil when MongoDB is unavailable
+func loadPrivateDataStore() PrivateDataStore {
+	if store, ok := privateDataStore.Load().(*PrivateDataStore); ok && store != nil {
+		return *store
+	}
+	return nil
+}
+
+// parsePage reads ?limit= and ?offset= with defaults; ok is false on invalid values
+func parsePage(r *http.Request) (page Page, ok bool) {
+	page.Limit = defaultPageLimit
+	query := r.URL.Query()
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return page, false
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+		page.Limit = limit
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return page, false
+		}
+		page.Offset = offset
+	}
+
+	return page, true
+}
+
+// writeError writes an ErrorResponse with the given status code
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Code:    code,
+		Message: message,
+	})
+}
+
+// handleOrders lists recent orders, optionally filtered by ?instId=
+func handleOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	page, ok := parsePage(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit or offset")
+		return
+	}
+
+	store := loadPrivateDataStore()
+	if store == nil {
+		writeError(w, http.StatusServiceUnavailable, "MongoDB is not available")
+		return
+	}
+
+	orders, err := store.GetRecentOrders(r.URL.Query().Get("instId"), page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Failed to query orders: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to query orders")
+		return
+	}
+
+	json.NewEncoder(w).Encode(OrdersResponse{
+		Code:    200,
+		Message: "success",
+		Page:    page,
+		Data:    orders,
+	})
+}
+
+// handlePositions lists open positions, optionally filtered by ?instId=
+func handlePositions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	page, ok := parsePage(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid limit or offset")
+		return
+	}
+
+	store := loadPrivateDataStore()
+	if store == nil {
+		writeError(w, http.StatusServiceUnavailable, "MongoDB is not available")
+		return
+	}
+
+	positions, err := store.GetOpenPositions(r.URL.Query().Get("instId"), page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Failed to query positions: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to query positions")
+		return
+	}
+
+	json.NewEncoder(w).Encode(PositionsResponse{
+		Code:    200,
+		Message: "success",
+		Page:    page,
+		Data:    positions,
+	})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// privateQuery is one call made to the fake store
+type privateQuery struct {
+	method      string
+	instID      string
+	limit, skip int
+}
+
+// fakePrivateData records the queries and returns one row per call
+type fakePrivateData struct{ queries []privateQuery }
+
+func (f *fakePrivateData) GetRecentOrders(instID string, limit, skip int) ([]mongodb.Order, error) {
+	f.queries = append(f.queries, privateQuery{"orders", instID, limit, skip})
+	return []mongodb.Order{{OrdID: "42", InstID: instID}}, nil
+}
+
+func (f *fakePrivateData) GetOpenPositions(instID string, limit, skip int) ([]mongodb.Position, error) {
+	f.queries = append(f.queries, privateQuery{"positions", instID, limit, skip})
+	return []mongodb.Position{{PosID: "7", InstID: instID}}, nil
+}
+
+func TestPrivateDataEndpointsPassFilterAndPage(t *testing.T) {
+	store := &fakePrivateData{}
+	SetPrivateDataStore(store)
+	t.Cleanup(func() { SetPrivateDataStore(nil) })
+
+	tests := []struct {
+		url      string
+		handler  http.HandlerFunc
+		want     privateQuery
+		wantPage Page
+	}{
+		{"/api/orders?instId=BTC-USDT&limit=10&offset=30", handleOrders,
+			privateQuery{"orders", "BTC-USDT", 10, 30}, Page{Limit: 10, Offset: 30}},
+		{"/api/orders", handleOrders,
+			privateQuery{"orders", "", defaultPageLimit, 0}, Page{Limit: defaultPageLimit}},
+		{"/api/positions?instId=ETH-USDT-SWAP&limit=100000", handlePositions,
+			privateQuery{"positions", "ETH-USDT-SWAP", maxPageLimit, 0}, Page{Limit: maxPageLimit}},
+	}
+	for _, tt := range tests {
+		store.queries = nil
+		rec := httptest.NewRecorder()
+		tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.url, rec.Code, rec.Body.String())
+		}
+		if len(store.queries) != 1 || store.queries[0] != tt.want {
+			t.Fatalf("%s: queries %+v, want %+v", tt.url, store.queries, tt.want)
+		}
+
+		var resp struct {
+			Page Page              `json:"page"`
+			Data []json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode: %v", tt.url, err)
+		}
+		if resp.Page != tt.wantPage || len(resp.Data) != 1 {
+			t.Fatalf("%s: page %+v with %d rows, want %+v with 1", tt.url, resp.Page, len(resp.Data), tt.wantPage)
+		}
+	}
+}
+
+func TestPrivateDataEndpointsRejectBadRequests(t *testing.T) {
+	store := &fakePrivateData{}
+	SetPrivateDataStore(store)
+
+	for url, want := range map[string]int{
+		"/api/orders?limit=0":   http.StatusBadRequest,
+		"/api/orders?limit=ten": http.StatusBadRequest,
+		"/api/orders?offset=-1": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		handleOrders(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status %d, want %d", url, rec.Code, want)
+		}
+	}
+	if len(store.queries) != 0 {
+		t.Fatalf("invalid requests queried MongoDB: %+v", store.queries)
+	}
+
+	rec := httptest.NewRecorder()
+	handlePositions(rec, httptest.NewRequest(http.MethodPost, "/api/positions", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d, want 405", rec.Code)
+	}
+
+	// Without MongoDB the endpoints are unavailable rather than empty
+	SetPrivateDataStore(nil)
+	rec = httptest.NewRecorder()
+	handleOrders(rec, httptest.NewRequest(http.MethodGet, "/api/orders", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without a store: status %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	mongoHealthy    int32 = 1 // stays 1 when MongoDB is not configured
+	startupComplete int32
+)
+
+// ProbeResponse is the response of GET /livez and GET /readyz
+type ProbeResponse struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Checks  map[string]string `json:"checks,omitempty"` // dependency -> "ok" / "unavailable", /readyz only
+}
+
+// SetMongoHealthy sets the MongoDB health status
+func SetMongoHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreInt32(&mongoHealthy, 1)
+	} else {
+		atomic.StoreInt32(&mongoHealthy, 0)
+	}
+}
+
+// SetStartupComplete marks connection setup as finished; /readyz reports 503 until then
+func SetStartupComplete() {
+	atomic.StoreInt32(&startupComplete, 1)
+}
+
+// handleLivez reports that the process is up; it never checks dependencies
+func handleLivez(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	json.NewEncoder(w).Encode(ProbeResponse{
+		Code:    200,
+		Message: "alive",
+	})
+}
+
+// handleReadyz reports 200 once startup is complete and WebSocket, Redis and MongoDB are healthy
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	checks := map[string]string{
+		"startup":   probeStatus(atomic.LoadInt32(&startupComplete) == 1),
+		"websocket": probeStatus(atomic.LoadInt32(&wsHealthy) == 1),
+		"redis":     probeStatus(atomic.LoadInt32(&redisHealthy) == 1),
+		"mongodb":   probeStatus(atomic.LoadInt32(&mongoHealthy) == 1),
+	}
+
+	response := ProbeResponse{
+		Code:    200,
+		Message: "ready",
+		Checks:  checks,
+	}
+	for _, status := range checks {
+		if status != "ok" {
+			response.Code = http.StatusServiceUnavailable
+			response.Message = "not ready"
+			break
+		}
+	}
+
+	w.WriteHeader(response.Code)
+	json.NewEncoder(w).Encode(response)
+}
+
+func probeStatus(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "unavailable"
+}
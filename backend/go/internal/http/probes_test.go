@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// probe calls a probe endpoint through a mux wired like StartHTTPServer
+func probe(t *testing.T, server *httptest.Server, path string) (int, ProbeResponse) {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	var body ProbeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("GET %s: decode: %v", path, err)
+	}
+	if body.Code != resp.StatusCode {
+		t.Fatalf("GET %s: body code %d, status %d", path, body.Code, resp.StatusCode)
+	}
+	return resp.StatusCode, body
+}
+
+func TestLivenessAndReadinessDuringStartup(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", handleLivez)
+	mux.HandleFunc("/readyz", handleReadyz)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Fresh process: nothing connected yet
+	atomic.StoreInt32(&startupComplete, 0)
+	SetWSHealthy(false)
+	SetRedisHealthy(false)
+	t.Cleanup(func() {
+		atomic.StoreInt32(&startupComplete, 0)
+		SetWSHealthy(true)
+		SetRedisHealthy(true)
+		SetMongoHealthy(true)
+	})
+
+	steps := []struct {
+		name  string
+		apply func()
+		ready bool
+		down  []string // checks reported unavailable
+	}{
+		{"before connecting", func() {}, false, []string{"startup", "websocket", "redis"}},
+		{"connections up, startup running", func() { SetWSHealthy(true); SetRedisHealthy(true) }, false, []string{"startup"}},
+		{"startup complete", SetStartupComplete, true, nil},
+		{"MongoDB lost", func() { SetMongoHealthy(false) }, false, []string{"mongodb"}},
+	}
+	for _, step := range steps {
+		step.apply()
+
+		if code, body := probe(t, server, "/livez"); code != http.StatusOK || body.Checks != nil {
+			t.Fatalf("%s: /livez = %d %+v, want 200 without dependency checks", step.name, code, body)
+		}
+
+		code, body := probe(t, server, "/readyz")
+		wantCode := http.StatusServiceUnavailable
+		if step.ready {
+			wantCode = http.StatusOK
+		}
+		if code != wantCode {
+			t.Fatalf("%s: /readyz = %d %+v, want %d", step.name, code, body, wantCode)
+		}
+		var down []string
+		for _, check := range []string{"startup", "websocket", "redis", "mongodb"} {
+			if body.Checks[check] == "unavailable" {
+				down = append(down, check)
+			} else if body.Checks[check] != "ok" {
+				t.Fatalf("%s: check %s = %q", step.name, check, body.Checks[check])
+			}
+		}
+		if len(down) != len(step.down) {
+			t.Fatalf("%s: unavailable %v, want %v", step.name, down, step.down)
+		}
+		for i := range down {
+			if down[i] != step.down[i] {
+				t.Fatalf("%s: unavailable %v, want %v", step.name, down, step.down)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+// resyncPathPrefix is followed by the instrument ID, e.g. /api/resync/BTC-USDT
+const resyncPathPrefix = "/api/resync/"
+
+// ErrInstrumentNotSubscribed is returned by a Resyncer for an instrument that is not subscribed
+var ErrInstrumentNotSubscribed = errors.New("instrument is not subscribed")
+
+// Resyncer clears an instrument's order book, resubscribes it so that OKEx sends a fresh
+// snapshot, and returns the instrument's status afterwards
+type Resyncer func(instID string) (orderbook.InstrumentStatus, error)
+
+var resyncer atomic.Value // Resyncer
+
+// SetResyncer sets the handler used by POST /api/resync/{instId}
+func SetResyncer(r Resyncer) {
+	resyncer.Store(r)
+}
+
+// InstrumentResponse is the response of POST /api/resync/{instId}
+type InstrumentResponse struct {
+	Code    int                        `json:"code"`
+	Message string                     `json:"message"`
+	Data    orderbook.InstrumentStatus `json:"data"`
+}
+
+// handleResync forces a resync of a single instrument's order book
+func handleResync(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID := strings.TrimPrefix(r.URL.Path, resyncPathPrefix)
+	if instID == "" || strings.Contains(instID, "/") {
+		writeError(w, http.StatusBadRequest, "instrument ID is required")
+		return
+	}
+
+	resync, ok := resyncer.Load().(Resyncer)
+	if !ok || resync == nil {
+		writeError(w, http.StatusServiceUnavailable, "public WebSocket is not enabled")
+		return
+	}
+
+	status, err := resync(instID)
+	if errors.Is(err, ErrInstrumentNotSubscribed) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to resync %s: %v", instID, err)
+		writeError(w, http.StatusInternalServerError, "failed to resync instrument")
+		return
+	}
+
+	log.Printf("Order book of %s resynced on request", instID)
+	json.NewEncoder(w).Encode(InstrumentResponse{
+		Code:    200,
+		Message: "success",
+		Data:    status,
+	})
+}
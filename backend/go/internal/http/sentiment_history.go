@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/redisclient"
+)
+
+// sentimentPathPrefix is followed by the instrument ID and /history,
+// e.g. /api/sentiment/BTC-USDT/history?from=1700000000&to=1700003600
+const sentimentPathPrefix = "/api/sentiment/"
+
+// sentimentHistorySuffix ends the path of GET /api/sentiment/{instId}/history
+const sentimentHistorySuffix = "/history"
+
+// SentimentHistoryReader returns the retained sentiment values of an instrument with
+// from <= ts <= to (unix seconds), oldest first, see redisclient.Client.GetSentimentHistory.
+// ctx is the request's context, canceled when the client goes away.
+type SentimentHistoryReader func(ctx context.Context, instID string, from, to int64) ([]redisclient.SentimentHistoryPoint, error)
+
+var sentimentHistoryReader atomic.Value // SentimentHistoryReader
+
+// SetSentimentHistoryReader sets the source used by GET /api/sentiment/{instId}/history
+func SetSentimentHistoryReader(reader SentimentHistoryReader) {
+	sentimentHistoryReader.Store(reader)
+}
+
+// SentimentHistoryResponse is the response of GET /api/sentiment/{instId}/history
+type SentimentHistoryResponse struct {
+	Code    int                                 `json:"code"`
+	Message string                              `json:"message"`
+	Data    []redisclient.SentimentHistoryPoint `json:"data"`
+}
+
+// handleSentimentHistory returns the sentiment time series of an instrument, oldest first.
+// from and to (unix seconds) are optional and default to the whole retained history.
+func handleSentimentHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, sentimentPathPrefix)
+	if !strings.HasSuffix(path, sentimentHistorySuffix) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	instID := strings.TrimSuffix(path, sentimentHistorySuffix)
+	if instID == "" || strings.Contains(instID, "/") {
+		writeError(w, http.StatusBadRequest, "instrument ID is required")
+		return
+	}
+
+	var from, to int64
+	query := r.URL.Query()
+	for _, param := range []struct {
+		name string
+		dst  *int64
+	}{{"from", &from}, {"to", &to}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 0 {
+			writeError(w, http.StatusBadRequest, param.name+" must be a non-negative unix timestamp in seconds")
+			return
+		}
+		*param.dst = value
+	}
+
+	read, ok := sentimentHistoryReader.Load().(SentimentHistoryReader)
+	if !ok || read == nil {
+		writeError(w, http.StatusServiceUnavailable, "sentiment history is not available")
+		return
+	}
+
+	points, err := read(r.Context(), instID, from, to)
+	if err != nil {
+		log.Printf("Failed to read sentiment history for %s: %v", instID, err)
+		writeError(w, http.StatusInternalServerError, "failed to read sentiment history")
+		return
+	}
+
+	json.NewEncoder(w).Encode(SentimentHistoryResponse{
+		Code:    200,
+		Message: "success",
+		Data:    points,
+	})
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/supermancell/okex-buddy/internal/redisclient"
+)
+
+func TestSentimentHistoryReadsWithRequestContext(t *testing.T) {
+	var readErr error
+	SetSentimentHistoryReader(func(ctx context.Context, instID string, from, to int64) ([]redisclient.SentimentHistoryPoint, error) {
+		readErr = ctx.Err()
+		return nil, readErr
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, sentimentPathPrefix+"BTC-USDT"+sentimentHistorySuffix, nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	handleSentimentHistory(rec, req)
+
+	if !errors.Is(readErr, context.Canceled) {
+		t.Fatalf("reader saw context error %v, want the canceled request context", readErr)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d for a failed read, want 500", rec.Code)
+	}
+}
+
+func TestSentimentHistoryEndpointServesTheSeries(t *testing.T) {
+	client, err := redisclient.NewClient(miniredis.RunT(t).Addr(), "")
+	if err != nil {
+		t.Fatalf("connect to miniredis: %v", err)
+	}
+	defer client.Close()
+	client.SetSentimentHistorySize(3)
+	for ts, sentiment := range map[int64]float64{1717000030: 0.4, 1717000010: -0.1, 1717000020: 0.2, 1717000000: 0.9} {
+		if err := client.AppendSentimentHistory("BTC-USDT", sentiment, ts); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	SetSentimentHistoryReader(func(ctx context.Context, instID string, from, to int64) ([]redisclient.SentimentHistoryPoint, error) {
+		return client.WithContext(ctx).GetSentimentHistory(instID, from, to)
+	})
+
+	get := func(path string) (int, []redisclient.SentimentHistoryPoint) {
+		rec := httptest.NewRecorder()
+		handleSentimentHistory(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp SentimentHistoryResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("%s: decode %s: %v", path, rec.Body.String(), err)
+			}
+		}
+		return rec.Code, resp.Data
+	}
+
+	status, points := get("/api/sentiment/BTC-USDT/history")
+	want := []redisclient.SentimentHistoryPoint{
+		{Timestamp: 1717000010, Sentiment: -0.1}, {Timestamp: 1717000020, Sentiment: 0.2}, {Timestamp: 1717000030, Sentiment: 0.4},
+	}
+	if status != http.StatusOK || !reflect.DeepEqual(points, want) {
+		t.Fatalf("status %d, series %v, want %v", status, points, want)
+	}
+	if _, points := get("/api/sentiment/BTC-USDT/history?from=1717000015&to=1717000025"); !reflect.DeepEqual(points, want[1:2]) {
+		t.Fatalf("ranged series %v, want %v", points, want[1:2])
+	}
+	// An instrument without history is an empty array, not null
+	rec := httptest.NewRecorder()
+	handleSentimentHistory(rec, httptest.NewRequest(http.MethodGet, "/api/sentiment/ETH-USDT/history", nil))
+	if !strings.Contains(rec.Body.String(), `"data":[]`) {
+		t.Fatalf("empty history body %s, want an empty array", rec.Body.String())
+	}
+
+	for path, code := range map[string]int{
+		"/api/sentiment/BTC-USDT/history?from=-1":  http.StatusBadRequest,
+		"/api/sentiment/BTC-USDT/history?to=later": http.StatusBadRequest,
+		"/api/sentiment//history":                  http.StatusBadRequest,
+		"/api/sentiment/BTC-USDT":                  http.StatusNotFound,
+	} {
+		if status, _ := get(path); status != code {
+			t.Errorf("%s: status %d, want %d", path, status, code)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"sync/atomic"
 	"time"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
 )
 
 var (
@@ -20,18 +22,61 @@ type HealthCheckResponse struct {
 	Message string `json:"message"`
 	Data    struct {
 		WebSocket struct {
-			Status    string `json:"status"`
-			Message   string `json:"message"`
-			Timestamp int64  `json:"timestamp"`
+			Status          string `json:"status"`
+			Message         string `json:"message"`
+			Timestamp       int64  `json:"timestamp"`
+			DroppedMessages int64  `json:"dropped_messages"` // dropped on a full message queue since start
 		} `json:"websocket"`
 		Redis struct {
 			Status    string `json:"status"`
 			Message   string `json:"message"`
 			Timestamp int64  `json:"timestamp"`
 		} `json:"redis"`
+		Processor *orderbook.ProcessorStats         `json:"processor,omitempty"`
+		Messages  map[string]orderbook.MessageStats `json:"messages,omitempty"` // per instrument
 	} `json:"data"`
 }
 
+// InstrumentsProvider returns the status of every subscribed instrument
+type InstrumentsProvider func() []orderbook.InstrumentStatus
+
+var instrumentsProvider atomic.Value // InstrumentsProvider
+
+// SetInstrumentsProvider sets the source used by GET /api/instruments
+func SetInstrumentsProvider(provider InstrumentsProvider) {
+	instrumentsProvider.Store(provider)
+}
+
+// ProcessorStatsProvider returns the processing loop lag metrics
+type ProcessorStatsProvider func() orderbook.ProcessorStats
+
+var processorStatsProvider atomic.Value // ProcessorStatsProvider
+
+// SetProcessorStatsProvider sets the source of the processor section in GET /health
+func SetProcessorStatsProvider(provider ProcessorStatsProvider) {
+	processorStatsProvider.Store(provider)
+}
+
+// MessageStatsProvider returns the processed message counters per instrument
+type MessageStatsProvider func() map[string]orderbook.MessageStats
+
+var messageStatsProvider atomic.Value // MessageStatsProvider
+
+// SetMessageStatsProvider sets the source of the messages section in GET /health
+func SetMessageStatsProvider(provider MessageStatsProvider) {
+	messageStatsProvider.Store(provider)
+}
+
+// DroppedMessagesProvider returns how many public WebSocket messages were dropped on a full queue
+type DroppedMessagesProvider func() int64
+
+var droppedMessagesProvider atomic.Value // DroppedMessagesProvider
+
+// SetDroppedMessagesProvider sets the source of websocket.dropped_messages in GET /health
+func SetDroppedMessagesProvider(provider DroppedMessagesProvider) {
+	droppedMessagesProvider.Store(provider)
+}
+
 // SetWSHealthy sets the WebSocket health status
 func SetWSHealthy(healthy bool) {
 	if healthy {
@@ -61,6 +106,17 @@ func StartHTTPServer(addr string, done chan struct{}, stop chan struct{}) {
 	}
 
 	mux.HandleFunc("/health", handleHealthCheck)
+	mux.HandleFunc("/livez", handleLivez)
+	mux.HandleFunc("/readyz", handleReadyz)
+	mux.HandleFunc("/api/instruments", handleInstruments)
+	mux.HandleFunc(resyncPathPrefix, handleResync)
+	mux.HandleFunc(slippagePathPrefix, handleSlippage)
+	mux.HandleFunc(depthPathPrefix, handleDepth)
+	mux.HandleFunc(sentimentPathPrefix, handleSentimentHistory)
+	mux.HandleFunc("/api/orders", handleOrders)
+	mux.HandleFunc("/api/positions", handlePositions)
+	mux.HandleFunc("/api/candles", handleCandles)
+	mux.HandleFunc(analysisConfigPath, handleAnalysisConfig)
 
 	go func() {
 		log.Printf("HTTP server listening on %s", addr)
@@ -84,9 +140,9 @@ func StartHTTPServer(addr string, done chan struct{}, stop chan struct{}) {
 func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"code":    405,
-			"message": "method not allowed",
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Code:    405,
+			Message: "method not allowed",
 		})
 		return
 	}
@@ -108,6 +164,9 @@ func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 		response.Code = 503
 	}
 	response.Data.WebSocket.Timestamp = time.Now().Unix()
+	if provider, ok := droppedMessagesProvider.Load().(DroppedMessagesProvider); ok && provider != nil {
+		response.Data.WebSocket.DroppedMessages = provider()
+	}
 
 	if redisStatus == 1 {
 		response.Data.Redis.Status = "healthy"
@@ -119,6 +178,15 @@ func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 	response.Data.Redis.Timestamp = time.Now().Unix()
 
+	// Lag is informational only and does not affect the status code
+	if provider, ok := processorStatsProvider.Load().(ProcessorStatsProvider); ok && provider != nil {
+		stats := provider()
+		response.Data.Processor = &stats
+	}
+	if provider, ok := messageStatsProvider.Load().(MessageStatsProvider); ok && provider != nil {
+		response.Data.Messages = provider()
+	}
+
 	if response.Code == 503 {
 		response.Message = "service unavailable"
 	}
@@ -127,3 +195,30 @@ func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(response.Code)
 	json.NewEncoder(w).Encode(response)
 }
+
+// handleInstruments lists subscribed instruments and their order book state
+func handleInstruments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Code:    405,
+			Message: "method not allowed",
+		})
+		return
+	}
+
+	instruments := []orderbook.InstrumentStatus{}
+	if provider, ok := instrumentsProvider.Load().(InstrumentsProvider); ok && provider != nil {
+		if statuses := provider(); statuses != nil {
+			instruments = statuses
+		}
+	}
+
+	json.NewEncoder(w).Encode(InstrumentsResponse{
+		Code:    200,
+		Message: "success",
+		Data:    instruments,
+	})
+}
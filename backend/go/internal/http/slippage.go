@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+// slippagePathPrefix is followed by the instrument ID, e.g. /api/slippage/BTC-USDT?side=buy&notional=10000
+const slippagePathPrefix = "/api/slippage/"
+
+// FillEstimator estimates the execution of a market order, see orderbook.Manager.EstimateFillPrice
+type FillEstimator func(instID, side string, notional float64) (*orderbook.FillEstimate, error)
+
+var fillEstimator atomic.Value // FillEstimator
+
+// SetFillEstimator sets the estimator used by GET /api/slippage/{instId}
+func SetFillEstimator(estimator FillEstimator) {
+	fillEstimator.Store(estimator)
+}
+
+// SlippageResponse is the response of GET /api/slippage/{instId}
+type SlippageResponse struct {
+	Code    int                     `json:"code"`
+	Message string                  `json:"message"`
+	Data    *orderbook.FillEstimate `json:"data"`
+}
+
+// handleSlippage estimates the average fill price and slippage of a market order
+func handleSlippage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	instID := strings.TrimPrefix(r.URL.Path, slippagePathPrefix)
+	if instID == "" || strings.Contains(instID, "/") {
+		writeError(w, http.StatusBadRequest, "instrument ID is required")
+		return
+	}
+
+	query := r.URL.Query()
+	side := query.Get("side")
+	if side != "buy" && side != "sell" {
+		writeError(w, http.StatusBadRequest, "side must be buy or sell")
+		return
+	}
+	notional, err := strconv.ParseFloat(query.Get("notional"), 64)
+	// ParseFloat accepts NaN and Inf, which cannot be walked nor encoded as JSON
+	if err != nil || notional <= 0 || math.IsNaN(notional) || math.IsInf(notional, 0) {
+		writeError(w, http.StatusBadRequest, "notional must be a positive number")
+		return
+	}
+
+	estimate, ok := fillEstimator.Load().(FillEstimator)
+	if !ok || estimate == nil {
+		writeError(w, http.StatusServiceUnavailable, "order books are not available")
+		return
+	}
+
+	result, err := estimate(instID, side, notional)
+	if errors.Is(err, orderbook.ErrBookNotFound) || errors.Is(err, orderbook.ErrInsufficientData) {
+		writeError(w, http.StatusNotFound, "no order book for "+instID)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to estimate fill for %s: %v", instID, err)
+		writeError(w, http.StatusInternalServerError, "failed to estimate fill")
+		return
+	}
+
+	json.NewEncoder(w).Encode(SlippageResponse{
+		Code:    200,
+		Message: "success",
+		Data:    result,
+	})
+}
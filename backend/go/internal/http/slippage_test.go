@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+// slippageServer serves GET /api/slippage/ over a books5 book of BTC-USDT with mid 100.25:
+// asks 100.5 x 2, 101 x 30 and bids 100 x 1, 99.5 x 4, 99 x 2
+func slippageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	m := orderbook.NewManager()
+	msg := `{"action":"snapshot","arg":{"channel":"books5","instId":"BTC-USDT"},"data":[{
+"asks":[["100.5","2","0","1"],["101","30","0","1"]],"bids":[["100","1","0","1"],["99.5","4","0","1"],["99","2","0","1"]],
+"ts":"1717000000000","checksum":0}]}`
+	if err := m.ProcessMessage([]byte(msg)); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	SetFillEstimator(m.EstimateFillPrice)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(slippagePathPrefix, handleSlippage)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getSlippage(t *testing.T, server *httptest.Server, path string) (int, orderbook.FillEstimate) {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	var body SlippageResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Data == nil {
+			t.Fatalf("GET %s: decode: %v", path, err)
+		}
+		return resp.StatusCode, *body.Data
+	}
+	return resp.StatusCode, orderbook.FillEstimate{}
+}
+
+func TestSlippageWalksTheAsks(t *testing.T) {
+	server := slippageServer(t)
+
+	// 201 at 100.5 fills the first level, the other 799 come from 101
+	status, est := getSlippage(t, server, "/api/slippage/BTC-USDT?side=buy&notional=1000")
+	if status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	size := 2 + 799/101.0
+	wantAvg := 1000 / size
+	if math.Abs(est.AvgPrice-wantAvg) > 1e-9 || math.Abs(est.FilledSize-size) > 1e-9 {
+		t.Fatalf("avg %v for size %v, want %v for %v", est.AvgPrice, est.FilledSize, wantAvg, size)
+	}
+	if wantBps := (wantAvg - 100.25) / 100.25 * 10000; math.Abs(est.SlippageBps-wantBps) > 1e-6 {
+		t.Fatalf("slippage %v bps, want %v", est.SlippageBps, wantBps)
+	}
+	if !est.Sufficient || est.LevelsConsumed != 2 || est.WorstPrice != 101 || est.MidPrice != 100.25 {
+		t.Fatalf("estimate %+v, want a sufficient fill over 2 levels down to 101", est)
+	}
+}
+
+func TestSlippageOnTooThinBook(t *testing.T) {
+	server := slippageServer(t)
+
+	// The bids hold 100 + 398 + 198 = 696 of the 10000 requested
+	status, est := getSlippage(t, server, "/api/slippage/BTC-USDT?side=sell&notional=10000")
+	if status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if est.Sufficient || est.FilledNotional != 696 || est.FilledSize != 7 || est.LevelsConsumed != 3 || est.WorstPrice != 99 {
+		t.Fatalf("estimate %+v, want a partial fill of 696 over all 3 bid levels", est)
+	}
+	// Selling below mid is adverse, so slippage is positive
+	if wantBps := (100.25 - 696/7.0) / 100.25 * 10000; math.Abs(est.SlippageBps-wantBps) > 1e-6 {
+		t.Fatalf("slippage %v bps, want %v", est.SlippageBps, wantBps)
+	}
+}
+
+func TestSlippageRejectsBadRequests(t *testing.T) {
+	server := slippageServer(t)
+
+	for path, want := range map[string]int{
+		"/api/slippage/?side=buy&notional=10":              http.StatusBadRequest,
+		"/api/slippage/BTC-USDT?notional=10":               http.StatusBadRequest,
+		"/api/slippage/BTC-USDT?side=long&notional=10":     http.StatusBadRequest,
+		"/api/slippage/BTC-USDT?side=buy":                  http.StatusBadRequest,
+		"/api/slippage/BTC-USDT?side=buy&notional=abc":     http.StatusBadRequest,
+		"/api/slippage/BTC-USDT?side=buy&notional=-5":      http.StatusBadRequest,
+		"/api/slippage/BTC-USDT?side=buy&notional=NaN":     http.StatusBadRequest,
+		"/api/slippage/BTC-USDT?side=buy&notional=Inf":     http.StatusBadRequest,
+		"/api/slippage/BTC-USDT/x?side=buy&notional=10":    http.StatusBadRequest,
+		"/api/slippage/DOGE-USDT?side=buy&notional=10":     http.StatusNotFound,
+		"/api/slippage/BTC-USDT?side=sell&notional=1e-300": http.StatusOK,
+	} {
+		if status, _ := getSlippage(t, server, path); status != want {
+			t.Errorf("GET %s: status %d, want %d", path, status, want)
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/api/slippage/BTC-USDT?side=buy&notional=10", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: status %d, want 405", resp.StatusCode)
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+// ErrorResponse is returned by every endpoint on failure (e.g. 405 method not allowed)
+type ErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// InstrumentsResponse is the response of GET /api/instruments
+type InstrumentsResponse struct {
+	Code    int                          `json:"code"`
+	Message string                       `json:"message"`
+	Data    []orderbook.InstrumentStatus `json:"data"`
+}
+
+// Page describes the pagination applied to a list response
+type Page struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// OrdersResponse is the response of GET /api/orders
+type OrdersResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Page    Page            `json:"page"`
+	Data    []mongodb.Order `json:"data"`
+}
+
+// CandlesResponse is the response of GET /api/candles
+type CandlesResponse struct {
+	Code    int                   `json:"code"`
+	Message string                `json:"message"`
+	Data    []mongodb.Candlestick `json:"data"`
+}
+
+// PositionsResponse is the response of GET /api/positions
+type PositionsResponse struct {
+	Code    int                `json:"code"`
+	Message string             `json:"message"`
+	Page    Page               `json:"page"`
+	Data    []mongodb.Position `json:"data"`
+}
@@ -0,0 +1,95 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetCandlesticksFiltersSortsAndLimits(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	cases := []struct {
+		name     string
+		bar      string
+		from, to int64
+		limit    int
+	}{
+		{name: "bar and range", bar: "1H", from: 1717000000000, to: 1717003600000, limit: 2},
+		{name: "open ended from", bar: "5m", from: 1717000000000, limit: 500},
+		{name: "every bar up to", to: 1717003600000, limit: 10},
+		{name: "unfiltered", limit: 1},
+	}
+
+	for _, tc := range cases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			ns := mt.Coll.Database().Name() + ".candlesticks"
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "inst_id", Value: "BTC-USDT"}, {Key: "bar", Value: "1H"}, {Key: "timestamp", Value: int64(1717000000000)}, {Key: "close", Value: 67000.5}},
+				bson.D{{Key: "inst_id", Value: "BTC-USDT"}, {Key: "bar", Value: "1H"}, {Key: "timestamp", Value: int64(1717003600000)}, {Key: "close", Value: 67120.0}}))
+			c := &Client{client: mt.Client, database: mt.DB}
+
+			candles, err := c.GetCandlesticks("BTC-USDT", tc.bar, tc.from, tc.to, tc.limit)
+			if err != nil {
+				t.Fatalf("query: %v", err)
+			}
+			if len(candles) != 2 || candles[0].Timestamp != 1717000000000 || candles[1].Close != 67120.0 {
+				t.Fatalf("candles = %+v", candles)
+			}
+
+			find := mt.GetStartedEvent().Command
+			if got := find.Lookup("find").StringValue(); got != "candlesticks" {
+				t.Fatalf("find on %s, want candlesticks", got)
+			}
+			filter := find.Lookup("filter").Document()
+			if got := filter.Lookup("inst_id").StringValue(); got != "BTC-USDT" {
+				t.Fatalf("filter inst_id = %q", got)
+			}
+			if bar, err := filter.LookupErr("bar"); (tc.bar == "") != (err != nil) || (tc.bar != "" && bar.StringValue() != tc.bar) {
+				t.Fatalf("filter bar = %v, want %q", bar, tc.bar)
+			}
+
+			// Each bound is present only when set, 0 leaves that side open
+			for op, bound := range map[string]int64{"$gte": tc.from, "$lte": tc.to} {
+				value, err := filter.LookupErr("timestamp", op)
+				if bound == 0 && err == nil {
+					t.Fatalf("unexpected timestamp %s %s", op, value)
+				}
+				if bound != 0 && (err != nil || value.AsInt64() != bound) {
+					t.Fatalf("timestamp %s = %v, want %d", op, value, bound)
+				}
+			}
+			if _, err := filter.LookupErr("timestamp"); (tc.from == 0 && tc.to == 0) != (err != nil) {
+				t.Fatalf("timestamp filter present = %v for from=%d to=%d", err == nil, tc.from, tc.to)
+			}
+
+			want, _ := bson.Marshal(bson.D{{Key: "timestamp", Value: int32(1)}})
+			if got := find.Lookup("sort").Document(); string(got) != string(want) {
+				t.Fatalf("sort = %s, want oldest first", got)
+			}
+			if got := find.Lookup("limit").AsInt64(); got != int64(tc.limit) {
+				t.Fatalf("limit = %d, want %d", got, tc.limit)
+			}
+		})
+	}
+
+	mt.Run("index", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		c := &Client{client: mt.Client, database: mt.DB}
+		if err := c.EnsureCandlestickIndexes(); err != nil {
+			t.Fatalf("index: %v", err)
+		}
+
+		command := mt.GetStartedEvent().Command
+		indexes, _ := command.Lookup("indexes").Array().Values()
+		if command.Lookup("createIndexes").StringValue() != "candlesticks" || len(indexes) != 1 {
+			t.Fatalf("createIndexes %s", command)
+		}
+		// Equality fields first, then the sorted range field
+		want, _ := bson.Marshal(bson.D{{Key: "inst_id", Value: int32(1)}, {Key: "bar", Value: int32(1)}, {Key: "timestamp", Value: int32(1)}})
+		if got := indexes[0].Document().Lookup("key").Document(); string(got) != string(want) {
+			t.Fatalf("index key %s, want %s", got, bson.Raw(want))
+		}
+	})
+}
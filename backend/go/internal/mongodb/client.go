@@ -28,76 +28,105 @@ type ConfigItem struct {
 
 // Candlestick represents a candlestick data point
 type Candlestick struct {
-	ID             string  `bson:"_id,omitempty"`
-	Bar            string  `bson:"bar"`
-	InstrumentID   string  `bson:"inst_id"`
-	Timestamp      int64   `bson:"timestamp"`
-	Close          float64 `bson:"close"`
-	Confirm        int     `bson:"confirm"`
-	DayOfWeek      int     `bson:"day_of_week"`
-	High           float64 `bson:"high"`
-	Low            float64 `bson:"low"`
-	Open           float64 `bson:"open"`
-	RecordDT       string  `bson:"record_dt"`
-	RecordHour     int     `bson:"record_hour"`
-	VolCcy         float64 `bson:"vol_ccy"`
-	VolCcyQuote    float64 `bson:"vol_ccy_quote"`
-	Volume         float64 `bson:"volume"`
+	ID             string  `bson:"_id,omitempty" json:"id,omitempty"`
+	Bar            string  `bson:"bar" json:"bar"`
+	InstrumentID   string  `bson:"inst_id" json:"inst_id"`
+	Timestamp      int64   `bson:"timestamp" json:"timestamp"`
+	Close          float64 `bson:"close" json:"close"`
+	Confirm        int     `bson:"confirm" json:"confirm"`
+	DayOfWeek      int     `bson:"day_of_week" json:"day_of_week"`
+	High           float64 `bson:"high" json:"high"`
+	Low            float64 `bson:"low" json:"low"`
+	Open           float64 `bson:"open" json:"open"`
+	RecordDT       string  `bson:"record_dt" json:"record_dt"`
+	RecordHour     int     `bson:"record_hour" json:"record_hour"`
+	VolCcy         float64 `bson:"vol_ccy" json:"vol_ccy"`
+	VolCcyQuote    float64 `bson:"vol_ccy_quote" json:"vol_ccy_quote"`
+	Volume         float64 `bson:"volume" json:"volume"`
 }
 
 // Order represents an OKEx order
 type Order struct {
-	ID            string  `bson:"_id,omitempty"`
-	InstID        string  `bson:"inst_id"`
-	OrdID         string  `bson:"ord_id"`
-	ClOrdID       string  `bson:"cl_ord_id"`
-	Tag           string  `bson:"tag"`
-	SignalID      string  `bson:"signal_id,omitempty"`
-	Side          string  `bson:"side"`
-	OrdType       string  `bson:"ord_type"`
-	PosSide       string  `bson:"pos_side"`
-	State         string  `bson:"state"`
-	Sz            string  `bson:"sz"`
-	Px            string  `bson:"px"`
-	Lever         string  `bson:"lever"`
-	Tm            string  `bson:"tm"`
-	CTime         string  `bson:"c_time"`
-	UTime         string  `bson:"u_time"`
-	ReqID         string  `bson:"req_id,omitempty"`
-	Fee           string  `bson:"fee,omitempty"`
-	FillSz        string  `bson:"fill_sz"`
-	FillPx        string  `bson:"fill_px"`
-	FillTime      string  `bson:"fill_time"`
-	FillNotionalUSD string `bson:"fill_notional_usd"`
-	Pnl           string  `bson:"pnl,omitempty"`
-	PnlRatio      string  `bson:"pnl_ratio,omitempty"`
-	Category      string  `bson:"category"`
-	Timestamp     int64   `bson:"timestamp"`
+	ID            string  `bson:"_id,omitempty" json:"id,omitempty"`
+	InstID        string  `bson:"inst_id" json:"inst_id"`
+	OrdID         string  `bson:"ord_id" json:"ord_id"`
+	ClOrdID       string  `bson:"cl_ord_id" json:"cl_ord_id"`
+	Tag           string  `bson:"tag" json:"tag"`
+	SignalID      string  `bson:"signal_id,omitempty" json:"signal_id,omitempty"`
+	Side          string  `bson:"side" json:"side"`
+	OrdType       string  `bson:"ord_type" json:"ord_type"`
+	PosSide       string  `bson:"pos_side" json:"pos_side"`
+	State         string  `bson:"state" json:"state"`
+	Sz            string  `bson:"sz" json:"sz"`
+	Px            string  `bson:"px" json:"px"`
+	Lever         string  `bson:"lever" json:"lever"`
+	Tm            string  `bson:"tm" json:"tm"`
+	CTime         string  `bson:"c_time" json:"c_time"`
+	UTime         string  `bson:"u_time" json:"u_time"`
+	ReqID         string  `bson:"req_id,omitempty" json:"req_id,omitempty"`
+	Fee           string  `bson:"fee,omitempty" json:"fee,omitempty"`
+	FillSz        string  `bson:"fill_sz" json:"fill_sz"`
+	FillPx        string  `bson:"fill_px" json:"fill_px"`
+	FillTime      string  `bson:"fill_time" json:"fill_time"`
+	FillNotionalUSD string `bson:"fill_notional_usd" json:"fill_notional_usd"`
+	Pnl           string  `bson:"pnl,omitempty" json:"pnl,omitempty"`
+	PnlRatio      string  `bson:"pnl_ratio,omitempty" json:"pnl_ratio,omitempty"`
+	Category      string  `bson:"category" json:"category"`
+	Timestamp     int64   `bson:"timestamp" json:"timestamp"`
 }
 
 // Position represents an OKEx position
 type Position struct {
-	ID             string  `bson:"_id,omitempty"`
-	InstID         string  `bson:"inst_id"`
-	MgnMode        string  `bson:"mgn_mode"`
-	PosID          string  `bson:"pos_id"`
-	PosSide        string  `bson:"pos_side"`
-	Pos            string  `bson:"pos"`
-	BaseBal        string  `bson:"base_bal"`
-	QuoteBal       string  `bson:"quote_bal"`
-	PosCcy         string  `bson:"pos_ccy"`
-	PnlRatio       string  `bson:"pnl_ratio"`
-	Upl            string  `bson:"upl"`
-	UplRatio       string  `bson:"upl_ratio"`
-	Lever          string  `bson:"lever"`
-	LiqPx          string  `bson:"liq_px"`
-	MarkPx         string  `bson:"mark_px"`
-	CTime          string  `bson:"c_time"`
-	UTime          string  `bson:"u_time"`
-	ADL            string  `bson:"adl"`
-	NotionalUSD    string  `bson:"notional_usd"`
-	Last           string  `bson:"last"`
-	Timestamp      int64   `bson:"timestamp"`
+	ID             string  `bson:"_id,omitempty" json:"id,omitempty"`
+	InstID         string  `bson:"inst_id" json:"inst_id"`
+	MgnMode        string  `bson:"mgn_mode" json:"mgn_mode"`
+	PosID          string  `bson:"pos_id" json:"pos_id"`
+	PosSide        string  `bson:"pos_side" json:"pos_side"`
+	Pos            string  `bson:"pos" json:"pos"`
+	BaseBal        string  `bson:"base_bal" json:"base_bal"`
+	QuoteBal       string  `bson:"quote_bal" json:"quote_bal"`
+	PosCcy         string  `bson:"pos_ccy" json:"pos_ccy"`
+	PnlRatio       string  `bson:"pnl_ratio" json:"pnl_ratio"`
+	Upl            string  `bson:"upl" json:"upl"`
+	UplRatio       string  `bson:"upl_ratio" json:"upl_ratio"`
+	Lever          string  `bson:"lever" json:"lever"`
+	LiqPx          string  `bson:"liq_px" json:"liq_px"`
+	MarkPx         string  `bson:"mark_px" json:"mark_px"`
+	CTime          string  `bson:"c_time" json:"c_time"`
+	UTime          string  `bson:"u_time" json:"u_time"`
+	ADL            string  `bson:"adl" json:"adl"`
+	NotionalUSD    string  `bson:"notional_usd" json:"notional_usd"`
+	Last           string  `bson:"last" json:"last"`
+	Timestamp      int64   `bson:"timestamp" json:"timestamp"`
+}
+
+// Trade represents a public trade from the OKEx trades channel
+type Trade struct {
+	ID        string  `bson:"_id,omitempty"`
+	InstID    string  `bson:"inst_id"`
+	TradeID   string  `bson:"trade_id"`
+	Px        float64 `bson:"px"`
+	Sz        float64 `bson:"sz"`
+	Side      string  `bson:"side"`
+	Timestamp int64   `bson:"ts"`
+}
+
+// BookLevel is a single order book level, price and size kept as sent by OKEx
+type BookLevel struct {
+	Price      string `bson:"px"`
+	Size       string `bson:"sz"`
+	OrderCount int    `bson:"orders"`
+}
+
+// OrderBookSnapshot is a full order book at one point in time, for research and replay
+type OrderBookSnapshot struct {
+	ID        string      `bson:"_id,omitempty"`
+	InstID    string      `bson:"inst_id"`
+	Asks      []BookLevel `bson:"asks"` // ascending by price
+	Bids      []BookLevel `bson:"bids"` // descending by price
+	Checksum  int32       `bson:"checksum"`
+	SeqID     int64       `bson:"seq_id"`
+	Timestamp int64       `bson:"timestamp"` // OKEx book timestamp (ms)
 }
 
 // TradingSignal represents a trading signal
@@ -123,22 +152,54 @@ type TradingSignal struct {
 	UpdatedAt        string  `bson:"updated_at"`
 }
 
-// NewClient creates a new MongoDB client
+// Connection defaults used when the corresponding Options field is zero
+const (
+	defaultConnectAttempts        = 5
+	defaultConnectRetryInterval   = time.Second
+	maxConnectRetryInterval       = 30 * time.Second
+	defaultMaxPoolSize            = 50
+	defaultConnectTimeout         = 10 * time.Second
+	defaultServerSelectionTimeout = 10 * time.Second
+)
+
+// Options configures the MongoDB connection; zero values use the defaults above
+type Options struct {
+	Addr     string
+	Database string
+
+	// ConnectAttempts bounds the connect+ping attempts at startup, so the service waits
+	// for a MongoDB that is still starting instead of giving up on the first failure
+	ConnectAttempts int
+	// RetryInterval is the wait before the second attempt, doubled after every failure
+	RetryInterval time.Duration
+
+	MaxPoolSize            uint64
+	ConnectTimeout         time.Duration // per connection dial and per ping
+	ServerSelectionTimeout time.Duration
+}
+
+// NewClient creates a new MongoDB client with default connection settings
 func NewClient(addr string, dbName string) (*Client, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	return NewClientWithOptions(Options{Addr: addr, Database: dbName})
+}
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(addr))
+// NewClientWithOptions connects to MongoDB, retrying the connect+ping with exponential
+// backoff up to ConnectAttempts times
+func NewClientWithOptions(opts Options) (*Client, error) {
+	opts = opts.withDefaults()
+
+	var client *mongo.Client
+	err := retryConnect(opts.ConnectAttempts, opts.RetryInterval, time.Sleep, func() error {
+		var err error
+		client, err = connect(opts)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if err := client.Ping(ctx, nil); err != nil {
-		return nil, err
-	}
-
-	database := client.Database(dbName)
-	log.Printf("Connected to MongoDB at %s, database: %s", addr, dbName)
+	database := client.Database(opts.Database)
+	log.Printf("Connected to MongoDB at %s, database: %s", opts.Addr, opts.Database)
 
 	return &Client{
 		client:   client,
@@ -146,6 +207,68 @@ func NewClient(addr string, dbName string) (*Client, error) {
 	}, nil
 }
 
+// withDefaults fills in zero fields
+func (o Options) withDefaults() Options {
+	if o.ConnectAttempts <= 0 {
+		o.ConnectAttempts = defaultConnectAttempts
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = defaultConnectRetryInterval
+	}
+	if o.MaxPoolSize == 0 {
+		o.MaxPoolSize = defaultMaxPoolSize
+	}
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = defaultConnectTimeout
+	}
+	if o.ServerSelectionTimeout <= 0 {
+		o.ServerSelectionTimeout = defaultServerSelectionTimeout
+	}
+	return o
+}
+
+// connect creates a client and pings it once, disconnecting again when the ping fails
+func connect(opts Options) (*mongo.Client, error) {
+	clientOpts := options.Client().
+		ApplyURI(opts.Addr).
+		SetMaxPoolSize(opts.MaxPoolSize).
+		SetConnectTimeout(opts.ConnectTimeout).
+		SetServerSelectionTimeout(opts.ServerSelectionTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, err
+	}
+	return client, nil
+}
+
+// retryConnect calls attempt up to attempts times, sleeping interval after the first
+// failure and doubling it (capped at maxConnectRetryInterval) after each further one
+func retryConnect(attempts int, interval time.Duration, sleep func(time.Duration), attempt func() error) error {
+	var err error
+	for i := 1; i <= attempts; i++ {
+		if err = attempt(); err == nil {
+			return nil
+		}
+		if i == attempts {
+			break
+		}
+
+		log.Printf("MongoDB connection attempt %d/%d failed: %v, retrying in %v", i, attempts, err, interval)
+		sleep(interval)
+		interval = min(interval*2, maxConnectRetryInterval)
+	}
+	return fmt.Errorf("failed to connect to MongoDB after %d attempts: %w", attempts, err)
+}
+
 // InsertCandlestick inserts or updates a candlestick record
 func (c *Client) InsertCandlestick(candle *Candlestick) error {
 	collection := c.database.Collection("candlesticks")
@@ -163,6 +286,55 @@ func (c *Client) InsertCandlestick(candle *Candlestick) error {
 	return err
 }
 
+// EnsureCandlestickIndexes creates the (inst_id, bar, timestamp) index used by InsertCandlestick
+// and GetCandlesticks
+func (c *Client) EnsureCandlestickIndexes() error {
+	collection := c.database.Collection("candlesticks")
+
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "inst_id", Value: 1}, {Key: "bar", Value: 1}, {Key: "timestamp", Value: 1}},
+	}
+	_, err := collection.Indexes().CreateOne(context.Background(), index)
+	return err
+}
+
+// GetCandlesticks returns the candlesticks of an instrument oldest first, optionally filtered
+// by bar (e.g. 1H) and by from <= timestamp <= to (ms, 0 = unbounded), at most limit of them.
+// Page forward by passing the last returned timestamp + 1 as the next from.
+func (c *Client) GetCandlesticks(instID, bar string, from, to int64, limit int) ([]Candlestick, error) {
+	collection := c.database.Collection("candlesticks")
+
+	filter := bson.M{"inst_id": instID}
+	if bar != "" {
+		filter["bar"] = bar
+	}
+	timeRange := bson.M{}
+	if from > 0 {
+		timeRange["$gte"] = from
+	}
+	if to > 0 {
+		timeRange["$lte"] = to
+	}
+	if len(timeRange) > 0 {
+		filter["timestamp"] = timeRange
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := []Candlestick{}
+	if err := cursor.All(context.Background(), &candles); err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
 // Close closes the MongoDB connection
 func (c *Client) Close() error {
 	return c.client.Disconnect(context.Background())
@@ -237,14 +409,198 @@ func (c *Client) InsertPosition(position *Position) error {
 	return err
 }
 
-// InsertTradingSignal inserts a new trading signal record
-func (c *Client) InsertTradingSignal(signal *TradingSignal) error {
-	collection := c.database.Collection("trading_signals")
+// EnsureTradeIndexes creates the (inst_id, ts) index on the trades collection
+func (c *Client) EnsureTradeIndexes() error {
+	collection := c.database.Collection("trades")
+
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "inst_id", Value: 1}, {Key: "ts", Value: 1}},
+	}
+	_, err := collection.Indexes().CreateOne(context.Background(), index)
+	return err
+}
+
+// InsertTrade inserts a single trade record
+func (c *Client) InsertTrade(trade *Trade) error {
+	collection := c.database.Collection("trades")
 
-	_, err := collection.InsertOne(context.Background(), signal)
+	_, err := collection.InsertOne(context.Background(), trade)
 	return err
 }
 
+// InsertTrades inserts a batch of trade records in one round-trip
+func (c *Client) InsertTrades(trades []Trade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	collection := c.database.Collection("trades")
+
+	docs := make([]interface{}, len(trades))
+	for i := range trades {
+		docs[i] = trades[i]
+	}
+
+	opts := options.InsertMany().SetOrdered(false)
+	_, err := collection.InsertMany(context.Background(), docs, opts)
+	return err
+}
+
+// EnsureOrderBookSnapshotIndexes creates the (inst_id, timestamp) index on the orderbook_snapshots collection
+func (c *Client) EnsureOrderBookSnapshotIndexes() error {
+	collection := c.database.Collection("orderbook_snapshots")
+
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "inst_id", Value: 1}, {Key: "timestamp", Value: 1}},
+	}
+	_, err := collection.Indexes().CreateOne(context.Background(), index)
+	return err
+}
+
+// InsertOrderBookSnapshot inserts a full order book snapshot
+func (c *Client) InsertOrderBookSnapshot(snapshot *OrderBookSnapshot) error {
+	collection := c.database.Collection("orderbook_snapshots")
+
+	_, err := collection.InsertOne(context.Background(), snapshot)
+	return err
+}
+
+// InsertOrdersBulk upserts a batch of order records with a single BulkWrite,
+// using the same filter as InsertOrder
+func (c *Client) InsertOrdersBulk(orders []*Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	collection := c.database.Collection("orders")
+
+	models := make([]mongo.WriteModel, 0, len(orders))
+	for _, order := range orders {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"ord_id": order.OrdID}).
+			SetUpdate(bson.M{"$set": order}).
+			SetUpsert(true))
+	}
+
+	_, err := collection.BulkWrite(context.Background(), models)
+	return err
+}
+
+// InsertPositionsBulk upserts a batch of position records with a single BulkWrite,
+// using the same filter as InsertPosition
+func (c *Client) InsertPositionsBulk(positions []*Position) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	collection := c.database.Collection("positions")
+
+	models := make([]mongo.WriteModel, 0, len(positions))
+	for _, position := range positions {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"inst_id": position.InstID, "pos_id": position.PosID}).
+			SetUpdate(bson.M{"$set": position}).
+			SetUpsert(true))
+	}
+
+	_, err := collection.BulkWrite(context.Background(), models)
+	return err
+}
+
+// EnsurePrivateDataIndexes creates the indexes used by GetRecentOrders and GetOpenPositions
+func (c *Client) EnsurePrivateDataIndexes() error {
+	orderIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "c_time", Value: -1}}},
+		{Keys: bson.D{{Key: "inst_id", Value: 1}, {Key: "c_time", Value: -1}}},
+	}
+	if _, err := c.database.Collection("orders").Indexes().CreateMany(context.Background(), orderIndexes); err != nil {
+		return fmt.Errorf("failed to create order indexes: %w", err)
+	}
+
+	positionIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "inst_id", Value: 1}, {Key: "u_time", Value: -1}},
+	}
+	if _, err := c.database.Collection("positions").Indexes().CreateOne(context.Background(), positionIndex); err != nil {
+		return fmt.Errorf("failed to create position index: %w", err)
+	}
+	return nil
+}
+
+// GetRecentOrders returns orders newest first, optionally filtered by instrument.
+// skip and limit page through the results.
+func (c *Client) GetRecentOrders(instID string, limit, skip int) ([]Order, error) {
+	collection := c.database.Collection("orders")
+
+	filter := bson.M{}
+	if instID != "" {
+		filter["inst_id"] = instID
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "c_time", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip))
+
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []Order{}
+	if err := cursor.All(context.Background(), &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetOpenPositions returns positions with a non-zero size, most recently updated first,
+// optionally filtered by instrument. skip and limit page through the results.
+func (c *Client) GetOpenPositions(instID string, limit, skip int) ([]Position, error) {
+	collection := c.database.Collection("positions")
+
+	filter := bson.M{
+		"pos": bson.M{"$nin": bson.A{"", "0"}},
+	}
+	if instID != "" {
+		filter["inst_id"] = instID
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "u_time", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip))
+
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := []Position{}
+	if err := cursor.All(context.Background(), &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// InsertTradingSignal inserts a trading signal record unless one with the same
+// signal_id already exists. inserted is false for a duplicate signal.
+func (c *Client) InsertTradingSignal(signal *TradingSignal) (inserted bool, err error) {
+	collection := c.database.Collection("trading_signals")
+
+	filter := bson.M{
+		"signal_id": signal.SignalID,
+	}
+
+	update := bson.M{"$setOnInsert": signal}
+
+	opts := options.Update().SetUpsert(true)
+	result, err := collection.UpdateOne(context.Background(), filter, update, opts)
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
 // UpdateTradingSignal updates a trading signal record
 func (c *Client) UpdateTradingSignal(signalID string, update bson.M) error {
 	collection := c.database.Collection("trading_signals")
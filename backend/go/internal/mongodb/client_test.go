@@ -0,0 +1,203 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestBulkUpsertsIssueOneBulkWrite(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("orders", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}))
+		c := &Client{client: mt.Client, database: mt.DB}
+
+		orders := []*Order{{OrdID: "1", InstID: "BTC-USDT"}, {OrdID: "2", InstID: "BTC-USDT"}, {OrdID: "3", InstID: "ETH-USDT"}}
+		if err := c.InsertOrdersBulk(orders); err != nil {
+			t.Fatalf("bulk: %v", err)
+		}
+		assertOneUpsertCommand(mt, "orders", []bson.D{
+			{{Key: "ord_id", Value: "1"}}, {{Key: "ord_id", Value: "2"}}, {{Key: "ord_id", Value: "3"}},
+		})
+	})
+
+	mt.Run("positions", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}))
+		c := &Client{client: mt.Client, database: mt.DB}
+
+		positions := []*Position{{InstID: "BTC-USDT-SWAP", PosID: "7"}, {InstID: "ETH-USDT-SWAP", PosID: "8"}}
+		if err := c.InsertPositionsBulk(positions); err != nil {
+			t.Fatalf("bulk: %v", err)
+		}
+		assertOneUpsertCommand(mt, "positions", []bson.D{
+			{{Key: "inst_id", Value: "BTC-USDT-SWAP"}, {Key: "pos_id", Value: "7"}},
+			{{Key: "inst_id", Value: "ETH-USDT-SWAP"}, {Key: "pos_id", Value: "8"}},
+		})
+	})
+
+	mt.Run("empty batch", func(mt *mtest.T) {
+		c := &Client{client: mt.Client, database: mt.DB}
+		if err := c.InsertOrdersBulk(nil); err != nil {
+			t.Fatalf("empty: %v", err)
+		}
+		if events := mt.GetAllStartedEvents(); len(events) != 0 {
+			t.Fatalf("empty batch sent %d commands", len(events))
+		}
+	})
+}
+
+// assertOneUpsertCommand checks that exactly one update command was sent to collection,
+// upserting with the given filters in order
+func assertOneUpsertCommand(mt *mtest.T, collection string, filters []bson.D) {
+	mt.Helper()
+
+	events := mt.GetAllStartedEvents()
+	if len(events) != 1 || events[0].CommandName != "update" {
+		mt.Fatalf("started %d commands, want a single update", len(events))
+	}
+	if got := events[0].Command.Lookup("update").StringValue(); got != collection {
+		mt.Fatalf("update on %s, want %s", got, collection)
+	}
+
+	updates, err := events[0].Command.Lookup("updates").Array().Values()
+	if err != nil || len(updates) != len(filters) {
+		mt.Fatalf("%d updates (%v), want %d", len(updates), err, len(filters))
+	}
+	for i, update := range updates {
+		doc := update.Document()
+		if !doc.Lookup("upsert").Boolean() {
+			mt.Fatalf("update %d is not an upsert", i)
+		}
+		want, _ := bson.Marshal(filters[i])
+		if got := doc.Lookup("q").Document(); string(got) != string(want) {
+			mt.Fatalf("update %d filter = %s, want %s", i, got, bson.Raw(want))
+		}
+	}
+}
+
+func TestPrivateDataQueriesApplyFilterAndPage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("recent orders", func(mt *mtest.T) {
+		ns := mt.Coll.Database().Name() + ".orders"
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			bson.D{{Key: "ord_id", Value: "9"}, {Key: "inst_id", Value: "ETH-USDT"}}))
+		c := &Client{client: mt.Client, database: mt.DB}
+
+		orders, err := c.GetRecentOrders("ETH-USDT", 20, 40)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		if len(orders) != 1 || orders[0].OrdID != "9" {
+			t.Fatalf("orders = %+v", orders)
+		}
+
+		find := mt.GetStartedEvent().Command
+		assertFind(mt, find, "orders", `{"inst_id": "ETH-USDT"}`, `{"c_time": {"$numberInt":"-1"}}`, 20, 40)
+	})
+
+	mt.Run("all open positions", func(mt *mtest.T) {
+		ns := mt.Coll.Database().Name() + ".positions"
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch))
+		c := &Client{client: mt.Client, database: mt.DB}
+
+		positions, err := c.GetOpenPositions("", 50, 0)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		if positions == nil || len(positions) != 0 {
+			t.Fatalf("positions = %#v, want an empty non-nil slice", positions)
+		}
+
+		find := mt.GetStartedEvent().Command
+		assertFind(mt, find, "positions", `{"pos": {"$nin": ["", "0"]}}`, `{"u_time": {"$numberInt":"-1"}}`, 50, 0)
+	})
+}
+
+// assertFind checks the collection, filter, sort and paging of a find command.
+// filter and sort are extended JSON.
+func assertFind(mt *mtest.T, find bson.Raw, collection, filter, sort string, limit, skip int64) {
+	mt.Helper()
+
+	if got := find.Lookup("find").StringValue(); got != collection {
+		mt.Fatalf("find on %s, want %s", got, collection)
+	}
+	for field, want := range map[string]string{"filter": filter, "sort": sort} {
+		var wantDoc bson.Raw
+		if err := bson.UnmarshalExtJSON([]byte(want), false, &wantDoc); err != nil {
+			mt.Fatalf("bad %s %s: %v", field, want, err)
+		}
+		if got := find.Lookup(field).Document(); string(got) != string(wantDoc) {
+			mt.Fatalf("%s = %s, want %s", field, got, wantDoc)
+		}
+	}
+	if got := find.Lookup("limit").AsInt64(); got != limit {
+		mt.Fatalf("limit = %d, want %d", got, limit)
+	}
+	if value, err := find.LookupErr("skip"); (err == nil && value.AsInt64() != skip) || (err != nil && skip != 0) {
+		mt.Fatalf("skip = %v, want %d", value, skip)
+	}
+}
+
+func TestOrderBookSnapshotDocument(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("insert", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		c := &Client{client: mt.Client, database: mt.DB}
+
+		err := c.InsertOrderBookSnapshot(&OrderBookSnapshot{
+			InstID:    "BTC-USDT",
+			Asks:      []BookLevel{{Price: "43250.1", Size: "0.5", OrderCount: 3}},
+			Bids:      []BookLevel{{Price: "43250", Size: "1.25", OrderCount: 7}, {Price: "43249.9", Size: "2", OrderCount: 1}},
+			Checksum:  -1881014294,
+			SeqID:     123456,
+			Timestamp: 1717000000000,
+		})
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+
+		insert := mt.GetStartedEvent().Command
+		if got := insert.Lookup("insert").StringValue(); got != "orderbook_snapshots" {
+			t.Fatalf("insert into %s, want orderbook_snapshots", got)
+		}
+		docs, _ := insert.Lookup("documents").Array().Values()
+		if len(docs) != 1 {
+			t.Fatalf("%d documents, want 1", len(docs))
+		}
+		doc := docs[0].Document()
+		if doc.Lookup("inst_id").StringValue() != "BTC-USDT" || doc.Lookup("checksum").Int32() != -1881014294 ||
+			doc.Lookup("seq_id").Int64() != 123456 || doc.Lookup("timestamp").Int64() != 1717000000000 {
+			t.Fatalf("document %s", doc)
+		}
+		bids, _ := doc.Lookup("bids").Array().Values()
+		if len(bids) != 2 {
+			t.Fatalf("%d bids, want 2", len(bids))
+		}
+		want, _ := bson.Marshal(bson.D{{Key: "px", Value: "43250"}, {Key: "sz", Value: "1.25"}, {Key: "orders", Value: int32(7)}})
+		if got := bids[0].Document(); string(got) != string(want) {
+			t.Fatalf("best bid %s, want %s", got, bson.Raw(want))
+		}
+	})
+
+	mt.Run("index", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		c := &Client{client: mt.Client, database: mt.DB}
+		if err := c.EnsureOrderBookSnapshotIndexes(); err != nil {
+			t.Fatalf("index: %v", err)
+		}
+
+		command := mt.GetStartedEvent().Command
+		indexes, _ := command.Lookup("indexes").Array().Values()
+		if command.Lookup("createIndexes").StringValue() != "orderbook_snapshots" || len(indexes) != 1 {
+			t.Fatalf("createIndexes %s", command)
+		}
+		want, _ := bson.Marshal(bson.D{{Key: "inst_id", Value: int32(1)}, {Key: "timestamp", Value: int32(1)}})
+		if got := indexes[0].Document().Lookup("key").Document(); string(got) != string(want) {
+			t.Fatalf("index key %s, want %s", got, bson.Raw(want))
+		}
+	})
+}
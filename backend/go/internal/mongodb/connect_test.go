@@ -0,0 +1,94 @@
+package mongodb
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryConnectUntilPingSucceeds(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(previous)
+
+	// MongoDB comes up on the fourth ping
+	pings := 0
+	ping := func() error {
+		pings++
+		if pings < 4 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	var slept []time.Duration
+	sleep := func(d time.Duration) { slept = append(slept, d) }
+
+	if err := retryConnect(5, time.Second, sleep, ping); err != nil {
+		t.Fatalf("connect after three failed pings: %v", err)
+	}
+	if pings != 4 {
+		t.Fatalf("%d pings, want 4", pings)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}; !reflect.DeepEqual(slept, want) {
+		t.Fatalf("waits %v, want %v", slept, want)
+	}
+	if n := strings.Count(buf.String(), "retrying in"); n != 3 {
+		t.Fatalf("%d retries logged, want 3:\n%s", n, buf.String())
+	}
+
+	// The backoff is capped and the last error is kept once the attempts run out
+	slept = nil
+	down := errors.New("server selection timeout")
+	err := retryConnect(4, 20*time.Second, sleep, func() error { return down })
+	if !errors.Is(err, down) || !strings.Contains(err.Error(), "after 4 attempts") {
+		t.Fatalf("error = %v, want the last ping error after 4 attempts", err)
+	}
+	if want := []time.Duration{20 * time.Second, maxConnectRetryInterval, maxConnectRetryInterval}; !reflect.DeepEqual(slept, want) {
+		t.Fatalf("waits %v, want %v", slept, want)
+	}
+}
+
+func TestNewClientGivesUpOnUnreachableServer(t *testing.T) {
+	// A port that was free a moment ago refuses every connection
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(previous)
+
+	start := time.Now()
+	client, err := NewClientWithOptions(Options{
+		Addr:                   "mongodb://" + addr,
+		Database:               "test",
+		ConnectAttempts:        3,
+		RetryInterval:          10 * time.Millisecond,
+		ConnectTimeout:         time.Second,
+		ServerSelectionTimeout: 50 * time.Millisecond,
+	})
+	if err == nil {
+		client.Close()
+		t.Fatal("connected to a closed port")
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("error = %v, want it to report 3 attempts", err)
+	}
+	if n := strings.Count(buf.String(), "MongoDB connection attempt"); n != 2 {
+		t.Fatalf("%d retries logged, want 2:\n%s", n, buf.String())
+	}
+	// Three 50ms server selections plus 10ms and 20ms waits, nowhere near the 10s defaults
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("gave up after %v", elapsed)
+	}
+}
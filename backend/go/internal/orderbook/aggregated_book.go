@@ -0,0 +1,112 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// AggregatedLevel represents a single level with its running cumulative size
+type AggregatedLevel struct {
+	Price          float64 `json:"price"`
+	Size           float64 `json:"size"`
+	CumulativeSize float64 `json:"cumulative_size"`
+}
+
+// AggregatedSide represents cumulative totals of one side of the book plus its top levels
+type AggregatedSide struct {
+	CumulativeSize float64           `json:"cumulative_size"` // 该方向全部档位数量之和
+	TotalNotional  float64           `json:"total_notional"`  // 该方向全部档位名义价值之和 (price * size)
+	LevelCount     int               `json:"level_count"`
+	TopLevels      []AggregatedLevel `json:"top_levels"`
+}
+
+// AggregatedBook is a compact view of an order book for the frontend
+// 聚合后的订单簿，减少前端读取的数据量
+type AggregatedBook struct {
+	InstrumentID string         `json:"instrument_id"`
+	Asks         AggregatedSide `json:"asks"`
+	Bids         AggregatedSide `json:"bids"`
+	Timestamp    int64          `json:"timestamp"`
+}
+
+// ToRedisMap converts AggregatedBook to a map for Redis storage
+func (a AggregatedBook) ToRedisMap() (map[string]interface{}, error) {
+	askLevels, err := json.Marshal(a.Asks.TopLevels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ask levels: %w", err)
+	}
+	bidLevels, err := json.Marshal(a.Bids.TopLevels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bid levels: %w", err)
+	}
+
+	return map[string]interface{}{
+		"timestamp":           a.Timestamp,
+		"ask_cumulative_size": a.Asks.CumulativeSize,
+		"ask_total_notional":  a.Asks.TotalNotional,
+		"ask_level_count":     a.Asks.LevelCount,
+		"ask_levels":          string(askLevels),
+		"bid_cumulative_size": a.Bids.CumulativeSize,
+		"bid_total_notional":  a.Bids.TotalNotional,
+		"bid_level_count":     a.Bids.LevelCount,
+		"bid_levels":          string(bidLevels),
+	}, nil
+}
+
+// ComputeAggregatedBook computes cumulative size and total notional per side
+// and keeps the top N levels of each side
+// 计算买卖双方的累计数量与名义价值，并保留前N档
+func (m *Manager) ComputeAggregatedBook(instID string, topN int) (*AggregatedBook, error) {
+	asks, bids, err := m.GetTop400(instID)
+	if err != nil {
+		return nil, err
+	}
+	return m.aggregateBook(instID, asks, bids, topN), nil
+}
+
+// aggregateBook is ComputeAggregatedBook on the levels of a book snapshot
+func (m *Manager) aggregateBook(instID string, asks, bids []PriceLevel, topN int) *AggregatedBook {
+	if topN <= 0 {
+		topN = 20 // Default to 20 levels per side
+	}
+
+	return &AggregatedBook{
+		InstrumentID: instID,
+		Asks:         aggregateLevels(asks, topN),
+		Bids:         aggregateLevels(bids, topN),
+		Timestamp:    m.now().Unix(),
+	}
+}
+
+// aggregateLevels sums sizes and notionals of levels ordered from best to worst price
+func aggregateLevels(levels []PriceLevel, topN int) AggregatedSide {
+	side := AggregatedSide{
+		TopLevels: make([]AggregatedLevel, 0, min(topN, len(levels))),
+	}
+
+	for _, level := range levels {
+		price, err := strconv.ParseFloat(level.Price, 64)
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseFloat(level.Size, 64)
+		if err != nil {
+			continue
+		}
+
+		side.CumulativeSize += size
+		side.TotalNotional += price * size
+		side.LevelCount++
+
+		if len(side.TopLevels) < topN {
+			side.TopLevels = append(side.TopLevels, AggregatedLevel{
+				Price:          price,
+				Size:           size,
+				CumulativeSize: side.CumulativeSize,
+			})
+		}
+	}
+
+	return side
+}
@@ -0,0 +1,68 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestAggregatedBookTotalsEqualSumOfLevels(t *testing.T) {
+	// 30 bids from 1000 down and 30 asks from 1001 up, level i holding i+1 contracts
+	var asks, bids [][2]string
+	var wantBidSize, wantBidNotional, wantAskSize, wantAskNotional float64
+	for i := 0; i < 30; i++ {
+		size := float64(i + 1)
+		bidPx, askPx := 1000-float64(i)*0.5, 1001+float64(i)*0.5
+		bids = append(bids, [2]string{strconv.FormatFloat(bidPx, 'f', -1, 64), strconv.Itoa(i + 1)})
+		asks = append(asks, [2]string{strconv.FormatFloat(askPx, 'f', -1, 64), strconv.Itoa(i + 1)})
+		wantBidSize += size
+		wantBidNotional += bidPx * size
+		wantAskSize += size
+		wantAskNotional += askPx * size
+	}
+	m := NewManager()
+	loadSnapshot(t, m, "BTC-USDT-SWAP", asks, bids)
+
+	agg, err := m.ComputeAggregatedBook("BTC-USDT-SWAP", 5)
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+	for name, c := range map[string][2]float64{
+		"bid cumulative size": {agg.Bids.CumulativeSize, wantBidSize},
+		"bid total notional":  {agg.Bids.TotalNotional, wantBidNotional},
+		"ask cumulative size": {agg.Asks.CumulativeSize, wantAskSize},
+		"ask total notional":  {agg.Asks.TotalNotional, wantAskNotional},
+	} {
+		if math.Abs(c[0]-c[1]) > 1e-6 {
+			t.Errorf("%s = %v, want %v", name, c[0], c[1])
+		}
+	}
+	if agg.Bids.LevelCount != 30 || len(agg.Bids.TopLevels) != 5 {
+		t.Fatalf("bids: %d levels, %d top levels, want 30 and 5", agg.Bids.LevelCount, len(agg.Bids.TopLevels))
+	}
+
+	// Top levels run from the best price with a running total
+	running := 0.0
+	for i, level := range agg.Bids.TopLevels {
+		running += level.Size
+		if level.CumulativeSize != running {
+			t.Fatalf("bid level %d cumulative %v, want %v", i, level.CumulativeSize, running)
+		}
+	}
+	if agg.Bids.TopLevels[0].Price != 1000 || agg.Asks.TopLevels[0].Price != 1001 || running != 15 {
+		t.Fatalf("top levels start at bid %v / ask %v with 5-level total %v", agg.Bids.TopLevels[0].Price, agg.Asks.TopLevels[0].Price, running)
+	}
+
+	fields, err := agg.ToRedisMap()
+	if err != nil {
+		t.Fatalf("redis map: %v", err)
+	}
+	var stored []AggregatedLevel
+	if err := json.Unmarshal([]byte(fields["ask_levels"].(string)), &stored); err != nil || len(stored) != 5 {
+		t.Fatalf("stored ask levels = %v (%v), want 5 levels", fields["ask_levels"], err)
+	}
+	if fields["bid_cumulative_size"] != wantBidSize {
+		t.Fatalf("stored bid cumulative size = %v, want %v", fields["bid_cumulative_size"], wantBidSize)
+	}
+}
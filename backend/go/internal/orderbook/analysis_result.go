@@ -0,0 +1,213 @@
+package orderbook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// defaultSpreadZScoreWindowMinutes is the spread Z-score look-back used when none is configured
+const defaultSpreadZScoreWindowMinutes = 5
+
+// SpreadZScoreData represents the spread Z-score against its recent history
+type SpreadZScoreData struct {
+	ZScore    float64 `json:"z_score"`
+	Spread    float64 `json:"spread"`
+	Readiness float64 `json:"readiness"` // 0..1, how much of the look-back is filled
+}
+
+// LargeOrderData represents the large order distribution and smoothed sentiment
+type LargeOrderData struct {
+	LargeBuyNotional  float64 `json:"large_buy_notional"`
+	LargeSellNotional float64 `json:"large_sell_notional"`
+	Sentiment         float64 `json:"sentiment"`
+	Readiness         float64 `json:"readiness"` // 0..1, how much of the smoothing window is filled
+
+	Levels []LargeOrderLevel `json:"levels"` // the largest qualifying levels (walls), largest first
+}
+
+// AnalysisResult holds every book-based analysis of an instrument, all computed from the
+// same book snapshot. A nil sub-result means that analysis failed, see Errors.
+type AnalysisResult struct {
+	InstrumentID      string
+	BookTimestamp     int64      // ts of the snapshot the analyses ran on (ms)
+	Timestamp         int64      // analysis time (unix seconds)
+	Book              *OrderBook // the snapshot itself, shared read-only by the storage jobs
+	AggregatedBook    *AggregatedBook
+	SupportResistance *SupportResistanceData
+	SpreadZScore      *SpreadZScoreData
+	LargeOrder        *LargeOrderData
+	DepthAnomaly      *DepthAnomalyData
+	LiquidityShrink   *LiquidityShrinkData
+	ImbalanceFlip     *ImbalanceFlipEvent // nil when the imbalance did not flip
+	Errors            map[string]error    // analysis name (AnalysisSupportResistance, ...) -> error
+}
+
+// ComputeAll runs support/resistance, spread Z-score, large order distribution, depth anomaly,
+// liquidity shrinkage, imbalance flip detection and the aggregated book against a single
+// snapshot of the book, so that all results describe the same book state and the book lock
+// is taken once per instrument and cycle. Individual failures are reported in AnalysisResult.Errors; an error is
+// returned only when the book is not available. Analyses disabled in cfg are skipped and
+// leave their sub-result nil without an error.
+func (m *Manager) ComputeAll(instID string, cfg config.AnalysisConfig) (*AnalysisResult, error) {
+	book, err := m.Snapshot(instID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBookAge(instID, book, m.now(), time.Duration(cfg.BookMaxAgeSec)*time.Second); err != nil {
+		return nil, err
+	}
+	asks, bids := book.Asks, book.Bids
+
+	result := &AnalysisResult{
+		InstrumentID:   instID,
+		BookTimestamp:  book.Timestamp,
+		Timestamp:      m.now().Unix(),
+		Book:           book,
+		AggregatedBook: m.aggregateBook(instID, asks, bids, cfg.AggregatedBookTopN),
+		Errors:         make(map[string]error),
+	}
+
+	// The spread z-score samples the support/resistance spread, so the levels are computed
+	// whenever either analysis is on; each one only records into its own window
+	if cfg.EnableSupportResistance || cfg.EnableSpreadZScore {
+		supports, resistances, spread, anchor, err := m.computeSupportResistance(instID, asks, bids,
+			cfg.SupportResistanceBinCount,
+			cfg.SupportResistanceSignificanceThreshold,
+			cfg.SupportResistanceTopN,
+			cfg.SupportResistanceMinDistancePercent,
+			cfg.SupportResistanceMinDistanceTicks,
+			cfg.SupportResistanceMinNotional)
+		if err != nil {
+			if cfg.EnableSupportResistance {
+				result.Errors[AnalysisSupportResistance] = err
+			}
+		} else {
+			if cfg.EnableSupportResistance {
+				result.SupportResistance = &SupportResistanceData{
+					Supports:    supports,
+					Resistances: resistances,
+					Spread:      spread,
+					DeepMid:     anchor,
+					Significant: len(supports) > 0 || len(resistances) > 0,
+					Timestamp:   result.Timestamp,
+				}
+				m.recordSupportResistance(instID, *result.SupportResistance)
+			}
+			if cfg.EnableSpreadZScore {
+				m.recordSpread(instID, spread)
+			}
+		}
+	}
+
+	if cfg.EnableSpreadZScore {
+		windowMinutes := cfg.SpreadZScoreWindowMinutes
+		if windowMinutes <= 0 {
+			windowMinutes = defaultSpreadZScoreWindowMinutes
+		}
+		zScore, currentSpread, err := m.AnalyzeSpreadZScore(instID, windowMinutes)
+		if err != nil {
+			result.Errors[AnalysisSpreadZScore] = err
+		} else {
+			result.SpreadZScore = &SpreadZScoreData{
+				ZScore:    zScore,
+				Spread:    currentSpread,
+				Readiness: m.windowCoverage(m.spreadWindows, instID, int64(windowMinutes)*60),
+			}
+		}
+	}
+
+	if cfg.EnableLargeOrder {
+		largeOrder, err := m.computeLargeOrderDistribution(instID, asks, bids,
+			cfg.LargeOrderPercentileAlpha,
+			cfg.LargeOrderMinNotional,
+			cfg.LargeOrderDecayLambda,
+			cfg.LargeOrderSentimentDeadzoneThreshold)
+		if err != nil {
+			result.Errors[AnalysisLargeOrder] = err
+		} else {
+			largeOrder.Readiness = m.windowCoverage(m.sentimentMap, instID, 0)
+			result.LargeOrder = largeOrder
+		}
+	}
+
+	if cfg.EnableDepthAnomaly {
+		depth := depthInRange(asks, bids, cfg.DepthAnomalyPriceRangePercent)
+		if anomaly, err := m.detectDepthAnomaly(instID, depth, cfg.DepthAnomalyWindowSize, cfg.DepthAnomalyZThreshold); err != nil {
+			result.Errors[AnalysisDepthAnomaly] = err
+		} else {
+			result.DepthAnomaly = anomaly
+		}
+	}
+
+	if cfg.EnableLiquidityShrink {
+		metrics, err := m.liquidityMetrics(instID, asks, bids, cfg.LiquidityShrinkNearPriceDeltaPercent, cfg.LiquidityShrinkMidPriceMode)
+		if err == nil {
+			result.LiquidityShrink, err = m.detectLiquidityShrinkage(instID, metrics,
+				cfg.LiquidityShrinkNearPriceDeltaPercent,
+				cfg.LiquidityShrinkShortWindowSeconds,
+				cfg.LiquidityShrinkLongWindowSeconds,
+				cfg.LiquidityShrinkSlopeThreshold)
+		}
+		if err != nil {
+			result.Errors[AnalysisLiquidityShrink] = err
+			result.LiquidityShrink = nil
+		}
+	}
+
+	if cfg.EnableImbalanceFlip {
+		if result.ImbalanceFlip, err = m.detectImbalanceFlip(instID, asks, bids); err != nil {
+			result.Errors[AnalysisImbalanceFlip] = err
+		}
+	}
+
+	return result, nil
+}
+
+// ToRedisMap flattens the result into a single Redis hash, prefixing each analysis'
+// fields with its name, e.g. "depth_anomaly.z_score". Failed analyses store "<name>.error".
+func (r *AnalysisResult) ToRedisMap() map[string]interface{} {
+	fields := map[string]interface{}{
+		"instrument_id":  r.InstrumentID,
+		"book_timestamp": r.BookTimestamp,
+		"timestamp":      r.Timestamp,
+	}
+
+	addFields := func(name string, sub map[string]interface{}) {
+		for k, v := range sub {
+			fields[fmt.Sprintf("%s.%s", name, k)] = v
+		}
+	}
+
+	if r.SupportResistance != nil {
+		addFields(AnalysisSupportResistance, r.SupportResistance.ToRedisMap())
+	}
+	if r.SpreadZScore != nil {
+		addFields(AnalysisSpreadZScore, map[string]interface{}{
+			"z_score":   r.SpreadZScore.ZScore,
+			"spread":    r.SpreadZScore.Spread,
+			"readiness": r.SpreadZScore.Readiness,
+		})
+	}
+	if r.LargeOrder != nil {
+		addFields(AnalysisLargeOrder, map[string]interface{}{
+			"large_buy_notional":  r.LargeOrder.LargeBuyNotional,
+			"large_sell_notional": r.LargeOrder.LargeSellNotional,
+			"sentiment":           r.LargeOrder.Sentiment,
+			"readiness":           r.LargeOrder.Readiness,
+		})
+	}
+	if r.DepthAnomaly != nil {
+		addFields(AnalysisDepthAnomaly, r.DepthAnomaly.ToRedisMap())
+	}
+	if r.LiquidityShrink != nil {
+		addFields(AnalysisLiquidityShrink, r.LiquidityShrink.ToRedisMap())
+	}
+
+	for name, err := range r.Errors {
+		fields[name+".error"] = err.Error()
+	}
+
+	return fields
+}
@@ -0,0 +1,181 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// testClock is a settable clock for NewManagerWithClock
+type testClock struct{ t time.Time }
+
+func (c *testClock) Now() time.Time { return c.t }
+
+// loadSnapshot pushes a books snapshot with the given [price, size] levels into m
+func loadSnapshot(t *testing.T, m *Manager, instID string, asks, bids [][2]string) {
+	t.Helper()
+	pushBooks(t, m, "snapshot", instID, asks, bids)
+}
+
+// pushBooks pushes a books snapshot or update with the given [price, size] levels into m
+func pushBooks(t *testing.T, m *Manager, action, instID string, asks, bids [][2]string) {
+	t.Helper()
+
+	levels := func(in [][2]string) [][]string {
+		out := make([][]string, 0, len(in))
+		for _, l := range in {
+			out = append(out, []string{l[0], l[1], "0", "1"})
+		}
+		return out
+	}
+	msg, err := json.Marshal(map[string]interface{}{
+		"action": action,
+		"arg":    map[string]string{"channel": "books", "instId": instID},
+		"data": []map[string]interface{}{{
+			"asks": levels(asks),
+			"bids": levels(bids),
+			"ts":   "1717000000000",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("marshal %s: %v", action, err)
+	}
+
+	m.SetVerifyChecksum(false)
+	if err := m.ProcessMessage(msg); err != nil {
+		t.Fatalf("process %s: %v", action, err)
+	}
+}
+
+func TestSpreadZScoreWithoutSupportResistance(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	loadSnapshot(t, m, "BTC-USDT",
+		[][2]string{{"100.5", "1"}, {"101", "5"}, {"101.5", "1"}, {"102", "1"}},
+		[][2]string{{"100", "1"}, {"99.5", "5"}, {"99", "1"}, {"98.5", "1"}})
+
+	cfg := config.LoadFromEnv().Analysis
+	cfg.EnableSupportResistance = false
+	cfg.EnableSpreadZScore = true
+	cfg.BookMaxAgeSec = 0
+
+	for i := 0; i < 3; i++ {
+		result, err := m.ComputeAll("BTC-USDT", cfg)
+		if err != nil {
+			t.Fatalf("cycle %d: %v", i, err)
+		}
+		if result.SupportResistance != nil {
+			t.Fatalf("cycle %d: support/resistance computed while disabled", i)
+		}
+		if i > 0 {
+			if err := result.Errors[AnalysisSpreadZScore]; err != nil {
+				t.Fatalf("cycle %d: spread z-score failed with support/resistance disabled: %v", i, err)
+			}
+			if result.SpreadZScore == nil {
+				t.Fatalf("cycle %d: no spread z-score", i)
+			}
+		}
+		clock.t = clock.t.Add(time.Second)
+	}
+
+	if w := m.supportResistanceWindows["BTC-USDT"]; w != nil && len(w.GetItems()) > 0 {
+		t.Fatal("support/resistance window filled while disabled")
+	}
+}
+
+func TestComputeAllUsesOneSnapshot(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	deepSnapshot(t, m, "BTC-USDT")
+
+	cfg := config.LoadFromEnv().Analysis
+	cfg.BookMaxAgeSec = 0
+	cfg.EnableSupportResistance, cfg.EnableSpreadZScore, cfg.EnableLargeOrder = true, true, true
+	cfg.EnableDepthAnomaly, cfg.EnableLiquidityShrink = true, true
+
+	// Every update rescales all sizes near the top at once, so results computed from two
+	// different book states would disagree with result.Book
+	stop := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for i := 1; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			var asks, bids [][]string
+			for l := 0; l < 20; l++ {
+				size := strconv.Itoa(1 + (i+l)%9)
+				asks = append(asks, []string{fmt.Sprintf("%d.5", 100+l), size, "0", "1"})
+				bids = append(bids, []string{strconv.Itoa(100 - l), size, "0", "1"})
+			}
+			msg, _ := json.Marshal(map[string]interface{}{
+				"action": "update",
+				"arg":    map[string]string{"channel": "books", "instId": "BTC-USDT"},
+				"data":   []map[string]interface{}{{"asks": asks, "bids": bids, "ts": "1717000000000"}},
+			})
+			if err := m.ProcessMessage(msg); err != nil {
+				t.Errorf("update %d: %v", i, err)
+				return
+			}
+		}
+	}()
+
+	var result *AnalysisResult
+	for cycle := 0; cycle < 5; cycle++ {
+		var err error
+		if result, err = m.ComputeAll("BTC-USDT", cfg); err != nil {
+			t.Fatalf("cycle %d: %v", cycle, err)
+		}
+		clock.t = clock.t.Add(time.Second)
+
+		// Recompute from the snapshot the result carries
+		reference := NewManager()
+		large, err := reference.computeLargeOrderDistribution("BTC-USDT", result.Book.Asks, result.Book.Bids,
+			cfg.LargeOrderPercentileAlpha, cfg.LargeOrderMinNotional, cfg.LargeOrderDecayLambda, cfg.LargeOrderSentimentDeadzoneThreshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.LargeOrder.LargeBuyNotional != large.LargeBuyNotional || result.LargeOrder.LargeSellNotional != large.LargeSellNotional {
+			t.Fatalf("cycle %d: large orders %v/%v, the snapshot gives %v/%v", cycle,
+				result.LargeOrder.LargeBuyNotional, result.LargeOrder.LargeSellNotional, large.LargeBuyNotional, large.LargeSellNotional)
+		}
+		_, _, spread, _, err := reference.computeSupportResistance("BTC-USDT", result.Book.Asks, result.Book.Bids,
+			cfg.SupportResistanceBinCount, cfg.SupportResistanceSignificanceThreshold, cfg.SupportResistanceTopN,
+			cfg.SupportResistanceMinDistancePercent, cfg.SupportResistanceMinDistanceTicks, cfg.SupportResistanceMinNotional)
+		if err != nil || spread != result.SupportResistance.Spread {
+			t.Fatalf("cycle %d: support/resistance spread %v, the snapshot gives %v (%v)", cycle, result.SupportResistance.Spread, spread, err)
+		}
+	}
+	close(stop)
+	<-writerDone
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("errors after warm-up: %v", result.Errors)
+	}
+	if result.SupportResistance == nil || result.SpreadZScore == nil || result.LargeOrder == nil ||
+		result.DepthAnomaly == nil || result.LiquidityShrink == nil || result.AggregatedBook == nil {
+		t.Fatalf("missing sub-results: %+v", result)
+	}
+	if result.BookTimestamp != result.Book.Timestamp {
+		t.Fatalf("BookTimestamp %d, snapshot ts %d", result.BookTimestamp, result.Book.Timestamp)
+	}
+
+	fields := result.ToRedisMap()
+	for _, prefix := range []string{AnalysisSupportResistance, AnalysisSpreadZScore, AnalysisLargeOrder, AnalysisDepthAnomaly, AnalysisLiquidityShrink} {
+		found := false
+		for key := range fields {
+			found = found || strings.HasPrefix(key, prefix+".")
+		}
+		if !found {
+			t.Errorf("ToRedisMap has no %s fields", prefix)
+		}
+	}
+}
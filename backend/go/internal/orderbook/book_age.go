@@ -0,0 +1,61 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStaleBook is returned by ComputeAll when the book has not been updated for longer
+// than BookMaxAgeSec, e.g. during a reconnect gap, so no warning is raised on frozen data
+var ErrStaleBook = errors.New("order book is stale")
+
+// OrderBookAge returns the time since the last snapshot or update was applied to the book
+func (m *Manager) OrderBookAge(instID string) (time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, exists := m.books[instID]
+	if !exists {
+		return 0, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
+	}
+	return m.now().Sub(time.UnixMilli(book.UpdatedAt)), nil
+}
+
+// checkBookAge returns ErrStaleBook (wrapped) when the book is older than maxAge; 0 disables the check
+func checkBookAge(instID string, book *OrderBook, now time.Time, maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	if age := now.Sub(time.UnixMilli(book.UpdatedAt)); age > maxAge {
+		return fmt.Errorf("%s: last update %s ago: %w", instID, age.Truncate(time.Second), ErrStaleBook)
+	}
+	return nil
+}
+
+// staleBookTracker remembers which books were stale in the last cycle, so that going stale
+// and recovering are logged once per transition instead of on every cycle
+type staleBookTracker struct {
+	mu    sync.Mutex
+	stale map[string]bool
+}
+
+// mark records whether the book of instID is stale and reports whether that changed
+func (t *staleBookTracker) mark(instID string, stale bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stale[instID] == stale {
+		return false
+	}
+	if stale {
+		if t.stale == nil {
+			t.stale = make(map[string]bool)
+		}
+		t.stale[instID] = true
+	} else {
+		delete(t.stale, instID)
+	}
+	return true
+}
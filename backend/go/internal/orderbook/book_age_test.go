@@ -0,0 +1,55 @@
+package orderbook
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/testutil"
+)
+
+// captureLog redirects the standard logger into the returned buffer for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	return &buf
+}
+
+func TestStaleBookLoggedOnTransition(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "1"}}, [][2]string{{"100", "1"}})
+
+	cfg := config.LoadFromEnv()
+	cfg.Analysis.BookMaxAgeSec = 5
+	store := testutil.NewRecordingStore()
+	breaker := NewCircuitBreaker(0, 0)
+	logs := captureLog(t)
+
+	clock.t = clock.t.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if jobs := instrumentJobs("BTC-USDT", m, store, cfg, breaker); len(jobs) != 0 {
+			t.Fatalf("cycle %d: %d jobs for a stale book", i, len(jobs))
+		}
+	}
+	if n := strings.Count(logs.String(), "Skipping analysis"); n != 1 {
+		t.Fatalf("stale book logged %d times over 3 cycles, want once:\n%s", n, logs)
+	}
+
+	pushBooks(t, m, "update", "BTC-USDT", nil, [][2]string{{"100", "2"}})
+	for i := 0; i < 2; i++ {
+		if jobs := instrumentJobs("BTC-USDT", m, store, cfg, breaker); len(jobs) == 0 {
+			t.Fatalf("cycle %d: no jobs for a fresh book", i)
+		}
+	}
+	if n := strings.Count(logs.String(), "analysis resumed"); n != 1 {
+		t.Fatalf("recovery logged %d times, want once:\n%s", n, logs)
+	}
+}
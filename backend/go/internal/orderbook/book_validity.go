@@ -0,0 +1,56 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// ErrCrossedBook is returned when a book's best bid is at or above its best ask, which
+// only happens after a bad merge and would poison every spread and mid based metric
+var ErrCrossedBook = errors.New("crossed order book")
+
+// crossedTop reports whether a book is crossed (best bid > best ask) or locked
+// (best bid == best ask). One-sided or unparsable books are not reported.
+func crossedTop(book *OrderBook) (bid, ask float64, crossed bool) {
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return 0, 0, false
+	}
+	bid, errBid := strconv.ParseFloat(book.Bids[0].Price, 64)
+	ask, errAsk := strconv.ParseFloat(book.Asks[0].Price, 64)
+	if errBid != nil || errAsk != nil {
+		return 0, 0, false
+	}
+	return bid, ask, bid >= ask
+}
+
+// IsBookValid reports whether an instrument has a book whose best bid is below its best ask
+func (m *Manager) IsBookValid(instID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	book, exists := m.books[instID]
+	if !exists {
+		return false
+	}
+	_, _, crossed := crossedTop(book)
+	return !crossed
+}
+
+// rejectCrossedLocked drops a crossed or locked book and requests a resync through the
+// sequence gap handler, so that no analysis runs on it. Caller holds mu.
+func (m *Manager) rejectCrossedLocked(instID string, book *OrderBook) error {
+	bid, ask, crossed := crossedTop(book)
+	if !crossed {
+		return nil
+	}
+
+	log.Print(config.Colorf(config.Red, "Crossed book for %s: best bid %v >= best ask %v, dropping it and resyncing", instID, bid, ask))
+	m.resetBookLocked(instID)
+	if m.onSequenceGap != nil {
+		m.onSequenceGap(instID)
+	}
+	return fmt.Errorf("%s: best bid %v >= best ask %v: %w", instID, bid, ask, ErrCrossedBook)
+}
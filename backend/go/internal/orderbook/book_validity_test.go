@@ -0,0 +1,104 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// booksFrame builds a raw books message whose levels are "price:size" pairs
+func booksFrame(action, instID string, asks, bids []string) []byte {
+	side := func(levels []string) string {
+		parts := make([]string, 0, len(levels))
+		for _, l := range levels {
+			px, sz, _ := strings.Cut(l, ":")
+			parts = append(parts, fmt.Sprintf(`[%q,%q,"0","1"]`, px, sz))
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	}
+	return []byte(fmt.Sprintf(`{"action":%q,"arg":{"channel":"books","instId":%q},"data":[{"asks":%s,"bids":%s,"ts":"1717000000000"}]}`,
+		action, instID, side(asks), side(bids)))
+}
+
+func TestCrossedAndLockedBooksAreResynced(t *testing.T) {
+	cases := []struct {
+		name       string
+		action     string
+		asks, bids []string
+	}{
+		{name: "bid update through the ask", action: "update", bids: []string{"100.7:2"}},
+		{name: "ask update onto the bid", action: "update", asks: []string{"100:3"}},
+		{name: "crossed snapshot", action: "snapshot", asks: []string{"99:1", "99.5:1"}, bids: []string{"100:1", "99.8:1"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := &testClock{t: time.UnixMilli(1717000000000)}
+			m := NewManagerWithClock(clock.Now)
+			m.SetVerifyChecksum(false)
+			var resynced []string
+			m.SetSequenceGapHandler(func(instID string) { resynced = append(resynced, instID) })
+
+			if err := m.ProcessMessage(booksFrame("snapshot", "ETH-USDT",
+				[]string{"100.5:1", "101:2"}, []string{"100:1", "99.5:2"})); err != nil {
+				t.Fatalf("clean snapshot: %v", err)
+			}
+			if !m.IsBookValid("ETH-USDT") {
+				t.Fatal("clean book reported invalid")
+			}
+
+			logs := captureLog(t)
+			err := m.ProcessMessage(booksFrame(tc.action, "ETH-USDT", tc.asks, tc.bids))
+			if !errors.Is(err, ErrCrossedBook) {
+				t.Fatalf("err = %v, want ErrCrossedBook", err)
+			}
+			if !strings.Contains(logs.String(), "Crossed book for ETH-USDT") {
+				t.Fatalf("crossed book not logged: %q", logs.String())
+			}
+			if len(resynced) != 1 || resynced[0] != "ETH-USDT" {
+				t.Fatalf("resync requested for %v, want ETH-USDT once", resynced)
+			}
+			if m.HasBook("ETH-USDT") || m.IsBookValid("ETH-USDT") {
+				t.Fatal("crossed book kept")
+			}
+
+			cfg := config.LoadFromEnv().Analysis
+			cfg.BookMaxAgeSec = 0
+			if _, err := m.ComputeAll("ETH-USDT", cfg); err == nil {
+				t.Fatal("analysis ran on a dropped book")
+			}
+
+			// Stray updates wait for the fresh snapshot, which restores the book
+			if err := m.ProcessMessage(booksFrame("update", "ETH-USDT", []string{"100.6:1"}, nil)); err != nil {
+				t.Fatalf("stray update: %v", err)
+			}
+			if err := m.ProcessMessage(booksFrame("snapshot", "ETH-USDT",
+				[]string{"100.5:1"}, []string{"100.4:1"})); err != nil {
+				t.Fatalf("resync snapshot: %v", err)
+			}
+			if !m.IsBookValid("ETH-USDT") {
+				t.Fatal("resynced book reported invalid")
+			}
+		})
+	}
+}
+
+func TestOneSidedBookIsNotCrossed(t *testing.T) {
+	m := NewManager()
+	m.SetVerifyChecksum(false)
+	m.SetSequenceGapHandler(func(instID string) { t.Fatalf("resync requested for %s", instID) })
+
+	if err := m.ProcessMessage(booksFrame("snapshot", "SOL-USDT", nil, []string{"20:5"})); err != nil {
+		t.Fatalf("bids only snapshot: %v", err)
+	}
+	if !m.IsBookValid("SOL-USDT") {
+		t.Fatal("one-sided book reported invalid")
+	}
+	if m.IsBookValid("DOGE-USDT") {
+		t.Fatal("missing book reported valid")
+	}
+}
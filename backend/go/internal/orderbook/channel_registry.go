@@ -0,0 +1,47 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// ChannelHandler parses the data of one push on a channel. action is "snapshot" or
+// "update" for books channels and empty for most others.
+type ChannelHandler func(arg ArgData, action string, data json.RawMessage) error
+
+// registerBuiltinChannels registers the handlers of the channels the Manager understands
+func (m *Manager) registerBuiltinChannels() {
+	for _, channel := range []string{config.BooksChannel, config.BooksL2TBTChannel, config.Books50L2TBTChannel,
+		config.Books5Channel, config.BBOTBTChannel} {
+		m.channelHandlers[channel] = m.processBooksMessage
+	}
+	m.channelHandlers[config.TickerChannel] = m.processTickersMessage
+	m.channelHandlers[config.TradesChannel] = m.processTradesMessage
+	m.channelHandlers[config.FundingRateChannel] = m.processFundingRateMessage
+	m.channelHandlers[config.OpenInterestChannel] = m.processOpenInterestMessage
+	m.channelHandlers[config.MarkPriceChannel] = m.processMarkPriceMessage
+	m.channelHandlers[config.IndexTickersChannel] = m.processIndexTickersMessage
+}
+
+// RegisterChannelHandler routes data pushes of channel to handler, replacing any handler
+// registered before, including the built-in ones of registerBuiltinChannels. Frames of
+// channels without a handler are reported as ErrUnknownFrame by ProcessMessage.
+func (m *Manager) RegisterChannelHandler(channel string, handler ChannelHandler) error {
+	if channel == "" || handler == nil {
+		return fmt.Errorf("channel handler needs a channel name and a handler")
+	}
+
+	m.handlersMu.Lock()
+	defer m.handlersMu.Unlock()
+	m.channelHandlers[channel] = handler
+	return nil
+}
+
+// channelHandler returns the handler registered for channel, nil if none
+func (m *Manager) channelHandler(channel string) ChannelHandler {
+	m.handlersMu.RLock()
+	defer m.handlersMu.RUnlock()
+	return m.channelHandlers[channel]
+}
@@ -0,0 +1,90 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// liquidationPush is a data push of a channel the Manager has no built-in handler for
+const liquidationPush = `{"arg":{"channel":"liquidation-orders","instId":"BTC-USDT-SWAP"},"data":[{"side":"sell","sz":"3"}]}`
+
+func TestCustomChannelHandlerIsInvoked(t *testing.T) {
+	m := NewManager()
+	captureLog(t)
+
+	// Unregistered, the push is an unknown frame
+	if err := m.ProcessMessage([]byte(liquidationPush)); !errors.Is(err, ErrUnknownFrame) {
+		t.Fatalf("unregistered channel: %v, want ErrUnknownFrame", err)
+	}
+
+	type push struct {
+		arg    ArgData
+		action string
+		data   string
+	}
+	var pushes []push
+	err := m.RegisterChannelHandler("liquidation-orders", func(arg ArgData, action string, data json.RawMessage) error {
+		pushes = append(pushes, push{arg, action, string(data)})
+		if arg.InstID == "ETH-USDT-SWAP" {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if err := m.ProcessMessage([]byte(liquidationPush)); err != nil {
+		t.Fatalf("registered channel: %v", err)
+	}
+	// Other channels keep their built-in handlers and never reach the custom one
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "1"}}, [][2]string{{"100", "1"}})
+	pushTicker(t, m, "BTC-USDT", "100", "101")
+
+	want := push{ArgData{Channel: "liquidation-orders", InstID: "BTC-USDT-SWAP"}, "", `[{"side":"sell","sz":"3"}]`}
+	if len(pushes) != 1 || pushes[0] != want {
+		t.Fatalf("handler saw %+v, want only %+v", pushes, want)
+	}
+	if _, err := m.Snapshot("BTC-USDT"); err != nil {
+		t.Fatal("books push did not reach the built-in handler")
+	}
+	if _, ok := m.GetTicker("BTC-USDT"); !ok {
+		t.Fatal("tickers push did not reach the built-in handler")
+	}
+
+	// The handler's error is returned by ProcessMessage
+	rejected := `{"arg":{"channel":"liquidation-orders","instId":"ETH-USDT-SWAP"},"data":[{"side":"buy","sz":"1"}]}`
+	if err := m.ProcessMessage([]byte(rejected)); err == nil || err.Error() != "rejected" {
+		t.Fatalf("handler error = %v, want it returned", err)
+	}
+}
+
+func TestRegisterChannelHandlerReplacesBuiltin(t *testing.T) {
+	m := NewManager()
+
+	var tickers int
+	if err := m.RegisterChannelHandler("tickers", func(ArgData, string, json.RawMessage) error {
+		tickers++
+		return nil
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	pushTicker(t, m, "ETH-USDT", "2000", "2000.1")
+
+	if tickers != 1 {
+		t.Fatalf("replacement handler ran %d times, want once", tickers)
+	}
+	if _, ok := m.GetTicker("ETH-USDT"); ok {
+		t.Fatal("the built-in tickers handler still ran")
+	}
+
+	for _, tt := range []struct {
+		channel string
+		handler ChannelHandler
+	}{{"", func(ArgData, string, json.RawMessage) error { return nil }}, {"liquidation-orders", nil}} {
+		if err := m.RegisterChannelHandler(tt.channel, tt.handler); err == nil {
+			t.Errorf("registering channel %q with handler %v succeeded", tt.channel, tt.handler != nil)
+		}
+	}
+}
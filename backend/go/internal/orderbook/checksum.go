@@ -0,0 +1,74 @@
+package orderbook
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// checksumLevels is the number of levels per side included in the OKEx checksum
+const checksumLevels = 25
+
+// BuildChecksumString builds the OKEx checksum string from the top 25 levels of each side,
+// interleaved as bid1:ask1:bid2:ask2:... with price and size of each level.
+// When one side has fewer than 25 levels the missing entries are skipped.
+// bids must be sorted descending and asks ascending by price.
+// Reference: https://www.okx.com/docs-v5/en/#overview-websocket-books-channel
+func BuildChecksumString(bids, asks []PriceLevel) string {
+	maxBids := min(len(bids), checksumLevels)
+	maxAsks := min(len(asks), checksumLevels)
+
+	parts := make([]string, 0, 2*(maxBids+maxAsks))
+	for i := 0; i < max(maxBids, maxAsks); i++ {
+		if i < maxBids {
+			parts = append(parts, bids[i].Price, bids[i].Size)
+		}
+		if i < maxAsks {
+			parts = append(parts, asks[i].Price, asks[i].Size)
+		}
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// ComputeChecksum returns the signed CRC32 checksum OKEx sends with every books message.
+// It can also be used to generate checksums for synthetic or replayed books.
+func ComputeChecksum(bids, asks []PriceLevel) int32 {
+	return checksumOf(BuildChecksumString(bids, asks))
+}
+
+// checksumOf returns the signed CRC32 of a checksum string. The conversion reinterprets
+// the 32 bits of the unsigned CRC as two's complement, so CRCs >= 2^31 become negative:
+// 0x80000000 -> -2147483648, 0xFFFFFFFF -> -1. This is exactly what OKEx sends.
+func checksumOf(checksumStr string) int32 {
+	return int32(crc32.ChecksumIEEE([]byte(checksumStr)))
+}
+
+// ChecksumValue is the checksum field of a books message. OKEx sends it as a signed
+// 32-bit integer, but captures written by other clients may hold the unsigned CRC
+// (0..2^32-1) or a quoted number. Any of those forms decodes to the same int32 that
+// checksumOf produces, instead of failing with an int32 overflow for values >= 2^31.
+type ChecksumValue int32
+
+// UnmarshalJSON accepts signed and unsigned 32-bit integers, bare or quoted
+func (c *ChecksumValue) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*c = 0
+		return nil
+	}
+
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid checksum %s: %w", data, err)
+	}
+	if value < math.MinInt32 || value > math.MaxUint32 {
+		return fmt.Errorf("checksum %d out of 32-bit range", value)
+	}
+	// Values above MaxInt32 are the unsigned form; truncating to 32 bits wraps them
+	// to the same two's complement value as checksumOf
+	*c = ChecksumValue(int32(uint32(value)))
+	return nil
+}
@@ -0,0 +1,222 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// booksMessage is a snapshot of bid 100 x 1 and ask 100.5 x 2 on channel, with the raw
+// JSON checksum field. Its checksum string is "100:1:100.5:2", CRC32 2670913447.
+func booksMessage(channel, checksum string) []byte {
+	return []byte(fmt.Sprintf(`{"action":"snapshot","arg":{"channel":%q,"instId":"BTC-USDT"},"data":[{
+"asks":[["100.5","2","0","1"]],"bids":[["100","1","0","1"]],"ts":"1717000000000","checksum":%s}]}`, channel, checksum))
+}
+
+func TestBooks5WithoutChecksumLogsNoMismatch(t *testing.T) {
+	logs := captureLog(t)
+	m := NewManager()
+
+	if err := m.ProcessMessage(booksMessage(config.Books5Channel, "0")); err != nil {
+		t.Fatalf("process books5: %v", err)
+	}
+	if strings.Contains(logs.String(), "mismatch") {
+		t.Fatalf("books5 snapshot logged a mismatch:\n%s", logs)
+	}
+	book, err := m.Snapshot("BTC-USDT")
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if !book.ChecksumOK {
+		t.Fatal("books5 book flagged with a bad checksum")
+	}
+
+	// The same zero checksum on a channel that carries one is a real mismatch
+	m = NewManager()
+	if err := m.ProcessMessage(booksMessage(config.BooksChannel, "0")); err != nil {
+		t.Fatalf("process books: %v", err)
+	}
+	if !strings.Contains(logs.String(), "Checksum mismatch") {
+		t.Fatal("zero checksum on the books channel was not reported")
+	}
+}
+
+func TestChecksumAboveMaxInt32(t *testing.T) {
+	// 2670913447 >= 2^31, which OKEx sends as the signed value
+	const signed, unsigned = "-1624053849", "2670913447"
+
+	bids := []PriceLevel{{Price: "100", Size: "1"}}
+	asks := []PriceLevel{{Price: "100.5", Size: "2"}}
+	if got := ComputeChecksum(bids, asks); fmt.Sprint(got) != signed {
+		t.Fatalf("checksum = %d, want %s", got, signed)
+	}
+
+	for _, checksum := range []string{signed, unsigned, `"` + unsigned + `"`} {
+		logs := captureLog(t)
+		m := NewManager()
+		if err := m.ProcessMessage(booksMessage(config.BooksChannel, checksum)); err != nil {
+			t.Fatalf("checksum %s: %v", checksum, err)
+		}
+		if book, _ := m.Snapshot("BTC-USDT"); !book.ChecksumOK || strings.Contains(logs.String(), "mismatch") {
+			t.Fatalf("checksum %s reported as a mismatch:\n%s", checksum, logs)
+		}
+	}
+}
+
+func TestChecksumValueBounds(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int32
+	}{
+		{"2147483647", math.MaxInt32},
+		{"2147483648", math.MinInt32},
+		{"-2147483648", math.MinInt32},
+		{"4294967295", -1},
+		{"-1", -1},
+		{"null", 0},
+	}
+	for _, tt := range tests {
+		var got ChecksumValue
+		if err := json.Unmarshal([]byte(tt.raw), &got); err != nil {
+			t.Fatalf("%s: %v", tt.raw, err)
+		}
+		if int32(got) != tt.want {
+			t.Errorf("%s decoded to %d, want %d", tt.raw, got, tt.want)
+		}
+	}
+
+	for _, raw := range []string{"4294967296", "-2147483649"} {
+		var got ChecksumValue
+		if err := json.Unmarshal([]byte(raw), &got); err == nil {
+			t.Errorf("%s decoded to %d, want an out of range error", raw, got)
+		}
+	}
+}
+
+func TestChecksumDocumentedExamples(t *testing.T) {
+	levels := func(pairs ...string) []PriceLevel {
+		var out []PriceLevel
+		for i := 0; i < len(pairs); i += 2 {
+			out = append(out, PriceLevel{Price: pairs[i], Size: pairs[i+1], OrderCount: 3})
+		}
+		return out
+	}
+
+	// Examples from the OKEx books channel docs; CRCs computed with zlib.crc32
+	tests := []struct {
+		name       string
+		bids, asks []PriceLevel
+		str        string
+		checksum   int32
+	}{
+		{"equal depth",
+			levels("3366.1", "7", "3366", "6"), levels("3366.8", "9", "3368", "8"),
+			"3366.1:7:3366.8:9:3366:6:3368:8", -1881014294},
+		{"fewer bids than asks",
+			levels("3366.1", "7"), levels("3366.8", "9", "3368", "8", "3372", "8"),
+			"3366.1:7:3366.8:9:3368:8:3372:8", 831078360},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildChecksumString(tt.bids, tt.asks); got != tt.str {
+				t.Fatalf("checksum string = %q, want %q", got, tt.str)
+			}
+			if got := ComputeChecksum(tt.bids, tt.asks); got != tt.checksum {
+				t.Fatalf("checksum = %d, want %d", got, tt.checksum)
+			}
+		})
+	}
+}
+
+func TestChecksumStringUsesTop25Levels(t *testing.T) {
+	var bids, asks []PriceLevel
+	for i := 0; i < 30; i++ {
+		bids = append(bids, PriceLevel{Price: fmt.Sprint(1000 - i), Size: "1"})
+		asks = append(asks, PriceLevel{Price: fmt.Sprint(1001 + i), Size: "2"})
+	}
+
+	parts := strings.Split(BuildChecksumString(bids, asks), ":")
+	if len(parts) != 4*25 {
+		t.Fatalf("%d fields, want %d", len(parts), 4*25)
+	}
+	if last := strings.Join(parts[len(parts)-4:], ":"); last != "976:1:1025:2" {
+		t.Fatalf("last level pair = %s, want the 25th bid and ask", last)
+	}
+	if ComputeChecksum(bids, asks) != ComputeChecksum(bids[:25], asks[:25]) {
+		t.Fatal("levels beyond 25 changed the checksum")
+	}
+}
+
+func TestVerifyChecksumAcceptsComputedChecksum(t *testing.T) {
+	bids := []PriceLevel{{Price: "100", Size: "1"}}
+	asks := []PriceLevel{{Price: "100.5", Size: "2"}}
+	checksum := ComputeChecksum(bids, asks)
+
+	for _, tt := range []struct {
+		checksum string
+		ok       bool
+	}{{fmt.Sprint(checksum), true}, {fmt.Sprint(checksum + 1), false}} {
+		m := NewManager()
+		captureLog(t)
+		if err := m.ProcessMessage(booksMessage(config.BooksChannel, tt.checksum)); err != nil {
+			t.Fatalf("checksum %s: %v", tt.checksum, err)
+		}
+		if book, _ := m.Snapshot("BTC-USDT"); book.ChecksumOK != tt.ok {
+			t.Fatalf("checksum %s: ChecksumOK = %v, want %v", tt.checksum, book.ChecksumOK, tt.ok)
+		}
+	}
+}
+
+// TestChecksumSignAcrossUpdates walks the best bid size through a run of updates whose CRCs
+// fall on both sides of 2^31, sending each checksum alternately in signed and unsigned form
+func TestChecksumSignAcrossUpdates(t *testing.T) {
+	logs := captureLog(t)
+	m := NewManager()
+	if err := m.ProcessMessage(booksMessage(config.BooksChannel, "-1624053849")); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	asks := []PriceLevel{{Price: "100.5", Size: "2"}}
+	var negative, positive int
+	for size := 2; size <= 60; size++ {
+		bids := []PriceLevel{{Price: "100", Size: fmt.Sprint(size)}}
+		crc := crc32.ChecksumIEEE([]byte(BuildChecksumString(bids, asks)))
+
+		// The sign of the int32 is the top bit of the CRC
+		signed := ComputeChecksum(bids, asks)
+		if signed < 0 != (crc >= 1<<31) || uint32(signed) != crc {
+			t.Fatalf("size %d: CRC %d converted to %d", size, crc, signed)
+		}
+		if signed < 0 {
+			negative++
+		} else {
+			positive++
+		}
+
+		raw := fmt.Sprint(signed)
+		if size%2 == 0 {
+			raw = fmt.Sprint(crc)
+		}
+		msg := fmt.Sprintf(`{"action":"update","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{
+"asks":[],"bids":[["100","%d","0","1"]],"ts":"1717000000000","checksum":%s}]}`, size, raw)
+		if err := m.ProcessMessage([]byte(msg)); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if book, _ := m.Snapshot("BTC-USDT"); !book.ChecksumOK || book.Checksum != signed {
+			t.Fatalf("size %d, checksum %s: ChecksumOK = %v, stored %d, want %d\n%s",
+				size, raw, book.ChecksumOK, book.Checksum, signed, logs)
+		}
+	}
+
+	if negative == 0 || positive == 0 {
+		t.Fatalf("%d negative and %d positive checksums; the run must cross the sign bit", negative, positive)
+	}
+	if strings.Contains(logs.String(), "mismatch") {
+		t.Fatalf("mismatch logged:\n%s", logs)
+	}
+}
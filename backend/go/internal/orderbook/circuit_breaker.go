@@ -0,0 +1,102 @@
+package orderbook
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// CircuitBreaker backs off an (instrument, analysis) pair after repeated consecutive failures,
+// so a permanently failing analysis (e.g. an empty book) doesn't run and log every cycle forever.
+// 连续失败达到阈值后暂停该分析一段时间，首次成功后自动恢复
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	states    map[string]*breakerState // instID|analysis -> state
+	now       func() time.Time
+}
+
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+	tripped   bool
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after threshold consecutive failures
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		states:    make(map[string]*breakerState),
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether the analysis may run for the instrument.
+// Once the cooldown has elapsed a single trial run is allowed.
+func (b *CircuitBreaker) Allow(instID, analysis string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.states[breakerKey(instID, analysis)]
+	if !ok || !state.tripped {
+		return true
+	}
+	return !b.now().Before(state.openUntil)
+}
+
+// RecordSuccess resets the failure count of the analysis for the instrument
+func (b *CircuitBreaker) RecordSuccess(instID, analysis string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := breakerKey(instID, analysis)
+	if state, ok := b.states[key]; ok {
+		if state.tripped {
+			log.Printf("Analysis %s for %s recovered, circuit breaker reset", analysis, instID)
+		}
+		delete(b.states, key)
+	}
+}
+
+// RecordFailure records a failure and opens the breaker once the threshold is reached.
+// It logs the failures up to the threshold and then only once per cooldown.
+func (b *CircuitBreaker) RecordFailure(instID, analysis string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := breakerKey(instID, analysis)
+	state, ok := b.states[key]
+	if !ok {
+		state = &breakerState{}
+		b.states[key] = state
+	}
+	state.failures++
+
+	if state.failures < b.threshold {
+		log.Printf("Analysis %s failed for %s (%d/%d): %v", analysis, instID, state.failures, b.threshold, err)
+		return
+	}
+
+	state.openUntil = b.now().Add(b.cooldown)
+	if !state.tripped {
+		state.tripped = true
+		log.Printf("Analysis %s failed %d times in a row for %s, backing off for %v: %v", analysis, state.failures, instID, b.cooldown, err)
+	}
+}
+
+// IsOpen reports whether the analysis is currently backed off for the instrument
+func (b *CircuitBreaker) IsOpen(instID, analysis string) bool {
+	return !b.Allow(instID, analysis)
+}
+
+func breakerKey(instID, analysis string) string {
+	return instID + "|" + analysis
+}
@@ -0,0 +1,66 @@
+package orderbook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerBacksOffFailingAnalysis(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	breaker := NewCircuitBreaker(3, time.Minute)
+	breaker.now = clock.Now
+	logs := captureLog(t)
+
+	failing := true
+	runs := 0
+	cycle := func() {
+		if !breaker.Allow("BTC-USDT", AnalysisIceberg) {
+			return
+		}
+		runs++
+		if failing {
+			breaker.RecordFailure("BTC-USDT", AnalysisIceberg, errors.New("empty book"))
+		} else {
+			breaker.RecordSuccess("BTC-USDT", AnalysisIceberg)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		cycle()
+	}
+	if runs != 3 {
+		t.Fatalf("analysis ran %d times in 10 cycles, want 3 before backing off", runs)
+	}
+	if n := strings.Count(logs.String(), "backing off"); n != 1 {
+		t.Fatalf("back-off logged %d times, want once", n)
+	}
+	if !breaker.Allow("ETH-USDT", AnalysisIceberg) || !breaker.Allow("BTC-USDT", AnalysisOFI) {
+		t.Fatal("breaker blocked another instrument or analysis")
+	}
+
+	// After the cooldown one trial runs; failing again reopens the breaker
+	clock.t = clock.t.Add(time.Minute)
+	cycle()
+	cycle()
+	if runs != 4 || !breaker.IsOpen("BTC-USDT", AnalysisIceberg) {
+		t.Fatalf("runs=%d open=%v after a failed trial, want 4 and open", runs, breaker.IsOpen("BTC-USDT", AnalysisIceberg))
+	}
+	if n := strings.Count(logs.String(), "backing off"); n != 1 {
+		t.Fatalf("back-off logged %d times after the trial, want once", n)
+	}
+
+	// A successful trial resumes the analysis on every cycle
+	clock.t = clock.t.Add(time.Minute)
+	failing = false
+	for i := 0; i < 3; i++ {
+		cycle()
+	}
+	if runs != 7 || breaker.IsOpen("BTC-USDT", AnalysisIceberg) {
+		t.Fatalf("runs=%d after recovery, want 7", runs)
+	}
+	if !strings.Contains(logs.String(), "recovered") {
+		t.Fatal("recovery not logged")
+	}
+}
@@ -0,0 +1,108 @@
+package orderbook
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/utils"
+)
+
+func TestSpreadWindowExpiresOnTheFakeClock(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+
+	m.recordSpread("BTC-USDT", 0.5)
+	clock.t = clock.t.Add((srWindowSeconds - 1) * time.Second)
+	m.recordSpread("BTC-USDT", 0.6)
+	if n := len(m.spreadWindows["BTC-USDT"].GetItems()); n != 2 {
+		t.Fatalf("%d samples one second before expiry, want 2", n)
+	}
+
+	// Exactly srWindowSeconds later the first sample is out
+	clock.t = clock.t.Add(time.Second)
+	m.recordSpread("BTC-USDT", 0.7)
+	items := m.spreadWindows["BTC-USDT"].GetItems()
+	if len(items) != 2 || items[0].(*SpreadWindowItem).Spread != 0.6 {
+		t.Fatalf("window after expiry = %d samples starting at %v, want 0.6 and 0.7", len(items), items[0])
+	}
+}
+
+func TestSpreadZScoreWindowBoundary(t *testing.T) {
+	t0 := time.Unix(1717000000, 0)
+	clock := &testClock{t: t0}
+	m := NewManagerWithClock(clock.Now)
+
+	for _, s := range []struct {
+		offset time.Duration
+		spread float64
+	}{{0, 100}, {30 * time.Second, 1}, {45 * time.Second, 2}, {60 * time.Second, 3}} {
+		clock.t = t0.Add(s.offset)
+		m.recordSpread("BTC-USDT", s.spread)
+	}
+
+	tests := []struct {
+		now    time.Duration
+		window []float64
+	}{
+		// The cutoff is inclusive: the sample exactly one minute old still counts
+		{60 * time.Second, []float64{100, 1, 2, 3}},
+		{61 * time.Second, []float64{1, 2, 3}},
+		{105 * time.Second, []float64{2, 3}},
+		// Fewer than two samples in the window falls back to every sample
+		{106 * time.Second, []float64{100, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		clock.t = t0.Add(tt.now)
+		z, current, err := m.AnalyzeSpreadZScore("BTC-USDT", 1)
+		if err != nil {
+			t.Fatalf("at +%v: %v", tt.now, err)
+		}
+		if want := utils.CalculateZScore(3, tt.window); current != 3 || math.Abs(z-want) > 1e-12 {
+			t.Errorf("at +%v: z = %v (current %v), want %v over %v", tt.now, z, current, want, tt.window)
+		}
+	}
+}
+
+func TestSentimentSmoothingOverThirtySeconds(t *testing.T) {
+	bullish := []PriceLevel{{Price: "100", Size: "40"}, {Price: "99.5", Size: "1"}, {Price: "99", Size: "1"}}
+	bearish := []PriceLevel{{Price: "100.5", Size: "40"}, {Price: "101", Size: "1"}, {Price: "101.5", Size: "1"}}
+	thin := []PriceLevel{{Price: "100.5", Size: "1"}, {Price: "101", Size: "1"}, {Price: "101.5", Size: "1"}}
+	thinBids := []PriceLevel{{Price: "100", Size: "1"}, {Price: "99.5", Size: "1"}, {Price: "99", Size: "1"}}
+
+	sentiment := func(m *Manager, asks, bids []PriceLevel) float64 {
+		t.Helper()
+		data, err := m.computeLargeOrderDistribution("BTC-USDT", asks, bids, 0.5, 0, 5, 0.3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data.Sentiment
+	}
+
+	// Unsmoothed values, each from a manager with an empty window
+	up := sentiment(NewManager(), thin, bullish)
+	down := sentiment(NewManager(), bearish, thinBids)
+	if up <= 0 || down >= 0 {
+		t.Fatalf("raw sentiments %v and %v, want one bullish and one bearish", up, down)
+	}
+
+	t0 := time.Unix(1717000000, 0)
+	clock := &testClock{t: t0}
+	m := NewManagerWithClock(clock.Now)
+	steps := []struct {
+		offset     time.Duration
+		asks, bids []PriceLevel
+		want       float64
+	}{
+		{0, thin, bullish, up},
+		{29 * time.Second, bearish, thinBids, (up + down) / 2},
+		// At +30s the first sample leaves the 30 second window
+		{30 * time.Second, bearish, thinBids, down},
+	}
+	for _, step := range steps {
+		clock.t = t0.Add(step.offset)
+		if got := sentiment(m, step.asks, step.bids); math.Abs(got-step.want) > 1e-12 {
+			t.Fatalf("at +%v: smoothed sentiment %v, want %v", step.offset, got, step.want)
+		}
+	}
+}
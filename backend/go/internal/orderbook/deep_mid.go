@@ -0,0 +1,102 @@
+package orderbook
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// deepMidLevels is the number of levels per side used for the depth-weighted mid
+const deepMidLevels = 20
+
+// SetTickSize records the tick size of an instrument (e.g. tickSz from /api/v5/public/instruments).
+// Without it, the tick size is inferred from the decimals of the book prices.
+func (m *Manager) SetTickSize(instID string, tickSize float64) {
+	if tickSize <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickSizes[instID] = tickSize
+}
+
+// TickSize returns the configured tick size of an instrument, false if none was set
+func (m *Manager) TickSize(instID string) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tick, ok := m.tickSizes[instID]
+	return tick, ok
+}
+
+// tickSizeFor returns the configured tick size, falling back to the smallest price
+// increment expressible with the decimals found in the book (e.g. "43250.1" -> 0.1).
+// Returns 0 when neither is available.
+func (m *Manager) tickSizeFor(instID string, asks, bids []PriceLevel) float64 {
+	if tick, ok := m.TickSize(instID); ok {
+		return tick
+	}
+
+	decimals := -1
+	scan := func(levels []PriceLevel) {
+		for _, lvl := range levels {
+			d := 0
+			if i := strings.IndexByte(lvl.Price, '.'); i >= 0 {
+				d = len(strings.TrimRight(lvl.Price[i+1:], "0"))
+			}
+			if d > decimals {
+				decimals = d
+			}
+		}
+	}
+	scan(bids)
+	scan(asks)
+
+	if decimals < 0 {
+		return 0
+	}
+	return math.Pow10(-decimals)
+}
+
+// GetDeepMid returns the depth-weighted mid price of an instrument, see deepMid
+func (m *Manager) GetDeepMid(instID string) (float64, error) {
+	asks, bids, err := m.GetTop400(instID)
+	if err != nil {
+		return 0, err
+	}
+	return deepMid(instID, asks, bids, deepMidLevels)
+}
+
+// deepMid computes a depth-weighted mid price over the top `levels` of each side:
+// the size-weighted average prices (VWAP) of both sides, weighted toward the thinner side
+// like the micro-price, so a single small top-of-book order cannot move it.
+// 深度加权中间价：(bidVWAP * askDepth + askVWAP * bidDepth) / (bidDepth + askDepth)
+func deepMid(instID string, asks, bids []PriceLevel, levels int) (float64, error) {
+	vwap := func(side []PriceLevel) (price, depth float64) {
+		var notional float64
+		for i, lvl := range side {
+			if i >= levels {
+				break
+			}
+			p, err1 := strconv.ParseFloat(lvl.Price, 64)
+			q, err2 := strconv.ParseFloat(lvl.Size, 64)
+			if err1 != nil || err2 != nil || q <= 0 {
+				continue
+			}
+			notional += p * q
+			depth += q
+		}
+		if depth == 0 {
+			return 0, 0
+		}
+		return notional / depth, depth
+	}
+
+	bidVWAP, bidDepth := vwap(bids)
+	askVWAP, askDepth := vwap(asks)
+	if bidDepth == 0 || askDepth == 0 {
+		return 0, fmt.Errorf("cannot compute deep mid for %s: missing bids or asks", instID)
+	}
+
+	return (bidVWAP*askDepth + askVWAP*bidDepth) / (bidDepth + askDepth), nil
+}
@@ -0,0 +1,76 @@
+package orderbook
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestSupportSpacingIsTheSameNearAndFarFromMid(t *testing.T) {
+	// Pairs of bid walls 4 and 7 ticks apart, once next to the mid and once 8% below it
+	walls := map[int]string{
+		995: "60", 991: "40", // near, 4 ticks
+		985: "50", 978: "45", // near, 7 ticks
+		920: "55", 916: "35", // far, 4 ticks
+		910: "48", 903: "42", // far, 7 ticks
+	}
+	var asks, bids [][2]string
+	for p := 1; p <= 100; p++ {
+		size := "1"
+		if wall, ok := walls[1000-p]; ok {
+			size = wall
+		}
+		asks = append(asks, [2]string{strconv.Itoa(1000 + p), "1"})
+		bids = append(bids, [2]string{strconv.Itoa(1000 - p), size})
+	}
+	m := NewManager()
+	loadSnapshot(t, m, "BTC-USDT", asks, bids)
+	m.SetTickSize("BTC-USDT", 1)
+	bookAsks, bookBids, err := m.GetTop400("BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One bin per tick, levels at least 5 ticks apart
+	supports, _, _, anchor, err := m.computeSupportResistance("BTC-USDT", bookAsks, bookBids, 200, 1.5, 10, 0.5, 5, 0)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	// The heavy bids pull the deep mid toward the thin ask side, within its top 20 levels
+	if anchor <= 1001 || anchor >= 1020 {
+		t.Fatalf("deep mid anchor %v, want within the top 20 ask levels", anchor)
+	}
+
+	var got []int
+	for _, s := range supports {
+		got = append(got, int(math.Floor(s)))
+	}
+	sort.Ints(got)
+	want := []int{903, 910, 920, 978, 985, 995}
+	if len(got) != len(want) {
+		t.Fatalf("supports %v, want %v: the larger wall of each 4-tick pair, both of each 7-tick pair", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("supports %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDeepMidIgnoresSmallTopOfBook(t *testing.T) {
+	// A lone 0.01 best ask far below the ask wall barely moves the deep mid
+	asks := []PriceLevel{{Price: "100.1", Size: "0.01"}, {Price: "101", Size: "10"}, {Price: "101.5", Size: "10"}}
+	bids := []PriceLevel{{Price: "99", Size: "10"}, {Price: "98.5", Size: "10"}}
+
+	mid, err := deepMid("BTC-USDT", asks, bids, deepMidLevels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(mid-99.99) > 0.05 {
+		t.Fatalf("deep mid %v, want about 99.99 between the walls", mid)
+	}
+	if _, err := deepMid("BTC-USDT", asks, nil, deepMidLevels); err == nil {
+		t.Fatal("one-sided book gave a deep mid")
+	}
+}
@@ -3,7 +3,6 @@ package orderbook
 import (
 	"math"
 	"strconv"
-	"time"
 
 	"github.com/supermancell/okex-buddy/internal/utils"
 )
@@ -17,7 +16,11 @@ func (m *Manager) DetectDepthAnomaly(instID string, priceRangePercent float64, w
 	if err != nil {
 		return nil, err
 	}
+	return m.detectDepthAnomaly(instID, currentDepth, windowSize, zThreshold)
+}
 
+// detectDepthAnomaly adds currentDepth to the depth window and scores it against the history
+func (m *Manager) detectDepthAnomaly(instID string, currentDepth float64, windowSize int, zThreshold float64) (*DepthAnomalyData, error) {
 	// Set default parameters if invalid
 	if windowSize <= 0 {
 		windowSize = 30 // Default to 30 data points
@@ -27,19 +30,17 @@ func (m *Manager) DetectDepthAnomaly(instID string, priceRangePercent float64, w
 	}
 
 	// Use time window utility for automatic expiration management
-	if m.depthWindows[instID] == nil {
-		m.depthWindows[instID] = utils.NewGenericTimeWindow(int64(windowSize))
-	}
+	depthWindow := m.window(m.depthWindows, instID, int64(windowSize))
 
 	// Add current depth to the time window
 	depthItem := &DepthWindowItem{
 		Depth:     currentDepth,
-		Timestamp: time.Now().Unix(),
+		Timestamp: m.now().Unix(),
 	}
-	m.depthWindows[instID].Add(depthItem)
+	depthWindow.Add(depthItem)
 
 	// Get current items from window
-	windowItems := m.depthWindows[instID].GetItems()
+	windowItems := depthWindow.GetItems()
 	if len(windowItems) < 2 {
 		return &DepthAnomalyData{
 			Anomaly:   false,
@@ -47,9 +48,10 @@ func (m *Manager) DetectDepthAnomaly(instID string, priceRangePercent float64, w
 			Depth:     currentDepth,
 			Mean:      currentDepth,
 			StdDev:    0,
-			Timestamp: time.Now().Unix(),
+			Timestamp: m.now().Unix(),
 			Direction: "", // Not enough data to determine direction
 			Intensity: 0,
+			Readiness: depthWindow.Coverage(0),
 		}, nil
 	}
 
@@ -92,9 +94,10 @@ func (m *Manager) DetectDepthAnomaly(instID string, priceRangePercent float64, w
 		Depth:     currentDepth,
 		Mean:      historicalMean,
 		StdDev:    stdDev,
-		Timestamp: time.Now().Unix(),
+		Timestamp: m.now().Unix(),
 		Direction: direction,
 		Intensity: intensity,
+		Readiness: depthWindow.Coverage(0),
 	}
 
 	return result, nil
@@ -110,6 +113,7 @@ func (d *DepthAnomalyData) ToRedisMap() map[string]interface{} {
 		"std_dev":   d.StdDev,
 		"direction": d.Direction,
 		"intensity": d.Intensity,
+		"readiness": d.Readiness,
 		"timestamp": d.Timestamp,
 	}
 }
@@ -120,21 +124,25 @@ func (m *Manager) CalculateDepthInRange(instID string, priceRangePercent float64
 	if err != nil {
 		return 0, err
 	}
+	return depthInRange(asks, bids, priceRangePercent), nil
+}
 
+// depthInRange sums the notional of the given levels within priceRangePercent of the mid price
+func depthInRange(asks, bids []PriceLevel, priceRangePercent float64) float64 {
 	if len(asks) == 0 || len(bids) == 0 {
-		return 0, nil
+		return 0
 	}
 
 	// Calculate mid price
 	bestBid, err1 := strconv.ParseFloat(bids[0].Price, 64)
 	bestAsk, err2 := strconv.ParseFloat(asks[0].Price, 64)
 	if err1 != nil || err2 != nil {
-		return 0, nil
+		return 0
 	}
 
 	midPrice := (bestBid + bestAsk) / 2.0
 	if midPrice <= 0 {
-		return 0, nil
+		return 0
 	}
 
 	// Calculate price range boundaries
@@ -176,5 +184,5 @@ func (m *Manager) CalculateDepthInRange(instID string, priceRangePercent float64
 		}
 	}
 
-	return totalDepth, nil
+	return totalDepth
 }
@@ -0,0 +1,58 @@
+package orderbook
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DepthStats are per-side aggregates over the full book
+type DepthStats struct {
+	InstrumentID     string  `json:"instrument_id"`
+	BidLevels        int     `json:"bid_levels"`
+	AskLevels        int     `json:"ask_levels"`
+	BidTotalSize     float64 `json:"bid_total_size"`
+	AskTotalSize     float64 `json:"ask_total_size"`
+	BidTotalNotional float64 `json:"bid_total_notional"` // sum of price * size
+	AskTotalNotional float64 `json:"ask_total_notional"`
+	Timestamp        int64   `json:"timestamp"` // OKEx ts of the last book message (ms)
+}
+
+// GetDepthStats returns the level count, total size and total notional of each side of an
+// instrument's book, computed under the read lock without copying the book.
+// Returns ErrBookNotFound when no book is held for the instrument.
+func (m *Manager) GetDepthStats(instID string) (*DepthStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, exists := m.books[instID]
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
+	}
+
+	stats := &DepthStats{
+		InstrumentID: instID,
+		BidLevels:    len(book.Bids),
+		AskLevels:    len(book.Asks),
+		Timestamp:    book.Timestamp,
+	}
+	stats.BidTotalSize, stats.BidTotalNotional = sideTotals(book.Bids)
+	stats.AskTotalSize, stats.AskTotalNotional = sideTotals(book.Asks)
+	return stats, nil
+}
+
+// sideTotals sums the sizes and notionals of levels, skipping levels that do not parse
+func sideTotals(levels []PriceLevel) (size, notional float64) {
+	for _, level := range levels {
+		price, err := strconv.ParseFloat(level.Price, 64)
+		if err != nil {
+			continue
+		}
+		levelSize, err := strconv.ParseFloat(level.Size, 64)
+		if err != nil {
+			continue
+		}
+		size += levelSize
+		notional += price * levelSize
+	}
+	return size, notional
+}
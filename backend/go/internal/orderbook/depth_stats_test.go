@@ -0,0 +1,66 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetDepthStats(t *testing.T) {
+	m := NewManager()
+	loadSnapshot(t, m, "ETH-USDT",
+		[][2]string{{"2001", "1.5"}, {"2002", "2"}},
+		[][2]string{{"2000", "3"}, {"1999", "0.5"}, {"1998", "1"}})
+
+	stats, err := m.GetDepthStats("ETH-USDT")
+	if err != nil {
+		t.Fatalf("depth stats: %v", err)
+	}
+	if stats.BidLevels != 3 || stats.AskLevels != 2 {
+		t.Fatalf("levels = %d bids, %d asks, want 3 and 2", stats.BidLevels, stats.AskLevels)
+	}
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"bid size", stats.BidTotalSize, 4.5},
+		{"ask size", stats.AskTotalSize, 3.5},
+		{"bid notional", stats.BidTotalNotional, 2000*3 + 1999*0.5 + 1998*1},
+		{"ask notional", stats.AskTotalNotional, 2001*1.5 + 2002*2},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if stats.Timestamp != 1717000000000 {
+		t.Errorf("timestamp = %d, want the book ts", stats.Timestamp)
+	}
+
+	// A zero-size update removes the level from the aggregates
+	pushBooks(t, m, "update", "ETH-USDT", nil, [][2]string{{"2000", "0"}})
+	stats, _ = m.GetDepthStats("ETH-USDT")
+	if stats.BidLevels != 2 || math.Abs(stats.BidTotalSize-1.5) > 1e-9 {
+		t.Fatalf("after removing 2000: %d bid levels, bid size %v", stats.BidLevels, stats.BidTotalSize)
+	}
+}
+
+func TestMissingBookIsErrBookNotFound(t *testing.T) {
+	m := NewManager()
+	lookups := map[string]func() error{
+		"GetDepthStats":             func() error { _, err := m.GetDepthStats("NONE"); return err },
+		"GetBestBidAsk":             func() error { _, _, err := m.GetBestBidAsk("NONE"); return err },
+		"Snapshot":                  func() error { _, err := m.Snapshot("NONE"); return err },
+		"GetTop400":                 func() error { _, _, err := m.GetTop400("NONE"); return err },
+		"OrderBookAge":              func() error { _, err := m.OrderBookAge("NONE"); return err },
+		"ComputeOrderBookImbalance": func() error { _, err := m.ComputeOrderBookImbalance("NONE", 5); return err },
+		"BookVsTickerDivergence":    func() error { _, err := m.BookVsTickerDivergence("NONE", time.Minute); return err },
+		"verifyChecksum":            func() error { return m.verifyChecksum("NONE") },
+	}
+	for name, lookup := range lookups {
+		if err := lookup(); !errors.Is(err, ErrBookNotFound) {
+			t.Errorf("%s: err = %v, want ErrBookNotFound", name, err)
+		}
+	}
+}
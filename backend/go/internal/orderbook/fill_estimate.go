@@ -0,0 +1,109 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ErrBookNotFound is returned when no order book is held for an instrument
+var ErrBookNotFound = errors.New("order book not found")
+
+// FillEstimate is the estimated execution of a market order of a given notional against the book
+type FillEstimate struct {
+	InstID         string  `json:"instrument_id"`
+	Side           string  `json:"side"`            // "buy" walks the asks, "sell" walks the bids
+	Notional       float64 `json:"notional"`        // requested notional (px * sz)
+	FilledNotional float64 `json:"filled_notional"` // notional the book can absorb, up to Notional
+	FilledSize     float64 `json:"filled_size"`     // base size executed
+	AvgPrice       float64 `json:"avg_price"`       // FilledNotional / FilledSize
+	MidPrice       float64 `json:"mid_price"`       // (best bid + best ask) / 2
+	SlippageBps    float64 `json:"slippage_bps"`    // adverse distance of AvgPrice from mid, in bps
+	LevelsConsumed int     `json:"levels_consumed"` // levels touched, the last one possibly partially
+	Sufficient     bool    `json:"sufficient"`      // whether the book is deep enough for Notional
+	WorstPrice     float64 `json:"worst_price"`     // price of the last level touched
+	Timestamp      int64   `json:"timestamp"`
+}
+
+// EstimateFillPrice walks the opposite side of the book for a market order of notional
+// (quote currency, px * sz) and returns the average fill price and slippage versus mid.
+// When the book is too thin the estimate covers what is available and Sufficient is false.
+// 估算市价单成交均价与滑点
+func (m *Manager) EstimateFillPrice(instID, side string, notional float64) (*FillEstimate, error) {
+	if side != "buy" && side != "sell" {
+		return nil, fmt.Errorf("invalid side %q, expected buy or sell", side)
+	}
+	if notional <= 0 {
+		return nil, fmt.Errorf("invalid notional %v, must be positive", notional)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, exists := m.books[instID]
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil, fmt.Errorf("%s: one-sided book: %w", instID, ErrInsufficientData)
+	}
+
+	bid, err := strconv.ParseFloat(book.Bids[0].Price, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid best bid for %s: %w", instID, err)
+	}
+	ask, err := strconv.ParseFloat(book.Asks[0].Price, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid best ask for %s: %w", instID, err)
+	}
+
+	levels := book.Asks
+	if side == "sell" {
+		levels = book.Bids
+	}
+
+	est := &FillEstimate{
+		InstID:    instID,
+		Side:      side,
+		Notional:  notional,
+		MidPrice:  (bid + ask) / 2.0,
+		Timestamp: m.now().Unix(),
+	}
+	for _, level := range levels {
+		if est.FilledNotional >= notional {
+			break
+		}
+		price, err := strconv.ParseFloat(level.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price for %s: %w", instID, err)
+		}
+		size, err := strconv.ParseFloat(level.Size, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size for %s: %w", instID, err)
+		}
+		if price <= 0 || size <= 0 {
+			continue
+		}
+
+		take := math.Min(price*size, notional-est.FilledNotional)
+		est.FilledNotional += take
+		est.FilledSize += take / price
+		est.LevelsConsumed++
+		est.WorstPrice = price
+	}
+
+	// Float accumulation may leave the fill a hair below the requested notional
+	est.Sufficient = est.FilledNotional >= notional*(1-1e-9)
+	if est.FilledSize > 0 {
+		est.AvgPrice = est.FilledNotional / est.FilledSize
+	}
+	if est.MidPrice > 0 && est.AvgPrice > 0 {
+		slippage := est.AvgPrice - est.MidPrice
+		if side == "sell" {
+			slippage = -slippage
+		}
+		est.SlippageBps = slippage / est.MidPrice * 10000
+	}
+	return est, nil
+}
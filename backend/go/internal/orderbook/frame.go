@@ -0,0 +1,99 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrUnknownFrame is returned by ProcessMessage for frames that are neither a known
+// event nor a data push of a handled channel, e.g. after an OKEx payload change
+var ErrUnknownFrame = errors.New("unknown OKEx frame")
+
+// unknownFrameLogInterval rate-limits the raw dump of unknown frames
+const unknownFrameLogInterval = 10 * time.Second
+
+// unknownFrameLogBytes caps how much of an unknown frame is logged
+const unknownFrameLogBytes = 512
+
+// frameKind is the category of an incoming OKEx frame
+type frameKind int
+
+const (
+	frameUnknown frameKind = iota
+	frameEvent             // {"event": ...}: subscribe/login/error/notice acknowledgements
+	frameData              // {"arg": {"channel": ...}, "data": [...]}
+)
+
+// knownEvents lists the event frames OKEx sends on the public connection
+var knownEvents = map[string]bool{
+	"subscribe":                true,
+	"unsubscribe":              true,
+	"login":                    true,
+	"error":                    true,
+	"notice":                   true,
+	"channel-conn-count":       true,
+	"channel-conn-count-error": true,
+}
+
+// classifyFrame parses a raw frame and tells event frames, data frames (a channel and
+// non-empty data) and anything else apart. arg is only set for data frames.
+func classifyFrame(msg []byte) (kind frameKind, okexMsg OKExMessage, arg ArgData) {
+	if err := json.Unmarshal(msg, &okexMsg); err != nil {
+		return frameUnknown, okexMsg, arg
+	}
+
+	if okexMsg.Event != "" {
+		if knownEvents[okexMsg.Event] {
+			return frameEvent, okexMsg, arg
+		}
+		return frameUnknown, okexMsg, arg
+	}
+
+	if len(okexMsg.Arg) == 0 || json.Unmarshal(okexMsg.Arg, &arg) != nil || arg.Channel == "" {
+		return frameUnknown, okexMsg, ArgData{}
+	}
+	var items []json.RawMessage
+	if json.Unmarshal(okexMsg.Data, &items) != nil || len(items) == 0 {
+		return frameUnknown, okexMsg, ArgData{}
+	}
+	return frameData, okexMsg, arg
+}
+
+// frameLogLimiter logs at most one unknown frame per interval, counting the rest
+type frameLogLimiter struct {
+	mu         sync.Mutex
+	lastLog    time.Time
+	suppressed int
+}
+
+// unknownFrame logs a non-empty unknown frame (rate-limited) and returns ErrUnknownFrame
+// 记录无法识别的消息，便于发现OKEx数据格式变化
+func (m *Manager) unknownFrame(msg []byte) error {
+	if len(msg) == 0 {
+		return fmt.Errorf("empty frame: %w", ErrUnknownFrame)
+	}
+
+	limiter := &m.unknownFrames
+	limiter.mu.Lock()
+	now := m.now()
+	if now.Sub(limiter.lastLog) < unknownFrameLogInterval {
+		limiter.suppressed++
+		limiter.mu.Unlock()
+		return ErrUnknownFrame
+	}
+	suppressed := limiter.suppressed
+	limiter.lastLog = now
+	limiter.suppressed = 0
+	limiter.mu.Unlock()
+
+	raw := msg
+	if len(raw) > unknownFrameLogBytes {
+		raw = raw[:unknownFrameLogBytes]
+	}
+	log.Printf("DEBUG: Unknown OKEx frame (%d more suppressed since last report): %s", suppressed, raw)
+	return ErrUnknownFrame
+}
@@ -0,0 +1,69 @@
+package orderbook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessMessageFrameCategories(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   string
+		kind    frameKind
+		unknown bool // ProcessMessage returns ErrUnknownFrame
+	}{
+		{"subscribe ack", `{"event":"subscribe","arg":{"channel":"books","instId":"BTC-USDT"},"connId":"a1"}`, frameEvent, false},
+		{"notice", `{"event":"notice","code":"64008","msg":"service upgrade","connId":"a1"}`, frameEvent, false},
+		{"books snapshot", `{"action":"snapshot","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{"asks":[["101","1","0","1"]],"bids":[["100","1","0","1"]],"ts":"1717000000000"}]}`, frameData, false},
+		{"ticker", `{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":[{"instId":"BTC-USDT","last":"100.5","ts":"1717000000000"}]}`, frameData, false},
+		{"new event", `{"event":"maintenance","msg":"soon"}`, frameUnknown, true},
+		{"data of an unhandled channel", `{"arg":{"channel":"liquidation-orders","instType":"SWAP"},"data":[{"instId":"BTC-USDT-SWAP"}]}`, frameData, true},
+		{"empty data", `{"arg":{"channel":"books","instId":"BTC-USDT"},"data":[]}`, frameUnknown, true},
+		{"arg without channel", `{"arg":{"instId":"BTC-USDT"},"data":[{"last":"1"}]}`, frameUnknown, true},
+		{"data object instead of array", `{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":{"last":"1"}}`, frameUnknown, true},
+		{"not JSON", `pong`, frameUnknown, true},
+		{"empty", ``, frameUnknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kind, _, _ := classifyFrame([]byte(tt.frame)); kind != tt.kind {
+				t.Fatalf("classified as %d, want %d", kind, tt.kind)
+			}
+			m := NewManager()
+			m.SetVerifyChecksum(false)
+			if err := m.ProcessMessage([]byte(tt.frame)); errors.Is(err, ErrUnknownFrame) != tt.unknown {
+				t.Fatalf("ProcessMessage err %v, unknown frame %v", err, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestUnknownFramesLoggedRateLimited(t *testing.T) {
+	logs := captureLog(t)
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+
+	for i := 0; i < 3; i++ {
+		m.ProcessMessage([]byte(`{"event":"maintenance","n":1}`))
+		clock.t = clock.t.Add(time.Second)
+	}
+	if n := strings.Count(logs.String(), "Unknown OKEx frame"); n != 1 {
+		t.Fatalf("%d unknown frames logged within the interval, want 1:\n%s", n, logs)
+	}
+	if !strings.Contains(logs.String(), `{"event":"maintenance","n":1}`) {
+		t.Fatalf("raw frame not logged:\n%s", logs)
+	}
+
+	clock.t = clock.t.Add(unknownFrameLogInterval)
+	m.ProcessMessage([]byte(`{"event":"maintenance","n":` + strings.Repeat("9", 2*unknownFrameLogBytes) + `}`))
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	last := lines[len(lines)-1]
+	if len(lines) != 2 || !strings.Contains(last, "2 more suppressed") {
+		t.Fatalf("second report %q, want it to count the 2 suppressed frames", last)
+	}
+	if strings.Count(last, "9") > unknownFrameLogBytes {
+		t.Fatalf("logged %d bytes of the frame, cap %d", strings.Count(last, "9"), unknownFrameLogBytes)
+	}
+}
@@ -0,0 +1,103 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// fundingRateData represents a push of the funding-rate channel in OKEx format
+type fundingRateData struct {
+	InstID          string `json:"instId"`
+	FundingRate     string `json:"fundingRate"`
+	NextFundingRate string `json:"nextFundingRate"` // empty when OKEx does not forecast it
+	FundingTime     string `json:"fundingTime"`     // settlement time of FundingRate (ms)
+	NextFundingTime string `json:"nextFundingTime"`
+	Ts              string `json:"ts"`
+}
+
+// FundingRateData is the latest funding rate of a perpetual swap
+type FundingRateData struct {
+	InstID          string  `json:"instrument_id"`
+	FundingRate     float64 `json:"funding_rate"`      // current period rate, e.g. 0.0001 = 0.01%
+	NextFundingRate float64 `json:"next_funding_rate"` // forecast of the next period, 0 if unknown
+	FundingTime     int64   `json:"funding_time"`      // ms
+	NextFundingTime int64   `json:"next_funding_time"` // ms
+	Timestamp       int64   `json:"timestamp"`         // OKEx push time (ms)
+}
+
+// ToRedisMap converts FundingRateData to a map for Redis storage
+func (f *FundingRateData) ToRedisMap() map[string]interface{} {
+	return map[string]interface{}{
+		"funding_rate":      f.FundingRate,
+		"next_funding_rate": f.NextFundingRate,
+		"funding_time":      f.FundingTime,
+		"next_funding_time": f.NextFundingTime,
+		"timestamp":         f.Timestamp,
+	}
+}
+
+// processFundingRateMessage keeps the latest funding rate of each instrument
+// 资金费率（仅永续合约）
+func (m *Manager) processFundingRateMessage(arg ArgData, _ string, rawData json.RawMessage) error {
+	var rates []fundingRateData
+	if err := json.Unmarshal(rawData, &rates); err != nil {
+		return fmt.Errorf("failed to unmarshal funding rate data: %w", err)
+	}
+
+	for _, raw := range rates {
+		rate, err := parseFundingRate(arg.InstID, raw)
+		if err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		m.fundingRates[arg.InstID] = rate
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// parseFundingRate converts the string fields of a funding-rate push
+func parseFundingRate(instID string, raw fundingRateData) (*FundingRateData, error) {
+	rate, err := strconv.ParseFloat(raw.FundingRate, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid funding rate for %s: %w", instID, err)
+	}
+
+	data := &FundingRateData{InstID: instID, FundingRate: rate}
+	// Optional fields: OKEx leaves them empty depending on the settlement method
+	if raw.NextFundingRate != "" {
+		if data.NextFundingRate, err = strconv.ParseFloat(raw.NextFundingRate, 64); err != nil {
+			return nil, fmt.Errorf("invalid next funding rate for %s: %w", instID, err)
+		}
+	}
+	for _, field := range []struct {
+		value string
+		dst   *int64
+	}{
+		{raw.FundingTime, &data.FundingTime},
+		{raw.NextFundingTime, &data.NextFundingTime},
+		{raw.Ts, &data.Timestamp},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if *field.dst, err = strconv.ParseInt(field.value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid funding time for %s: %w", instID, err)
+		}
+	}
+	return data, nil
+}
+
+// GetFundingRate returns the latest funding rate of an instrument, false if none was received
+func (m *Manager) GetFundingRate(instID string) (*FundingRateData, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rate, exists := m.fundingRates[instID]
+	if !exists {
+		return nil, false
+	}
+	copied := *rate
+	return &copied, true
+}
@@ -0,0 +1,78 @@
+package orderbook
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/redisclient"
+)
+
+// fundingRatePush is a funding-rate push as documented by OKEx, negative rate included
+const fundingRatePush = `{"arg":{"channel":"funding-rate","instId":"BTC-USDT-SWAP"},"data":[{
+"fundingRate":"-0.0000812","fundingTime":"1717027200000","instId":"BTC-USDT-SWAP","instType":"SWAP",
+"method":"current_period","nextFundingRate":"","nextFundingTime":"1717056000000",
+"settFundingRate":"0.0001","settState":"settled","ts":"1717000000123"}]}`
+
+func TestFundingRateRoundTrip(t *testing.T) {
+	m := NewManager()
+	if _, ok := m.GetFundingRate("BTC-USDT-SWAP"); ok {
+		t.Fatal("funding rate before any push")
+	}
+	if err := m.ProcessMessage([]byte(fundingRatePush)); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	rate, ok := m.GetFundingRate("BTC-USDT-SWAP")
+	want := FundingRateData{InstID: "BTC-USDT-SWAP", FundingRate: -0.0000812,
+		FundingTime: 1717027200000, NextFundingTime: 1717056000000, Timestamp: 1717000000123}
+	if !ok || *rate != want {
+		t.Fatalf("funding rate = %+v, want %+v", rate, want)
+	}
+	// The returned value is a copy
+	rate.FundingRate = 1
+	if again, _ := m.GetFundingRate("BTC-USDT-SWAP"); again.FundingRate != want.FundingRate {
+		t.Fatal("GetFundingRate returned the stored value")
+	}
+
+	server := miniredis.RunT(t)
+	client, err := redisclient.NewClient(server.Addr(), "")
+	if err != nil {
+		t.Fatalf("connect to miniredis: %v", err)
+	}
+	defer client.Close()
+	processFundingRate("BTC-USDT-SWAP", m, client)
+
+	key := fmt.Sprintf(config.FundingRateKey, "BTC-USDT-SWAP")
+	for field, value := range map[string]string{
+		"instrument_id":     "BTC-USDT-SWAP",
+		"funding_rate":      "-0.0000812",
+		"next_funding_rate": "0",
+		"funding_time":      "1717027200000",
+		"next_funding_time": "1717056000000",
+		"timestamp":         "1717000000123",
+	} {
+		if got := server.HGet(key, field); got != value {
+			t.Errorf("%s %s = %q, want %q", key, field, got, value)
+		}
+	}
+}
+
+func TestFundingRateRejectsBadFields(t *testing.T) {
+	m := NewManager()
+	for _, data := range []string{
+		`{"fundingRate":"","ts":"1"}`,
+		`{"fundingRate":"0.0001","nextFundingRate":"n/a"}`,
+		`{"fundingRate":"0.0001","nextFundingTime":"soon"}`,
+	} {
+		msg := `{"arg":{"channel":"funding-rate","instId":"ETH-USDT-SWAP"},"data":[` + data + `]}`
+		if err := m.ProcessMessage([]byte(msg)); err == nil {
+			t.Errorf("%s: accepted", data)
+		}
+	}
+	if _, ok := m.GetFundingRate("ETH-USDT-SWAP"); ok {
+		t.Fatal("a rejected push was kept")
+	}
+}
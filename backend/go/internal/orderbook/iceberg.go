@@ -0,0 +1,186 @@
+package orderbook
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/supermancell/okex-buddy/internal/utils"
+)
+
+// Default iceberg detection parameters, see SetIcebergParams
+const (
+	defaultIcebergWindowSeconds = 60
+	defaultIcebergMinRefills    = 3
+)
+
+// IcebergCandidate represents a price level that repeatedly refilled after being consumed
+type IcebergCandidate struct {
+	Side        string  `json:"side"` // "bid" or "ask"
+	Price       float64 `json:"price"`
+	Refills     int     `json:"refills"`      // number of consume-then-refill cycles in the window
+	HiddenSize  float64 `json:"hidden_size"`  // estimated hidden size: total refilled size in the window
+	VisibleSize float64 `json:"visible_size"` // currently displayed size at the level (0 if consumed)
+	LastRefill  int64   `json:"last_refill"`
+}
+
+// IcebergRefillItem represents a single refill of a price level in the sliding window
+type IcebergRefillItem struct {
+	Refill    float64
+	Timestamp int64
+}
+
+func (i *IcebergRefillItem) GetTimestamp() int64 {
+	return i.Timestamp
+}
+
+// icebergLevelState tracks the size of one price level between updates
+type icebergLevelState struct {
+	side     string
+	price    float64
+	size     float64 // last seen size
+	consumed bool    // size decreased (or level removed) since the last refill
+	updated  int64
+}
+
+// icebergTracker holds per-level refill state for one instrument, guarded by Manager.mu
+type icebergTracker struct {
+	levels  map[string]*icebergLevelState       // side:price -> state
+	refills map[string]*utils.GenericTimeWindow // side:price -> sliding window of refills
+}
+
+// SetIcebergParams configures the refill window (seconds) and the number of refills
+// needed before a level is reported as an iceberg candidate
+func (m *Manager) SetIcebergParams(windowSeconds int, minRefills int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if windowSeconds > 0 {
+		m.icebergWindowSeconds = windowSeconds
+	}
+	if minRefills > 0 {
+		m.icebergMinRefills = minRefills
+	}
+}
+
+// SetIcebergEnabled turns the per-level refill tracking on or off. It is on by default;
+// turning it off drops the tracked levels, so a disabled detection holds no state.
+func (m *Manager) SetIcebergEnabled(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.icebergEnabled = enabled
+	if !enabled {
+		m.icebergs = make(map[string]*icebergTracker)
+	}
+}
+
+// observeLevelChange records a size change of a price level from oldSize to newSize.
+// A decrease marks the level as consumed; a following increase at the same price is
+// recorded as a refill. Must be called with m.mu held.
+func (m *Manager) observeLevelChange(instID, price string, oldSize, newSize float64, isAsk bool) {
+	side := "bid"
+	if isAsk {
+		side = "ask"
+	}
+	key := side + ":" + price
+
+	tracker := m.icebergs[instID]
+	if tracker == nil {
+		tracker = &icebergTracker{
+			levels:  make(map[string]*icebergLevelState),
+			refills: make(map[string]*utils.GenericTimeWindow),
+		}
+		m.icebergs[instID] = tracker
+	}
+
+	now := m.now().Unix()
+	state := tracker.levels[key]
+	if state == nil {
+		if newSize >= oldSize {
+			return // only start tracking once the level gets consumed
+		}
+		priceFloat, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			return
+		}
+		state = &icebergLevelState{side: side, price: priceFloat}
+		tracker.levels[key] = state
+	}
+
+	switch {
+	case newSize < oldSize:
+		state.consumed = true
+	case newSize > oldSize && state.consumed:
+		// Size restored at the same price after being consumed: 冰山订单补单
+		window := tracker.refills[key]
+		if window == nil {
+			window = utils.NewGenericTimeWindowWithCap(int64(m.icebergWindowSeconds), windowMaxItems)
+			window.SetClock(m.now)
+			tracker.refills[key] = window
+		}
+		window.Add(&IcebergRefillItem{Refill: newSize - oldSize, Timestamp: now})
+		state.consumed = false
+	}
+
+	state.size = newSize
+	state.updated = now
+}
+
+// DetectIceberg returns candidate iceberg levels: prices whose size was consumed and then
+// restored at least minRefills times within the window, with the estimated hidden size.
+// Candidates are sorted by hidden size, largest first.
+// 检测冰山订单：同一价格被吃掉后反复补单
+func (m *Manager) DetectIceberg(instID string) ([]IcebergCandidate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	candidates := make([]IcebergCandidate, 0)
+
+	tracker := m.icebergs[instID]
+	if tracker == nil {
+		return candidates, nil
+	}
+
+	cutoff := m.now().Unix() - int64(m.icebergWindowSeconds)
+	for key, state := range tracker.levels {
+		window := tracker.refills[key]
+
+		var items []utils.TimeWindowItem
+		if window != nil {
+			items = window.GetItems()
+		}
+
+		// Drop levels that have been idle for a whole window to bound memory
+		if len(items) == 0 {
+			if state.updated < cutoff {
+				delete(tracker.levels, key)
+				delete(tracker.refills, key)
+			}
+			continue
+		}
+
+		if len(items) < m.icebergMinRefills {
+			continue
+		}
+
+		candidate := IcebergCandidate{
+			Side:        state.side,
+			Price:       state.price,
+			Refills:     len(items),
+			VisibleSize: state.size,
+		}
+		for _, item := range items {
+			if refill, ok := item.(*IcebergRefillItem); ok {
+				candidate.HiddenSize += refill.Refill
+				if refill.Timestamp > candidate.LastRefill {
+					candidate.LastRefill = refill.Timestamp
+				}
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].HiddenSize > candidates[j].HiddenSize
+	})
+
+	return candidates, nil
+}
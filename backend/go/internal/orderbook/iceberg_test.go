@@ -0,0 +1,50 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+// refillBid consumes and restores the 100 bid n times
+func refillBid(t *testing.T, m *Manager, clock *testClock, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		pushBooks(t, m, "update", "BTC-USDT", nil, [][2]string{{"100", "1"}})
+		clock.t = clock.t.Add(time.Second)
+		pushBooks(t, m, "update", "BTC-USDT", nil, [][2]string{{"100", "5"}})
+		clock.t = clock.t.Add(time.Second)
+	}
+}
+
+func TestDetectIcebergRefills(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "1"}}, [][2]string{{"100", "5"}})
+
+	refillBid(t, m, clock, defaultIcebergMinRefills)
+
+	candidates, err := m.DetectIceberg("BTC-USDT")
+	if err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Price != 100 || candidates[0].Refills != defaultIcebergMinRefills {
+		t.Fatalf("candidates = %+v, want the 100 bid with %d refills", candidates, defaultIcebergMinRefills)
+	}
+}
+
+func TestIcebergNotTrackedWhenDisabled(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "1"}}, [][2]string{{"100", "5"}})
+
+	refillBid(t, m, clock, 1)
+	m.SetIcebergEnabled(false)
+	if len(m.icebergs) != 0 {
+		t.Fatal("disabling iceberg detection kept the tracked levels")
+	}
+
+	refillBid(t, m, clock, defaultIcebergMinRefills)
+	if len(m.icebergs) != 0 {
+		t.Fatalf("levels tracked while iceberg detection is disabled: %d instruments", len(m.icebergs))
+	}
+}
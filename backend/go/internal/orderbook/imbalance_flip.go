@@ -0,0 +1,164 @@
+package orderbook
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Default imbalance flip parameters, see SetImbalanceFlipParams
+const (
+	defaultImbalanceLevels   = 5
+	defaultImbalanceDeadband = 0.2
+)
+
+// ImbalanceFlipEvent is emitted when the top-of-book imbalance crosses zero beyond the deadband
+type ImbalanceFlipEvent struct {
+	InstID        string  `json:"instrument_id"`
+	Direction     string  `json:"direction"`      // "to_bid" (asks-heavy -> bids-heavy) or "to_ask"
+	Imbalance     float64 `json:"imbalance"`      // current OBI, -1..1
+	PrevImbalance float64 `json:"prev_imbalance"` // last OBI beyond the deadband on the other side
+	Magnitude     float64 `json:"magnitude"`      // |Imbalance - PrevImbalance|, up to 2
+	Levels        int     `json:"levels"`
+	Timestamp     int64   `json:"timestamp"`
+}
+
+// imbalanceState is the last OBI outside the deadband of one instrument
+type imbalanceState struct {
+	sign      int // +1 bids-heavy, -1 asks-heavy
+	imbalance float64
+}
+
+// SetImbalanceFlipParams configures the number of top levels per side used for the
+// imbalance and the deadband |OBI| must exceed before a side counts as dominant
+func (m *Manager) SetImbalanceFlipParams(levels int, deadband float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if levels > 0 {
+		m.imbalanceLevels = levels
+	}
+	if deadband >= 0 && deadband < 1 {
+		m.imbalanceDeadband = deadband
+	}
+}
+
+// ComputeOrderBookImbalance returns the order book imbalance over the top levels of each side:
+//
+//	OBI = (bidSize - askSize) / (bidSize + askSize)
+//
+// from -1 (only asks) to 1 (only bids). Returns ErrInsufficientData (wrapped) for a one-sided book.
+// 盘口买卖量不平衡度
+func (m *Manager) ComputeOrderBookImbalance(instID string, levels int) (float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, exists := m.books[instID]
+	if !exists {
+		return 0, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
+	}
+	return computeImbalance(instID, book.Asks, book.Bids, levels)
+}
+
+// computeImbalance returns the OBI of the given levels, see ComputeOrderBookImbalance
+func computeImbalance(instID string, asks, bids []PriceLevel, levels int) (float64, error) {
+	if len(bids) == 0 || len(asks) == 0 {
+		return 0, fmt.Errorf("%s: one-sided book: %w", instID, ErrInsufficientData)
+	}
+
+	bidSize, err := sumSizes(bids, levels)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bid size for %s: %w", instID, err)
+	}
+	askSize, err := sumSizes(asks, levels)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ask size for %s: %w", instID, err)
+	}
+	if bidSize+askSize == 0 {
+		return 0, fmt.Errorf("%s: empty top of book: %w", instID, ErrInsufficientData)
+	}
+
+	return (bidSize - askSize) / (bidSize + askSize), nil
+}
+
+// sumSizes adds the sizes of the first levels entries
+func sumSizes(levels []PriceLevel, count int) (float64, error) {
+	var total float64
+	for i := 0; i < len(levels) && i < count; i++ {
+		size, err := strconv.ParseFloat(levels[i].Size, 64)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// DetectImbalanceFlip computes the current imbalance and returns a flip event when it is
+// beyond the deadband on the opposite side of the last one that was, nil otherwise.
+// Values inside the deadband never change the remembered side, so an imbalance
+// oscillating around zero does not chatter.
+// 盘口不平衡方向反转检测
+func (m *Manager) DetectImbalanceFlip(instID string) (*ImbalanceFlipEvent, error) {
+	m.mu.RLock()
+	levels := m.imbalanceLevels
+	m.mu.RUnlock()
+
+	imbalance, err := m.ComputeOrderBookImbalance(instID, levels)
+	if err != nil {
+		return nil, err
+	}
+	return m.recordImbalance(instID, imbalance, levels), nil
+}
+
+// detectImbalanceFlip is DetectImbalanceFlip on the levels of a book snapshot
+func (m *Manager) detectImbalanceFlip(instID string, asks, bids []PriceLevel) (*ImbalanceFlipEvent, error) {
+	m.mu.RLock()
+	levels := m.imbalanceLevels
+	m.mu.RUnlock()
+
+	imbalance, err := computeImbalance(instID, asks, bids, levels)
+	if err != nil {
+		return nil, err
+	}
+	return m.recordImbalance(instID, imbalance, levels), nil
+}
+
+// recordImbalance remembers the side of an imbalance beyond the deadband and returns a flip
+// event when it is opposite to the previous one, nil otherwise
+func (m *Manager) recordImbalance(instID string, imbalance float64, levels int) *ImbalanceFlipEvent {
+	m.mu.RLock()
+	deadband := m.imbalanceDeadband
+	m.mu.RUnlock()
+
+	if math.Abs(imbalance) <= deadband {
+		return nil
+	}
+
+	sign := 1
+	if imbalance < 0 {
+		sign = -1
+	}
+
+	m.windowsMu.Lock()
+	prev, hasPrev := m.imbalanceStates[instID]
+	m.imbalanceStates[instID] = imbalanceState{sign: sign, imbalance: imbalance}
+	m.windowsMu.Unlock()
+
+	if !hasPrev || prev.sign == sign {
+		return nil
+	}
+
+	direction := "to_bid"
+	if sign < 0 {
+		direction = "to_ask"
+	}
+	return &ImbalanceFlipEvent{
+		InstID:        instID,
+		Direction:     direction,
+		Imbalance:     imbalance,
+		PrevImbalance: prev.imbalance,
+		Magnitude:     math.Abs(imbalance - prev.imbalance),
+		Levels:        levels,
+		Timestamp:     m.now().Unix(),
+	}
+}
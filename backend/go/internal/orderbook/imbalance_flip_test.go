@@ -0,0 +1,78 @@
+package orderbook
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/testutil"
+)
+
+func TestImbalanceFlipFiresOncePerCrossing(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+	m.SetImbalanceFlipParams(1, 0.2)
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "50"}}, [][2]string{{"100", "50"}})
+
+	// Each OBI is set through the best bid and ask sizes, which add up to 100
+	series := []float64{0.5, 0.1, -0.1, 0.15, -0.19, -0.2, -0.6, -0.1, 0.1, -0.05, -0.45, 0.19}
+	var flips []*ImbalanceFlipEvent
+	for i, obi := range series {
+		clock.t = clock.t.Add(time.Second)
+		bid, ask := 50*(1+obi), 50*(1-obi)
+		pushBooks(t, m, "update", "BTC-USDT",
+			[][2]string{{"101", fmt.Sprint(ask)}}, [][2]string{{"100", fmt.Sprint(bid)}})
+
+		event, err := m.DetectImbalanceFlip("BTC-USDT")
+		if err != nil {
+			t.Fatalf("step %d (OBI %v): %v", i, obi, err)
+		}
+		if event != nil {
+			flips = append(flips, event)
+		}
+	}
+
+	if len(flips) != 1 {
+		t.Fatalf("%d flips, want exactly one for the crossing from 0.5 to -0.6", len(flips))
+	}
+	flip := flips[0]
+	if flip.Direction != "to_ask" || math.Abs(flip.PrevImbalance-0.5) > 1e-9 ||
+		math.Abs(flip.Imbalance+0.6) > 1e-9 || math.Abs(flip.Magnitude-1.1) > 1e-9 {
+		t.Fatalf("flip = %+v, want to_ask from 0.5 to -0.6 with magnitude 1.1", flip)
+	}
+	if flip.Levels != 1 || flip.Timestamp != 1717000007 {
+		t.Fatalf("flip over %d levels at %d, want 1 level at the seventh step", flip.Levels, flip.Timestamp)
+	}
+}
+
+func TestImbalanceFlipStoredOnlyWhenItFires(t *testing.T) {
+	m := NewManager()
+	m.SetImbalanceFlipParams(2, 0.3)
+	store := testutil.NewRecordingStore()
+
+	// Two levels per side: 8 vs 2 is 0.6, then 1 vs 9 is -0.8
+	for _, book := range []struct {
+		bids, asks [][2]string
+		stored     int
+	}{
+		{[][2]string{{"100", "5"}, {"99", "3"}}, [][2]string{{"101", "1"}, {"102", "1"}}, 0},
+		{[][2]string{{"100", "0.5"}, {"99", "0.5"}}, [][2]string{{"101", "4"}, {"102", "5"}}, 1},
+		{[][2]string{{"100", "0.5"}, {"99", "0.5"}}, [][2]string{{"101", "4"}, {"102", "5"}}, 1},
+	} {
+		loadSnapshot(t, m, "ETH-USDT", book.asks, book.bids)
+		result := &AnalysisResult{Errors: map[string]error{}}
+		result.ImbalanceFlip, result.Errors[AnalysisImbalanceFlip] = m.DetectImbalanceFlip("ETH-USDT")
+		if err := processImbalanceFlip("ETH-USDT", result, store); err != nil {
+			t.Fatalf("process: %v", err)
+		}
+		if n := store.Methods("ETH-USDT")["StoreImbalanceFlip"]; n != book.stored {
+			t.Fatalf("%d flips stored, want %d", n, book.stored)
+		}
+	}
+
+	event := store.Calls()[0].Args[0].(*ImbalanceFlipEvent)
+	if event.Direction != "to_ask" || math.Abs(event.Imbalance+0.8) > 1e-9 || event.Levels != 2 {
+		t.Fatalf("stored event %+v, want to_ask at -0.8 over 2 levels", event)
+	}
+}
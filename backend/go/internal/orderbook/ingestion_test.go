@@ -0,0 +1,127 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// updateBurst returns n books updates for instID that each move the best bid size
+func updateBurst(instID string, n int) [][]byte {
+	burst := make([][]byte, n)
+	for i := range burst {
+		burst[i] = []byte(fmt.Sprintf(`{"action":"update","arg":{"channel":"books","instId":%q},"data":[{
+"asks":[["%d.5","1","0","1"]],"bids":[["100","%d","0","1"]],"ts":"1717000000000"}]}`, instID, 101+i%50, i+1))
+	}
+	return burst
+}
+
+// deepSnapshot loads 400 levels per side so that analysis has real work to do
+func deepSnapshot(t testing.TB, m *Manager, instID string) {
+	t.Helper()
+
+	var asks, bids [][]string
+	for i := 0; i < 400; i++ {
+		asks = append(asks, []string{fmt.Sprintf("%d.5", 100+i), "3", "0", "1"})
+		bids = append(bids, []string{fmt.Sprintf("%d", 100-i), "3", "0", "1"})
+	}
+	msg, _ := json.Marshal(map[string]interface{}{
+		"action": "snapshot",
+		"arg":    map[string]string{"channel": "books", "instId": instID},
+		"data":   []map[string]interface{}{{"asks": asks, "bids": bids, "ts": "1717000000000"}},
+	})
+	m.SetVerifyChecksum(false)
+	if err := m.ProcessMessage(msg); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+}
+
+func TestIngestionDoesNotRunAnalysis(t *testing.T) {
+	m := NewManager()
+	deepSnapshot(t, m, "BTC-USDT")
+	for i, msg := range updateBurst("BTC-USDT", 500) {
+		if err := m.ProcessMessage(msg); err != nil {
+			t.Fatalf("update %d: %v", i, err)
+		}
+	}
+
+	m.windowsMu.Lock()
+	defer m.windowsMu.Unlock()
+	for name, windows := range map[string]int{
+		"sentiment":          len(m.sentimentMap),
+		"depth":              len(m.depthWindows),
+		"liquidity":          len(m.liquidityWindows),
+		"support/resistance": len(m.supportResistanceWindows),
+		"spread":             len(m.spreadWindows),
+	} {
+		if windows != 0 {
+			t.Errorf("ingestion filled the %s window; analysis must only run on the processor tick", name)
+		}
+	}
+}
+
+func TestIngestionKeepsUpWhileAnalysisRuns(t *testing.T) {
+	m := NewManager()
+	deepSnapshot(t, m, "BTC-USDT")
+	cfg := config.LoadFromEnv().Analysis
+	cfg.BookMaxAgeSec = 0
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var analyses int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				m.ComputeAll("BTC-USDT", cfg)
+				analyses++
+			}
+		}
+	}()
+
+	burst := updateBurst("BTC-USDT", 2000)
+	start := time.Now()
+	for i, msg := range burst {
+		if err := m.ProcessMessage(msg); err != nil {
+			t.Fatalf("update %d: %v", i, err)
+		}
+	}
+	elapsed := time.Since(start)
+	close(stop)
+	wg.Wait()
+
+	if analyses == 0 {
+		t.Fatal("no analysis ran during the burst")
+	}
+	book, _ := m.Snapshot("BTC-USDT")
+	if book.Bids[0].Size != "2000" {
+		t.Fatalf("best bid size = %s after the burst, want the last update", book.Bids[0].Size)
+	}
+	// Generous bound: a burst must not serialize behind full analyses
+	if elapsed > 5*time.Second {
+		t.Fatalf("%d updates took %v with analysis running", len(burst), elapsed)
+	}
+	t.Logf("%d updates in %v alongside %d analyses", len(burst), elapsed, analyses)
+}
+
+func BenchmarkIngestUpdateBurst(b *testing.B) {
+	m := NewManager()
+	deepSnapshot(b, m, "BTC-USDT")
+	burst := updateBurst("BTC-USDT", 1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.ProcessMessage(burst[i%len(burst)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package orderbook
+
+import "sort"
+
+// InstrumentStatus summarizes the in-memory state of one instrument for operators
+type InstrumentStatus struct {
+	InstrumentID  string       `json:"instrument_id"`
+	HasBook       bool         `json:"has_book"`
+	LastUpdate    int64        `json:"last_update"`    // local receive time of the last book message (ms)
+	BookTimestamp int64        `json:"book_timestamp"` // OKEx ts of the last book message (ms)
+	ChecksumOK    bool         `json:"checksum_ok"`
+	AskLevels     int          `json:"ask_levels"`
+	BidLevels     int          `json:"bid_levels"`
+	Sentiment     *float64     `json:"sentiment"` // nil until large order distribution has been computed
+	Messages      MessageStats `json:"messages"`  // processed message counters
+
+	// UnconfirmedChannels were subscribed but not acknowledged by OKEx; set by the caller
+	// from the ws client, as the Manager does not know about subscriptions
+	UnconfirmedChannels []string `json:"unconfirmed_channels,omitempty"`
+}
+
+// InstrumentStatus returns the status of a single instrument
+func (m *Manager) InstrumentStatus(instID string) InstrumentStatus {
+	status := InstrumentStatus{InstrumentID: instID}
+
+	m.mu.RLock()
+	if book, exists := m.books[instID]; exists {
+		status.HasBook = true
+		status.LastUpdate = book.UpdatedAt
+		status.BookTimestamp = book.Timestamp
+		status.ChecksumOK = book.ChecksumOK
+		status.AskLevels = len(book.Asks)
+		status.BidLevels = len(book.Bids)
+	}
+	m.mu.RUnlock()
+
+	m.windowsMu.Lock()
+	if sentiment, ok := m.lastSentiment[instID]; ok {
+		status.Sentiment = &sentiment
+	}
+	m.windowsMu.Unlock()
+
+	status.Messages = m.MessageStatsOf(instID)
+
+	return status
+}
+
+// InstrumentStatuses returns the status of each given instrument and of every instrument
+// that has a book, sorted by instrument ID and listed once each.
+// The result is never nil so that it encodes as an empty JSON array.
+func (m *Manager) InstrumentStatuses(instIDs []string) []InstrumentStatus {
+	seen := make(map[string]bool, len(instIDs))
+	sorted := make([]string, 0, len(instIDs))
+	for _, instID := range append(append([]string(nil), instIDs...), m.Instruments()...) {
+		if !seen[instID] {
+			seen[instID] = true
+			sorted = append(sorted, instID)
+		}
+	}
+	sort.Strings(sorted)
+
+	statuses := make([]InstrumentStatus, 0, len(sorted))
+	for _, instID := range sorted {
+		statuses = append(statuses, m.InstrumentStatus(instID))
+	}
+	return statuses
+}
@@ -0,0 +1,67 @@
+package orderbook
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/testutil"
+)
+
+func TestInstrumentsFollowLoadedBooks(t *testing.T) {
+	captureLog(t)
+	m := NewManagerWithClock((&testClock{t: time.UnixMilli(1717000000000)}).Now)
+
+	check := func(step string, want ...string) {
+		t.Helper()
+		if got := m.Instruments(); !reflect.DeepEqual(got, append([]string{}, want...)) {
+			t.Fatalf("%s: instruments %v, want %v", step, got, want)
+		}
+		for _, instID := range []string{"BTC-USDT", "ETH-USDT", "SOL-USDT"} {
+			has := false
+			for _, w := range want {
+				has = has || w == instID
+			}
+			if m.HasBook(instID) != has {
+				t.Fatalf("%s: HasBook(%s) = %v, want %v", step, instID, !has, has)
+			}
+		}
+	}
+
+	check("empty")
+	for _, instID := range []string{"SOL-USDT", "BTC-USDT", "ETH-USDT"} {
+		loadSnapshot(t, m, instID, [][2]string{{"101", "1"}}, [][2]string{{"100", "1"}})
+	}
+	// A ticker alone has no book
+	pushTicker(t, m, "DOGE-USDT", "0.1", "0.11")
+	check("three snapshots, sorted", "BTC-USDT", "ETH-USDT", "SOL-USDT")
+
+	m.RemoveBook("ETH-USDT")
+	check("removed", "BTC-USDT", "SOL-USDT")
+
+	// The unsubscribe acknowledgement of the books channel drops the book too
+	unsubscribed := `{"event":"unsubscribe","arg":{"channel":"books","instId":"SOL-USDT"},"connId":"a4d3ae55"}`
+	if err := m.ProcessMessage([]byte(unsubscribed)); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	check("unsubscribed", "BTC-USDT")
+
+	// The processing loop stores exactly the instruments listed
+	cfg := config.LoadFromEnv()
+	cfg.Analysis.BookMaxAgeSec = 0
+	pool := NewAnalysisPool(2)
+	defer pool.Close()
+	store := testutil.NewRecordingStore()
+	runCycle(m.Instruments(), m, store, cfg, NewCircuitBreaker(0, 0), pool)
+
+	stored := make(map[string]bool)
+	for _, call := range store.Calls() {
+		if call.Method == "StoreOrderBookSnapshot" {
+			stored[call.InstID] = true
+		}
+	}
+	if !reflect.DeepEqual(stored, map[string]bool{"BTC-USDT": true}) {
+		t.Fatalf("cycle stored books of %v, want only BTC-USDT", stored)
+	}
+}
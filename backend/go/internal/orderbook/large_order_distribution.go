@@ -5,50 +5,65 @@ import (
 	"math"
 	"sort"
 	"strconv"
-	"time"
-
-	"github.com/supermancell/okex-buddy/internal/utils"
 )
 
+// maxStoredLargeOrders is how many of the largest qualifying levels are kept with the
+// large order distribution and stored in Redis
+const maxStoredLargeOrders = 10
+
+// LargeOrderLevel is a price level whose notional is above the dynamic large order threshold (a wall)
+type LargeOrderLevel struct {
+	Side     string  `json:"side"` // "bid" or "ask"
+	Price    float64 `json:"price"`
+	Size     float64 `json:"size"`
+	Notional float64 `json:"notional"` // price * size
+}
+
 // ComputeLargeOrderDistribution computes large order distribution and sentiment
 // for a given instrument based on the current in-memory order book.
 // It implements a simplified version of PRD 3.3.2:
 //   - compute notional p*q for each price level
-//   - determine dynamic threshold by percentile
+//   - determine dynamic threshold by percentile, floored at minNotional
 //   - apply distance-based exponential decay weighting
 //   - aggregate weighted notional for bids (BullPower) and asks (BearPower)
 //   - apply sliding window smoothing to sentiment values
-func (m *Manager) ComputeLargeOrderDistribution(instID string, percentileAlpha float64, decayLambda float64, sentimentDeadzoneThreshold float64) (largeBuyNotional, largeSellNotional, sentiment float64, err error) {
+func (m *Manager) ComputeLargeOrderDistribution(instID string, percentileAlpha float64, minNotional float64, decayLambda float64, sentimentDeadzoneThreshold float64) (largeBuyNotional, largeSellNotional, sentiment float64, err error) {
 	asks, bids, err := m.GetTop400(instID)
 	if err != nil {
 		return 0, 0, 0, err
 	}
-
-	if len(asks) == 0 && len(bids) == 0 {
-		return 0, 0, 0, fmt.Errorf("empty order book for %s", instID)
-	}
-
-	// Determine mid price from best bid / best ask
-	if len(bids) == 0 || len(asks) == 0 {
-		return 0, 0, 0, fmt.Errorf("cannot compute mid price for %s: missing bids or asks", instID)
+	data, err := m.computeLargeOrderDistribution(instID, asks, bids, percentileAlpha, minNotional, decayLambda, sentimentDeadzoneThreshold)
+	if err != nil {
+		return 0, 0, 0, err
 	}
+	return data.LargeBuyNotional, data.LargeSellNotional, data.Sentiment, nil
+}
 
-	bestBid, err1 := strconv.ParseFloat(bids[0].Price, 64)
-	bestAsk, err2 := strconv.ParseFloat(asks[0].Price, 64)
-	if err1 != nil || err2 != nil || bestBid <= 0 || bestAsk <= 0 {
-		return 0, 0, 0, fmt.Errorf("invalid best bid/ask for %s", instID)
+// GetLargeOrders returns the levels of both sides whose notional is above the dynamic
+// threshold, the percentileAlpha percentile of all level notionals but at least minNotional,
+// largest notional first
+// 返回名义价值超过动态阈值的大单价位（挂单墙）
+func (m *Manager) GetLargeOrders(instID string, percentileAlpha float64, minNotional float64) ([]LargeOrderLevel, error) {
+	asks, bids, err := m.GetTop400(instID)
+	if err != nil {
+		return nil, err
 	}
-
-	mid := (bestBid + bestAsk) / 2.0
-	if mid <= 0 {
-		return 0, 0, 0, fmt.Errorf("invalid mid price for %s", instID)
+	if len(asks) == 0 && len(bids) == 0 {
+		return nil, fmt.Errorf("empty order book for %s", instID)
 	}
+	return largeOrderLevels(asks, bids, percentileAlpha, minNotional), nil
+}
 
-	// Collect notionals for percentile threshold
+// largeOrderLevels returns the levels with a notional above the percentileAlpha percentile of
+// all level notionals, largest first. percentileAlpha outside (0, 1) defaults to 0.95.
+// minNotional floors the threshold, so that in a thin book the top percentile of small
+// orders doesn't count as large; <= 0 disables the floor.
+func largeOrderLevels(asks, bids []PriceLevel, percentileAlpha float64, minNotional float64) []LargeOrderLevel {
+	var levels []LargeOrderLevel
 	var notionals []float64
 
-	appendNotionals := func(levels []PriceLevel) {
-		for _, lvl := range levels {
+	appendLevels := func(side string, book []PriceLevel) {
+		for _, lvl := range book {
 			p, err1 := strconv.ParseFloat(lvl.Price, 64)
 			q, err2 := strconv.ParseFloat(lvl.Size, 64)
 			if err1 != nil || err2 != nil || q <= 0 {
@@ -58,27 +73,21 @@ func (m *Manager) ComputeLargeOrderDistribution(instID string, percentileAlpha f
 			if n <= 0 {
 				continue
 			}
+			levels = append(levels, LargeOrderLevel{Side: side, Price: p, Size: q, Notional: n})
 			notionals = append(notionals, n)
 		}
 	}
 
-	appendNotionals(bids)
-	appendNotionals(asks)
+	appendLevels("bid", bids)
+	appendLevels("ask", asks)
 
 	if len(notionals) == 0 {
-		// No meaningful orders; treat as no large orders
-		return 0, 0, 0, nil
+		return nil
 	}
 
 	if percentileAlpha <= 0 || percentileAlpha >= 1 {
 		percentileAlpha = 0.95
 	}
-	if decayLambda <= 0 {
-		decayLambda = 5.0
-	}
-	if sentimentDeadzoneThreshold <= 0 {
-		sentimentDeadzoneThreshold = 0.3
-	}
 
 	sort.Float64s(notionals)
 
@@ -89,42 +98,74 @@ func (m *Manager) ComputeLargeOrderDistribution(instID string, percentileAlpha f
 	if idx >= len(notionals) {
 		idx = len(notionals) - 1
 	}
-	threshold := notionals[idx]
+	threshold := math.Max(notionals[idx], minNotional)
 
-	var bullPower, bearPower float64
+	large := make([]LargeOrderLevel, 0, len(levels)-idx)
+	for _, level := range levels {
+		if level.Notional > threshold {
+			large = append(large, level)
+		}
+	}
+	sort.SliceStable(large, func(i, j int) bool {
+		return large[i].Notional > large[j].Notional
+	})
+	return large
+}
 
-	// Helper to process one side
-	processSide := func(levels []PriceLevel, isBid bool) {
-		for _, lvl := range levels {
-			p, err1 := strconv.ParseFloat(lvl.Price, 64)
-			q, err2 := strconv.ParseFloat(lvl.Size, 64)
-			if err1 != nil || err2 != nil || q <= 0 {
-				continue
-			}
-			n := p * q
-			if n <= threshold {
-				continue
-			}
+// computeLargeOrderDistribution computes large order distribution and sentiment from the given
+// book levels. Levels holds the largest qualifying levels, Readiness is left to the caller.
+func (m *Manager) computeLargeOrderDistribution(instID string, asks, bids []PriceLevel, percentileAlpha float64, minNotional float64, decayLambda float64, sentimentDeadzoneThreshold float64) (*LargeOrderData, error) {
+	if len(asks) == 0 && len(bids) == 0 {
+		return nil, fmt.Errorf("empty order book for %s", instID)
+	}
 
-			// Distance-based weight relative to mid price
-			w := math.Exp(-decayLambda * math.Abs(p-mid) / mid)
+	// Determine mid price from best bid / best ask
+	if len(bids) == 0 || len(asks) == 0 {
+		return nil, fmt.Errorf("cannot compute mid price for %s: missing bids or asks", instID)
+	}
 
-			if isBid {
-				largeBuyNotional += n
-				bullPower += n * w
-			} else {
-				largeSellNotional += n
-				bearPower += n * w
-			}
-		}
+	bestBid, err1 := strconv.ParseFloat(bids[0].Price, 64)
+	bestAsk, err2 := strconv.ParseFloat(asks[0].Price, 64)
+	if err1 != nil || err2 != nil || bestBid <= 0 || bestAsk <= 0 {
+		return nil, fmt.Errorf("invalid best bid/ask for %s", instID)
 	}
 
-	processSide(bids, true)
-	processSide(asks, false)
+	mid := (bestBid + bestAsk) / 2.0
+	if mid <= 0 {
+		return nil, fmt.Errorf("invalid mid price for %s", instID)
+	}
+
+	// No meaningful orders leaves large empty, treated as no large orders
+	large := largeOrderLevels(asks, bids, percentileAlpha, minNotional)
+
+	if decayLambda <= 0 {
+		decayLambda = 5.0
+	}
+	if sentimentDeadzoneThreshold <= 0 {
+		sentimentDeadzoneThreshold = 0.3
+	}
+
+	data := &LargeOrderData{
+		Levels: append(make([]LargeOrderLevel, 0, maxStoredLargeOrders), large[:min(len(large), maxStoredLargeOrders)]...),
+	}
+	var bullPower, bearPower float64
+
+	for _, level := range large {
+		// Distance-based weight relative to mid price
+		w := math.Exp(-decayLambda * math.Abs(level.Price-mid) / mid)
+
+		if level.Side == "bid" {
+			data.LargeBuyNotional += level.Notional
+			bullPower += level.Notional * w
+		} else {
+			data.LargeSellNotional += level.Notional
+			bearPower += level.Notional * w
+		}
+	}
 
 	totalPower := bullPower + bearPower
 	if totalPower == 0 {
-		return largeBuyNotional, largeSellNotional, 0, nil
+		return data, nil
 	}
 
 	// Compute raw sentiment
@@ -143,19 +184,17 @@ func (m *Manager) ComputeLargeOrderDistribution(instID string, percentileAlpha f
 	}
 
 	// Apply sliding window smoothing to sentiment values (30-second window)
-	if m.sentimentMap[instID] == nil {
-		m.sentimentMap[instID] = utils.NewGenericTimeWindow(30) // 30 seconds
-	}
+	sentimentWindow := m.window(m.sentimentMap, instID, 30) // 30 seconds
 
 	// Add current sentiment to the time window
 	sentimentItem := &PriceLevelWithTimeItem{
 		Value:     transformedSentiment,
-		Timestamp: time.Now().Unix(),
+		Timestamp: m.now().Unix(),
 	}
-	m.sentimentMap[instID].Add(sentimentItem)
+	sentimentWindow.Add(sentimentItem)
 
 	// Calculate smoothed sentiment as average of values in the window
-	windowItems := m.sentimentMap[instID].GetItems()
+	windowItems := sentimentWindow.GetItems()
 	if len(windowItems) > 0 {
 		var sum float64
 		for _, item := range windowItems {
@@ -163,10 +202,14 @@ func (m *Manager) ComputeLargeOrderDistribution(instID string, percentileAlpha f
 				sum += sentimentItem.Value
 			}
 		}
-		sentiment = sum / float64(len(windowItems))
+		data.Sentiment = sum / float64(len(windowItems))
 	} else {
-		sentiment = transformedSentiment
+		data.Sentiment = transformedSentiment
 	}
 
-	return largeBuyNotional, largeSellNotional, sentiment, nil
+	m.windowsMu.Lock()
+	m.lastSentiment[instID] = data.Sentiment
+	m.windowsMu.Unlock()
+
+	return data, nil
 }
@@ -0,0 +1,70 @@
+package orderbook
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// flatBook returns n levels per side of the given size, one tick apart around 100
+func flatBook(n int, size string) (asks, bids [][2]string) {
+	for i := 0; i < n; i++ {
+		asks = append(asks, [2]string{fmt.Sprintf("%.1f", 100.5+float64(i)*0.5), size})
+		bids = append(bids, [2]string{fmt.Sprintf("%.1f", 100-float64(i)*0.5), size})
+	}
+	return asks, bids
+}
+
+func TestGetLargeOrdersReturnsWalls(t *testing.T) {
+	m := NewManagerWithClock((&testClock{t: time.UnixMilli(1717000000000)}).Now)
+	asks, bids := flatBook(20, "1")
+	asks[5][1] = "60" // 103 * 60 = 6180
+	bids[8][1] = "50" // 96 * 50 = 4800
+	loadSnapshot(t, m, "BTC-USDT", asks, bids)
+
+	walls, err := m.GetLargeOrders("BTC-USDT", 0.95, 0)
+	if err != nil {
+		t.Fatalf("GetLargeOrders: %v", err)
+	}
+	want := []LargeOrderLevel{
+		{Side: "ask", Price: 103, Size: 60, Notional: 6180},
+		{Side: "bid", Price: 96, Size: 50, Notional: 4800},
+	}
+	if len(walls) != len(want) {
+		t.Fatalf("walls = %+v, want %+v", walls, want)
+	}
+	for i := range want {
+		if walls[i] != want[i] {
+			t.Fatalf("wall %d = %+v, want %+v", i, walls[i], want[i])
+		}
+	}
+}
+
+func TestLargeOrderMinNotionalFloor(t *testing.T) {
+	m := NewManagerWithClock((&testClock{t: time.UnixMilli(1717000000000)}).Now)
+	asks, bids := flatBook(10, "1")
+	bids[2][1] = "3" // the top percentile of a thin book, but only ~300 notional
+	loadSnapshot(t, m, "BTC-USDT", asks, bids)
+
+	const minNotional = 1000
+	buy, sell, _, err := m.ComputeLargeOrderDistribution("BTC-USDT", 0.9, minNotional, 0, 0)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if buy != 0 || sell != 0 {
+		t.Fatalf("thin book counted as large: buy=%v sell=%v", buy, sell)
+	}
+	if walls, _ := m.GetLargeOrders("BTC-USDT", 0.9, minNotional); len(walls) != 0 {
+		t.Fatalf("thin book walls = %+v, want none", walls)
+	}
+
+	// Above the floor it counts
+	pushBooks(t, m, "update", "BTC-USDT", nil, [][2]string{{bids[2][0], "20"}})
+	buy, sell, _, err = m.ComputeLargeOrderDistribution("BTC-USDT", 0.9, minNotional, 0, 0)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if buy <= 0 || sell != 0 {
+		t.Fatalf("order above the floor: buy=%v sell=%v, want buy only", buy, sell)
+	}
+}
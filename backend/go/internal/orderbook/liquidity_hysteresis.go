@@ -0,0 +1,71 @@
+package orderbook
+
+// Default number of consecutive evaluations a new liquidity warning level must hold, see SetLiquidityHysteresis
+const (
+	defaultLiquidityEscalateCount   = 2
+	defaultLiquidityDeescalateCount = 5
+)
+
+// liquidityLevelRank orders the warning levels from none to severe
+var liquidityLevelRank = map[string]int{
+	"none":     0,
+	"light":    1,
+	"moderate": 2,
+	"severe":   3,
+}
+
+// liquidityLevelState is the per-instrument hysteresis state, guarded by Manager.windowsMu
+type liquidityLevelState struct {
+	level     string // reported level
+	candidate string // raw level waiting to be confirmed
+	count     int    // consecutive evaluations the candidate has held
+}
+
+// SetLiquidityHysteresis configures how many consecutive evaluations a higher warning level
+// must hold before it is reported (escalate) and how many a lower level must hold before the
+// warning is relaxed (deescalate). 1/1 reports every raw level immediately.
+func (m *Manager) SetLiquidityHysteresis(escalateCount, deescalateCount int) {
+	m.windowsMu.Lock()
+	defer m.windowsMu.Unlock()
+	if escalateCount > 0 {
+		m.liquidityEscalateCount = escalateCount
+	}
+	if deescalateCount > 0 {
+		m.liquidityDeescalateCount = deescalateCount
+	}
+}
+
+// applyLiquidityHysteresis feeds the raw warning level of one evaluation into the
+// instrument's hysteresis state and returns the level to report
+func (m *Manager) applyLiquidityHysteresis(instID, rawLevel string) string {
+	m.windowsMu.Lock()
+	defer m.windowsMu.Unlock()
+
+	state, ok := m.liquidityLevels[instID]
+	if !ok {
+		state = &liquidityLevelState{level: "none"}
+		m.liquidityLevels[instID] = state
+	}
+
+	if rawLevel == state.level {
+		state.candidate, state.count = "", 0
+		return state.level
+	}
+
+	if rawLevel == state.candidate {
+		state.count++
+	} else {
+		state.candidate, state.count = rawLevel, 1
+	}
+
+	required := m.liquidityDeescalateCount
+	if liquidityLevelRank[rawLevel] > liquidityLevelRank[state.level] {
+		required = m.liquidityEscalateCount
+	}
+	if state.count >= required {
+		state.level = rawLevel
+		state.candidate, state.count = "", 0
+	}
+
+	return state.level
+}
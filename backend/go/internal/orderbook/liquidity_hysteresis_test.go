@@ -0,0 +1,83 @@
+package orderbook
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLiquidityLevelDoesNotChatter(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+	m.SetLiquidityHysteresis(2, 4)
+
+	healthy := LiquidityMetrics{Liquidity: 100, Spread: 1, Depth: 100}
+	thin := LiquidityMetrics{Liquidity: 60, Spread: 2, Depth: 60}
+	evaluate := func(metrics LiquidityMetrics) *LiquidityShrinkData {
+		t.Helper()
+		clock.t = clock.t.Add(time.Second)
+		result, err := m.detectLiquidityShrinkage("BTC-USDT", &metrics, 0.5, 30, 1800, -2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// A long healthy baseline
+	for i := 0; i < 60; i++ {
+		evaluate(healthy)
+	}
+
+	// Liquidity and spread flip across their thresholds on every evaluation
+	var raw, reported []string
+	for i := 0; i < 20; i++ {
+		metrics := healthy
+		if i%2 == 0 {
+			metrics = thin
+		}
+		result := evaluate(metrics)
+		raw = append(raw, result.RawLevel)
+		reported = append(reported, result.WarningLevel)
+	}
+	if !strings.Contains(strings.Join(raw, " "), "none") || raw[0] == "none" {
+		t.Fatalf("raw levels %v, want a series oscillating around the boundary", raw)
+	}
+	for i, level := range reported {
+		if level != "none" {
+			t.Fatalf("evaluation %d reported %s on an oscillating series: %v (raw %v)", i, level, reported, raw)
+		}
+	}
+
+	// A sustained shrink escalates after 2 evaluations
+	evaluate(thin)
+	if result := evaluate(thin); result.WarningLevel == "none" || !result.Warning {
+		t.Fatalf("sustained shrink still reports %s (raw %s)", result.WarningLevel, result.RawLevel)
+	}
+	escalated := evaluate(thin).WarningLevel
+
+	// Brief recoveries don't relax the warning; 4 in a row do
+	for i := 0; i < 3; i++ {
+		if result := evaluate(healthy); result.WarningLevel == "none" {
+			t.Fatalf("recovery %d relaxed the warning early (raw %s)", i+1, result.RawLevel)
+		}
+	}
+	if result := evaluate(thin); result.WarningLevel == "none" {
+		t.Fatalf("warning dropped from %s on a relapse", escalated)
+	}
+	for i := 1; i <= 4; i++ {
+		result := evaluate(healthy)
+		if want := i == 4; (result.WarningLevel == "none") != want {
+			t.Fatalf("healthy evaluation %d of 4 reports %s (raw %s)", i, result.WarningLevel, result.RawLevel)
+		}
+	}
+}
+
+func TestLiquidityHysteresisOneOneIsRaw(t *testing.T) {
+	m := NewManager()
+	m.SetLiquidityHysteresis(1, 1)
+	for _, level := range []string{"light", "none", "severe", "moderate", "none"} {
+		if got := m.applyLiquidityHysteresis("ETH-USDT", level); got != level {
+			t.Fatalf("1/1 hysteresis reported %s for raw %s", got, level)
+		}
+	}
+}
@@ -4,28 +4,35 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
-	"time"
 
 	"github.com/supermancell/okex-buddy/internal/utils"
 )
 
+// defaultLiquiditySlopeThreshold is the default normalized slope threshold (% of mean liquidity per minute)
+const defaultLiquiditySlopeThreshold = -2.0
+
 // DetectLiquidityShrinkage detects liquidity shrinkage using multiple conditions
 // 参数说明 ：
 // - instID ：交易对ID（如 "BTC-USDT"）
 // - nearPriceDeltaPercent ：价格附近的百分比阈值（用于计算流动性）
 // - shortWindowSeconds ：短期趋势分析窗口（秒）
 // - longWindowSeconds ：长期基准比较窗口（秒）
-// - slopeThreshold ：流动性变化斜率阈值（负值表示收缩趋势）
+// - slopeThreshold ：流动性变化斜率阈值，单位为均值流动性的百分比/分钟（负值表示收缩趋势），见 NormalizeLiquiditySlope
+// - midPriceMode ：价格范围的中心，MidPriceSimple（默认）或 MidPriceMicro
 // 返回值 ：
 // - *LiquidityShrinkData ：包含流动性状态、警告级别等信息的结构体
 // - error ：可能的错误信息
-func (m *Manager) DetectLiquidityShrinkage(instID string, nearPriceDeltaPercent float64, shortWindowSeconds int, longWindowSeconds int, slopeThreshold float64) (*LiquidityShrinkData, error) {
+func (m *Manager) DetectLiquidityShrinkage(instID string, nearPriceDeltaPercent float64, shortWindowSeconds int, longWindowSeconds int, slopeThreshold float64, midPriceMode string) (*LiquidityShrinkData, error) {
 	// Calculate current liquidity metrics
-	currentMetrics, err := m.CalculateLiquidityMetrics(instID, nearPriceDeltaPercent)
+	currentMetrics, err := m.CalculateLiquidityMetricsWithMid(instID, nearPriceDeltaPercent, midPriceMode)
 	if err != nil {
 		return nil, err
 	}
+	return m.detectLiquidityShrinkage(instID, currentMetrics, nearPriceDeltaPercent, shortWindowSeconds, longWindowSeconds, slopeThreshold)
+}
 
+// detectLiquidityShrinkage adds currentMetrics to the liquidity window and evaluates the shrinkage conditions
+func (m *Manager) detectLiquidityShrinkage(instID string, currentMetrics *LiquidityMetrics, nearPriceDeltaPercent float64, shortWindowSeconds int, longWindowSeconds int, slopeThreshold float64) (*LiquidityShrinkData, error) {
 	// Set default parameters if invalid
 	if shortWindowSeconds <= 0 {
 		shortWindowSeconds = 30 // 短期趋势分析窗口 Default to 30 seconds
@@ -36,35 +43,36 @@ func (m *Manager) DetectLiquidityShrinkage(instID string, nearPriceDeltaPercent
 	if slopeThreshold > 0 {
 		slopeThreshold = -slopeThreshold // Ensure it's negative 确保为负值（表示收缩趋势）
 	} else if slopeThreshold == 0 {
-		slopeThreshold = -0.01 // Default slope threshold 默认斜率阈值
+		slopeThreshold = defaultLiquiditySlopeThreshold // Default slope threshold 默认斜率阈值
 	}
 	if nearPriceDeltaPercent <= 0 {
 		nearPriceDeltaPercent = 0.5 // Default to 0.5%
 	}
 
 	// Use time window utility for automatic expiration management
-	if m.liquidityWindows[instID] == nil {
-		m.liquidityWindows[instID] = utils.NewGenericTimeWindow(int64(longWindowSeconds))
-	}
+	liquidityWindow := m.window(m.liquidityWindows, instID, int64(longWindowSeconds))
 
 	// Add current metrics to the time window
 	liquidityItem := &LiquidityWindowItem{
 		Metrics:   *currentMetrics,
-		Timestamp: time.Now().Unix(),
+		Timestamp: m.now().Unix(),
 	}
-	m.liquidityWindows[instID].Add(liquidityItem)
+	liquidityWindow.Add(liquidityItem)
 
 	// Get current items from window
-	windowItems := m.liquidityWindows[instID].GetItems()
+	windowItems := liquidityWindow.GetItems()
 	if len(windowItems) < 2 {
 		return &LiquidityShrinkData{
 			Warning:      false,
 			WarningLevel: "none",
+			RawLevel:     "none",
 			Liquidity:    currentMetrics.Liquidity,
 			Spread:       currentMetrics.Spread,
 			Depth:        currentMetrics.Depth,
 			Slope:        0,
-			Timestamp:    time.Now().Unix(),
+			MidPriceMode: currentMetrics.MidPriceMode,
+			Readiness:    liquidityWindow.Coverage(0),
+			Timestamp:    m.now().Unix(),
 		}, nil
 	}
 
@@ -82,7 +90,7 @@ func (m *Manager) DetectLiquidityShrinkage(instID string, nearPriceDeltaPercent
 	}
 
 	// Separate data for short-term trend analysis
-	shortWindowStart := time.Now().Unix() - int64(shortWindowSeconds)
+	shortWindowStart := m.now().Unix() - int64(shortWindowSeconds)
 	var shortWindowItems []LiquidityWindowItem
 
 	for _, item := range typedItems {
@@ -94,6 +102,7 @@ func (m *Manager) DetectLiquidityShrinkage(instID string, nearPriceDeltaPercent
 	// Calculate slope for short-term trend
 	// 计算短期趋势分析的斜率
 	slope := m.PerformLinearRegression(shortWindowItems)
+	normalizedSlope := NormalizeLiquiditySlope(slope, shortWindowItems)
 
 	// Calculate percentiles for long-term comparison
 	// 计算长期基准比较的25%和75%分位数
@@ -102,7 +111,7 @@ func (m *Manager) DetectLiquidityShrinkage(instID string, nearPriceDeltaPercent
 
 	// Check conditions for liquidity shrinkage
 	conditionA := currentMetrics.Liquidity < liquidity25thPercentile // Low absolute liquidity 绝对流动性低
-	conditionB := slope < slopeThreshold                             // Negative trend 负趋势
+	conditionB := normalizedSlope < slopeThreshold                   // Negative trend 负趋势
 	conditionC := currentMetrics.Spread > spread75thPercentile       // High spread 高价差
 
 	// Count satisfied conditions
@@ -119,51 +128,75 @@ func (m *Manager) DetectLiquidityShrinkage(instID string, nearPriceDeltaPercent
 
 	// Determine warning level
 	// 根据满足条件的数量确定警告级别
-	warning := satisfiedConditions >= 2
-	warningLevel := "none"
+	rawLevel := "none"
 	switch satisfiedConditions {
 	case 2:
-		warningLevel = "light" //轻：2个条件满足
+		rawLevel = "light" //轻：2个条件满足
 	case 3:
-		if slope < 2*slopeThreshold { // Severe negative trend 严重负趋势
-			warningLevel = "severe" //重：3个条件满足且斜率达到严重程度
+		if normalizedSlope < 2*slopeThreshold { // Severe negative trend 严重负趋势
+			rawLevel = "severe" //重：3个条件满足且斜率达到严重程度
 		} else {
-			warningLevel = "moderate" //中：3个条件满足但斜率未达到严重程度
+			rawLevel = "moderate" //中：3个条件满足但斜率未达到严重程度
 		}
 	}
 
+	// Hysteresis keeps the level from chattering when a condition oscillates at its boundary
+	// 滞后处理：级别需连续保持若干次才会升级/降级
+	warningLevel := m.applyLiquidityHysteresis(instID, rawLevel)
+
 	return &LiquidityShrinkData{
-		Warning:      warning,
-		WarningLevel: warningLevel,
-		Liquidity:    currentMetrics.Liquidity,
-		Spread:       currentMetrics.Spread,
-		Depth:        currentMetrics.Depth,
-		Slope:        slope,
-		Timestamp:    time.Now().Unix(),
+		Warning:         warningLevel != "none",
+		WarningLevel:    warningLevel,
+		RawLevel:        rawLevel,
+		Liquidity:       currentMetrics.Liquidity,
+		Spread:          currentMetrics.Spread,
+		Depth:           currentMetrics.Depth,
+		Slope:           slope,
+		NormalizedSlope: normalizedSlope,
+		MidPriceMode:    currentMetrics.MidPriceMode,
+		Readiness:       liquidityWindow.Coverage(0),
+		Timestamp:       m.now().Unix(),
 	}, nil
 }
 
 // ToRedisMap converts LiquidityShrinkData to a map for Redis storage
 func (l *LiquidityShrinkData) ToRedisMap() map[string]interface{} {
 	return map[string]interface{}{
-		"warning":       l.Warning,
-		"warning_level": l.WarningLevel,
-		"liquidity":     l.Liquidity,
-		"spread":        l.Spread,
-		"depth":         l.Depth,
-		"slope":         l.Slope,
-		"timestamp":     l.Timestamp,
+		"warning":          l.Warning,
+		"warning_level":    l.WarningLevel,
+		"raw_level":        l.RawLevel,
+		"liquidity":        l.Liquidity,
+		"spread":           l.Spread,
+		"depth":            l.Depth,
+		"slope":            l.Slope,
+		"normalized_slope": l.NormalizedSlope,
+		"mid_price_mode":   l.MidPriceMode,
+		"readiness":        l.Readiness,
+		"timestamp":        l.Timestamp,
 	}
 }
 
-// CalculateLiquidityMetrics calculates the liquidity metrics for an instrument
+// CalculateLiquidityMetrics calculates the liquidity metrics for an instrument,
+// centering the near-price range on the simple mid
 // 计算INST的流动性指标
 func (m *Manager) CalculateLiquidityMetrics(instID string, nearPriceDeltaPercent float64) (*LiquidityMetrics, error) {
+	return m.CalculateLiquidityMetricsWithMid(instID, nearPriceDeltaPercent, MidPriceSimple)
+}
+
+// CalculateLiquidityMetricsWithMid calculates the liquidity metrics for an instrument,
+// centering the near-price range on the simple mid or the micro-price.
+// The micro-price keeps the window representative when the top of book is lopsided.
+// 计算流动性指标，可选择以简单中间价或微观价格为中心
+func (m *Manager) CalculateLiquidityMetricsWithMid(instID string, nearPriceDeltaPercent float64, midPriceMode string) (*LiquidityMetrics, error) {
 	asks, bids, err := m.GetTop400(instID)
 	if err != nil {
 		return nil, err
 	}
+	return m.liquidityMetrics(instID, asks, bids, nearPriceDeltaPercent, midPriceMode)
+}
 
+// liquidityMetrics calculates the liquidity metrics from the given book levels
+func (m *Manager) liquidityMetrics(instID string, asks, bids []PriceLevel, nearPriceDeltaPercent float64, midPriceMode string) (*LiquidityMetrics, error) {
 	if len(asks) == 0 || len(bids) == 0 {
 		return nil, fmt.Errorf("insufficient data for %s: need both asks and bids", instID)
 	}
@@ -183,10 +216,23 @@ func (m *Manager) CalculateLiquidityMetrics(instID string, nearPriceDeltaPercent
 	// Calculate spread
 	effectiveSpread := (bestAskPrice - bestBidPrice) / midPrice
 
+	// Choose the center of the near-price range
+	// 微观价格: (bid * askSz + ask * bidSz) / (bidSz + askSz)
+	centerPrice := midPrice
+	if midPriceMode == MidPriceMicro {
+		bestBidSize, err1 := strconv.ParseFloat(bids[0].Size, 64)
+		bestAskSize, err2 := strconv.ParseFloat(asks[0].Size, 64)
+		if err1 == nil && err2 == nil && bestBidSize+bestAskSize > 0 {
+			centerPrice = (bestBidPrice*bestAskSize + bestAskPrice*bestBidSize) / (bestBidSize + bestAskSize)
+		}
+	} else {
+		midPriceMode = MidPriceSimple
+	}
+
 	// Calculate near-price depth
-	priceRange := midPrice * nearPriceDeltaPercent / 100.0
-	minPrice := midPrice - priceRange
-	maxPrice := midPrice + priceRange
+	priceRange := centerPrice * nearPriceDeltaPercent / 100.0
+	minPrice := centerPrice - priceRange
+	maxPrice := centerPrice + priceRange
 
 	var totalDepth float64
 
@@ -223,13 +269,15 @@ func (m *Manager) CalculateLiquidityMetrics(instID string, nearPriceDeltaPercent
 	// Calculate composite liquidity metric
 	liquidity := totalDepth / (1 + effectiveSpread)
 
-	currentTime := time.Now().Unix()
+	currentTime := m.now().Unix()
 
 	return &LiquidityMetrics{
-		Spread:    effectiveSpread,
-		Depth:     totalDepth,
-		Liquidity: liquidity,
-		Timestamp: currentTime,
+		Spread:       effectiveSpread,
+		Depth:        totalDepth,
+		Liquidity:    liquidity,
+		MidPrice:     centerPrice,
+		MidPriceMode: midPriceMode,
+		Timestamp:    currentTime,
 	}, nil
 }
 
@@ -272,3 +320,24 @@ func (m *Manager) PerformLinearRegression(items []LiquidityWindowItem) float64 {
 
 	return slope
 }
+
+// NormalizeLiquiditySlope converts a regression slope in liquidity units per second into
+// percent of the items' mean liquidity per minute, so that thresholds are comparable
+// across instruments with different nominal liquidity. Returns 0 without liquidity.
+// 将斜率归一化为“均值流动性的百分比/分钟”
+func NormalizeLiquiditySlope(slope float64, items []LiquidityWindowItem) float64 {
+	if len(items) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, item := range items {
+		sum += item.Metrics.Liquidity
+	}
+	mean := sum / float64(len(items))
+	if mean <= 0 {
+		return 0
+	}
+
+	return slope * 60 / mean * 100
+}
@@ -0,0 +1,40 @@
+package orderbook
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLiquidityDepthCenteredOnMicroPrice(t *testing.T) {
+	m := NewManager()
+	// Buyers stack the best bid, so the micro-price sits near the ask
+	loadSnapshot(t, m, "BTC-USDT",
+		[][2]string{{"102", "1"}, {"102.5", "5"}, {"103", "3"}},
+		[][2]string{{"100", "9"}, {"99", "10"}})
+
+	simple, err := m.CalculateLiquidityMetrics("BTC-USDT", 1)
+	if err != nil {
+		t.Fatalf("simple mid: %v", err)
+	}
+	micro, err := m.CalculateLiquidityMetricsWithMid("BTC-USDT", 1, MidPriceMicro)
+	if err != nil {
+		t.Fatalf("micro-price: %v", err)
+	}
+
+	// Simple mid 101, window 99.99..102.01: bid 100 x 9 and ask 102 x 1
+	if simple.MidPriceMode != MidPriceSimple || simple.MidPrice != 101 || simple.Depth != 10 {
+		t.Fatalf("simple: mode %s mid %v depth %v, want simple 101 10", simple.MidPriceMode, simple.MidPrice, simple.Depth)
+	}
+	// Micro-price (100*1 + 102*9)/10 = 101.8, window 100.782..102.818: asks 102 x 1 and 102.5 x 5
+	if micro.MidPriceMode != MidPriceMicro || math.Abs(micro.MidPrice-101.8) > 1e-9 || micro.Depth != 6 {
+		t.Fatalf("micro: mode %s mid %v depth %v, want micro 101.8 6", micro.MidPriceMode, micro.MidPrice, micro.Depth)
+	}
+	if simple.Spread != micro.Spread {
+		t.Fatalf("spread depends on the centering: %v vs %v", simple.Spread, micro.Spread)
+	}
+
+	// Unknown modes fall back to the simple mid
+	if other, _ := m.CalculateLiquidityMetricsWithMid("BTC-USDT", 1, "vwap"); other.MidPriceMode != MidPriceSimple || other.Depth != simple.Depth {
+		t.Fatalf("unknown mode: %+v", other)
+	}
+}
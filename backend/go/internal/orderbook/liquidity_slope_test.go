@@ -0,0 +1,82 @@
+package orderbook
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// shrinkingBook reloads instID every 5 seconds with a one level book around mid whose sizes
+// lose 5% of base per step, and returns the shrinkage result of each step
+func shrinkingBook(t *testing.T, instID string, mid, base float64, steps int, slopeThreshold float64) []*LiquidityShrinkData {
+	t.Helper()
+
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	var results []*LiquidityShrinkData
+	for k := 0; k < steps; k++ {
+		size := format(base * (1 - 0.05*float64(k)))
+		loadSnapshot(t, m, instID,
+			[][2]string{{format(mid * 1.0005), size}},
+			[][2]string{{format(mid * 0.9995), size}})
+
+		shrink, err := m.DetectLiquidityShrinkage(instID, 0.5, 30, 1800, slopeThreshold, MidPriceSimple)
+		if err != nil {
+			t.Fatalf("%s step %d: %v", instID, k, err)
+		}
+		results = append(results, shrink)
+		clock.t = clock.t.Add(5 * time.Second)
+	}
+	return results
+}
+
+func TestProportionalShrinkageHasEqualNormalizedSlopes(t *testing.T) {
+	btc := shrinkingBook(t, "BTC-USDT", 60000, 3, 7, -2)
+	doge := shrinkingBook(t, "DOGE-USDT", 0.1, 2500000, 7, -2)
+
+	for k := 1; k < len(btc); k++ {
+		// The raw slopes differ by the ratio of nominal liquidity...
+		if ratio := doge[k].Slope / btc[k].Slope; math.Abs(ratio-2500000.0/3) > 1e-3*ratio {
+			t.Fatalf("step %d: raw slope ratio %v, want %v", k, ratio, 2500000.0/3)
+		}
+		// ...the normalized ones do not
+		if diff := math.Abs(doge[k].NormalizedSlope - btc[k].NormalizedSlope); diff > 1e-6 {
+			t.Fatalf("step %d: normalized slopes %v and %v", k, btc[k].NormalizedSlope, doge[k].NormalizedSlope)
+		}
+	}
+
+	// Liquidity falls by 5% of base per 5s step, the mean of the 7 points in the 30s
+	// window is 85% of base: -1%/s of base = -60/0.85 %/min of the mean
+	if got, want := btc[6].NormalizedSlope, -60/0.85; math.Abs(got-want) > 1e-6 {
+		t.Fatalf("normalized slope %v, want %v", got, want)
+	}
+}
+
+func TestLiquiditySlopeThresholdIsConfigurable(t *testing.T) {
+	t.Setenv("LIQUIDITY_SHRINK_SLOPE_THRESHOLD", "-100")
+	if got := config.LoadFromEnv().Analysis.LiquidityShrinkSlopeThreshold; got != -100 {
+		t.Fatalf("LiquidityShrinkSlopeThreshold = %v, want -100", got)
+	}
+
+	// At about -70.6%/min the low liquidity condition is joined by the slope one
+	// only when the threshold is less steep
+	cases := []struct {
+		threshold float64
+		wantLevel string
+	}{
+		{-50, "light"},
+		{50, "light"}, // positive thresholds are taken as their negative
+		{-100, "none"},
+	}
+	for _, tc := range cases {
+		results := shrinkingBook(t, "ETH-USDT", 3000, 40, 7, tc.threshold)
+		if got := results[6].RawLevel; got != tc.wantLevel {
+			t.Errorf("threshold %v: raw level %q, want %q", tc.threshold, got, tc.wantLevel)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/supermancell/okex-buddy/internal/ws"
+)
+
+// markPriceData represents a push of the mark-price channel in OKEx format
+type markPriceData struct {
+	InstID string `json:"instId"`
+	MarkPx string `json:"markPx"`
+	Ts     string `json:"ts"`
+}
+
+// indexTickerData represents a push of the index-tickers channel in OKEx format.
+// Its instId is the index (e.g. BTC-USDT), not a tradable instrument.
+type indexTickerData struct {
+	InstID string `json:"instId"`
+	IdxPx  string `json:"idxPx"`
+	Ts     string `json:"ts"`
+}
+
+// PriceData is the latest mark or index price of an instrument or index
+type PriceData struct {
+	InstID    string  `json:"instrument_id"`
+	Price     float64 `json:"price"`
+	Timestamp int64   `json:"timestamp"` // OKEx push time (ms)
+}
+
+// BasisData is the basis of a derivative, its mark price minus the price of its index
+type BasisData struct {
+	InstID     string  `json:"instrument_id"`
+	IndexID    string  `json:"index_id"`
+	MarkPrice  float64 `json:"mark_price"`
+	IndexPrice float64 `json:"index_price"`
+	Basis      float64 `json:"basis"`     // MarkPrice - IndexPrice
+	BasisPct   float64 `json:"basis_pct"` // Basis relative to IndexPrice, in percent
+	MarkTs     int64   `json:"mark_ts"`   // ms
+	IndexTs    int64   `json:"index_ts"`  // ms
+}
+
+// processMarkPriceMessage keeps the latest mark price of each instrument
+// 标记价格（杠杆/永续/交割/期权）
+func (m *Manager) processMarkPriceMessage(arg ArgData, _ string, rawData json.RawMessage) error {
+	var prices []markPriceData
+	if err := json.Unmarshal(rawData, &prices); err != nil {
+		return fmt.Errorf("failed to unmarshal mark price data: %w", err)
+	}
+
+	for _, raw := range prices {
+		price, err := parsePrice(arg.InstID, "mark price", raw.MarkPx, raw.Ts)
+		if err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		m.markPrices[arg.InstID] = price
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// processIndexTickersMessage keeps the latest price of each index
+// 指数行情
+func (m *Manager) processIndexTickersMessage(arg ArgData, _ string, rawData json.RawMessage) error {
+	var tickers []indexTickerData
+	if err := json.Unmarshal(rawData, &tickers); err != nil {
+		return fmt.Errorf("failed to unmarshal index ticker data: %w", err)
+	}
+
+	for _, raw := range tickers {
+		price, err := parsePrice(arg.InstID, "index price", raw.IdxPx, raw.Ts)
+		if err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		m.indexPrices[arg.InstID] = price
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// parsePrice converts the string price and timestamp of a mark-price or index-tickers push
+func parsePrice(instID, name, px, ts string) (*PriceData, error) {
+	price, err := strconv.ParseFloat(px, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s for %s: %w", name, instID, err)
+	}
+
+	data := &PriceData{InstID: instID, Price: price}
+	if ts != "" {
+		if data.Timestamp, err = strconv.ParseInt(ts, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s timestamp for %s: %w", name, instID, err)
+		}
+	}
+	return data, nil
+}
+
+// GetMarkPrice returns the latest mark price of an instrument, false if none was received
+func (m *Manager) GetMarkPrice(instID string) (*PriceData, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	price, exists := m.markPrices[instID]
+	if !exists {
+		return nil, false
+	}
+	copied := *price
+	return &copied, true
+}
+
+// GetIndexPrice returns the latest price of the index an instrument tracks (e.g. BTC-USDT for
+// BTC-USDT-SWAP, see ws.IndexOf), false if none was received
+func (m *Manager) GetIndexPrice(instID string) (*PriceData, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	price, exists := m.indexPrices[ws.IndexOf(instID)]
+	if !exists {
+		return nil, false
+	}
+	copied := *price
+	return &copied, true
+}
+
+// ComputeBasis returns the basis of a derivative from its latest mark price and the latest
+// price of its index. Both the mark-price and index-tickers channels must be subscribed.
+func (m *Manager) ComputeBasis(instID string) (*BasisData, error) {
+	mark, ok := m.GetMarkPrice(instID)
+	if !ok {
+		return nil, fmt.Errorf("mark price not found for %s", instID)
+	}
+	index, ok := m.GetIndexPrice(instID)
+	if !ok {
+		return nil, fmt.Errorf("index price not found for %s", ws.IndexOf(instID))
+	}
+
+	basis := &BasisData{
+		InstID:     instID,
+		IndexID:    index.InstID,
+		MarkPrice:  mark.Price,
+		IndexPrice: index.Price,
+		Basis:      mark.Price - index.Price,
+		MarkTs:     mark.Timestamp,
+		IndexTs:    index.Timestamp,
+	}
+	if index.Price > 0 {
+		basis.BasisPct = basis.Basis / index.Price * 100
+	}
+	return basis, nil
+}
@@ -0,0 +1,92 @@
+package orderbook
+
+import (
+	"sync/atomic"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// MessageStats counts the messages processed for one instrument
+type MessageStats struct {
+	Snapshots int64 `json:"snapshots"` // books snapshots (including full-push channels like books5)
+	Updates   int64 `json:"updates"`   // books incremental updates
+	Tickers   int64 `json:"tickers"`
+	Trades    int64 `json:"trades"`
+	Errors    int64 `json:"errors"` // messages whose processing failed
+}
+
+// messageCounters holds the live counters behind MessageStats, incremented atomically
+type messageCounters struct {
+	snapshots int64
+	updates   int64
+	tickers   int64
+	trades    int64
+	errors    int64
+}
+
+// counters returns the counters of an instrument, creating them on first use
+func (m *Manager) counters(instID string) *messageCounters {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	c, ok := m.messageCounts[instID]
+	if !ok {
+		c = &messageCounters{}
+		m.messageCounts[instID] = c
+	}
+	return c
+}
+
+// countMessage records one processed message of a channel for an instrument
+func (m *Manager) countMessage(instID, channel, action string, err error) {
+	c := m.counters(instID)
+
+	switch {
+	case channel == "tickers":
+		atomic.AddInt64(&c.tickers, 1)
+	case channel == config.TradesChannel:
+		atomic.AddInt64(&c.trades, 1)
+	case !IsBooksChannel(channel):
+		// Channels added with RegisterChannelHandler only count errors
+	case action == "update":
+		atomic.AddInt64(&c.updates, 1)
+	default:
+		atomic.AddInt64(&c.snapshots, 1)
+	}
+	if err != nil {
+		atomic.AddInt64(&c.errors, 1)
+	}
+}
+
+// MessageStatsOf returns the message counters of a single instrument
+func (m *Manager) MessageStatsOf(instID string) MessageStats {
+	m.statsMu.Lock()
+	c, ok := m.messageCounts[instID]
+	m.statsMu.Unlock()
+	if !ok {
+		return MessageStats{}
+	}
+	return c.snapshot()
+}
+
+// Stats returns the message counters of every instrument that has received a message
+func (m *Manager) Stats() map[string]MessageStats {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	stats := make(map[string]MessageStats, len(m.messageCounts))
+	for instID, c := range m.messageCounts {
+		stats[instID] = c.snapshot()
+	}
+	return stats
+}
+
+func (c *messageCounters) snapshot() MessageStats {
+	return MessageStats{
+		Snapshots: atomic.LoadInt64(&c.snapshots),
+		Updates:   atomic.LoadInt64(&c.updates),
+		Tickers:   atomic.LoadInt64(&c.tickers),
+		Trades:    atomic.LoadInt64(&c.trades),
+		Errors:    atomic.LoadInt64(&c.errors),
+	}
+}
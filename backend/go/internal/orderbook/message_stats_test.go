@@ -0,0 +1,49 @@
+package orderbook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestMessageCountersPerInstrument(t *testing.T) {
+	m := NewManager()
+	m.SetVerifyChecksum(false)
+
+	messages := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{`{"action":"snapshot","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{"asks":[["101","1","0","1"]],"bids":[["100","1","0","1"]],"ts":"1717000000000"}]}`, false},
+		{`{"action":"update","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{"asks":[["102","2","0","1"]],"bids":[],"ts":"1717000000100"}]}`, false},
+		{`{"action":"update","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{"asks":[],"bids":[["99","3","0","1"]],"ts":"1717000000200"}]}`, false},
+		{`{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":[{"instId":"BTC-USDT","bidPx":"100","askPx":"101","last":"100.5","ts":"1717000000300"}]}`, false},
+		// An update before any snapshot fails
+		{`{"action":"update","arg":{"channel":"books","instId":"ETH-USDT"},"data":[{"asks":[["3001","1","0","1"]],"bids":[],"ts":"1717000000000"}]}`, true},
+		{`{"arg":{"channel":"trades","instId":"ETH-USDT"},"data":[{"instId":"ETH-USDT","px":"3000","sz":"0.5","side":"buy","ts":"1717000000000"}]}`, false},
+		{`{"arg":{"channel":"trades","instId":"ETH-USDT"},"data":[{"instId":"ETH-USDT","px":"oops","sz":"0.5","side":"sell","ts":"1717000000000"}]}`, true},
+		// books5 pushes full snapshots without an action
+		{`{"arg":{"channel":"` + config.Books5Channel + `","instId":"SOL-USDT"},"data":[{"asks":[["151","4","0","1"]],"bids":[["150","4","0","1"]],"ts":"1717000000000"}]}`, false},
+	}
+	for i, msg := range messages {
+		if err := m.ProcessMessage([]byte(msg.raw)); (err != nil) != msg.wantErr {
+			t.Fatalf("message %d: err %v, want error %v", i, err, msg.wantErr)
+		}
+	}
+
+	want := map[string]MessageStats{
+		"BTC-USDT": {Snapshots: 1, Updates: 2, Tickers: 1},
+		"ETH-USDT": {Updates: 1, Trades: 2, Errors: 2},
+		"SOL-USDT": {Snapshots: 1},
+	}
+	if got := m.Stats(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stats %+v, want %+v", got, want)
+	}
+	if got := m.MessageStatsOf("ETH-USDT"); got != want["ETH-USDT"] {
+		t.Fatalf("ETH-USDT stats %+v", got)
+	}
+	if got := m.MessageStatsOf("XRP-USDT"); got != (MessageStats{}) {
+		t.Fatalf("unseen instrument has stats %+v", got)
+	}
+}
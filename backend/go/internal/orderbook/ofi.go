@@ -0,0 +1,144 @@
+package orderbook
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// defaultOFIWindowSeconds is the default OFI accumulation window, see SetOFIWindow
+const defaultOFIWindowSeconds = 60
+
+// topOfBook holds the best bid/ask of the previous book update
+type topOfBook struct {
+	bidPrice, bidSize float64
+	askPrice, askSize float64
+}
+
+// OFIWindowItem represents a single OFI increment in the sliding window
+type OFIWindowItem struct {
+	Increment float64
+	Timestamp int64
+}
+
+func (i *OFIWindowItem) GetTimestamp() int64 {
+	return i.Timestamp
+}
+
+// OFIData represents the windowed order flow imbalance
+type OFIData struct {
+	OFI           float64 `json:"ofi"`            // sum of increments in the window, positive = buy pressure
+	LastIncrement float64 `json:"last_increment"` // increment of the latest book update
+	Updates       int     `json:"updates"`        // number of increments in the window
+	WindowSeconds int     `json:"window_seconds"`
+	Readiness     float64 `json:"readiness"` // 0..1, how much of the window is filled
+	Timestamp     int64   `json:"timestamp"`
+}
+
+// ToRedisMap converts OFIData to a map for Redis storage
+func (o *OFIData) ToRedisMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ofi":            o.OFI,
+		"last_increment": o.LastIncrement,
+		"updates":        o.Updates,
+		"window_seconds": o.WindowSeconds,
+		"readiness":      o.Readiness,
+		"timestamp":      o.Timestamp,
+	}
+}
+
+// SetOFIWindow configures the OFI accumulation window in seconds.
+// It applies to windows created afterwards.
+func (m *Manager) SetOFIWindow(windowSeconds int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if windowSeconds > 0 {
+		m.ofiWindowSeconds = windowSeconds
+	}
+}
+
+// recordOFI compares the current best bid/ask with the previous update and adds the
+// OFI increment (Cont, Kukanov & Stoikov) to the instrument's window:
+//
+//	e = 1{Pb >= Pb'}·qb - 1{Pb <= Pb'}·qb' - 1{Pa <= Pa'}·qa + 1{Pa >= Pa'}·qa'
+//
+// where ' marks the previous update. Must be called with m.mu held.
+// 订单流不平衡：比较相邻两次更新的最优买卖价和数量
+func (m *Manager) recordOFI(instID string, book *OrderBook) {
+	current, ok := bookTop(book)
+	if !ok {
+		delete(m.prevTops, instID)
+		return
+	}
+
+	prev, hasPrev := m.prevTops[instID]
+	m.prevTops[instID] = current
+	if !hasPrev {
+		return
+	}
+
+	var increment float64
+	if current.bidPrice >= prev.bidPrice {
+		increment += current.bidSize
+	}
+	if current.bidPrice <= prev.bidPrice {
+		increment -= prev.bidSize
+	}
+	if current.askPrice <= prev.askPrice {
+		increment -= current.askSize
+	}
+	if current.askPrice >= prev.askPrice {
+		increment += prev.askSize
+	}
+
+	window := m.window(m.ofiWindows, instID, int64(m.ofiWindowSeconds))
+	window.Add(&OFIWindowItem{Increment: increment, Timestamp: m.now().Unix()})
+}
+
+// bookTop parses the best bid/ask of a book
+func bookTop(book *OrderBook) (topOfBook, bool) {
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return topOfBook{}, false
+	}
+
+	var top topOfBook
+	var errs [4]error
+	top.bidPrice, errs[0] = strconv.ParseFloat(book.Bids[0].Price, 64)
+	top.bidSize, errs[1] = strconv.ParseFloat(book.Bids[0].Size, 64)
+	top.askPrice, errs[2] = strconv.ParseFloat(book.Asks[0].Price, 64)
+	top.askSize, errs[3] = strconv.ParseFloat(book.Asks[0].Size, 64)
+	for _, err := range errs {
+		if err != nil {
+			return topOfBook{}, false
+		}
+	}
+	return top, true
+}
+
+// ComputeOFI returns the order flow imbalance accumulated over the OFI window
+func (m *Manager) ComputeOFI(instID string) (*OFIData, error) {
+	m.mu.RLock()
+	windowSeconds := m.ofiWindowSeconds
+	m.mu.RUnlock()
+
+	m.windowsMu.Lock()
+	window := m.ofiWindows[instID]
+	m.windowsMu.Unlock()
+	if window == nil {
+		return nil, fmt.Errorf("no OFI data for %s", instID)
+	}
+
+	data := &OFIData{
+		WindowSeconds: windowSeconds,
+		Readiness:     window.Coverage(int64(windowSeconds)),
+		Timestamp:     m.now().Unix(),
+	}
+	for _, item := range window.GetItems() {
+		if ofiItem, ok := item.(*OFIWindowItem); ok {
+			data.OFI += ofiItem.Increment
+			data.LastIncrement = ofiItem.Increment
+			data.Updates++
+		}
+	}
+
+	return data, nil
+}
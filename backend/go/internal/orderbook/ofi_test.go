@@ -0,0 +1,52 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOFIAccumulatesTopOfBookChanges(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "5"}}, [][2]string{{"100", "5"}})
+
+	steps := []struct {
+		name       string
+		asks, bids [][2]string
+		increment  float64
+	}{
+		{"bid size grows at the same price", nil, [][2]string{{"100", "8"}}, 3},
+		{"ask size shrinks at the same price", [][2]string{{"101", "2"}}, nil, 3},
+		{"bid improves to a new price", nil, [][2]string{{"100.5", "1"}}, 1},
+		{"ask improves to a new price", [][2]string{{"100.8", "4"}}, nil, -4},
+	}
+
+	total := 0.0
+	for i, step := range steps {
+		clock.t = clock.t.Add(time.Second)
+		pushBooks(t, m, "update", "BTC-USDT", step.asks, step.bids)
+		total += step.increment
+
+		data, err := m.ComputeOFI("BTC-USDT")
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if data.LastIncrement != step.increment || data.OFI != total || data.Updates != i+1 {
+			t.Fatalf("%s: increment %v OFI %v updates %d, want %v %v %d",
+				step.name, data.LastIncrement, data.OFI, data.Updates, step.increment, total, i+1)
+		}
+	}
+}
+
+func TestOFIWithoutUpdates(t *testing.T) {
+	m := NewManager()
+	if _, err := m.ComputeOFI("BTC-USDT"); err == nil {
+		t.Fatal("OFI computed without any book")
+	}
+
+	// The snapshot is the baseline, not an increment
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "5"}}, [][2]string{{"100", "5"}})
+	if data, err := m.ComputeOFI("BTC-USDT"); err == nil && data.Updates != 0 {
+		t.Fatalf("snapshot counted as %d updates", data.Updates)
+	}
+}
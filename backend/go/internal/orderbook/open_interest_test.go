@@ -0,0 +1,51 @@
+package orderbook
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOpenInterestDeltaOverWindow(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+	m.SetOpenInterestWindow(60)
+
+	steps := []struct {
+		after           time.Duration
+		oi, oiCcy       string
+		delta, ccyDelta float64
+		readiness       float64
+	}{
+		{0, "1000", "10", 0, 0, 0},
+		// Two updates: the delta is against the first one
+		{20 * time.Second, "1250", "12.5", 250, 2.5, 20.0 / 60},
+		// 70s after the first update it has left the window; the 20s one is the oldest now
+		{50 * time.Second, "1100", "11", -150, -1.5, 1},
+	}
+	for i, step := range steps {
+		clock.t = clock.t.Add(step.after)
+		msg := fmt.Sprintf(`{"arg":{"channel":"open-interest","instId":"ETH-USD-SWAP"},"data":[{
+"instId":"ETH-USD-SWAP","instType":"SWAP","oi":%q,"oiCcy":%q,"oiUsd":"0","ts":"%d"}]}`,
+			step.oi, step.oiCcy, clock.t.UnixMilli())
+		if err := m.ProcessMessage([]byte(msg)); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+
+		oi, ok := m.GetOpenInterest("ETH-USD-SWAP")
+		if !ok {
+			t.Fatalf("step %d: no open interest", i)
+		}
+		if oi.OiDelta != step.delta || math.Abs(oi.OiCcyDelta-step.ccyDelta) > 1e-9 {
+			t.Errorf("step %d: delta %v (%v ccy), want %v (%v)", i, oi.OiDelta, oi.OiCcyDelta, step.delta, step.ccyDelta)
+		}
+		if math.Abs(oi.Readiness-step.readiness) > 1e-9 || oi.WindowSeconds != 60 || oi.Timestamp != clock.t.UnixMilli() {
+			t.Errorf("step %d: readiness %v of %ds at %d", i, oi.Readiness, oi.WindowSeconds, oi.Timestamp)
+		}
+	}
+
+	if _, ok := m.GetOpenInterest("BTC-USD-SWAP"); ok {
+		t.Fatal("open interest for an instrument without pushes")
+	}
+}
@@ -3,46 +3,162 @@ package orderbook
 import (
 	"encoding/json"
 	"fmt"
-	"hash/crc32"
 	"log"
 	"sort"
 	"strconv"
-	"strings"
+	"sync"
+	"time"
 
+	"github.com/supermancell/okex-buddy/internal/config"
 	"github.com/supermancell/okex-buddy/internal/utils"
 )
 
+// windowMaxItems caps every per-instrument sliding window so that a shorter processing
+// interval cannot grow a 30-minute window without bound
+const windowMaxItems = 10000
+
 // Manager manages order books for multiple instruments
 type Manager struct {
+	mu                       sync.RWMutex                        // guards books and tickers
 	books                    map[string]*OrderBook               // instrument_id -> order book
 	tickers                  map[string]*TickerData              // instrument_id -> ticker data
+	tickerReceivedAt         map[string]time.Time                // instrument_id -> local receive time of the latest ticker
+	fundingRates             map[string]*FundingRateData         // instrument_id -> latest funding rate, guarded by mu
+	openInterest             map[string]*OpenInterestData        // instrument_id -> latest open interest, guarded by mu
+	markPrices               map[string]*PriceData               // instrument_id -> latest mark price, guarded by mu
+	indexPrices              map[string]*PriceData               // index (e.g. BTC-USDT) -> latest index price, guarded by mu
 	sentimentMap             map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of sentiment values
 	depthWindows             map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of depth values
 	liquidityWindows         map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of liquidity metrics
 	supportResistanceWindows map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of support/resistance levels
 	spreadWindows            map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of spread values
+	ofiWindows               map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of OFI increments
+	tradeWindows             map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of executed trades
+	openInterestWindows      map[string]*utils.GenericTimeWindow // instrument_id -> sliding window of open interest updates
+	lastSentiment            map[string]float64                  // instrument_id -> last smoothed sentiment
+	liquidityLevels          map[string]*liquidityLevelState     // instrument_id -> liquidity warning hysteresis
+	imbalanceStates          map[string]imbalanceState           // instrument_id -> last imbalance beyond the deadband
+	liquidityEscalateCount   int
+	liquidityDeescalateCount int
+	windowsMu                sync.Mutex // guards the window maps, lastSentiment, the liquidity hysteresis and imbalanceStates above
+
+	icebergs             map[string]*icebergTracker // instrument_id -> refill tracking, guarded by mu
+	icebergWindowSeconds int
+	icebergMinRefills    int
+	icebergEnabled       bool // levels are only tracked while iceberg detection is on, see SetIcebergEnabled
+
+	prevTops         map[string]topOfBook // instrument_id -> best bid/ask of the previous update, guarded by mu
+	ofiWindowSeconds int
+
+	tradeWindowSeconds int // see SetTradePressureWindow, guarded by mu
+
+	openInterestWindowSeconds int // see SetOpenInterestWindow, guarded by mu
+
+	imbalanceLevels   int     // see SetImbalanceFlipParams, guarded by mu
+	imbalanceDeadband float64 // see SetImbalanceFlipParams, guarded by mu
+
+	onSequenceGap func(instID string) // see SetSequenceGapHandler, guarded by mu
+
+	awaitingSnapshot map[string]bool // instrument_id -> book reset, updates dropped until the next snapshot, guarded by mu
+
+	tickSizes map[string]float64 // instrument_id -> tick size, see SetTickSize, guarded by mu
+
+	verifyChecksums bool // see SetVerifyChecksum, guarded by mu
+
+	unknownFrames frameLogLimiter // rate-limits the unknown frame log, see ProcessMessage
+
+	staleBooks staleBookTracker // books skipped as stale, see instrumentJobs
+
+	channelHandlers map[string]ChannelHandler // channel -> data push handler, see RegisterChannelHandler
+	handlersMu      sync.RWMutex              // guards channelHandlers
+
+	messageCounts map[string]*messageCounters // instrument_id -> processed message counters, see Stats
+	statsMu       sync.Mutex                  // guards messageCounts; the counters themselves are atomic
+
+	now func() time.Time // clock for receive times, analysis timestamps and windows
 }
 
-// NewManager creates a new order book manager
+// NewManager creates a new order book manager using the real clock
 func NewManager() *Manager {
-	return &Manager{
-		books:                    make(map[string]*OrderBook),
-		tickers:                  make(map[string]*TickerData),
-		sentimentMap:             make(map[string]*utils.GenericTimeWindow),
-		depthWindows:             make(map[string]*utils.GenericTimeWindow),
-		liquidityWindows:         make(map[string]*utils.GenericTimeWindow),
-		supportResistanceWindows: make(map[string]*utils.GenericTimeWindow),
-		spreadWindows:            make(map[string]*utils.GenericTimeWindow),
+	return NewManagerWithClock(time.Now)
+}
+
+// NewManagerWithClock creates a new order book manager whose receive times, analysis
+// timestamps and sliding windows all follow now, e.g. a fake clock for replays and tests
+func NewManagerWithClock(now func() time.Time) *Manager {
+	if now == nil {
+		now = time.Now
 	}
+	m := &Manager{
+		books:                     make(map[string]*OrderBook),
+		tickers:                   make(map[string]*TickerData),
+		tickerReceivedAt:          make(map[string]time.Time),
+		fundingRates:              make(map[string]*FundingRateData),
+		openInterest:              make(map[string]*OpenInterestData),
+		markPrices:                make(map[string]*PriceData),
+		indexPrices:               make(map[string]*PriceData),
+		sentimentMap:              make(map[string]*utils.GenericTimeWindow),
+		depthWindows:              make(map[string]*utils.GenericTimeWindow),
+		liquidityWindows:          make(map[string]*utils.GenericTimeWindow),
+		supportResistanceWindows:  make(map[string]*utils.GenericTimeWindow),
+		spreadWindows:             make(map[string]*utils.GenericTimeWindow),
+		ofiWindows:                make(map[string]*utils.GenericTimeWindow),
+		tradeWindows:              make(map[string]*utils.GenericTimeWindow),
+		openInterestWindows:       make(map[string]*utils.GenericTimeWindow),
+		lastSentiment:             make(map[string]float64),
+		liquidityLevels:           make(map[string]*liquidityLevelState),
+		imbalanceStates:           make(map[string]imbalanceState),
+		liquidityEscalateCount:    defaultLiquidityEscalateCount,
+		liquidityDeescalateCount:  defaultLiquidityDeescalateCount,
+		icebergs:                  make(map[string]*icebergTracker),
+		icebergWindowSeconds:      defaultIcebergWindowSeconds,
+		icebergMinRefills:         defaultIcebergMinRefills,
+		icebergEnabled:            true,
+		prevTops:                  make(map[string]topOfBook),
+		ofiWindowSeconds:          defaultOFIWindowSeconds,
+		tradeWindowSeconds:        defaultTradePressureWindowSeconds,
+		openInterestWindowSeconds: defaultOpenInterestWindowSeconds,
+		imbalanceLevels:           defaultImbalanceLevels,
+		imbalanceDeadband:         defaultImbalanceDeadband,
+		awaitingSnapshot:          make(map[string]bool),
+		tickSizes:                 make(map[string]float64),
+		verifyChecksums:           true,
+		messageCounts:             make(map[string]*messageCounters),
+		channelHandlers:           make(map[string]ChannelHandler),
+		now:                       now,
+	}
+	m.registerBuiltinChannels()
+	return m
 }
 
-// ProcessMessage processes incoming WebSocket messages for both books and tickers channels
+// ProcessMessage processes incoming WebSocket messages for the books, tickers and trades
+// channels, and any channel added with RegisterChannelHandler.
+// It only updates in-memory state (books, tickers, trades, OFI/iceberg tracking); all per-second
+// analysis runs in StartOrderBookProcessor and never inline here.
+// Frames that are neither a known event nor a data push of a registered channel are logged
+// (rate-limited) and reported as ErrUnknownFrame.
 func (m *Manager) ProcessMessage(msg []byte) error {
-	var okexMsg OKExMessage
-	if err := json.Unmarshal(msg, &okexMsg); err != nil {
-		return fmt.Errorf("failed to unmarshal message: %w", err)
+	kind, okexMsg, arg := classifyFrame(msg)
+	if kind == frameEvent {
+		return m.processEvent(okexMsg)
 	}
 
+	// Route data pushes through the channel registry, see RegisterChannelHandler
+	var handler ChannelHandler
+	if kind == frameData {
+		handler = m.channelHandler(arg.Channel)
+	}
+	if handler == nil {
+		return m.unknownFrame(msg)
+	}
+
+	err := handler(arg, okexMsg.Action, okexMsg.Data)
+	m.countMessage(arg.InstID, arg.Channel, okexMsg.Action, err)
+	return err
+}
+
+// processEvent handles event frames: subscribe/login acknowledgements and errors
+func (m *Manager) processEvent(okexMsg OKExMessage) error {
 	// Handle subscription confirmation
 	if okexMsg.Event == "subscribe" {
 		// Extract channel and instID from arg field for logging
@@ -55,40 +171,126 @@ func (m *Manager) ProcessMessage(msg []byte) error {
 		return nil
 	}
 
+	// Login confirmation on the public connection (books-l2-tbt)
+	if okexMsg.Event == "login" {
+		return nil
+	}
+
 	// Handle error messages
 	if okexMsg.Event == "error" {
 		return fmt.Errorf("OKEx error: code=%s, msg=%s", okexMsg.Code, okexMsg.Msg)
 	}
 
-	// Extract channel and instID from arg field
-	var arg ArgData
-	if err := json.Unmarshal(okexMsg.Arg, &arg); err != nil {
-		log.Printf("WARNING: Failed to parse arg field: %v", err)
+	// An unsubscribed books channel receives no more updates; drop its book so that
+	// Instruments stops listing it
+	if okexMsg.Event == "unsubscribe" {
+		var arg ArgData
+		if err := json.Unmarshal(okexMsg.Arg, &arg); err == nil && IsBooksChannel(arg.Channel) {
+			m.RemoveBook(arg.InstID)
+		}
 		return nil
 	}
 
-	// Route message based on channel type
-	switch arg.Channel {
-	case "books":
-		return m.processBooksMessage(okexMsg, arg)
-	case "tickers":
-		return m.processTickersMessage(okexMsg, arg)
-	default:
-		log.Printf("WARNING: Unknown channel type: %s", arg.Channel)
-		return nil
+	// notice and connection count events need no action
+	return nil
+}
+
+// IsBooksChannel reports whether a channel carries order book data handled by the Manager
+func IsBooksChannel(channel string) bool {
+	switch channel {
+	case config.BooksChannel, config.BooksL2TBTChannel, config.Books50L2TBTChannel,
+		config.Books5Channel, config.BBOTBTChannel:
+		return true
 	}
+	return false
+}
+
+// ChannelHasChecksum reports whether a books channel sends a checksum. books5 and bbo-tbt
+// push full snapshots without one, verifying them would always report a mismatch.
+func ChannelHasChecksum(channel string) bool {
+	return channel != config.Books5Channel && channel != config.BBOTBTChannel
+}
+
+// SetVerifyChecksum enables or disables checksum verification for all books (on by default)
+func (m *Manager) SetVerifyChecksum(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifyChecksums = enabled
+}
+
+// MarkAwaitingSnapshot drops the books of the given instruments and ignores their
+// incremental updates until a fresh snapshot arrives, so that updates can never be merged
+// onto a stale book (e.g. after a reconnect, when the old book is no longer continuous).
+func (m *Manager) MarkAwaitingSnapshot(instIDs []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, instID := range instIDs {
+		m.resetBookLocked(instID)
+	}
+}
+
+// resetBookLocked drops an instrument's book state and waits for the next snapshot. Caller holds mu.
+func (m *Manager) resetBookLocked(instID string) {
+	delete(m.books, instID)
+	delete(m.icebergs, instID)
+	delete(m.prevTops, instID)
+	m.awaitingSnapshot[instID] = true
+}
+
+// RemoveBook drops an instrument's book state without waiting for a new snapshot,
+// e.g. once its books channel is unsubscribed
+func (m *Manager) RemoveBook(instID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.books, instID)
+	delete(m.icebergs, instID)
+	delete(m.prevTops, instID)
+	delete(m.awaitingSnapshot, instID)
+	m.staleBooks.mark(instID, false)
+}
+
+// ResetWindows clears the book-derived analysis history of an instrument (depth, liquidity,
+// spread, sentiment, support/resistance and OFI windows, the smoothed sentiment and the
+// liquidity/imbalance state), so that data after a resync is not mixed with the history
+// from before the gap. Windows are recreated empty on the next analysis.
+func (m *Manager) ResetWindows(instID string) {
+	m.windowsMu.Lock()
+	defer m.windowsMu.Unlock()
+
+	for _, windows := range []map[string]*utils.GenericTimeWindow{
+		m.sentimentMap, m.depthWindows, m.liquidityWindows, m.supportResistanceWindows,
+		m.spreadWindows, m.ofiWindows,
+	} {
+		delete(windows, instID)
+	}
+	delete(m.lastSentiment, instID)
+	delete(m.liquidityLevels, instID)
+	delete(m.imbalanceStates, instID)
+}
+
+// SetSequenceGapHandler sets the callback invoked when an update's prevSeqId does not match
+// the last applied seqId, when a book ends up crossed or locked (best bid >= best ask), or
+// when it diverges from the ticker, see ResyncIfDivergent.
+// The book is dropped and the handler should request a new snapshot
+// (e.g. by resubscribing). The handler is called with the Manager lock held, so it must not
+// call back into the Manager synchronously.
+func (m *Manager) SetSequenceGapHandler(handler func(instID string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onSequenceGap = handler
 }
 
 // processBooksMessage handles order book data messages
-func (m *Manager) processBooksMessage(okexMsg OKExMessage, arg ArgData) error {
+func (m *Manager) processBooksMessage(arg ArgData, action string, rawData json.RawMessage) error {
 	// Process order book data
-	if len(okexMsg.Data) == 0 {
+	if len(rawData) == 0 {
 		return nil // No data to process
 	}
 
 	// Parse book data from raw JSON
 	var bookDatas []BookData
-	if err := json.Unmarshal(okexMsg.Data, &bookDatas); err != nil {
+	if err := json.Unmarshal(rawData, &bookDatas); err != nil {
 		return fmt.Errorf("failed to unmarshal book data: %w", err)
 	}
 
@@ -97,7 +299,7 @@ func (m *Manager) processBooksMessage(okexMsg OKExMessage, arg ArgData) error {
 		// Use instID from arg field (this is where OKX puts it)
 		data.InstID = arg.InstID
 
-		if err := m.updateOrderBook(data, okexMsg.Action); err != nil {
+		if err := m.updateOrderBook(data, arg.Channel, action); err != nil {
 			return fmt.Errorf("failed to update order book for %s: %w", data.InstID, err)
 		}
 	}
@@ -106,15 +308,15 @@ func (m *Manager) processBooksMessage(okexMsg OKExMessage, arg ArgData) error {
 }
 
 // processTickersMessage handles ticker data messages
-func (m *Manager) processTickersMessage(okexMsg OKExMessage, arg ArgData) error {
+func (m *Manager) processTickersMessage(arg ArgData, _ string, rawData json.RawMessage) error {
 	// Process ticker data
-	if len(okexMsg.Data) == 0 {
+	if len(rawData) == 0 {
 		return nil // No data to process
 	}
 
 	// Parse ticker data from raw JSON
 	var tickerDatas []TickerData
-	if err := json.Unmarshal(okexMsg.Data, &tickerDatas); err != nil {
+	if err := json.Unmarshal(rawData, &tickerDatas); err != nil {
 		return fmt.Errorf("failed to unmarshal ticker data: %w", err)
 	}
 
@@ -141,12 +343,42 @@ func (m *Manager) processTickersMessage(okexMsg OKExMessage, arg ArgData) error
 
 // storeTickerData stores ticker data in Redis for quick access
 func (m *Manager) storeTickerData(tickerData TickerData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.tickers[tickerData.InstID] = &tickerData
+	m.tickerReceivedAt[tickerData.InstID] = m.now()
 	return nil
 }
 
-// updateOrderBook updates the order book based on snapshot or incremental data
-func (m *Manager) updateOrderBook(data BookData, action string) error {
+// window returns the sliding window of an instrument from the given map, creating it on first use
+func (m *Manager) window(windows map[string]*utils.GenericTimeWindow, instID string, durationSeconds int64) *utils.GenericTimeWindow {
+	m.windowsMu.Lock()
+	defer m.windowsMu.Unlock()
+
+	if windows[instID] == nil {
+		windows[instID] = utils.NewGenericTimeWindowWithCap(durationSeconds, windowMaxItems)
+		windows[instID].SetClock(m.now)
+	}
+	return windows[instID]
+}
+
+// windowCoverage returns the Coverage of an instrument's window, 0 if it does not exist yet
+func (m *Manager) windowCoverage(windows map[string]*utils.GenericTimeWindow, instID string, neededSeconds int64) float64 {
+	m.windowsMu.Lock()
+	window := windows[instID]
+	m.windowsMu.Unlock()
+	if window == nil {
+		return 0
+	}
+	return window.Coverage(neededSeconds)
+}
+
+// updateOrderBook updates the order book based on snapshot or incremental data.
+// channel decides at snapshot time whether the book's checksum is verified.
+func (m *Manager) updateOrderBook(data BookData, channel, action string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	ts, err := strconv.ParseInt(data.Timestamp, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid timestamp: %w", err)
@@ -157,32 +389,14 @@ func (m *Manager) updateOrderBook(data BookData, action string) error {
 		book := &OrderBook{
 			InstrumentID: data.InstID,
 			Timestamp:    ts,
-			Checksum:     data.Checksum,
+			Checksum:     int32(data.Checksum),
+			SeqID:        data.SeqID,
+			Channel:      channel,
+			HasChecksum:  ChannelHasChecksum(channel),
 		}
 
-		// Parse asks
-		book.Asks = make([]PriceLevel, 0, len(data.Asks))
-		for _, ask := range data.Asks {
-			if len(ask) < 2 {
-				continue
-			}
-			book.Asks = append(book.Asks, PriceLevel{
-				Price: ask[0],
-				Size:  ask[1],
-			})
-		}
-
-		// Parse bids
-		book.Bids = make([]PriceLevel, 0, len(data.Bids))
-		for _, bid := range data.Bids {
-			if len(bid) < 2 {
-				continue
-			}
-			book.Bids = append(book.Bids, PriceLevel{
-				Price: bid[0],
-				Size:  bid[1],
-			})
-		}
+		book.Asks = parseSnapshotLevels(data.InstID, "ask", data.Asks)
+		book.Bids = parseSnapshotLevels(data.InstID, "bid", data.Bids)
 
 		// IMPORTANT: Sort the data after parsing
 		// Asks should be sorted ascending by price
@@ -190,33 +404,52 @@ func (m *Manager) updateOrderBook(data BookData, action string) error {
 		// Bids should be sorted descending by price
 		m.sortLevels(&book.Bids, false)
 
+		// A new snapshot restarts refill and OFI tracking
+		delete(m.icebergs, data.InstID)
+		delete(m.prevTops, data.InstID)
+		delete(m.awaitingSnapshot, data.InstID)
+
 		// Store the order book
+		book.UpdatedAt = m.now().UnixMilli()
 		m.books[data.InstID] = book
+		m.recordOFI(data.InstID, book)
 
 		// Verify checksum (log warning but don't fail)
-		if err := m.verifyChecksum(data.InstID); err != nil {
-			log.Printf("WARNING: %v (continuing anyway)", err)
-			// Don't return error - just log and continue
-		}
+		m.checkBook(data.InstID, book)
 
-		return nil
+		return m.rejectCrossedLocked(data.InstID, book)
 	}
 
 	// Handle incremental update
 	if action == "update" {
+		// Book was reset, drop stray updates until the fresh snapshot replaces it
+		if m.awaitingSnapshot[data.InstID] {
+			return nil
+		}
+
 		book, exists := m.books[data.InstID]
 		if !exists {
 			return fmt.Errorf("order book not initialized for %s", data.InstID)
 		}
 
+		// Sequence gap: a message was lost, the book can no longer be trusted
+		if book.SeqID > 0 && data.PrevSeqID > 0 && data.PrevSeqID != book.SeqID {
+			m.resetBookLocked(data.InstID)
+			if m.onSequenceGap != nil {
+				m.onSequenceGap(data.InstID)
+			}
+			return fmt.Errorf("sequence gap for %s: prevSeqId=%d, last seqId=%d", data.InstID, data.PrevSeqID, book.SeqID)
+		}
+
 		book.Timestamp = ts
+		book.SeqID = data.SeqID
 
 		// Update asks
 		for _, ask := range data.Asks {
 			if len(ask) < 2 {
 				continue
 			}
-			m.updateLevel(&book.Asks, ask[0], ask[1], true)
+			m.updateLevel(data.InstID, &book.Asks, ask[0], ask[1], levelOrderCount(ask), true)
 		}
 
 		// Update bids
@@ -224,9 +457,14 @@ func (m *Manager) updateOrderBook(data BookData, action string) error {
 			if len(bid) < 2 {
 				continue
 			}
-			m.updateLevel(&book.Bids, bid[0], bid[1], false)
+			m.updateLevel(data.InstID, &book.Bids, bid[0], bid[1], levelOrderCount(bid), false)
 		}
 
+		// Inserts are appended unsorted, so sort both sides once before trimming;
+		// this guarantees the retained 400 levels are the best 400 by price
+		m.sortLevels(&book.Asks, true)
+		m.sortLevels(&book.Bids, false)
+
 		// Trim to top 400 levels
 		if len(book.Asks) > 400 {
 			book.Asks = book.Asks[:400]
@@ -235,23 +473,25 @@ func (m *Manager) updateOrderBook(data BookData, action string) error {
 			book.Bids = book.Bids[:400]
 		}
 
-		book.Checksum = data.Checksum
+		book.Checksum = int32(data.Checksum)
+		book.UpdatedAt = m.now().UnixMilli()
+		m.recordOFI(data.InstID, book)
 
 		// Verify checksum (log warning but don't fail)
-		if err := m.verifyChecksum(data.InstID); err != nil {
-			log.Printf("WARNING: %v (continuing anyway)", err)
-			// Don't return error - just log and continue
-		}
+		m.checkBook(data.InstID, book)
 
-		return nil
+		return m.rejectCrossedLocked(data.InstID, book)
 	}
 
 	return fmt.Errorf("unknown action: %s", action)
 }
 
 // updateLevel updates a single price level
-func (m *Manager) updateLevel(levels *[]PriceLevel, price, size string, isAsk bool) {
+func (m *Manager) updateLevel(instID string, levels *[]PriceLevel, price, size string, orderCount int, isAsk bool) {
 	sizeFloat, err := strconv.ParseFloat(size, 64)
+	if err == nil && m.icebergEnabled {
+		m.observeLevelChange(instID, price, levelSize(*levels, price), sizeFloat, isAsk)
+	}
 	if err != nil || sizeFloat == 0 {
 		// Remove this level if size is 0 or invalid
 		m.removeLevel(levels, price)
@@ -263,21 +503,73 @@ func (m *Manager) updateLevel(levels *[]PriceLevel, price, size string, isAsk bo
 	for i, level := range *levels {
 		if level.Price == price {
 			(*levels)[i].Size = size
+			(*levels)[i].OrderCount = orderCount
 			found = true
 			break
 		}
 	}
 
 	if !found {
-		// Insert new level and sort
+		// Insert new level; the caller sorts once after applying all updates
 		*levels = append(*levels, PriceLevel{
-			Price: price,
-			Size:  size,
+			Price:      price,
+			Size:       size,
+			OrderCount: orderCount,
 		})
-		m.sortLevels(levels, isAsk)
 	}
 }
 
+// parseSnapshotLevels converts the levels of one side of a snapshot. A price listed more
+// than once keeps its last entry, so duplicates cannot corrupt the checksum or depth sums.
+func parseSnapshotLevels(instID, side string, raw [][]string) []PriceLevel {
+	levels := make([]PriceLevel, 0, len(raw))
+	index := make(map[string]int, len(raw)) // price -> position in levels
+	duplicates := 0
+	for _, entry := range raw {
+		if len(entry) < 2 {
+			continue
+		}
+		if i, seen := index[entry[0]]; seen {
+			levels[i].Size = entry[1]
+			levels[i].OrderCount = levelOrderCount(entry)
+			duplicates++
+			continue
+		}
+		index[entry[0]] = len(levels)
+		levels = append(levels, PriceLevel{
+			Price:      entry[0],
+			Size:       entry[1],
+			OrderCount: levelOrderCount(entry),
+		})
+	}
+
+	if duplicates > 0 {
+		log.Printf("Snapshot for %s has %d duplicate %s price level(s), keeping the last entry of each", instID, duplicates, side)
+	}
+	return levels
+}
+
+// levelOrderCount returns the number of orders of a level entry [price, size, "0", count],
+// 0 when OKEx omits it
+func levelOrderCount(entry []string) int {
+	if len(entry) < 4 {
+		return 0
+	}
+	count, _ := strconv.Atoi(entry[3])
+	return count
+}
+
+// levelSize returns the current size at a price, 0 if the level does not exist
+func levelSize(levels []PriceLevel, price string) float64 {
+	for _, level := range levels {
+		if level.Price == price {
+			size, _ := strconv.ParseFloat(level.Size, 64)
+			return size
+		}
+	}
+	return 0
+}
+
 // removeLevel removes a price level
 func (m *Manager) removeLevel(levels *[]PriceLevel, price string) {
 	for i, level := range *levels {
@@ -288,16 +580,55 @@ func (m *Manager) removeLevel(levels *[]PriceLevel, price string) {
 	}
 }
 
-// sortLevels sorts price levels
+// sortLevels sorts price levels by parsed price, asks ascending and bids descending.
+// Prices are parsed once per level. Levels with equal parsed prices (e.g. "100.0" and
+// "100.00") are ordered by price string, then size, so the order, and with it the
+// checksum string, does not depend on the previous order.
 func (m *Manager) sortLevels(levels *[]PriceLevel, isAsk bool) {
-	sort.Slice(*levels, func(i, j int) bool {
-		pi, _ := strconv.ParseFloat((*levels)[i].Price, 64)
-		pj, _ := strconv.ParseFloat((*levels)[j].Price, 64)
-		if isAsk {
-			return pi < pj // asks ascending
+	type keyedLevel struct {
+		level PriceLevel
+		price float64
+	}
+
+	keyed := make([]keyedLevel, len(*levels))
+	for i, level := range *levels {
+		price, _ := strconv.ParseFloat(level.Price, 64)
+		keyed[i] = keyedLevel{level: level, price: price}
+	}
+
+	sort.SliceStable(keyed, func(i, j int) bool {
+		a, b := keyed[i], keyed[j]
+		if a.price != b.price {
+			if isAsk {
+				return a.price < b.price // asks ascending
+			}
+			return a.price > b.price // bids descending
 		}
-		return pi > pj // bids descending
+		if a.level.Price != b.level.Price {
+			return a.level.Price < b.level.Price
+		}
+		return a.level.Size < b.level.Size
 	})
+
+	for i := range keyed {
+		(*levels)[i] = keyed[i].level
+	}
+}
+
+// checkBook verifies the book's checksum when its channel carries one and records the result.
+// A mismatch is only logged. Caller holds mu.
+func (m *Manager) checkBook(instID string, book *OrderBook) {
+	if !book.HasChecksum || !m.verifyChecksums {
+		book.ChecksumOK = true
+		return
+	}
+
+	err := m.verifyChecksum(instID)
+	book.ChecksumOK = err == nil
+	if err != nil {
+		log.Printf("WARNING: %v (continuing anyway)", err)
+		// Don't return error - just log and continue
+	}
 }
 
 // verifyChecksum verifies the order book checksum according to OKEx specification
@@ -310,61 +641,24 @@ func (m *Manager) sortLevels(levels *[]PriceLevel, isAsk bool) {
 func (m *Manager) verifyChecksum(instID string) error {
 	book, exists := m.books[instID]
 	if !exists {
-		return fmt.Errorf("order book not found for %s", instID)
+		return fmt.Errorf("%s: %w", instID, ErrBookNotFound)
 	}
 
 	// Build checksum string according to OKEx spec
-	var parts []string
-
-	// Determine how many levels to include (max 25)
-	maxLevels := 25
-	bidCount := len(book.Bids)
-	askCount := len(book.Asks)
-
-	// Calculate actual max levels (take minimum of available and 25)
-	maxBids := bidCount
-	if maxBids > maxLevels {
-		maxBids = maxLevels
-	}
-	maxAsks := askCount
-	if maxAsks > maxLevels {
-		maxAsks = maxLevels
-	}
-
-	// Use the larger of the two for interleaving
-	iterCount := maxBids
-	if maxAsks > iterCount {
-		iterCount = maxAsks
-	}
-
-	// Interleave bids and asks: bid[price:size]:ask[price:size]:...
-	for i := 0; i < iterCount; i++ {
-		// Add bid if available
-		if i < maxBids {
-			parts = append(parts, book.Bids[i].Price)
-			parts = append(parts, book.Bids[i].Size)
-		}
-		// Add ask if available
-		if i < maxAsks {
-			parts = append(parts, book.Asks[i].Price)
-			parts = append(parts, book.Asks[i].Size)
-		}
-	}
-
-	// Calculate CRC32 checksum
-	checksumStr := strings.Join(parts, ":")
-	calculated := int32(crc32.ChecksumIEEE([]byte(checksumStr)))
+	checksumStr := BuildChecksumString(book.Bids, book.Asks)
+	calculated := checksumOf(checksumStr)
 
 	if calculated != book.Checksum {
 		// Log first few levels for debugging
 		log.Printf("Checksum mismatch for %s:", instID)
 		log.Printf("  Calculated: %d, Expected: %d", calculated, book.Checksum)
 		log.Printf("  Checksum string (first 200 chars): %s", checksumStr[:min(200, len(checksumStr))])
-		log.Printf("  Bids count: %d (using %d), Asks count: %d (using %d)", bidCount, maxBids, askCount, maxAsks)
-		if maxBids > 0 {
+		log.Printf("  Bids count: %d (using %d), Asks count: %d (using %d)",
+			len(book.Bids), min(len(book.Bids), checksumLevels), len(book.Asks), min(len(book.Asks), checksumLevels))
+		if len(book.Bids) > 0 {
 			log.Printf("  First bid: %s @ %s", book.Bids[0].Size, book.Bids[0].Price)
 		}
-		if maxAsks > 0 {
+		if len(book.Asks) > 0 {
 			log.Printf("  First ask: %s @ %s", book.Asks[0].Size, book.Asks[0].Price)
 		}
 		return fmt.Errorf("checksum mismatch: calculated=%d, expected=%d, instID=%s", calculated, book.Checksum, instID)
@@ -380,35 +674,90 @@ func min(a, b int) int {
 	return b
 }
 
-// GetOrderBook returns the order book for an instrument
+// GetOrderBook returns the live order book for an instrument.
+// The book is mutated by incoming updates; use Snapshot for a consistent copy.
 func (m *Manager) GetOrderBook(instID string) (*OrderBook, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	book, exists := m.books[instID]
 	return book, exists
 }
 
+// Instruments returns the IDs of the instruments that currently have a book, sorted
+func (m *Manager) Instruments() []string {
+	m.mu.RLock()
+	instIDs := make([]string, 0, len(m.books))
+	for instID := range m.books {
+		instIDs = append(instIDs, instID)
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(instIDs)
+	return instIDs
+}
+
+// HasBook reports whether an instrument currently has a book
+func (m *Manager) HasBook(instID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists := m.books[instID]
+	return exists
+}
+
 func (m *Manager) GetTicker(instID string) (*TickerData, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	ticker, exists := m.tickers[instID]
 	return ticker, exists
 }
 
-// GetTop400 returns the top 400 levels of asks and bids
-func (m *Manager) GetTop400(instID string) (asks, bids []PriceLevel, err error) {
-	book, exists := m.books[instID]
+// GetTickerWithAge returns the ticker for an instrument together with the time elapsed
+// since it was received. A growing age while books keep flowing means the tickers
+// channel has silently stalled.
+func (m *Manager) GetTickerWithAge(instID string) (*TickerData, time.Duration, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ticker, exists := m.tickers[instID]
 	if !exists {
-		return nil, nil, fmt.Errorf("order book not found for %s", instID)
+		return nil, 0, false
 	}
+	return ticker, m.now().Sub(m.tickerReceivedAt[instID]), true
+}
 
-	askCount := 400
-	if len(book.Asks) < askCount {
-		askCount = len(book.Asks)
+// Snapshot returns a deep copy of the order book for an instrument, so that callers
+// operate on a consistent view that incoming updates cannot mutate
+func (m *Manager) Snapshot(instID string) (*OrderBook, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, exists := m.books[instID]
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
 	}
-	asks = book.Asks[:askCount]
 
-	bidCount := 400
-	if len(book.Bids) < bidCount {
-		bidCount = len(book.Bids)
+	snapshot := *book
+	snapshot.Asks = copyLevels(book.Asks, len(book.Asks))
+	snapshot.Bids = copyLevels(book.Bids, len(book.Bids))
+	return &snapshot, nil
+}
+
+// GetTop400 returns copies of the top 400 levels of asks and bids
+func (m *Manager) GetTop400(instID string) (asks, bids []PriceLevel, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, exists := m.books[instID]
+	if !exists {
+		return nil, nil, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
 	}
-	bids = book.Bids[:bidCount]
 
-	return asks, bids, nil
+	return copyLevels(book.Asks, 400), copyLevels(book.Bids, 400), nil
+}
+
+// copyLevels returns a copy of the first n levels, never sharing the backing array
+func copyLevels(levels []PriceLevel, n int) []PriceLevel {
+	n = min(n, len(levels))
+	copied := make([]PriceLevel, n)
+	copy(copied, levels[:n])
+	return copied
 }
@@ -2,69 +2,373 @@ package orderbook
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/supermancell/okex-buddy/internal/config"
 	"github.com/supermancell/okex-buddy/internal/redisclient"
-	"github.com/supermancell/okex-buddy/internal/ws"
 )
 
-// ProcessInstrument handles all analysis computations for a single instrument
-func ProcessInstrument(instID string, obManager *Manager, redisClient *redisclient.Client, cfg config.AppConfig) {
-	_, _, err := obManager.GetTop400(instID)
+// Analysis names used as circuit breaker keys
+const (
+	AnalysisSupportResistance = "support_resistance"
+	AnalysisSpreadZScore      = "spread_zscore"
+	AnalysisLargeOrder        = "large_order"
+	AnalysisDepthAnomaly      = "depth_anomaly"
+	AnalysisLiquidityShrink   = "liquidity_shrink"
+	AnalysisIceberg           = "iceberg"
+	AnalysisOFI               = "ofi"
+	AnalysisTradePressure     = "trade_pressure"
+	AnalysisImbalanceFlip     = "imbalance_flip"
+)
+
+// AnalysisEnabled reports whether the named analysis is switched on in cfg.
+// Unknown names are treated as enabled.
+func AnalysisEnabled(cfg config.AnalysisConfig, name string) bool {
+	switch name {
+	case AnalysisSupportResistance:
+		return cfg.EnableSupportResistance
+	case AnalysisSpreadZScore:
+		return cfg.EnableSpreadZScore
+	case AnalysisLargeOrder:
+		return cfg.EnableLargeOrder
+	case AnalysisDepthAnomaly:
+		return cfg.EnableDepthAnomaly
+	case AnalysisLiquidityShrink:
+		return cfg.EnableLiquidityShrink
+	case AnalysisIceberg:
+		return cfg.EnableIceberg
+	case AnalysisOFI:
+		return cfg.EnableOFI
+	case AnalysisTradePressure:
+		return cfg.EnableTradePressure
+	case AnalysisImbalanceFlip:
+		return cfg.EnableImbalanceFlip
+	}
+	return true
+}
+
+// instrumentJobs computes the snapshot analyses of an instrument and returns the jobs that
+// store them, one per enabled analysis plus one for the book, ticker, funding rate and open interest
+func instrumentJobs(instID string, obManager *Manager, redisClient redisclient.RedisStore, cfg config.AppConfig, breaker *CircuitBreaker) []func() {
+	// Book-based analyses all run against one snapshot; the jobs below only store results
+	result, err := obManager.ComputeAll(instID, cfg.Analysis)
+	if errors.Is(err, ErrStaleBook) {
+		// Logged once when the book goes stale, not on every skipped cycle
+		if obManager.staleBooks.mark(instID, true) {
+			log.Print(config.Colorf(config.Yellow, "Skipping analysis until the book updates again, %v", err))
+		}
+		return nil
+	}
 	if err != nil {
 		log.Printf("Order book not ready yet %s: %v", instID, err)
-		return
+		return nil
+	}
+	if obManager.staleBooks.mark(instID, false) {
+		log.Printf("Order book of %s updates again, analysis resumed", instID)
+	}
+
+	analyses := map[string]func() error{
+		AnalysisSupportResistance: func() error { return processSupportResistance(instID, result, redisClient) },
+		AnalysisSpreadZScore:      func() error { return processSpreadZScore(instID, result, redisClient, cfg) },
+		AnalysisLargeOrder:        func() error { return processLargeOrderDistribution(instID, result, redisClient) },
+		AnalysisDepthAnomaly:      func() error { return processDepthAnomaly(instID, result, redisClient) },
+		AnalysisLiquidityShrink:   func() error { return processLiquidityShrinkage(instID, result, redisClient) },
+		AnalysisIceberg:           func() error { return processIceberg(instID, obManager, redisClient) },
+		AnalysisOFI:               func() error { return processOFI(instID, obManager, redisClient) },
+		AnalysisTradePressure:     func() error { return processTradePressure(instID, obManager, redisClient, cfg) },
+		AnalysisImbalanceFlip:     func() error { return processImbalanceFlip(instID, result, redisClient) },
 	}
 
-	go processSnapshot(instID, obManager, redisClient)
+	jobs := []func(){
+		func() {
+			processSnapshot(instID, result, redisClient)
+			processAggregatedBook(instID, result, redisClient)
+			processTicker(instID, obManager, redisClient, cfg)
+			processFundingRate(instID, obManager, redisClient)
+			processOpenInterest(instID, obManager, redisClient)
+		},
+	}
+
+	for name, analysis := range analyses {
+		if !AnalysisEnabled(cfg.Analysis, name) || !breaker.Allow(instID, name) {
+			continue
+		}
+
+		jobs = append(jobs, func() {
+			err := analysis()
+			if err != nil {
+				breaker.RecordFailure(instID, name, err)
+			} else {
+				breaker.RecordSuccess(instID, name)
+			}
+
+			// Lets consumers tell a stale value left by a failed run from a fresh one
+			if statusErr := redisClient.StoreAnalysisStatus(instID, name, err); statusErr != nil {
+				log.Printf("Failed to save %s status for %s: %v", name, instID, statusErr)
+			}
+		})
+	}
+
+	return jobs
 }
 
-func processSnapshot(instID string, obManager *Manager, redisClient *redisclient.Client) {
-	bids, asks, err := obManager.GetTop400(instID)
+// processSnapshot stores the book snapshot the cycle's analyses ran on
+func processSnapshot(instID string, result *AnalysisResult, redisClient redisclient.RedisStore) {
+	book := result.Book
+
+	if err := redisClient.StoreOrderBookSnapshot(instID, book.Asks, book.Bids, book.Checksum); err != nil {
+		log.Printf("Failed to save order book snapshot for %s: %v", instID, err)
+	}
+
+	history := map[string]interface{}{
+		"instrument_id": instID,
+		"asks":          book.Asks,
+		"bids":          book.Bids,
+		"checksum":      book.Checksum,
+	}
+	if err := redisClient.AppendOrderBookHistory(instID, history, book.Timestamp); err != nil {
+		log.Printf("Failed to append order book history for %s: %v", instID, err)
+	}
+}
+
+// processAggregatedBook stores the compact aggregated view of the order book
+func processAggregatedBook(instID string, result *AnalysisResult, redisClient redisclient.RedisStore) {
+	fields, err := result.AggregatedBook.ToRedisMap()
 	if err != nil {
-		log.Printf("Failed to get order book snapshot for %s: %v", instID, err)
+		log.Printf("Failed to encode aggregated order book for %s: %v", instID, err)
 		return
 	}
 
-	data := map[string]interface{}{
-		"timestamp": time.Now().Unix(),
-		"bids":      bids,
-		"asks":      asks,
+	if err := redisClient.StoreAggregatedBook(instID, fields); err != nil {
+		log.Printf("Failed to save aggregated order book for %s: %v", instID, err)
 	}
+}
 
-	if err := redisClient.StoreOrderBookSnapshot(instID, data["asks"], data["bids"], 0); err != nil {
-		log.Printf("Failed to save order book snapshot for %s: %v", instID, err)
+// processTicker stores the latest ticker, warns when the tickers channel looks stalled and
+// otherwise resyncs books whose top of book diverges from it
+func processTicker(instID string, obManager *Manager, redisClient redisclient.RedisStore, cfg config.AppConfig) {
+	ticker, age, ok := obManager.GetTickerWithAge(instID)
+	if !ok {
+		return
+	}
+
+	maxAge := time.Duration(cfg.Analysis.TickerMaxAgeSec) * time.Second
+	if maxAge > 0 && age > maxAge {
+		log.Print(config.Colorf(config.Yellow, "Ticker for %s is stale: last update %s ago", instID, age.Truncate(time.Second)))
+	} else if threshold := cfg.Analysis.TickerDivergenceThreshold; threshold > 0 {
+		// Only a fresh ticker is a reliable cross-check of the book's top of book
+		obManager.ResyncIfDivergent(instID, maxAge, threshold)
+	}
+
+	if err := redisClient.StoreTickerSnapshot(instID, ticker); err != nil {
+		log.Printf("Failed to save ticker snapshot for %s: %v", instID, err)
+	}
+}
+
+// processFundingRate stores the latest funding rate of a perpetual swap
+func processFundingRate(instID string, obManager *Manager, redisClient redisclient.RedisStore) {
+	rate, ok := obManager.GetFundingRate(instID)
+	if !ok {
+		return
+	}
+
+	if err := redisClient.StoreFundingRate(instID, rate.ToRedisMap()); err != nil {
+		log.Printf("Failed to save funding rate for %s: %v", instID, err)
+	}
+}
+
+// processOpenInterest stores the latest open interest of a derivative and its delta over the window
+func processOpenInterest(instID string, obManager *Manager, redisClient redisclient.RedisStore) {
+	oi, ok := obManager.GetOpenInterest(instID)
+	if !ok {
+		return
+	}
+
+	if err := redisClient.StoreOpenInterest(instID, oi.ToRedisMap()); err != nil {
+		log.Printf("Failed to save open interest for %s: %v", instID, err)
 	}
 }
 
-// StartOrderBookProcessor starts order book processing loop
-func StartOrderBookProcessor(ctx context.Context, wsClient *ws.PublicClient, obManager *Manager, redisClient *redisclient.Client, cfg config.AppConfig) {
-	ticker := time.NewTicker(time.Duration(cfg.Redis.PollIntervalSec) * time.Second)
+// processSupportResistance stores support/resistance levels
+func processSupportResistance(instID string, result *AnalysisResult, redisClient redisclient.RedisStore) error {
+	if err := result.Errors[AnalysisSupportResistance]; err != nil {
+		return err
+	}
+	sr := result.SupportResistance
+
+	return redisClient.StoreSupportResistance(instID, sr.Supports, sr.Resistances, sr.Spread)
+}
+
+// processSpreadZScore stores the spread Z-score, alerting on unusual spreads
+func processSpreadZScore(instID string, result *AnalysisResult, redisClient redisclient.RedisStore, cfg config.AppConfig) error {
+	if err := result.Errors[AnalysisSpreadZScore]; err != nil {
+		return err
+	}
+	zScore, currentSpread := result.SpreadZScore.ZScore, result.SpreadZScore.Spread
+	readiness := result.SpreadZScore.Readiness
+
+	if threshold := cfg.Analysis.SpreadZScoreAlertThreshold; threshold > 0 && math.Abs(zScore) > threshold {
+		log.Print(config.Colorf(config.Yellow, "Spread alert for %s: z-score=%.2f, spread=%.4f", instID, zScore, currentSpread))
+	}
+
+	return redisClient.StoreSpreadZScore(instID, zScore, currentSpread, readiness)
+}
+
+// processLargeOrderDistribution stores large order distribution and sentiment
+func processLargeOrderDistribution(instID string, result *AnalysisResult, redisClient redisclient.RedisStore) error {
+	if err := result.Errors[AnalysisLargeOrder]; err != nil {
+		return err
+	}
+	lo := result.LargeOrder
+
+	if err := redisClient.StoreSentiment(instID, lo.LargeBuyNotional, lo.LargeSellNotional, lo.Sentiment, lo.Readiness); err != nil {
+		return err
+	}
+	if err := redisClient.StoreLargeOrders(instID, lo.Levels, len(lo.Levels)); err != nil {
+		return err
+	}
+	return redisClient.AppendSentimentHistory(instID, lo.Sentiment, result.Timestamp)
+}
+
+// processDepthAnomaly stores depth anomalies
+func processDepthAnomaly(instID string, result *AnalysisResult, redisClient redisclient.RedisStore) error {
+	if err := result.Errors[AnalysisDepthAnomaly]; err != nil {
+		return err
+	}
+	anomaly := result.DepthAnomaly
+
+	if anomaly.Anomaly {
+		log.Print(config.Colorf(config.Blue, "Depth anomaly for %s: direction=%s, z-score=%.2f", instID, anomaly.Direction, anomaly.ZScore))
+	}
+
+	return redisClient.StoreDepthAnomaly(instID, anomaly.ToRedisMap())
+}
+
+// processLiquidityShrinkage stores liquidity shrinkage warnings
+func processLiquidityShrinkage(instID string, result *AnalysisResult, redisClient redisclient.RedisStore) error {
+	if err := result.Errors[AnalysisLiquidityShrink]; err != nil {
+		return err
+	}
+	shrink := result.LiquidityShrink
+
+	if shrink.Warning {
+		log.Print(config.Colorf(config.Red, "Liquidity shrinkage warning for %s: level=%s, slope=%.2f%%/min", instID, shrink.WarningLevel, shrink.NormalizedSlope))
+	}
+
+	return redisClient.StoreLiquidityShrink(instID, shrink.ToRedisMap())
+}
+
+// processIceberg detects and stores iceberg order candidates
+func processIceberg(instID string, obManager *Manager, redisClient redisclient.RedisStore) error {
+	candidates, err := obManager.DetectIceberg(instID)
+	if err != nil {
+		return err
+	}
+
+	return redisClient.StoreIceberg(instID, candidates, len(candidates))
+}
+
+// processOFI stores the windowed order flow imbalance
+func processOFI(instID string, obManager *Manager, redisClient redisclient.RedisStore) error {
+	ofi, err := obManager.ComputeOFI(instID)
+	if err != nil {
+		return err
+	}
+
+	return redisClient.StoreOFI(instID, ofi.ToRedisMap())
+}
+
+// processTradePressure stores the buy/sell aggressor ratio of recent trades
+func processTradePressure(instID string, obManager *Manager, redisClient redisclient.RedisStore, cfg config.AppConfig) error {
+	pressure, err := obManager.GetTradePressure(instID, cfg.Analysis.TradePressureWindowSeconds)
+	if errors.Is(err, ErrInsufficientData) {
+		// No trades in the window is normal for quiet instruments
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return redisClient.StoreTradePressure(instID, pressure.ToRedisMap())
+}
+
+// processImbalanceFlip stores an event when the top-of-book imbalance flips side
+func processImbalanceFlip(instID string, result *AnalysisResult, redisClient redisclient.RedisStore) error {
+	if err := result.Errors[AnalysisImbalanceFlip]; err != nil {
+		return err
+	}
+	event := result.ImbalanceFlip
+	if event == nil {
+		return nil
+	}
+
+	log.Printf("Imbalance flip %s for %s: %.3f -> %.3f", event.Direction, instID, event.PrevImbalance, event.Imbalance)
+	return redisClient.StoreImbalanceFlip(instID, event)
+}
+
+// runCycle processes every instrument once on pool: first the snapshot computations, then
+// all storage jobs. Two stages keep jobs from submitting jobs, which could deadlock the pool.
+func runCycle(instIDs []string, obManager *Manager, redisClient redisclient.RedisStore, cfg config.AppConfig, breaker *CircuitBreaker, pool *AnalysisPool) {
+	var (
+		computed sync.WaitGroup
+		jobsMu   sync.Mutex
+		jobs     []func()
+	)
+	for _, instID := range instIDs {
+		pool.Submit(&computed, func() {
+			instJobs := instrumentJobs(instID, obManager, redisClient, cfg, breaker)
+			jobsMu.Lock()
+			jobs = append(jobs, instJobs...)
+			jobsMu.Unlock()
+		})
+	}
+	computed.Wait()
+
+	var stored sync.WaitGroup
+	for _, job := range jobs {
+		pool.Submit(&stored, job)
+	}
+	stored.Wait()
+}
+
+// StartOrderBookProcessor starts order book processing loop over the instruments that have a book.
+// Each cycle uses the analysis config currently held by settings (cfg.Analysis when nil), so
+// parameters tuned at runtime apply from the next cycle; the worker pool and circuit breaker
+// keep the values of cfg.
+func StartOrderBookProcessor(ctx context.Context, obManager *Manager, redisClient redisclient.RedisStore, cfg config.AppConfig, settings *config.AnalysisSettings) {
+	interval := time.Duration(cfg.Redis.PollIntervalSec) * time.Second
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	atomic.StoreInt64(&processorStats.intervalMs, interval.Milliseconds())
+
+	// Fixed workers shared by all instruments and analyses, reused across ticks
+	pool := NewAnalysisPool(cfg.Analysis.WorkerPoolSize)
+	defer pool.Close()
 
-	const maxConcurrent = 10
-	semaphore := make(chan struct{}, maxConcurrent)
+	breaker := NewCircuitBreaker(cfg.Analysis.CircuitBreakerThreshold,
+		time.Duration(cfg.Analysis.CircuitBreakerCooldownSec)*time.Second)
 
 	for {
 		select {
 		case <-ticker.C:
-			var wg sync.WaitGroup
-
-			for _, instID := range wsClient.GetSubscribed() {
-				wg.Add(1)
-				go func(instrumentID string) {
-					defer wg.Done()
-					semaphore <- struct{}{}
-					defer func() { <-semaphore }()
-
-					ProcessInstrument(instrumentID, obManager, redisClient, cfg)
-				}(instID)
+			start := time.Now()
+			cycleCfg := cfg
+			if settings != nil {
+				cycleCfg.Analysis = settings.Get()
 			}
+			runCycle(obManager.Instruments(), obManager, redisClient, cycleCfg, breaker, pool)
 
-			wg.Wait()
+			// time.Ticker drops ticks silently while we are busy, so count them here
+			duration := time.Since(start)
+			if missed := recordCycle(duration, interval, time.Now()); missed > 0 {
+				log.Print(config.Colorf(config.Yellow, "Order book processing is falling behind: cycle took %s (interval %s), %d tick(s) missed; raise the interval or ANALYSIS_WORKER_POOL_SIZE",
+					duration.Truncate(time.Millisecond), interval, missed))
+			}
 		case <-ctx.Done():
 			log.Println("Order book processing stopped")
 			return
@@ -0,0 +1,92 @@
+package orderbook
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/testutil"
+)
+
+func TestAnalysisStatusWrittenOnError(t *testing.T) {
+	m := NewManagerWithClock((&testClock{t: time.UnixMilli(1717000000000)}).Now)
+	// Bids only: support/resistance works, large orders need a mid price and fail
+	loadSnapshot(t, m, "BTC-USDT", nil, [][2]string{{"100", "1"}, {"99.5", "2"}, {"99", "1"}})
+
+	cfg := config.LoadFromEnv()
+	cfg.Analysis.BookMaxAgeSec = 0
+	store := testutil.NewRecordingStore()
+	for _, job := range instrumentJobs("BTC-USDT", m, store, cfg, NewCircuitBreaker(0, 0)) {
+		job()
+	}
+
+	statuses := make(map[string]interface{})
+	for _, call := range store.Calls() {
+		if call.Method == "StoreAnalysisStatus" {
+			statuses[call.Args[0].(string)] = call.Args[1]
+		}
+	}
+
+	if err, ok := statuses[AnalysisLargeOrder]; !ok || err == nil {
+		t.Fatalf("large order status = %v (written: %v), want the analysis error", err, ok)
+	}
+	if err, ok := statuses[AnalysisSupportResistance]; !ok || err != nil {
+		t.Fatalf("support/resistance status = %v (written: %v), want a success", err, ok)
+	}
+	if n := store.Methods("BTC-USDT")["StoreSentiment"]; n != 0 {
+		t.Fatalf("failed large order analysis stored a sentiment %d times", n)
+	}
+}
+
+func TestNormalInstrumentStoreCalls(t *testing.T) {
+	captureLog(t)
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	wallBook(t, m, "BTC-USDT")
+	pushTicker(t, m, "BTC-USDT", "999", "1001")
+	pushTrades(t, m, "BTC-USDT", "buy 1001 2", "sell 999 1")
+
+	cfg := config.LoadFromEnv()
+	cfg.Analysis.BookMaxAgeSec = 0
+	cfg.Analysis.EnableTradePressure = true
+	store := testutil.NewRecordingStore()
+	breaker := NewCircuitBreaker(0, 0)
+
+	// A few ticks with a moving best bid build the spread and OFI history
+	for tick := 0; tick < 8; tick++ {
+		store.Reset()
+		clock.t = clock.t.Add(time.Second)
+		pushBooks(t, m, "update", "BTC-USDT", nil, [][2]string{{"999", fmt.Sprint(2 + tick)}})
+		for _, job := range instrumentJobs("BTC-USDT", m, store, cfg, breaker) {
+			job()
+		}
+	}
+
+	want := map[string]int{
+		"StoreOrderBookSnapshot": 1, "AppendOrderBookHistory": 1, "StoreAggregatedBook": 1,
+		"StoreTickerSnapshot": 1, "StoreSupportResistance": 1, "StoreSpreadZScore": 1,
+		"StoreSentiment": 1, "StoreLargeOrders": 1, "AppendSentimentHistory": 1,
+		"StoreDepthAnomaly": 1, "StoreLiquidityShrink": 1, "StoreIceberg": 1,
+		"StoreOFI": 1, "StoreTradePressure": 1, "StoreAnalysisStatus": 9,
+	}
+	if got := store.Methods("BTC-USDT"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("store calls of the last tick:\n got %v\nwant %v", got, want)
+	}
+
+	book, _ := m.Snapshot("BTC-USDT")
+	for _, call := range store.Calls() {
+		switch call.Method {
+		case "StoreAnalysisStatus":
+			if call.Args[1] != nil {
+				t.Errorf("%s failed: %v", call.Args[0], call.Args[1])
+			}
+		case "StoreOrderBookSnapshot":
+			if call.Args[2] != book.Checksum || len(call.Args[1].([]PriceLevel)) != len(book.Bids) {
+				t.Errorf("snapshot stored with checksum %v and %d bids, want the current book",
+					call.Args[2], len(call.Args[1].([]PriceLevel)))
+			}
+		}
+	}
+}
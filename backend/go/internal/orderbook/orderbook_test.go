@@ -0,0 +1,276 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// referenceSide is a plain price -> size model of one side of the book
+type referenceSide map[float64]string
+
+// apply mirrors an incremental update: size "0" removes the level
+func (r referenceSide) apply(levels [][2]string) {
+	for _, l := range levels {
+		price, _ := strconv.ParseFloat(l[0], 64)
+		if l[1] == "0" {
+			delete(r, price)
+		} else {
+			r[price] = l[1]
+		}
+	}
+}
+
+// best returns the n best prices, ascending for asks and descending for bids
+func (r referenceSide) best(n int, isAsk bool) []float64 {
+	prices := make([]float64, 0, len(r))
+	for p := range r {
+		prices = append(prices, p)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if isAsk {
+			return prices[i] < prices[j]
+		}
+		return prices[i] > prices[j]
+	})
+	return prices[:min(n, len(prices))]
+}
+
+// trim drops everything beyond the best n, as the book does
+func (r referenceSide) trim(n int, isAsk bool) {
+	keep := map[float64]bool{}
+	for _, p := range r.best(n, isAsk) {
+		keep[p] = true
+	}
+	for p := range r {
+		if !keep[p] {
+			delete(r, p)
+		}
+	}
+}
+
+func assertBestLevels(t *testing.T, step int, side string, got []PriceLevel, want []float64, ref referenceSide) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("step %d: %s has %d levels, want %d", step, side, len(got), len(want))
+	}
+	for i, level := range got {
+		price, _ := strconv.ParseFloat(level.Price, 64)
+		if price != want[i] || level.Size != ref[price] {
+			t.Fatalf("step %d: %s[%d] = %s x %s, want %v x %s", step, side, i, level.Price, level.Size, want[i], ref[want[i]])
+		}
+	}
+}
+
+func TestTrimKeepsBest400AfterBurst(t *testing.T) {
+	rng := rand.New(rand.NewSource(2078))
+	m := NewManager()
+
+	// Prices straddle a power of ten so that a lexical sort would misorder them
+	price := func(mid float64) string {
+		return strconv.FormatFloat(mid+float64(rng.Intn(2000)-1000)/10, 'f', -1, 64)
+	}
+	asks, bids := referenceSide{}, referenceSide{}
+	var snapAsks, snapBids [][2]string
+	for i := 0; i < 400; i++ {
+		snapAsks = append(snapAsks, [2]string{strconv.FormatFloat(1000.5+float64(i)/4, 'f', -1, 64), "1"})
+		snapBids = append(snapBids, [2]string{strconv.FormatFloat(999.75-float64(i)/4, 'f', -1, 64), "1"})
+	}
+	loadSnapshot(t, m, "ETH-USDT", snapAsks, snapBids)
+	asks.apply(snapAsks)
+	bids.apply(snapBids)
+
+	for step := 0; step < 200; step++ {
+		var updAsks, updBids [][2]string
+		for i := 0; i < 20; i++ {
+			size := strconv.Itoa(1 + rng.Intn(9))
+			// Roughly a third of the changes delete an existing level
+			if rng.Intn(3) == 0 {
+				for p := range asks {
+					updAsks = append(updAsks, [2]string{strconv.FormatFloat(p, 'f', -1, 64), "0"})
+					break
+				}
+				for p := range bids {
+					updBids = append(updBids, [2]string{strconv.FormatFloat(p, 'f', -1, 64), "0"})
+					break
+				}
+				continue
+			}
+			updAsks = append(updAsks, [2]string{price(1100), size})
+			updBids = append(updBids, [2]string{price(900), size})
+		}
+		pushBooks(t, m, "update", "ETH-USDT", updAsks, updBids)
+		asks.apply(updAsks)
+		bids.apply(updBids)
+		asks.trim(400, true)
+		bids.trim(400, false)
+
+		gotAsks, gotBids, err := m.GetTop400("ETH-USDT")
+		if err != nil {
+			t.Fatalf("step %d: %v", step, err)
+		}
+		assertBestLevels(t, step, "asks", gotAsks, asks.best(400, true), asks)
+		assertBestLevels(t, step, "bids", gotBids, bids.best(400, false), bids)
+	}
+}
+
+func TestSnapshotIsStableWhileBookUpdates(t *testing.T) {
+	m := NewManager()
+	loadSnapshot(t, m, "BTC-USDT",
+		[][2]string{{"100.5", "1"}, {"101", "1"}, {"101.5", "1"}},
+		[][2]string{{"100", "1"}, {"99.5", "1"}, {"99", "1"}})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// Resize the top levels and insert/remove a level each round
+			size := strconv.Itoa(2 + i%7)
+			extra := "0"
+			if i%2 == 0 {
+				extra = "1"
+			}
+			msg := fmt.Sprintf(`{"action":"update","arg":{"channel":"books","instId":"BTC-USDT"},"data":[{
+"asks":[["100.5","%[1]s","0","1"],["100.75","%[2]s","0","1"]],
+"bids":[["100","%[1]s","0","1"],["99.75","%[2]s","0","1"]],"ts":"1717000000000"}]}`, size, extra)
+			if err := m.ProcessMessage([]byte(msg)); err != nil {
+				t.Errorf("update %d: %v", i, err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		book, err := m.Snapshot("BTC-USDT")
+		if err != nil {
+			t.Fatal(err)
+		}
+		frozen := *book
+		frozen.Asks = append([]PriceLevel(nil), book.Asks...)
+		frozen.Bids = append([]PriceLevel(nil), book.Bids...)
+
+		asks, bids, err := m.GetTop400("BTC-USDT")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Reading every level races with the writer unless the slices are copies
+		for _, level := range append(asks, bids...) {
+			_ = level.Price + level.Size
+		}
+
+		if !reflect.DeepEqual(*book, frozen) {
+			t.Fatalf("snapshot %d changed while the book was updated", i)
+		}
+		if book.Asks[0].Size != book.Bids[0].Size {
+			t.Fatalf("snapshot %d is torn: best ask %s x %s, best bid %s x %s", i,
+				book.Asks[0].Price, book.Asks[0].Size, book.Bids[0].Price, book.Bids[0].Size)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestSnapshotDoesNotAliasTheBook(t *testing.T) {
+	m := NewManager()
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"100.5", "1"}}, [][2]string{{"100", "1"}})
+
+	book, err := m.Snapshot("BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book.Asks[0].Size = "999"
+	book.Bids = append(book.Bids[:0], PriceLevel{Price: "1", Size: "1"})
+
+	live, _ := m.Snapshot("BTC-USDT")
+	if live.Asks[0].Size != "1" || live.Bids[0].Price != "100" {
+		t.Fatalf("writing to a snapshot changed the book: asks %v bids %v", live.Asks, live.Bids)
+	}
+}
+
+// tbtMessage is a books-l2-tbt push with seqId/prevSeqId and a checksum over the
+// levels the book will hold after it is applied
+func tbtMessage(action string, prevSeqID, seqID int64, asks, bids [][]string, bookBids, bookAsks []PriceLevel) []byte {
+	msg, _ := json.Marshal(map[string]interface{}{
+		"action": action,
+		"arg":    map[string]string{"channel": config.BooksL2TBTChannel, "instId": "BTC-USDT-SWAP"},
+		"data": []map[string]interface{}{{
+			"asks": asks, "bids": bids, "ts": "1717000000000",
+			"seqId": seqID, "prevSeqId": prevSeqID,
+			"checksum": ComputeChecksum(bookBids, bookAsks),
+		}},
+	})
+	return msg
+}
+
+func TestBooksL2TBTSnapshotAndUpdates(t *testing.T) {
+	logs := captureLog(t)
+	m := NewManager()
+	var gaps []string
+	m.SetSequenceGapHandler(func(instID string) { gaps = append(gaps, instID) })
+
+	snapBids := []PriceLevel{{Price: "67000", Size: "3"}, {Price: "66999.9", Size: "1"}}
+	snapAsks := []PriceLevel{{Price: "67000.1", Size: "2"}}
+	// Tick-by-tick: every update chains on the previous seqId
+	upd1Bids := []PriceLevel{{Price: "67000", Size: "3"}}
+	upd1Asks := []PriceLevel{{Price: "67000.1", Size: "2"}, {Price: "67000.2", Size: "5"}}
+	upd2Bids := []PriceLevel{{Price: "67000", Size: "1.5"}}
+
+	steps := []struct {
+		msg        []byte
+		bids, asks []PriceLevel // the book after the step
+	}{
+		{tbtMessage("snapshot", -1, 100,
+			[][]string{{"67000.1", "2", "0", "1"}},
+			[][]string{{"67000", "3", "0", "2"}, {"66999.9", "1", "0", "1"}}, snapBids, snapAsks),
+			snapBids, snapAsks},
+		{tbtMessage("update", 100, 101,
+			[][]string{{"67000.2", "5", "0", "1"}},
+			[][]string{{"66999.9", "0", "0", "0"}}, upd1Bids, upd1Asks),
+			upd1Bids, upd1Asks},
+		{tbtMessage("update", 101, 102, nil,
+			[][]string{{"67000", "1.5", "0", "1"}}, upd2Bids, upd1Asks),
+			upd2Bids, upd1Asks},
+	}
+	for i, step := range steps {
+		if err := m.ProcessMessage(step.msg); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		book, err := m.Snapshot("BTC-USDT-SWAP")
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if !book.ChecksumOK || book.Channel != config.BooksL2TBTChannel {
+			t.Fatalf("step %d: ChecksumOK=%v channel=%s\n%s", i, book.ChecksumOK, book.Channel, logs)
+		}
+		if BuildChecksumString(book.Bids, book.Asks) != BuildChecksumString(step.bids, step.asks) {
+			t.Fatalf("step %d: book bids %v asks %v", i, book.Bids, book.Asks)
+		}
+	}
+
+	// seqId 103 is lost: the next update no longer chains and the book is reset
+	lost := tbtMessage("update", 103, 104, [][]string{{"67000.3", "1", "0", "1"}}, nil, nil, nil)
+	if err := m.ProcessMessage(lost); err == nil || !strings.Contains(err.Error(), "sequence gap") {
+		t.Fatalf("update after a lost message: err = %v, want a sequence gap", err)
+	}
+	if len(gaps) != 1 || gaps[0] != "BTC-USDT-SWAP" {
+		t.Fatalf("gap handler calls = %v", gaps)
+	}
+	if _, err := m.Snapshot("BTC-USDT-SWAP"); err == nil {
+		t.Fatal("book still served after a sequence gap")
+	}
+}
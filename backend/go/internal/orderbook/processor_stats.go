@@ -0,0 +1,60 @@
+package orderbook
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ProcessorStats describes how well the processing loop keeps up with its tick interval
+type ProcessorStats struct {
+	IntervalMs     int64 `json:"interval_ms"`       // configured tick interval
+	Cycles         int64 `json:"cycles"`            // completed processing cycles
+	MissedTicks    int64 `json:"missed_ticks"`      // ticks dropped because a cycle overran the interval
+	LastCycleMs    int64 `json:"last_cycle_ms"`     // duration of the most recent cycle
+	MaxCycleMs     int64 `json:"max_cycle_ms"`      // longest cycle since start
+	LastCycleEndAt int64 `json:"last_cycle_end_at"` // unix seconds
+}
+
+// processorStats is updated by StartOrderBookProcessor and read via GetProcessorStats
+var processorStats struct {
+	intervalMs     int64
+	cycles         int64
+	missedTicks    int64
+	lastCycleMs    int64
+	maxCycleMs     int64
+	lastCycleEndAt int64
+}
+
+// GetProcessorStats returns the processing loop lag metrics
+func GetProcessorStats() ProcessorStats {
+	return ProcessorStats{
+		IntervalMs:     atomic.LoadInt64(&processorStats.intervalMs),
+		Cycles:         atomic.LoadInt64(&processorStats.cycles),
+		MissedTicks:    atomic.LoadInt64(&processorStats.missedTicks),
+		LastCycleMs:    atomic.LoadInt64(&processorStats.lastCycleMs),
+		MaxCycleMs:     atomic.LoadInt64(&processorStats.maxCycleMs),
+		LastCycleEndAt: atomic.LoadInt64(&processorStats.lastCycleEndAt),
+	}
+}
+
+// recordCycle records one processing cycle and returns the number of ticks it caused
+// time.Ticker to drop (a cycle of 2.5 intervals drops 2 ticks)
+func recordCycle(duration, interval time.Duration, end time.Time) int64 {
+	ms := duration.Milliseconds()
+	atomic.AddInt64(&processorStats.cycles, 1)
+	atomic.StoreInt64(&processorStats.lastCycleMs, ms)
+	atomic.StoreInt64(&processorStats.lastCycleEndAt, end.Unix())
+	for {
+		max := atomic.LoadInt64(&processorStats.maxCycleMs)
+		if ms <= max || atomic.CompareAndSwapInt64(&processorStats.maxCycleMs, max, ms) {
+			break
+		}
+	}
+
+	if interval <= 0 || duration <= interval {
+		return 0
+	}
+	missed := int64(duration / interval)
+	atomic.AddInt64(&processorStats.missedTicks, missed)
+	return missed
+}
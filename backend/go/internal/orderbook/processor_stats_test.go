@@ -0,0 +1,80 @@
+package orderbook
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/testutil"
+)
+
+// slowStore stalls the first snapshot write long enough for its cycle to overrun a tick
+type slowStore struct {
+	*testutil.RecordingStore
+	stall time.Duration
+	once  sync.Once
+}
+
+func (s *slowStore) StoreOrderBookSnapshot(instID string, asks, bids interface{}, checksum int32) error {
+	s.once.Do(func() { time.Sleep(s.stall) })
+	return s.RecordingStore.StoreOrderBookSnapshot(instID, asks, bids, checksum)
+}
+
+func TestSlowCycleCountsMissedTicks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the processing loop on its 1s tick")
+	}
+	logs := captureLog(t)
+	m := NewManager()
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"101", "1"}}, [][2]string{{"100", "1"}})
+
+	cfg := config.LoadFromEnv()
+	cfg.Redis.PollIntervalSec = 1
+	store := &slowStore{RecordingStore: testutil.NewRecordingStore(), stall: 1300 * time.Millisecond}
+	before := GetProcessorStats()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		StartOrderBookProcessor(ctx, m, store, cfg, nil)
+	}()
+	deadline := time.After(5 * time.Second)
+	for GetProcessorStats().Cycles == before.Cycles {
+		select {
+		case <-deadline:
+			t.Fatal("no processing cycle completed")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	stats := GetProcessorStats()
+	if missed := stats.MissedTicks - before.MissedTicks; missed != 1 {
+		t.Fatalf("a 1.3s cycle on a 1s tick counted %d missed ticks, want 1", missed)
+	}
+	if stats.IntervalMs != 1000 || stats.MaxCycleMs < 1300 {
+		t.Fatalf("stats %+v, want a 1000ms interval and a longest cycle of at least 1300ms", stats)
+	}
+	if !strings.Contains(logs.String(), "falling behind") {
+		t.Fatalf("no lag warning logged:\n%s", logs)
+	}
+}
+
+func TestRecordCycleWithinInterval(t *testing.T) {
+	before := GetProcessorStats()
+	if missed := recordCycle(900*time.Millisecond, time.Second, time.Unix(1717000000, 0)); missed != 0 {
+		t.Fatalf("a cycle within the interval missed %d ticks", missed)
+	}
+	if missed := recordCycle(2500*time.Millisecond, time.Second, time.Unix(1717000001, 0)); missed != 2 {
+		t.Fatalf("a 2.5 interval cycle missed %d ticks, want 2", missed)
+	}
+	stats := GetProcessorStats()
+	if stats.Cycles-before.Cycles != 2 || stats.MissedTicks-before.MissedTicks != 2 || stats.LastCycleEndAt != 1717000001 {
+		t.Fatalf("stats %+v after two cycles, from %+v", stats, before)
+	}
+}
@@ -0,0 +1,62 @@
+package orderbook
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestReadinessRisesAsWindowsFill(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+	wallBook(t, m, "BTC-USDT")
+
+	cfg := config.LoadFromEnv().Analysis
+	cfg.BookMaxAgeSec = 0
+	cfg.EnableSpreadZScore, cfg.EnableLargeOrder, cfg.EnableDepthAnomaly, cfg.EnableLiquidityShrink = true, true, true, true
+	cfg.SpreadZScoreWindowMinutes = 1
+	cfg.DepthAnomalyWindowSize = 40
+	cfg.LiquidityShrinkLongWindowSeconds = 90
+
+	// Window length of each analysis in seconds
+	windows := map[string]float64{
+		AnalysisSpreadZScore:    60,
+		AnalysisLargeOrder:      30, // the sentiment window
+		AnalysisDepthAnomaly:    40,
+		AnalysisLiquidityShrink: 90,
+	}
+	for elapsed := 0; elapsed <= 120; elapsed += 5 {
+		result, err := m.ComputeAll("BTC-USDT", cfg)
+		if err != nil {
+			t.Fatalf("+%ds: %v", elapsed, err)
+		}
+		readiness := map[string]float64{
+			AnalysisLargeOrder:      result.LargeOrder.Readiness,
+			AnalysisDepthAnomaly:    result.DepthAnomaly.Readiness,
+			AnalysisLiquidityShrink: result.LiquidityShrink.Readiness,
+		}
+		if result.SpreadZScore != nil {
+			readiness[AnalysisSpreadZScore] = result.SpreadZScore.Readiness
+		}
+		fields := result.ToRedisMap()
+		for name, window := range windows {
+			got, ok := readiness[name]
+			if !ok {
+				if name == AnalysisSpreadZScore && elapsed == 0 {
+					continue // needs two spread samples
+				}
+				t.Fatalf("+%ds: no %s result", elapsed, name)
+			}
+			want := math.Min(1, float64(elapsed)/window)
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("+%ds: %s readiness %v, want %v", elapsed, name, got, want)
+			}
+			if stored := fields[name+".readiness"]; stored != got {
+				t.Fatalf("+%ds: stored %s readiness %v, computed %v", elapsed, name, stored, got)
+			}
+		}
+		clock.t = clock.t.Add(5 * time.Second)
+	}
+}
@@ -0,0 +1,137 @@
+package orderbook
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// levels parses "price:size:orders" entries
+func levels(entries ...string) []PriceLevel {
+	out := make([]PriceLevel, 0, len(entries))
+	for _, e := range entries {
+		var l PriceLevel
+		parts := strings.Split(e, ":")
+		l.Price, l.Size = parts[0], parts[1]
+		fmt.Sscan(parts[2], &l.OrderCount)
+		out = append(out, l)
+	}
+	return out
+}
+
+// capturedBooks renders a books frame as OKEx sends it. checksum is the CRC of the book
+// after applying the frame, computed from wantBids and wantAsks unless forced.
+func capturedBooks(action string, seq, prevSeq, ts int64, asks, bids, wantAsks, wantBids []PriceLevel, forced *int32) string {
+	side := func(in []PriceLevel) string {
+		parts := make([]string, 0, len(in))
+		for _, l := range in {
+			parts = append(parts, fmt.Sprintf(`["%s","%s","0","%d"]`, l.Price, l.Size, l.OrderCount))
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	}
+	checksum := checksumOf(BuildChecksumString(wantBids, wantAsks))
+	if forced != nil {
+		checksum = *forced
+	}
+	return fmt.Sprintf(`{"arg":{"channel":"books","instId":"BTC-USDT"},"action":%q,"data":[{"asks":%s,"bids":%s,"ts":"%d","checksum":%d,"seqId":%d,"prevSeqId":%d}]}`,
+		action, side(asks), side(bids), ts, checksum, seq, prevSeq)
+}
+
+func TestStepToDumpsTheBookAtThatFrame(t *testing.T) {
+	snapshotAsks, snapshotBids := levels("101:1:1", "102:2:3"), levels("100:1:2", "99:3:1")
+	step2Asks, step2Bids := levels("102:2:3"), levels("100.5:2:1", "100:1:2", "99:3:1")
+	step3Bids := levels("100.5:2:1", "100:1:2", "99:5:4")
+	stale := int32(123456789)
+
+	capture := strings.Join([]string{
+		`{"event":"subscribe","arg":{"channel":"books","instId":"BTC-USDT"},"connId":"a1"}`,
+		capturedBooks("snapshot", 10, -1, 1717000000000, snapshotAsks, snapshotBids, snapshotAsks, snapshotBids, nil),
+		"",
+		capturedBooks("update", 11, 10, 1717000000100, levels("101:0:0"), levels("100.5:2:1"), step2Asks, step2Bids, nil),
+		capturedBooks("update", 12, 11, 1717000000200, nil, levels("99:5:4"), step2Asks, step3Bids, &stale),
+	}, "\n")
+
+	replayer := NewReplayer(NewManager(), config.LoadFromEnv().Analysis, 0)
+	replayer.Load(strings.NewReader(capture))
+
+	if err := replayer.StepTo(3); err != nil {
+		t.Fatalf("step to 3: %v", err)
+	}
+	dump, err := replayer.DumpBook("BTC-USDT")
+	if err != nil {
+		t.Fatalf("dump at 3: %v", err)
+	}
+	want := BookDump{
+		Frame:            3,
+		InstrumentID:     "BTC-USDT",
+		Timestamp:        1717000000100,
+		SeqID:            11,
+		Asks:             step2Asks,
+		Bids:             step2Bids,
+		ChecksumString:   "100.5:2:102:2:100:1:99:3",
+		ComputedChecksum: checksumOf("100.5:2:102:2:100:1:99:3"),
+		ExpectedChecksum: checksumOf("100.5:2:102:2:100:1:99:3"),
+		HasChecksum:      true,
+		ChecksumOK:       true,
+	}
+	if !reflect.DeepEqual(*dump, want) {
+		t.Fatalf("book at frame 3:\n got %+v\nwant %+v", *dump, want)
+	}
+
+	// The last frame carries a wrong checksum: the dump shows the mismatch and the string
+	// matches the one built from the expected book byte for byte
+	logs := captureLog(t)
+	if err := replayer.StepTo(4); err != nil {
+		t.Fatalf("step to 4: %v", err)
+	}
+	if !strings.Contains(logs.String(), "Checksum mismatch for BTC-USDT") {
+		t.Fatalf("mismatch not logged: %q", logs.String())
+	}
+	dump, err = replayer.DumpBook("BTC-USDT")
+	if err != nil {
+		t.Fatalf("dump at 4: %v", err)
+	}
+	if dump.ChecksumOK || dump.ExpectedChecksum != stale {
+		t.Fatalf("frame 4: ok=%v expected=%d, want a mismatch against %d", dump.ChecksumOK, dump.ExpectedChecksum, stale)
+	}
+	if expected := BuildChecksumString(step3Bids, step2Asks); dump.ChecksumString != expected {
+		t.Fatalf("checksum string %q, want %q", dump.ChecksumString, expected)
+	}
+
+	if err := replayer.StepTo(2); err == nil {
+		t.Fatal("stepping back succeeded")
+	}
+	if err := replayer.StepTo(5); err == nil {
+		t.Fatal("stepping past the end of the capture succeeded")
+	}
+}
+
+func TestStepToTimestampStopsBeforeLaterFrames(t *testing.T) {
+	asks, bids := levels("101:1:1"), levels("100:1:1")
+	moved := levels("100.2:4:2")
+	capture := capturedBooks("snapshot", 1, -1, 1717000000000, asks, bids, asks, bids, nil) + "\n" +
+		capturedBooks("update", 2, 1, 1717000000500, nil, moved, asks, levels("100.2:4:2", "100:1:1"), nil) + "\n"
+
+	replayer := NewReplayer(NewManager(), config.LoadFromEnv().Analysis, 0)
+	replayer.Load(strings.NewReader(capture))
+
+	frame, err := replayer.StepToTimestamp(1717000000499)
+	if err != nil || frame != 1 {
+		t.Fatalf("StepToTimestamp = %d, %v; want frame 1", frame, err)
+	}
+	dump, err := replayer.DumpBook("BTC-USDT")
+	if err != nil || dump.Bids[0].Price != "100" {
+		t.Fatalf("best bid before the update: %+v, %v", dump, err)
+	}
+
+	// The frame read ahead is applied by the next step
+	if frame, err := replayer.StepToTimestamp(1717000000500); err != nil || frame != 2 {
+		t.Fatalf("StepToTimestamp = %d, %v; want frame 2", frame, err)
+	}
+	if dump, _ := replayer.DumpBook("BTC-USDT"); dump.Bids[0].Price != "100.2" || !dump.ChecksumOK {
+		t.Fatalf("book after the update: %+v", dump)
+	}
+}
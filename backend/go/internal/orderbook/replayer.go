@@ -0,0 +1,365 @@
+package orderbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// maxReplayFrameSize bounds a single captured frame (a full 400-level books snapshot is well below this)
+const maxReplayFrameSize = 4 * 1024 * 1024
+
+// ReplayMetrics holds the analysis results computed after replaying a books frame
+type ReplayMetrics struct {
+	Frame             int                  `json:"frame"`
+	InstrumentID      string               `json:"instrument_id"`
+	Timestamp         int64                `json:"timestamp"` // original frame ts (ms)
+	Supports          []float64            `json:"supports"`
+	Resistances       []float64            `json:"resistances"`
+	Spread            float64              `json:"spread"`
+	LargeBuyNotional  float64              `json:"large_buy_notional"`
+	LargeSellNotional float64              `json:"large_sell_notional"`
+	Sentiment         float64              `json:"sentiment"`
+	DepthAnomaly      *DepthAnomalyData    `json:"depth_anomaly,omitempty"`
+	LiquidityShrink   *LiquidityShrinkData `json:"liquidity_shrink,omitempty"`
+}
+
+// ReplayClock is a clock driven by the data timestamps of replayed frames. A Manager created
+// with NewManagerWithClock(clock.Now) then fills its windows and stamps its analyses in
+// capture time, so replaying the same capture always yields the same metrics.
+type ReplayClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewReplayClock creates a clock at the unix epoch, until the first frame with a timestamp
+func NewReplayClock() *ReplayClock {
+	return &ReplayClock{now: time.UnixMilli(0)}
+}
+
+// Now returns the timestamp of the latest replayed frame
+func (c *ReplayClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// advance moves the clock to ts (ms); out-of-order frames never move it back
+func (c *ReplayClock) advance(ts int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t := time.UnixMilli(ts); t.After(c.now) {
+		c.now = t
+	}
+}
+
+// Replayer feeds recorded raw OKEx frames (one JSON message per line) through a Manager
+// and runs the analysis functions after every books frame.
+// 回放录制的原始WebSocket帧，用于复现线上问题
+type Replayer struct {
+	manager  *Manager
+	analysis config.AnalysisConfig
+	speed    float64 // <= 0 replays as fast as possible, 1 honors original timestamps, 2 is twice as fast...
+	sleep    func(time.Duration)
+	clock    *ReplayClock // advanced before each frame, see SetClock
+
+	// Stepping state, see Load
+	scanner *bufio.Scanner
+	frame   int    // frames applied since Load
+	pending []byte // frame read ahead by StepToTimestamp
+}
+
+// NewReplayer creates a new replayer over the given manager
+func NewReplayer(manager *Manager, analysis config.AnalysisConfig, speed float64) *Replayer {
+	return &Replayer{
+		manager:  manager,
+		analysis: analysis,
+		speed:    speed,
+		sleep:    time.Sleep,
+	}
+}
+
+// SetClock makes the replayer advance clock to each frame's timestamp before applying it.
+// clock should be the one the manager was created with, see ReplayClock.
+func (r *Replayer) SetClock(clock *ReplayClock) {
+	r.clock = clock
+}
+
+// Replay reads frames line by line from r, processes them and calls emit with the metrics
+// computed after each books frame. Empty lines are skipped.
+func (r *Replayer) Replay(reader io.Reader, emit func(ReplayMetrics) error) error {
+	scanner := newFrameScanner(reader)
+
+	var lastTs int64
+	frame := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		frame++
+
+		channel, instID, ts := frameInfo(line)
+
+		// Pace according to the original frame timestamps
+		if r.speed > 0 && ts > 0 {
+			if lastTs > 0 && ts > lastTs {
+				r.sleep(time.Duration(float64(ts-lastTs)/r.speed) * time.Millisecond)
+			}
+			lastTs = ts
+		}
+
+		if err := r.apply(frame, line); err != nil {
+			return err
+		}
+
+		if !IsBooksChannel(channel) || instID == "" || ts == 0 {
+			continue
+		}
+
+		metrics, err := r.computeMetrics(instID)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", frame, err)
+		}
+		metrics.Frame = frame
+		metrics.Timestamp = ts
+
+		if emit != nil {
+			if err := emit(metrics); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read capture: %w", err)
+	}
+	return nil
+}
+
+// apply feeds one frame to the manager, skipping frames of channels it does not handle
+func (r *Replayer) apply(frame int, line []byte) error {
+	if r.clock != nil {
+		if _, _, ts := frameInfo(line); ts > 0 {
+			r.clock.advance(ts)
+		}
+	}
+
+	if err := r.manager.ProcessMessage(line); err != nil {
+		if errors.Is(err, ErrUnknownFrame) {
+			// Captures may hold frames of channels the Manager does not handle
+			return nil
+		}
+		return fmt.Errorf("frame %d: %w", frame, err)
+	}
+	return nil
+}
+
+// newFrameScanner returns a line scanner sized for captured frames
+func newFrameScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayFrameSize)
+	return scanner
+}
+
+// Load prepares stepping through a capture with StepTo and StepToTimestamp, one frame
+// per non-empty line. Frames are applied to the replayer's manager without pacing or
+// metrics, so the book can be inspected with DumpBook at any point.
+func (r *Replayer) Load(reader io.Reader) {
+	r.scanner = newFrameScanner(reader)
+	r.frame = 0
+	r.pending = nil
+}
+
+// Frame returns the number of frames applied since Load
+func (r *Replayer) Frame() int {
+	return r.frame
+}
+
+// StepTo applies frames until n frames (1-based, counting non-empty lines) have been
+// applied since Load. The manager cannot be rewound, so n must not be behind the
+// current frame; load the capture into a fresh replayer to go back.
+func (r *Replayer) StepTo(n int) error {
+	if r.scanner == nil {
+		return errors.New("no capture loaded")
+	}
+	if n < r.frame {
+		return fmt.Errorf("cannot step back from frame %d to %d", r.frame, n)
+	}
+
+	for r.frame < n {
+		line, err := r.next()
+		if err != nil {
+			return fmt.Errorf("capture ended at frame %d before frame %d: %w", r.frame, n, err)
+		}
+		r.frame++
+		if err := r.apply(r.frame, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StepToTimestamp applies frames up to and including the last one whose data timestamp
+// is <= ts (ms) and returns the current frame. Frames without a timestamp (e.g. events)
+// are applied along the way. Reaching the end of the capture is not an error.
+func (r *Replayer) StepToTimestamp(ts int64) (int, error) {
+	if r.scanner == nil {
+		return r.frame, errors.New("no capture loaded")
+	}
+
+	for {
+		line, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return r.frame, nil
+		}
+		if err != nil {
+			return r.frame, err
+		}
+
+		if _, _, frameTs := frameInfo(line); frameTs > ts {
+			// Keep the frame for the next step
+			r.pending = line
+			return r.frame, nil
+		}
+
+		r.frame++
+		if err := r.apply(r.frame, line); err != nil {
+			return r.frame, err
+		}
+	}
+}
+
+// next returns the next non-empty frame of the loaded capture, io.EOF at its end
+func (r *Replayer) next() ([]byte, error) {
+	if r.pending != nil {
+		line := r.pending
+		r.pending = nil
+		return line, nil
+	}
+
+	for r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		// The scanner reuses its buffer, the frame may be kept as pending
+		return append([]byte(nil), line...), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read capture: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// BookDump is the state of an order book at a replay step. ChecksumString is exactly the
+// string that is CRC'd, so it can be diffed byte by byte against one built from an
+// expected snapshot with BuildChecksumString.
+type BookDump struct {
+	Frame            int          `json:"frame"`
+	InstrumentID     string       `json:"instrument_id"`
+	Timestamp        int64        `json:"timestamp"` // ts of the last applied books message (ms)
+	SeqID            int64        `json:"seq_id"`
+	Asks             []PriceLevel `json:"asks"`
+	Bids             []PriceLevel `json:"bids"`
+	ChecksumString   string       `json:"checksum_string"`
+	ComputedChecksum int32        `json:"computed_checksum"`
+	ExpectedChecksum int32        `json:"expected_checksum"` // checksum of the last applied books message
+	HasChecksum      bool         `json:"has_checksum"`
+	ChecksumOK       bool         `json:"checksum_ok"`
+}
+
+// DumpBook returns a copy of an instrument's book at the current step with its checksum
+// string. A book dropped after a checksum mismatch is not found; step to the frame before
+// the mismatch to inspect the book it was applied to.
+func (r *Replayer) DumpBook(instID string) (*BookDump, error) {
+	book, err := r.manager.Snapshot(instID)
+	if err != nil {
+		return nil, err
+	}
+
+	checksumStr := BuildChecksumString(book.Bids, book.Asks)
+	return &BookDump{
+		Frame:            r.frame,
+		InstrumentID:     instID,
+		Timestamp:        book.Timestamp,
+		SeqID:            book.SeqID,
+		Asks:             book.Asks,
+		Bids:             book.Bids,
+		ChecksumString:   checksumStr,
+		ComputedChecksum: checksumOf(checksumStr),
+		ExpectedChecksum: book.Checksum,
+		HasChecksum:      book.HasChecksum,
+		ChecksumOK:       book.ChecksumOK,
+	}, nil
+}
+
+// computeMetrics runs all analysis functions for an instrument using the replayer's config
+func (r *Replayer) computeMetrics(instID string) (ReplayMetrics, error) {
+	cfg := r.analysis
+	metrics := ReplayMetrics{InstrumentID: instID}
+
+	supports, resistances, spread, err := r.manager.ComputeSupportResistance(instID,
+		cfg.SupportResistanceBinCount, cfg.SupportResistanceSignificanceThreshold,
+		cfg.SupportResistanceTopN, cfg.SupportResistanceMinDistancePercent, cfg.SupportResistanceMinNotional)
+	if err != nil {
+		return metrics, err
+	}
+	metrics.Supports = supports
+	metrics.Resistances = resistances
+	metrics.Spread = spread
+
+	largeBuy, largeSell, sentiment, err := r.manager.ComputeLargeOrderDistribution(instID,
+		cfg.LargeOrderPercentileAlpha, cfg.LargeOrderMinNotional, cfg.LargeOrderDecayLambda, cfg.LargeOrderSentimentDeadzoneThreshold)
+	if err != nil {
+		return metrics, err
+	}
+	metrics.LargeBuyNotional = largeBuy
+	metrics.LargeSellNotional = largeSell
+	metrics.Sentiment = sentiment
+
+	anomaly, err := r.manager.DetectDepthAnomaly(instID,
+		cfg.DepthAnomalyPriceRangePercent, cfg.DepthAnomalyWindowSize, cfg.DepthAnomalyZThreshold)
+	if err != nil {
+		return metrics, err
+	}
+	metrics.DepthAnomaly = anomaly
+
+	shrink, err := r.manager.DetectLiquidityShrinkage(instID,
+		cfg.LiquidityShrinkNearPriceDeltaPercent, cfg.LiquidityShrinkShortWindowSeconds,
+		cfg.LiquidityShrinkLongWindowSeconds, cfg.LiquidityShrinkSlopeThreshold, cfg.LiquidityShrinkMidPriceMode)
+	if err != nil {
+		return metrics, err
+	}
+	metrics.LiquidityShrink = shrink
+
+	return metrics, nil
+}
+
+// frameInfo extracts the channel, instrument and data timestamp (ms) from a raw frame.
+// Zero values are returned for fields that are not present (e.g. event frames).
+func frameInfo(msg []byte) (channel, instID string, ts int64) {
+	var frame struct {
+		Arg  ArgData `json:"arg"`
+		Data []struct {
+			Timestamp string `json:"ts"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(msg, &frame); err != nil {
+		return "", "", 0
+	}
+
+	channel = frame.Arg.Channel
+	instID = frame.Arg.InstID
+	if len(frame.Data) > 0 {
+		ts, _ = strconv.ParseInt(frame.Data[0].Timestamp, 10, 64)
+	}
+	return channel, instID, ts
+}
@@ -0,0 +1,70 @@
+package orderbook
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// SnapshotStore persists full order book snapshots
+type SnapshotStore interface {
+	InsertOrderBookSnapshot(snapshot *mongodb.OrderBookSnapshot) error
+}
+
+// StartSnapshotArchiver writes the full book of every instrument returned by instruments
+// to store once per interval, until ctx is cancelled. Books that are not ready are skipped.
+// 定期归档完整订单簿，用于历史研究
+func StartSnapshotArchiver(ctx context.Context, obManager *Manager, store SnapshotStore, interval time.Duration, instruments func() []string) {
+	if interval <= 0 {
+		return
+	}
+	log.Printf("Order book snapshot archiver started (every %v)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Order book snapshot archiver stopped")
+			return
+		case <-ticker.C:
+			archiveSnapshots(obManager, store, instruments())
+		}
+	}
+}
+
+// archiveSnapshots writes one snapshot per instrument
+func archiveSnapshots(obManager *Manager, store SnapshotStore, instIDs []string) {
+	for _, instID := range instIDs {
+		book, err := obManager.Snapshot(instID)
+		if err != nil {
+			continue
+		}
+		if err := store.InsertOrderBookSnapshot(toSnapshotDocument(book)); err != nil {
+			log.Printf("Failed to archive order book snapshot for %s: %v", instID, err)
+		}
+	}
+}
+
+// toSnapshotDocument converts a book copy to its MongoDB document
+func toSnapshotDocument(book *OrderBook) *mongodb.OrderBookSnapshot {
+	return &mongodb.OrderBookSnapshot{
+		InstID:    book.InstrumentID,
+		Asks:      toBookLevels(book.Asks),
+		Bids:      toBookLevels(book.Bids),
+		Checksum:  book.Checksum,
+		SeqID:     book.SeqID,
+		Timestamp: book.Timestamp,
+	}
+}
+
+func toBookLevels(levels []PriceLevel) []mongodb.BookLevel {
+	result := make([]mongodb.BookLevel, len(levels))
+	for i, level := range levels {
+		result[i] = mongodb.BookLevel{Price: level.Price, Size: level.Size, OrderCount: level.OrderCount}
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package orderbook
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestSnapshotDuplicatePriceLastEntryWins(t *testing.T) {
+	logs := captureLog(t)
+	m := NewManager()
+
+	// OKEx checksums the book it holds, i.e. one level per price
+	wantAsks := []PriceLevel{{Price: "101", Size: "5", OrderCount: 2}, {Price: "102", Size: "2", OrderCount: 1}}
+	wantBids := []PriceLevel{{Price: "100", Size: "4", OrderCount: 7}, {Price: "99", Size: "1", OrderCount: 1}}
+	msg := fmt.Sprintf(`{"action":"snapshot","arg":{"channel":%q,"instId":"BTC-USDT"},"data":[{
+"asks":[["101","1","0","1"],["102","2","0","1"],["101","5","0","2"]],
+"bids":[["100","3","0","1"],["99","1","0","1"],["100","4","0","7"]],
+"ts":"1717000000000","checksum":%d}]}`, config.BooksChannel, ComputeChecksum(wantBids, wantAsks))
+	if err := m.ProcessMessage([]byte(msg)); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	book, err := m.Snapshot("BTC-USDT")
+	if err != nil {
+		t.Fatalf("book: %v", err)
+	}
+	if !reflect.DeepEqual(book.Asks, wantAsks) || !reflect.DeepEqual(book.Bids, wantBids) {
+		t.Fatalf("book asks %v bids %v, want %v and %v", book.Asks, book.Bids, wantAsks, wantBids)
+	}
+	if !book.ChecksumOK {
+		t.Fatalf("checksum of the de-duplicated book failed:\n%s", logs)
+	}
+
+	// Depth sums count each price once: 4 + 1 bid, not 3 + 1 + 4
+	if size := levelSize(book.Bids, "100"); size != 4 {
+		t.Fatalf("size at 100 = %v, want 4", size)
+	}
+	for _, side := range []string{"ask", "bid"} {
+		if !strings.Contains(logs.String(), "Snapshot for BTC-USDT has 1 duplicate "+side+" price level(s)") {
+			t.Errorf("duplicate %s not logged:\n%s", side, logs)
+		}
+	}
+
+	// A clean snapshot logs nothing
+	logs.Reset()
+	loadSnapshot(t, m, "ETH-USDT", [][2]string{{"2001", "1"}}, [][2]string{{"2000", "1"}})
+	if strings.Contains(logs.String(), "duplicate") {
+		t.Fatalf("clean snapshot logged duplicates:\n%s", logs)
+	}
+}
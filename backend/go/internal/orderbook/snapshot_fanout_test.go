@@ -0,0 +1,112 @@
+package orderbook
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// swappingClock is a test clock that, once armed, replaces a book of its manager on the next
+// reading, i.e. right after ComputeAll has taken its snapshot and before any analysis runs
+type swappingClock struct {
+	mu          sync.Mutex
+	t           time.Time
+	m           *Manager
+	instID      string
+	replacement *OrderBook
+}
+
+func (c *swappingClock) Now() time.Time {
+	c.mu.Lock()
+	replacement := c.replacement
+	c.replacement = nil
+	now := c.t
+	c.mu.Unlock()
+
+	if replacement != nil {
+		c.m.mu.Lock()
+		c.m.books[c.instID] = replacement
+		c.m.mu.Unlock()
+	}
+	return now
+}
+
+func (c *swappingClock) armSwap(book *OrderBook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replacement = book
+}
+
+func TestAllAnalysesOfATickSeeTheSameBook(t *testing.T) {
+	clock := &swappingClock{t: time.UnixMilli(1717000000000), instID: "BTC-USDT"}
+	m := NewManagerWithClock(clock.Now)
+	clock.m = m
+	wallBook(t, m, "BTC-USDT")
+	original, err := m.Snapshot("BTC-USDT")
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	// A thin book 5% higher, which no analysis may see in this tick
+	other := NewManager()
+	loadSnapshot(t, other, "BTC-USDT",
+		[][2]string{{"1050.5", "0.1"}, {"1051", "0.2"}},
+		[][2]string{{"1050", "0.1"}, {"1049.5", "0.3"}})
+	moved, _ := other.Snapshot("BTC-USDT")
+
+	cfg := config.LoadFromEnv().Analysis
+	cfg.BookMaxAgeSec = 0
+	cfg.EnableSupportResistance, cfg.EnableSpreadZScore, cfg.EnableLargeOrder = true, true, true
+	cfg.EnableDepthAnomaly, cfg.EnableLiquidityShrink, cfg.EnableImbalanceFlip = true, true, true
+
+	clock.armSwap(moved)
+	result, err := m.ComputeAll("BTC-USDT", cfg)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if asks, _, _ := m.GetTop400("BTC-USDT"); len(asks) != 2 {
+		t.Fatal("the book was not replaced during the tick")
+	}
+	if !reflect.DeepEqual(result.Book, original) {
+		t.Fatalf("result book %+v, want the book at the start of the tick", result.Book)
+	}
+
+	// Every analysis must agree with one recomputed from the tick's snapshot
+	reference := NewManagerWithClock(func() time.Time { return clock.t })
+	asks, bids := original.Asks, original.Bids
+
+	if want := reference.aggregateBook("BTC-USDT", asks, bids, cfg.AggregatedBookTopN); !reflect.DeepEqual(result.AggregatedBook, want) {
+		t.Errorf("aggregated book %+v, snapshot gives %+v", result.AggregatedBook, want)
+	}
+	supports, resistances, spread, _, err := reference.computeSupportResistance("BTC-USDT", asks, bids,
+		cfg.SupportResistanceBinCount, cfg.SupportResistanceSignificanceThreshold, cfg.SupportResistanceTopN,
+		cfg.SupportResistanceMinDistancePercent, cfg.SupportResistanceMinDistanceTicks, cfg.SupportResistanceMinNotional)
+	if err != nil || !reflect.DeepEqual(result.SupportResistance.Supports, supports) ||
+		!reflect.DeepEqual(result.SupportResistance.Resistances, resistances) || result.SupportResistance.Spread != spread {
+		t.Errorf("support/resistance %+v, snapshot gives %v %v %v (%v)", result.SupportResistance, supports, resistances, spread, err)
+	}
+	large, err := reference.computeLargeOrderDistribution("BTC-USDT", asks, bids,
+		cfg.LargeOrderPercentileAlpha, cfg.LargeOrderMinNotional, cfg.LargeOrderDecayLambda, cfg.LargeOrderSentimentDeadzoneThreshold)
+	if err != nil || result.LargeOrder.LargeBuyNotional != large.LargeBuyNotional || result.LargeOrder.LargeSellNotional != large.LargeSellNotional {
+		t.Errorf("large orders %+v, snapshot gives %+v (%v)", result.LargeOrder, large, err)
+	}
+	if depth := depthInRange(asks, bids, cfg.DepthAnomalyPriceRangePercent); result.DepthAnomaly.Depth != depth {
+		t.Errorf("depth anomaly depth %v, snapshot gives %v", result.DepthAnomaly.Depth, depth)
+	}
+	metrics, err := reference.liquidityMetrics("BTC-USDT", asks, bids, cfg.LiquidityShrinkNearPriceDeltaPercent, cfg.LiquidityShrinkMidPriceMode)
+	if err != nil || result.LiquidityShrink.Depth != metrics.Depth || result.LiquidityShrink.Liquidity != metrics.Liquidity {
+		t.Errorf("liquidity %+v, snapshot gives %+v (%v)", result.LiquidityShrink, metrics, err)
+	}
+	if len(result.Errors) != 1 || result.Errors[AnalysisSpreadZScore] == nil {
+		t.Errorf("errors %v, want only the spread z-score warming up", result.Errors)
+	}
+
+	// The next tick sees the new book
+	next, err := m.ComputeAll("BTC-USDT", cfg)
+	if err != nil || next.Book.Bids[0].Price != "1050" || next.SupportResistance.Spread == result.SupportResistance.Spread {
+		t.Fatalf("next tick: book %+v, err %v", next.Book, err)
+	}
+}
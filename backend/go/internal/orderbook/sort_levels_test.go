@@ -0,0 +1,71 @@
+package orderbook
+
+import (
+	"reflect"
+	"testing"
+)
+
+// permutations calls fn with every ordering of levels
+func permutations(levels []PriceLevel, fn func([]PriceLevel)) {
+	var permute func(k int)
+	permute = func(k int) {
+		if k == len(levels) {
+			fn(append([]PriceLevel(nil), levels...))
+			return
+		}
+		for i := k; i < len(levels); i++ {
+			levels[k], levels[i] = levels[i], levels[k]
+			permute(k + 1)
+			levels[k], levels[i] = levels[i], levels[k]
+		}
+	}
+	permute(0)
+}
+
+func TestSortLevelsIsIndependentOfInputOrder(t *testing.T) {
+	m := NewManager()
+	// Three spellings of 100 and two sizes at "100": equal parsed prices
+	levels := []PriceLevel{
+		{Price: "100.0", Size: "3"},
+		{Price: "100", Size: "2"},
+		{Price: "100", Size: "1.5"},
+		{Price: "100.00", Size: "7"},
+		{Price: "99.5", Size: "4"},
+		{Price: "100.5", Size: "1"},
+	}
+	wantAsks := []PriceLevel{
+		{Price: "99.5", Size: "4"},
+		{Price: "100", Size: "1.5"},
+		{Price: "100", Size: "2"},
+		{Price: "100.0", Size: "3"},
+		{Price: "100.00", Size: "7"},
+		{Price: "100.5", Size: "1"},
+	}
+	wantBids := []PriceLevel{
+		{Price: "100.5", Size: "1"},
+		{Price: "100", Size: "1.5"},
+		{Price: "100", Size: "2"},
+		{Price: "100.0", Size: "3"},
+		{Price: "100.00", Size: "7"},
+		{Price: "99.5", Size: "4"},
+	}
+	wantChecksum := checksumOf(BuildChecksumString(wantBids, wantAsks))
+
+	orders := 0
+	permutations(levels, func(asks []PriceLevel) {
+		orders++
+		bids := append([]PriceLevel(nil), asks...)
+		m.sortLevels(&asks, true)
+		m.sortLevels(&bids, false)
+
+		if !reflect.DeepEqual(asks, wantAsks) || !reflect.DeepEqual(bids, wantBids) {
+			t.Fatalf("order %d:\nasks %v\nbids %v", orders, asks, bids)
+		}
+		if got := checksumOf(BuildChecksumString(bids, asks)); got != wantChecksum {
+			t.Fatalf("order %d: checksum %d, want %d", orders, got, wantChecksum)
+		}
+	})
+	if orders != 720 {
+		t.Fatalf("checked %d orders, want 720", orders)
+	}
+}
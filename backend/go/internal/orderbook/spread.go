@@ -0,0 +1,60 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrInsufficientData is returned when the book lacks the levels needed for a calculation,
+// e.g. a one-sided book
+var ErrInsufficientData = errors.New("insufficient order book data")
+
+// GetBestBidAsk returns the best bid and best ask prices of an instrument.
+// Returns ErrInsufficientData (wrapped) when either side is empty.
+func (m *Manager) GetBestBidAsk(instID string) (bid, ask float64, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	book, exists := m.books[instID]
+	if !exists {
+		return 0, 0, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return 0, 0, fmt.Errorf("%s: one-sided book: %w", instID, ErrInsufficientData)
+	}
+
+	bid, err = strconv.ParseFloat(book.Bids[0].Price, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid best bid for %s: %w", instID, err)
+	}
+	ask, err = strconv.ParseFloat(book.Asks[0].Price, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid best ask for %s: %w", instID, err)
+	}
+	return bid, ask, nil
+}
+
+// GetSpread returns the top-of-book spread as an absolute price difference, relative
+// to the mid price, and in basis points of the mid price
+func (m *Manager) GetSpread(instID string) (absolute, relative, bps float64, err error) {
+	bid, ask, err := m.GetBestBidAsk(instID)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	mid := (bid + ask) / 2.0
+	if mid <= 0 {
+		return 0, 0, 0, fmt.Errorf("invalid mid price for %s", instID)
+	}
+
+	absolute = ask - bid
+	relative = absolute / mid
+	return absolute, relative, relative * 10000, nil
+}
+
+// GetSpreadBps returns the top-of-book spread in basis points of the mid price
+func (m *Manager) GetSpreadBps(instID string) (float64, error) {
+	_, _, bps, err := m.GetSpread(instID)
+	return bps, err
+}
@@ -0,0 +1,45 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestGetSpreadKnownBook(t *testing.T) {
+	m := NewManager()
+	// bid 99.95 / ask 100.05: mid 100, spread 0.1 = 0.001 = 10 bps
+	loadSnapshot(t, m, "BTC-USDT",
+		[][2]string{{"100.05", "2"}, {"100.1", "4"}},
+		[][2]string{{"99.95", "1"}, {"99.9", "3"}})
+
+	absolute, relative, bps, err := m.GetSpread("BTC-USDT")
+	if err != nil {
+		t.Fatalf("spread: %v", err)
+	}
+	for name, got := range map[string][2]float64{
+		"absolute": {absolute, 0.1},
+		"relative": {relative, 0.001},
+		"bps":      {bps, 10},
+	} {
+		if math.Abs(got[0]-got[1]) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, got[0], got[1])
+		}
+	}
+
+	if only, err := m.GetSpreadBps("BTC-USDT"); err != nil || only != bps {
+		t.Errorf("GetSpreadBps = %v, %v, want %v", only, err, bps)
+	}
+}
+
+func TestGetSpreadOneSidedBook(t *testing.T) {
+	m := NewManager()
+	loadSnapshot(t, m, "ETH-USDT", nil, [][2]string{{"3000", "1"}})
+
+	if _, _, _, err := m.GetSpread("ETH-USDT"); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("one-sided book: err = %v, want ErrInsufficientData", err)
+	}
+	if _, err := m.GetSpreadBps("SOL-USDT"); !errors.Is(err, ErrBookNotFound) {
+		t.Fatalf("unknown book: err = %v, want ErrBookNotFound", err)
+	}
+}
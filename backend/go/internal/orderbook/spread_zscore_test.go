@@ -0,0 +1,87 @@
+package orderbook
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/testutil"
+)
+
+func TestSpreadZScoreUsesConfiguredWindow(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+	wallBook(t, m, "BTC-USDT")
+
+	// 20 to 11 minutes ago the spread was wide, in the last 90s it is tight
+	now := clock.t
+	for _, sample := range []struct {
+		ago    time.Duration
+		spread float64
+	}{
+		{20 * time.Minute, 40}, {17 * time.Minute, 44}, {14 * time.Minute, 36}, {11 * time.Minute, 40},
+		{90 * time.Second, 1}, {60 * time.Second, 1.2}, {30 * time.Second, 0.8},
+	} {
+		clock.t = now.Add(-sample.ago)
+		m.recordSpread("BTC-USDT", sample.spread)
+	}
+	clock.t = now
+
+	cfg := config.LoadFromEnv().Analysis
+	cfg.BookMaxAgeSec = 0
+	zScores := make(map[int]float64)
+	for _, tt := range []struct {
+		minutes   int
+		readiness float64
+	}{{2, 1}, {30, 20.0 / 30}} {
+		cfg.SpreadZScoreWindowMinutes = tt.minutes
+		result, err := m.ComputeAll("BTC-USDT", cfg)
+		if err != nil || result.Errors[AnalysisSpreadZScore] != nil {
+			t.Fatalf("%d minutes: %v %v", tt.minutes, err, result.Errors)
+		}
+		// Same window contents and clock, so the direct call must agree
+		z, spread, err := m.AnalyzeSpreadZScore("BTC-USDT", tt.minutes)
+		if err != nil || z != result.SpreadZScore.ZScore || spread != result.SpreadZScore.Spread {
+			t.Fatalf("%d minutes: result %+v, direct z=%v spread=%v err=%v",
+				tt.minutes, result.SpreadZScore, z, spread, err)
+		}
+		if math.Abs(result.SpreadZScore.Readiness-tt.readiness) > 1e-9 {
+			t.Fatalf("%d minutes: readiness %v, want %v", tt.minutes, result.SpreadZScore.Readiness, tt.readiness)
+		}
+		zScores[tt.minutes] = z
+	}
+
+	// The book's current spread is far from the tight last minutes but inside the wide history
+	if math.Abs(zScores[2]) <= math.Abs(zScores[30]) {
+		t.Fatalf("z-score over 2 minutes %v, over 30 minutes %v; the short window must react more",
+			zScores[2], zScores[30])
+	}
+}
+
+func TestSpreadAlertUsesConfiguredThreshold(t *testing.T) {
+	result := &AnalysisResult{
+		Errors:       map[string]error{},
+		SpreadZScore: &SpreadZScoreData{ZScore: -2.8, Spread: 0.4, Readiness: 1},
+	}
+	cfg := config.LoadFromEnv()
+
+	for _, tt := range []struct {
+		threshold float64
+		alert     bool
+	}{{2.5, true}, {3, false}, {2.8, false}, {0, false}} {
+		logs := captureLog(t)
+		store := testutil.NewRecordingStore()
+		cfg.Analysis.SpreadZScoreAlertThreshold = tt.threshold
+		if err := processSpreadZScore("ETH-USDT", result, store, cfg); err != nil {
+			t.Fatalf("threshold %v: %v", tt.threshold, err)
+		}
+		if alerted := strings.Contains(logs.String(), "Spread alert for ETH-USDT: z-score=-2.80"); alerted != tt.alert {
+			t.Errorf("threshold %v: alert %v, want %v\n%s", tt.threshold, alerted, tt.alert, logs)
+		}
+		if n := store.Methods("ETH-USDT")["StoreSpreadZScore"]; n != 1 {
+			t.Errorf("threshold %v: stored %d times, want once", tt.threshold, n)
+		}
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"math"
 	"sort"
-	"strconv"
-	"time"
 
 	"github.com/supermancell/okex-buddy/internal/utils"
 )
@@ -16,16 +14,59 @@ import (
 //   - price range is divided into bins
 //   - per-bin notional volume is accumulated
 //   - local maxima above a significance threshold are selected and sorted
-//     TODO 支撑位和阻力位之间的间隔太近了，没有什么实际意义
-func (m *Manager) ComputeSupportResistance(instID string, binCount int, significanceThreshold float64, topN int, minDistancePercent float64) (supports, resistances []float64, spread float64, err error) {
-	// First, compute the current support and resistance levels
+//   - bins below minNotional are never candidates, so a thin book yields empty slices
+//   - levels closer than minDistancePercent of the depth-weighted mid are dropped
+func (m *Manager) ComputeSupportResistance(instID string, binCount int, significanceThreshold float64, topN int, minDistancePercent, minNotional float64) (supports, resistances []float64, spread float64, err error) {
 	asks, bids, err := m.GetTop400(instID)
 	if err != nil {
 		return nil, nil, 0, err
 	}
+	supports, resistances, spread, anchor, err := m.computeSupportResistance(instID, asks, bids, binCount, significanceThreshold, topN, minDistancePercent, 0, minNotional)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	m.recordSupportResistance(instID, SupportResistanceData{
+		Supports:    supports,
+		Resistances: resistances,
+		Spread:      spread,
+		DeepMid:     anchor,
+		Significant: len(supports) > 0 || len(resistances) > 0,
+		Timestamp:   m.now().Unix(),
+	})
+	m.recordSpread(instID, spread)
+	return supports, resistances, spread, nil
+}
+
+// srWindowSeconds is how long support/resistance and spread samples are kept (30 minutes)
+const srWindowSeconds = 1800
+
+// recordSupportResistance adds a support/resistance result to the instrument's time window.
+func (m *Manager) recordSupportResistance(instID string, data SupportResistanceData) {
+	m.window(m.supportResistanceWindows, instID, srWindowSeconds).Add(&SupportResistanceWindowItem{
+		Data:      data,
+		Timestamp: data.Timestamp,
+	})
+}
+
+// recordSpread adds a spread sample to the instrument's time window, which feeds
+// AnalyzeSpreadZScore.
+func (m *Manager) recordSpread(instID string, spread float64) {
+	m.window(m.spreadWindows, instID, srWindowSeconds).Add(&SpreadWindowItem{
+		Spread:    spread,
+		Timestamp: m.now().Unix(),
+	})
+}
 
+// computeSupportResistance computes support and resistance levels from the given book levels.
+// The minimum distance between two levels is an absolute price distance, so spacing is the
+// same in every price region: minDistanceTicks ticks when > 0 and a tick size is known,
+// otherwise minDistancePercent of the depth-weighted mid (returned as anchor).
+// A bin whose notional is below minNotional can't be a level; when no bin of a side clears
+// it, that side is empty rather than filled with the largest (insignificant) bins.
+// Nothing is recorded; callers add the result to the windows they need.
+func (m *Manager) computeSupportResistance(instID string, asks, bids []PriceLevel, binCount int, significanceThreshold float64, topN int, minDistancePercent float64, minDistanceTicks int, minNotional float64) (supports, resistances []float64, spread, anchor float64, err error) {
 	if len(asks) == 0 && len(bids) == 0 {
-		return nil, nil, 0, fmt.Errorf("empty order book for %s", instID)
+		return nil, nil, 0, 0, fmt.Errorf("empty order book for %s", instID)
 	}
 
 	if binCount <= 0 {
@@ -41,69 +82,25 @@ func (m *Manager) ComputeSupportResistance(instID string, binCount int, signific
 		minDistancePercent = 0.5
 	}
 
-	// Determine price range from bids and asks
-	minPrice := 0.0
-	maxPrice := 0.0
-	first := true
-
-	updateRange := func(levels []PriceLevel) {
-		for _, lvl := range levels {
-			p, err := strconv.ParseFloat(lvl.Price, 64)
-			if err != nil {
-				continue
-			}
-			if first {
-				minPrice, maxPrice = p, p
-				first = false
-			} else {
-				if p < minPrice {
-					minPrice = p
-				}
-				if p > maxPrice {
-					maxPrice = p
-				}
-			}
-		}
-	}
-
-	updateRange(bids)
-	updateRange(asks)
-
-	if first || maxPrice <= minPrice {
-		return nil, nil, 0, fmt.Errorf("invalid price range for %s", instID)
+	bins, err := binBook(instID, asks, bids, binCount)
+	if err != nil {
+		return nil, nil, 0, 0, err
 	}
+	bidVolumes, askVolumes := bins.BidNotional, bins.AskNotional
 
-	binWidth := (maxPrice - minPrice) / float64(binCount)
-	if binWidth <= 0 {
-		return nil, nil, 0, fmt.Errorf("invalid bin width for %s", instID)
+	// Anchor the minimum distance on the depth-weighted mid; a one-sided book falls back
+	// to the middle of the price range
+	anchor, err = deepMid(instID, asks, bids, deepMidLevels)
+	if err != nil {
+		anchor = bins.MinPrice + bins.BinWidth*float64(binCount)/2
 	}
-
-	bidVolumes := make([]float64, binCount)
-	askVolumes := make([]float64, binCount)
-
-	// Accumulate notional by bin for bids and asks
-	accumulate := func(levels []PriceLevel, vols []float64) {
-		for _, lvl := range levels {
-			p, err1 := strconv.ParseFloat(lvl.Price, 64)
-			q, err2 := strconv.ParseFloat(lvl.Size, 64)
-			if err1 != nil || err2 != nil || q <= 0 {
-				continue
-			}
-			notional := p * q
-			idx := int((p - minPrice) / binWidth)
-			if idx < 0 {
-				idx = 0
-			}
-			if idx >= binCount {
-				idx = binCount - 1
-			}
-			vols[idx] += notional
+	minDistance := anchor * minDistancePercent / 100
+	if minDistanceTicks > 0 {
+		if tick := m.tickSizeFor(instID, asks, bids); tick > 0 {
+			minDistance = float64(minDistanceTicks) * tick
 		}
 	}
 
-	accumulate(bids, bidVolumes)
-	accumulate(asks, askVolumes)
-
 	// Helper to find peaks
 	findPeaks := func(vols []float64) []struct {
 		Index int
@@ -127,7 +124,7 @@ func (m *Manager) ComputeSupportResistance(instID string, binCount int, signific
 
 		for i := 1; i < len(vols)-1; i++ {
 			v := vols[i]
-			if v <= 0 {
+			if v <= 0 || v < minNotional {
 				continue
 			}
 			if v > significanceThreshold*avg && v > vols[i-1] && v > vols[i+1] {
@@ -138,10 +135,10 @@ func (m *Manager) ComputeSupportResistance(instID string, binCount int, signific
 			}
 		}
 
-		// Fallback: if no peaks, pick top bins by volume
+		// Fallback: if no peaks, pick top bins by volume among those clearing the floor
 		if len(peaks) == 0 {
 			for i, v := range vols {
-				if v > 0 {
+				if v > 0 && v >= minNotional {
 					peaks = append(peaks, struct {
 						Index int
 						Value float64
@@ -161,18 +158,13 @@ func (m *Manager) ComputeSupportResistance(instID string, binCount int, signific
 	bidPeaks := findPeaks(bidVolumes)
 	askPeaks := findPeaks(askVolumes)
 
-	binCenter := func(idx int) float64 {
-		return minPrice + (float64(idx)+0.5)*binWidth
-	}
-
 	// Collect top-N support levels from bids with minimum distance filtering
 	for i := 0; i < len(bidPeaks) && len(supports) < topN; i++ {
-		candidate := binCenter(bidPeaks[i].Index)
+		candidate := bins.center(bidPeaks[i].Index)
 		// Check distance from all existing supports
 		tooClose := false
 		for _, existing := range supports {
-			diffPercent := math.Abs((candidate-existing)/existing) * 100
-			if diffPercent < minDistancePercent {
+			if math.Abs(candidate-existing) < minDistance {
 				tooClose = true
 				break
 			}
@@ -184,12 +176,11 @@ func (m *Manager) ComputeSupportResistance(instID string, binCount int, signific
 
 	// Collect top-N resistance levels from asks with minimum distance filtering
 	for i := 0; i < len(askPeaks) && len(resistances) < topN; i++ {
-		candidate := binCenter(askPeaks[i].Index)
+		candidate := bins.center(askPeaks[i].Index)
 		// Check distance from all existing resistances
 		tooClose := false
 		for _, existing := range resistances {
-			diffPercent := math.Abs((candidate-existing)/existing) * 100
-			if diffPercent < minDistancePercent {
+			if math.Abs(candidate-existing) < minDistance {
 				tooClose = true
 				break
 			}
@@ -223,49 +214,21 @@ func (m *Manager) ComputeSupportResistance(instID string, binCount int, signific
 		spread = 0 // No valid support/resistance pair to calculate spread
 	}
 
-	// Use time window utility for support/resistance data (30 minutes window)
-	const maxWindowSeconds = 1800 // 30 minutes
-	if m.supportResistanceWindows[instID] == nil {
-		m.supportResistanceWindows[instID] = utils.NewGenericTimeWindow(maxWindowSeconds)
-	}
-
-	// Add current result to the time window
-	srItem := &SupportResistanceWindowItem{
-		Data: SupportResistanceData{
-			Supports:    supports,
-			Resistances: resistances,
-			Spread:      spread,
-			Timestamp:   time.Now().Unix(),
-		},
-		Timestamp: time.Now().Unix(),
-	}
-	m.supportResistanceWindows[instID].Add(srItem)
-
-	// Use time window utility for spread data (30 minutes window)
-	if m.spreadWindows[instID] == nil {
-		m.spreadWindows[instID] = utils.NewGenericTimeWindow(maxWindowSeconds)
-	}
-
-	// Add current spread to the time window
-	spreadItem := &SpreadWindowItem{
-		Spread:    spread,
-		Timestamp: time.Now().Unix(),
-	}
-	m.spreadWindows[instID].Add(spreadItem)
-
 	//log.Printf("Computed support and resistance levels for %s: supports=%v, resistances=%v", instID, supports, resistances)
-	return supports, resistances, spread, nil
+	return supports, resistances, spread, anchor, nil
 }
 
 // AnalyzeSpreadZScore calculates a Z-score for the current spread relative to historical values
 // This provides a standardized measure of how unusual the current spread is
 func (m *Manager) AnalyzeSpreadZScore(instID string, windowSizeMinutes int) (zScore float64, currentSpread float64, err error) {
 	if windowSizeMinutes <= 0 {
-		windowSizeMinutes = 5 // default to 5 minutes
+		windowSizeMinutes = defaultSpreadZScoreWindowMinutes
 	}
 
 	// Get window items
+	m.windowsMu.Lock()
 	window := m.spreadWindows[instID]
+	m.windowsMu.Unlock()
 	if window == nil {
 		return 0, 0, fmt.Errorf("no spread window for %s", instID)
 	}
@@ -276,7 +239,7 @@ func (m *Manager) AnalyzeSpreadZScore(instID string, windowSizeMinutes int) (zSc
 	}
 
 	// Calculate statistics for the specified time window
-	currentTime := time.Now().Unix()
+	currentTime := m.now().Unix()
 	cutoffTime := currentTime - int64(windowSizeMinutes*60)
 
 	// Collect spreads within the time window
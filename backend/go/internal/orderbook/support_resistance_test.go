@@ -0,0 +1,135 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// wallBook loads a book around 1000 with a wall every 15 price units on both sides
+func wallBook(t *testing.T, m *Manager, instID string) {
+	t.Helper()
+
+	var asks, bids [][2]string
+	for i := 1; i <= 100; i++ {
+		size := "1"
+		if i%15 == 10 {
+			size = "50"
+		}
+		asks = append(asks, [2]string{strconv.Itoa(1000 + i), size})
+		bids = append(bids, [2]string{strconv.Itoa(1000 - i), size})
+	}
+	loadSnapshot(t, m, instID, asks, bids)
+}
+
+func TestSupportResistanceFiveLevelsRoundTrip(t *testing.T) {
+	m := NewManager()
+	wallBook(t, m, "BTC-USDT")
+
+	supports, resistances, spread, err := m.ComputeSupportResistance("BTC-USDT", 100, 1.5, 5, 0.5, 0)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if len(supports) != 5 || len(resistances) != 5 {
+		t.Fatalf("topN=5 gave %d supports %v and %d resistances %v", len(supports), supports, len(resistances), resistances)
+	}
+
+	data := SupportResistanceData{Supports: supports, Resistances: resistances, Spread: spread, Significant: true}
+	fields := data.ToRedisMap()
+
+	for name, want := range map[string][]float64{"supports": supports, "resistances": resistances} {
+		raw, ok := fields[name].(string)
+		if !ok {
+			t.Fatalf("%s field = %#v, want a JSON string", name, fields[name])
+		}
+		var got []float64
+		if err := json.Unmarshal([]byte(raw), &got); err != nil {
+			t.Fatalf("%s = %s: %v", name, raw, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s round-tripped to %v, want %v", name, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s round-tripped to %v, want %v", name, got, want)
+			}
+		}
+	}
+
+	// The two legacy fields keep the strongest two levels
+	if fields["support_high"] != supports[0] || fields["support_low"] != supports[1] ||
+		fields["resistance_high"] != resistances[0] || fields["resistance_low"] != resistances[1] {
+		t.Fatalf("legacy fields = %v", fields)
+	}
+}
+
+func TestSupportResistanceNoLevelsStoresEmptyArrays(t *testing.T) {
+	fields := SupportResistanceData{}.ToRedisMap()
+	if fields["supports"] != "[]" || fields["resistances"] != "[]" {
+		t.Fatalf("empty levels stored as %v / %v, want []", fields["supports"], fields["resistances"])
+	}
+	if _, ok := fields["support_high"]; ok {
+		t.Fatal("support_high stored without a level")
+	}
+}
+
+func TestThinBookHasNoSignificantLevels(t *testing.T) {
+	m := NewManager()
+	// A handful of small orders, a few hundred USDT in total
+	loadSnapshot(t, m, "DUST-USDT",
+		[][2]string{{"100.5", "0.3"}, {"101", "1"}, {"103", "0.2"}, {"106", "0.5"}},
+		[][2]string{{"100", "0.5"}, {"99", "0.1"}, {"97", "1.2"}, {"94", "0.4"}})
+
+	// Without a floor the largest bins are returned as if they were walls
+	supports, resistances, _, err := m.ComputeSupportResistance("DUST-USDT", 20, 1.5, 2, 0.5, 0)
+	if err != nil || len(supports) == 0 || len(resistances) == 0 {
+		t.Fatalf("no floor: supports %v, resistances %v, err %v; want the fallback levels", supports, resistances, err)
+	}
+
+	cfg := config.LoadFromEnv().Analysis
+	cfg.BookMaxAgeSec = 0
+	if cfg.SupportResistanceMinNotional <= 0 {
+		t.Fatalf("default floor is %v, want a positive notional", cfg.SupportResistanceMinNotional)
+	}
+	result, err := m.ComputeAll("DUST-USDT", cfg)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if err := result.Errors[AnalysisSupportResistance]; err != nil {
+		t.Fatalf("thin book reported as an error: %v", err)
+	}
+	sr := result.SupportResistance
+	if sr.Significant || len(sr.Supports) != 0 || len(sr.Resistances) != 0 {
+		t.Fatalf("thin book gave %+v, want no significant levels", sr)
+	}
+	if fields := sr.ToRedisMap(); fields["significant"] != false || fields["supports"] != "[]" || fields["resistances"] != "[]" {
+		t.Fatalf("stored fields %v, want empty levels marked insignificant", fields)
+	}
+}
+
+func TestFloorKeepsOnlyTheSideWithAWall(t *testing.T) {
+	m := NewManager()
+	// One 19000 USDT bid wall at 95; the asks stay thin
+	loadSnapshot(t, m, "SOL-USDT",
+		[][2]string{{"100.5", "1"}, {"102", "2"}, {"104", "1"}},
+		[][2]string{{"100", "1"}, {"98", "2"}, {"95", "200"}, {"92", "1"}})
+
+	supports, resistances, _, err := m.ComputeSupportResistance("SOL-USDT", 24, 1.5, 3, 0.5, 10000)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if len(supports) != 1 || math.Abs(supports[0]-95) > 0.6 {
+		t.Fatalf("supports = %v, want only the wall near 95", supports)
+	}
+	if len(resistances) != 0 {
+		t.Fatalf("resistances = %v, want none below the floor", resistances)
+	}
+
+	items := m.supportResistanceWindows["SOL-USDT"].GetItems()
+	if recorded := items[len(items)-1].(*SupportResistanceWindowItem).Data; !recorded.Significant {
+		t.Fatal("a book with a wall was recorded as insignificant")
+	}
+}
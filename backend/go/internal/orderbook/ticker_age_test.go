@@ -0,0 +1,33 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickerAgeFollowsTheClock(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+
+	if _, _, ok := m.GetTickerWithAge("BTC-USDT"); ok {
+		t.Fatal("age reported before any ticker")
+	}
+
+	pushTicker(t, m, "BTC-USDT", "100", "100.1")
+	for _, elapsed := range []time.Duration{0, 3 * time.Second, 90 * time.Second} {
+		clock.t = time.UnixMilli(1717000000000).Add(elapsed)
+		ticker, age, ok := m.GetTickerWithAge("BTC-USDT")
+		if !ok || ticker.BidPx != "100" {
+			t.Fatalf("ticker = %+v, %v", ticker, ok)
+		}
+		if age != elapsed {
+			t.Fatalf("age = %v after %v, want %v", age, elapsed, elapsed)
+		}
+	}
+
+	// A new ticker resets the age
+	pushTicker(t, m, "BTC-USDT", "101", "101.1")
+	if _, age, _ := m.GetTickerWithAge("BTC-USDT"); age != 0 {
+		t.Fatalf("age = %v right after a new ticker", age)
+	}
+}
@@ -0,0 +1,82 @@
+package orderbook
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// BookVsTickerDivergence returns the relative difference between the book's top of book and
+// the best bid/ask of the latest ticker, the larger of |bookBid-tickerBid|/tickerBid and
+// |bookAsk-tickerAsk|/tickerAsk. A large value means the book has likely been corrupted.
+// Returns ErrInsufficientData (wrapped) when the book is one-sided, the ticker has no bid/ask
+// or the ticker is older than maxTickerAge (<= 0 accepts any age).
+func (m *Manager) BookVsTickerDivergence(instID string, maxTickerAge time.Duration) (float64, error) {
+	m.mu.RLock()
+	book, hasBook := m.books[instID]
+	ticker, hasTicker := m.tickers[instID]
+	age := m.now().Sub(m.tickerReceivedAt[instID])
+	var bookBid, bookAsk string
+	if hasBook && len(book.Bids) > 0 && len(book.Asks) > 0 {
+		bookBid, bookAsk = book.Bids[0].Price, book.Asks[0].Price
+	}
+	var tickerBid, tickerAsk string
+	if hasTicker {
+		tickerBid, tickerAsk = ticker.BidPx, ticker.AskPx
+	}
+	m.mu.RUnlock()
+
+	if !hasBook {
+		return 0, fmt.Errorf("%s: %w", instID, ErrBookNotFound)
+	}
+	if bookBid == "" {
+		return 0, fmt.Errorf("%s: one-sided book: %w", instID, ErrInsufficientData)
+	}
+	if !hasTicker || tickerBid == "" || tickerAsk == "" {
+		return 0, fmt.Errorf("%s: no ticker bid/ask: %w", instID, ErrInsufficientData)
+	}
+	if maxTickerAge > 0 && age > maxTickerAge {
+		return 0, fmt.Errorf("%s: ticker is %s old: %w", instID, age.Truncate(time.Second), ErrInsufficientData)
+	}
+
+	prices := make([]float64, 4)
+	for i, raw := range []string{bookBid, bookAsk, tickerBid, tickerAsk} {
+		price, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid price %q for %s: %w", raw, instID, err)
+		}
+		prices[i] = price
+	}
+	if prices[2] <= 0 || prices[3] <= 0 {
+		return 0, fmt.Errorf("%s: non-positive ticker bid/ask: %w", instID, ErrInsufficientData)
+	}
+
+	bidDiff := math.Abs(prices[0]-prices[2]) / prices[2]
+	askDiff := math.Abs(prices[1]-prices[3]) / prices[3]
+	return math.Max(bidDiff, askDiff), nil
+}
+
+// ResyncIfDivergent drops the book of an instrument and requests a resync through the
+// sequence gap handler when BookVsTickerDivergence exceeds threshold. It returns the
+// divergence and whether a resync was triggered; threshold <= 0 only measures.
+func (m *Manager) ResyncIfDivergent(instID string, maxTickerAge time.Duration, threshold float64) (float64, bool, error) {
+	divergence, err := m.BookVsTickerDivergence(instID, maxTickerAge)
+	if err != nil || threshold <= 0 || divergence <= threshold {
+		return divergence, false, err
+	}
+
+	log.Print(config.Colorf(config.Red, "Order book of %s diverges from its ticker by %.4f%% (threshold %.4f%%), dropping it and resyncing",
+		instID, divergence*100, threshold*100))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resetBookLocked(instID)
+	if m.onSequenceGap != nil {
+		m.onSequenceGap(instID)
+	}
+	return divergence, true, nil
+}
@@ -0,0 +1,75 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// pushTicker feeds a tickers message with the given best bid and ask into m
+func pushTicker(t *testing.T, m *Manager, instID, bidPx, askPx string) {
+	t.Helper()
+
+	msg := fmt.Sprintf(`{"arg":{"channel":"tickers","instId":%q},"data":[{"instId":%q,"bidPx":%q,"askPx":%q,"last":%q,"ts":"1717000000000"}]}`,
+		instID, instID, bidPx, askPx, bidPx)
+	if err := m.ProcessMessage([]byte(msg)); err != nil {
+		t.Fatalf("process ticker: %v", err)
+	}
+}
+
+func TestBookVsTickerDivergence(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	var resynced []string
+	m.SetSequenceGapHandler(func(instID string) { resynced = append(resynced, instID) })
+
+	// The book is stuck 10% above the market
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"110.1", "1"}}, [][2]string{{"110", "1"}})
+	pushTicker(t, m, "BTC-USDT", "100", "100.1")
+
+	divergence, err := m.BookVsTickerDivergence("BTC-USDT", time.Minute)
+	if err != nil {
+		t.Fatalf("divergence: %v", err)
+	}
+	if math.Abs(divergence-0.1) > 1e-3 {
+		t.Fatalf("divergence = %v, want about 0.1", divergence)
+	}
+
+	// Below the threshold the book is kept
+	if _, resync, err := m.ResyncIfDivergent("BTC-USDT", time.Minute, 0.2); err != nil || resync {
+		t.Fatalf("resync=%v err=%v under a 20%% threshold", resync, err)
+	}
+
+	_, resync, err := m.ResyncIfDivergent("BTC-USDT", time.Minute, 0.01)
+	if err != nil || !resync {
+		t.Fatalf("resync=%v err=%v for a 10%% divergence over a 1%% threshold", resync, err)
+	}
+	if len(resynced) != 1 || resynced[0] != "BTC-USDT" {
+		t.Fatalf("resync requested for %v, want BTC-USDT", resynced)
+	}
+	if _, err := m.BookVsTickerDivergence("BTC-USDT", time.Minute); !errors.Is(err, ErrBookNotFound) {
+		t.Fatalf("divergent book not dropped: err = %v", err)
+	}
+}
+
+func TestBookVsTickerDivergenceNeedsFreshTicker(t *testing.T) {
+	clock := &testClock{t: time.UnixMilli(1717000000000)}
+	m := NewManagerWithClock(clock.Now)
+	loadSnapshot(t, m, "BTC-USDT", [][2]string{{"100.1", "1"}}, [][2]string{{"100", "1"}})
+
+	if _, err := m.BookVsTickerDivergence("BTC-USDT", time.Minute); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("without a ticker: err = %v, want ErrInsufficientData", err)
+	}
+
+	pushTicker(t, m, "BTC-USDT", "100", "100.1")
+	if divergence, err := m.BookVsTickerDivergence("BTC-USDT", time.Minute); err != nil || divergence != 0 {
+		t.Fatalf("matching book and ticker: divergence=%v err=%v", divergence, err)
+	}
+
+	clock.t = clock.t.Add(2 * time.Minute)
+	if _, err := m.BookVsTickerDivergence("BTC-USDT", time.Minute); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("stale ticker: err = %v, want ErrInsufficientData", err)
+	}
+}
@@ -0,0 +1,145 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// defaultTradePressureWindowSeconds is the default trade pressure window, see SetTradePressureWindow
+const defaultTradePressureWindowSeconds = 60
+
+// TradeWindowItem represents a single executed trade in the sliding window
+type TradeWindowItem struct {
+	Notional  float64 // px * sz
+	IsBuy     bool    // taker side was buy
+	Timestamp int64
+}
+
+func (i *TradeWindowItem) GetTimestamp() int64 {
+	return i.Timestamp
+}
+
+// tradeData represents a single trade in OKEx trades channel format
+type tradeData struct {
+	Px   string `json:"px"`
+	Sz   string `json:"sz"`
+	Side string `json:"side"` // taker side: buy or sell
+}
+
+// TradePressureData represents the buy/sell aggressor notional over a window
+type TradePressureData struct {
+	BuyRatio      float64 `json:"buy_ratio"` // buy notional / total notional, 0.5 = balanced
+	BuyNotional   float64 `json:"buy_notional"`
+	SellNotional  float64 `json:"sell_notional"`
+	Trades        int     `json:"trades"` // number of trades in the window
+	WindowSeconds int     `json:"window_seconds"`
+	Readiness     float64 `json:"readiness"` // 0..1, how much of the window is filled
+	Timestamp     int64   `json:"timestamp"`
+}
+
+// ToRedisMap converts TradePressureData to a map for Redis storage
+func (t *TradePressureData) ToRedisMap() map[string]interface{} {
+	return map[string]interface{}{
+		"buy_ratio":      t.BuyRatio,
+		"buy_notional":   t.BuyNotional,
+		"sell_notional":  t.SellNotional,
+		"trades":         t.Trades,
+		"window_seconds": t.WindowSeconds,
+		"readiness":      t.Readiness,
+		"timestamp":      t.Timestamp,
+	}
+}
+
+// SetTradePressureWindow configures how long trades are kept for ComputeTradePressure, in seconds.
+// It applies to windows created afterwards and caps the windowSeconds ComputeTradePressure can use.
+func (m *Manager) SetTradePressureWindow(windowSeconds int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if windowSeconds > 0 {
+		m.tradeWindowSeconds = windowSeconds
+	}
+}
+
+// processTradesMessage adds the trades of a trades channel push to the instrument's window
+// 按主动成交方向记录成交名义价值
+func (m *Manager) processTradesMessage(arg ArgData, _ string, rawData json.RawMessage) error {
+	var trades []tradeData
+	if err := json.Unmarshal(rawData, &trades); err != nil {
+		return fmt.Errorf("failed to unmarshal trades data: %w", err)
+	}
+
+	m.mu.RLock()
+	windowSeconds := m.tradeWindowSeconds
+	m.mu.RUnlock()
+
+	window := m.window(m.tradeWindows, arg.InstID, int64(windowSeconds))
+	now := m.now().Unix()
+	for _, trade := range trades {
+		px, err := strconv.ParseFloat(trade.Px, 64)
+		if err != nil {
+			return fmt.Errorf("invalid trade price for %s: %w", arg.InstID, err)
+		}
+		sz, err := strconv.ParseFloat(trade.Sz, 64)
+		if err != nil {
+			return fmt.Errorf("invalid trade size for %s: %w", arg.InstID, err)
+		}
+		window.Add(&TradeWindowItem{Notional: px * sz, IsBuy: trade.Side == "buy", Timestamp: now})
+	}
+	return nil
+}
+
+// ComputeTradePressure returns the share of buy-side (taker bought) notional among the
+// trades of the last windowSeconds, 1 = only buys, 0 = only sells.
+// Returns ErrInsufficientData (wrapped) when no trade falls in the window.
+func (m *Manager) ComputeTradePressure(instID string, windowSeconds int) (buyRatio float64, err error) {
+	data, err := m.GetTradePressure(instID, windowSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return data.BuyRatio, nil
+}
+
+// GetTradePressure returns the buy/sell notional breakdown behind ComputeTradePressure
+func (m *Manager) GetTradePressure(instID string, windowSeconds int) (*TradePressureData, error) {
+	m.mu.RLock()
+	maxWindow := m.tradeWindowSeconds
+	m.mu.RUnlock()
+	if windowSeconds <= 0 || windowSeconds > maxWindow {
+		windowSeconds = maxWindow
+	}
+
+	m.windowsMu.Lock()
+	window := m.tradeWindows[instID]
+	m.windowsMu.Unlock()
+	if window == nil {
+		return nil, fmt.Errorf("no trades for %s: %w", instID, ErrInsufficientData)
+	}
+
+	now := m.now().Unix()
+	data := &TradePressureData{
+		WindowSeconds: windowSeconds,
+		Readiness:     window.Coverage(int64(windowSeconds)),
+		Timestamp:     now,
+	}
+	cutoff := now - int64(windowSeconds)
+	for _, item := range window.GetItems() {
+		trade, ok := item.(*TradeWindowItem)
+		if !ok || trade.Timestamp < cutoff {
+			continue
+		}
+		if trade.IsBuy {
+			data.BuyNotional += trade.Notional
+		} else {
+			data.SellNotional += trade.Notional
+		}
+		data.Trades++
+	}
+
+	total := data.BuyNotional + data.SellNotional
+	if total == 0 {
+		return nil, fmt.Errorf("no trades for %s in the last %ds: %w", instID, windowSeconds, ErrInsufficientData)
+	}
+	data.BuyRatio = data.BuyNotional / total
+	return data, nil
+}
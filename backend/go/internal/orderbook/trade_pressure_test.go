@@ -0,0 +1,80 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// pushTrades pushes one trades message; each trade is "side px sz"
+func pushTrades(t *testing.T, m *Manager, instID string, trades ...string) {
+	t.Helper()
+
+	var data []string
+	for _, trade := range trades {
+		var side, px, sz string
+		fmt.Sscan(trade, &side, &px, &sz)
+		data = append(data, fmt.Sprintf(`{"instId":%q,"px":%q,"sz":%q,"side":%q,"ts":"1717000000000"}`, instID, px, sz, side))
+	}
+	msg := fmt.Sprintf(`{"arg":{"channel":%q,"instId":%q},"data":[%s]}`, config.TradesChannel, instID, strings.Join(data, ","))
+	if err := m.ProcessMessage([]byte(msg)); err != nil {
+		t.Fatalf("process trades: %v", err)
+	}
+}
+
+func TestTradePressureFromMixedSides(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	m := NewManagerWithClock(clock.Now)
+	m.SetTradePressureWindow(60)
+
+	// 40s ago: 3000 bought, 1000 sold
+	pushTrades(t, m, "ETH-USDT", "buy 3000 1", "sell 2000 0.5")
+	clock.t = clock.t.Add(30 * time.Second)
+	// 10s ago: 600 bought, 1000 sold
+	pushTrades(t, m, "ETH-USDT", "sell 2000 0.2", "buy 3000 0.2", "sell 3000 0.2")
+	clock.t = clock.t.Add(10 * time.Second)
+
+	for _, tt := range []struct {
+		window int
+		want   float64
+	}{
+		{60, 3600.0 / 5600},
+		{0, 3600.0 / 5600},   // the configured window
+		{600, 3600.0 / 5600}, // capped at the configured window
+		{20, 600.0 / 1600},
+	} {
+		ratio, err := m.ComputeTradePressure("ETH-USDT", tt.window)
+		if err != nil || math.Abs(ratio-tt.want) > 1e-9 {
+			t.Errorf("window %ds: buy ratio %v (%v), want %v", tt.window, ratio, err, tt.want)
+		}
+	}
+
+	data, err := m.GetTradePressure("ETH-USDT", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Trades != 5 || data.BuyNotional != 3600 || data.SellNotional != 2000 {
+		t.Fatalf("pressure %+v, want 5 trades, 3600 bought, 2000 sold", data)
+	}
+	if fields := data.ToRedisMap(); fields["buy_ratio"] != data.BuyRatio || fields["trades"] != 5 {
+		t.Fatalf("redis fields %v", fields)
+	}
+
+	// Only buys, then nothing once the trades age out
+	pushTrades(t, m, "BTC-USDT", "buy 60000 0.1")
+	if ratio, _ := m.ComputeTradePressure("BTC-USDT", 60); ratio != 1 {
+		t.Fatalf("buy-only ratio %v, want 1", ratio)
+	}
+	clock.t = clock.t.Add(2 * time.Minute)
+	if _, err := m.ComputeTradePressure("ETH-USDT", 60); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("expired trades: err %v, want ErrInsufficientData", err)
+	}
+	if _, err := m.ComputeTradePressure("SOL-USDT", 60); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("no trades: err %v, want ErrInsufficientData", err)
+	}
+}
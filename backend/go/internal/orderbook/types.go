@@ -11,6 +11,11 @@ type OrderBook struct {
 	Asks         []PriceLevel // sorted ascending by price
 	Bids         []PriceLevel // sorted descending by price
 	Checksum     int32
+	SeqID        int64  // seqId of the last applied message, used to detect gaps
+	UpdatedAt    int64  // local receive time of the last snapshot/update (ms)
+	ChecksumOK   bool   // result of the last checksum verification, always true without a checksum
+	Channel      string // books channel the book is fed from
+	HasChecksum  bool   // whether Channel carries a checksum, see ChannelHasChecksum
 }
 
 // PriceLevel represents a single price level with price and size
@@ -58,11 +63,13 @@ type ArgData struct {
 
 // BookData represents the order book data
 type BookData struct {
-	Asks      [][]string `json:"asks"`
-	Bids      [][]string `json:"bids"`
-	Timestamp string     `json:"ts"`
-	Checksum  int32      `json:"checksum"`
-	InstID    string     `json:"instId"`
+	Asks      [][]string    `json:"asks"`
+	Bids      [][]string    `json:"bids"`
+	Timestamp string        `json:"ts"`
+	Checksum  ChecksumValue `json:"checksum"` // signed CRC32, see ChecksumValue
+	InstID    string        `json:"instId"`
+	SeqID     int64         `json:"seqId"`
+	PrevSeqID int64         `json:"prevSeqId"` // seqId of the previous message, -1 for snapshots
 }
 
 // PriceLevelWithTime represents a price level with timestamp for sliding window calculations
@@ -87,6 +94,7 @@ type DepthAnomalyData struct {
 	Timestamp int64   `json:"timestamp"`
 	Direction string  `json:"direction"` // "increase" or "decrease"
 	Intensity float64 `json:"intensity"`
+	Readiness float64 `json:"readiness"` // 0..1, how much of the window is filled
 }
 
 // DepthWindowItem represents an item in the depth sliding window
@@ -100,14 +108,19 @@ type SupportResistanceData struct {
 	Supports    []float64 `json:"supports"`
 	Resistances []float64 `json:"resistances"`
 	Spread      float64   `json:"spread"`
-	Timestamp   int64     `json:"timestamp"`
+	DeepMid     float64   `json:"deep_mid"` // depth-weighted mid the minimum distance is anchored on
+	// Significant is false when no bin cleared the notional floor, Supports and Resistances are then empty
+	Significant bool  `json:"significant"`
+	Timestamp   int64 `json:"timestamp"`
 }
 
 // ToRedisMap converts SupportResistanceData to a map for Redis storage
 func (s SupportResistanceData) ToRedisMap() map[string]interface{} {
 	fields := map[string]interface{}{
-		"timestamp": s.Timestamp,
-		"spread":    s.Spread,
+		"timestamp":   s.Timestamp,
+		"spread":      s.Spread,
+		"deep_mid":    s.DeepMid,
+		"significant": s.Significant,
 	}
 
 	if len(s.Supports) > 0 {
@@ -123,6 +136,14 @@ func (s SupportResistanceData) ToRedisMap() map[string]interface{} {
 		fields["resistance_low"] = s.Resistances[1]
 	}
 
+	// All levels as JSON arrays, for consumers wanting more than two
+	if data, err := json.Marshal(append([]float64{}, s.Supports...)); err == nil {
+		fields["supports"] = string(data)
+	}
+	if data, err := json.Marshal(append([]float64{}, s.Resistances...)); err == nil {
+		fields["resistances"] = string(data)
+	}
+
 	return fields
 }
 
@@ -132,23 +153,35 @@ type SupportResistanceWindowItem struct {
 	Timestamp int64
 }
 
+// Mid price modes used to center the near-price range of liquidity metrics
+const (
+	MidPriceSimple = "simple" // (bestBid + bestAsk) / 2
+	MidPriceMicro  = "micro"  // size-weighted micro-price, leans towards the thinner side
+)
+
 // LiquidityMetrics represents the liquidity metrics at a point in time
 type LiquidityMetrics struct {
-	Spread    float64 `json:"spread"`
-	Depth     float64 `json:"depth"`
-	Liquidity float64 `json:"liquidity"`
-	Timestamp int64   `json:"timestamp"`
+	Spread       float64 `json:"spread"`
+	Depth        float64 `json:"depth"`
+	Liquidity    float64 `json:"liquidity"`
+	MidPrice     float64 `json:"mid_price"`      // center of the near-price range
+	MidPriceMode string  `json:"mid_price_mode"` // MidPriceSimple or MidPriceMicro
+	Timestamp    int64   `json:"timestamp"`
 }
 
 // LiquidityShrinkData represents the liquidity shrinkage warning result
 type LiquidityShrinkData struct {
-	Warning      bool    `json:"warning"`
-	WarningLevel string  `json:"warning_level"` // "none", "light", "moderate", "severe"
-	Liquidity    float64 `json:"liquidity"`
-	Spread       float64 `json:"spread"`
-	Depth        float64 `json:"depth"`
-	Slope        float64 `json:"slope"`
-	Timestamp    int64   `json:"timestamp"`
+	Warning         bool    `json:"warning"`
+	WarningLevel    string  `json:"warning_level"` // "none", "light", "moderate", "severe", after hysteresis
+	RawLevel        string  `json:"raw_level"`     // level from this evaluation alone
+	Liquidity       float64 `json:"liquidity"`
+	Spread          float64 `json:"spread"`
+	Depth           float64 `json:"depth"`
+	Slope           float64 `json:"slope"`            // liquidity units per second
+	NormalizedSlope float64 `json:"normalized_slope"` // % of mean liquidity per minute, compared against the threshold
+	MidPriceMode    string  `json:"mid_price_mode"`
+	Readiness       float64 `json:"readiness"` // 0..1, how much of the long window is filled
+	Timestamp       int64   `json:"timestamp"`
 }
 
 // LiquidityWindowItem represents an item in the liquidity sliding window
@@ -0,0 +1,156 @@
+package orderbook
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// priceBins holds the book's notional accumulated into equal-width price bins
+// spanning the lowest to the highest price level
+type priceBins struct {
+	MinPrice    float64
+	BinWidth    float64
+	BidNotional []float64
+	AskNotional []float64
+}
+
+// center returns the center price of bin idx
+func (b *priceBins) center(idx int) float64 {
+	return b.MinPrice + (float64(idx)+0.5)*b.BinWidth
+}
+
+// binBook divides the book's price range into binCount bins and accumulates
+// per-bin notional (price * size) separately for bids and asks
+func binBook(instID string, asks, bids []PriceLevel, binCount int) (*priceBins, error) {
+	// Determine price range from bids and asks
+	minPrice := 0.0
+	maxPrice := 0.0
+	first := true
+
+	updateRange := func(levels []PriceLevel) {
+		for _, lvl := range levels {
+			p, err := strconv.ParseFloat(lvl.Price, 64)
+			if err != nil {
+				continue
+			}
+			if first {
+				minPrice, maxPrice = p, p
+				first = false
+			} else {
+				if p < minPrice {
+					minPrice = p
+				}
+				if p > maxPrice {
+					maxPrice = p
+				}
+			}
+		}
+	}
+
+	updateRange(bids)
+	updateRange(asks)
+
+	if first || maxPrice <= minPrice {
+		return nil, fmt.Errorf("invalid price range for %s", instID)
+	}
+
+	binWidth := (maxPrice - minPrice) / float64(binCount)
+	if binWidth <= 0 {
+		return nil, fmt.Errorf("invalid bin width for %s", instID)
+	}
+
+	bins := &priceBins{
+		MinPrice:    minPrice,
+		BinWidth:    binWidth,
+		BidNotional: make([]float64, binCount),
+		AskNotional: make([]float64, binCount),
+	}
+
+	// Accumulate notional by bin for bids and asks
+	accumulate := func(levels []PriceLevel, vols []float64) {
+		for _, lvl := range levels {
+			p, err1 := strconv.ParseFloat(lvl.Price, 64)
+			q, err2 := strconv.ParseFloat(lvl.Size, 64)
+			if err1 != nil || err2 != nil || q <= 0 {
+				continue
+			}
+			notional := p * q
+			idx := int((p - minPrice) / binWidth)
+			if idx < 0 {
+				idx = 0
+			}
+			if idx >= binCount {
+				idx = binCount - 1
+			}
+			vols[idx] += notional
+		}
+	}
+
+	accumulate(bids, bins.BidNotional)
+	accumulate(asks, bins.AskNotional)
+
+	return bins, nil
+}
+
+// VolumeProfileBin is a single price bin of the volume profile
+type VolumeProfileBin struct {
+	PriceCenter float64 `json:"price_center"`
+	BidNotional float64 `json:"bid_notional"`
+	AskNotional float64 `json:"ask_notional"`
+}
+
+// VolumeProfile is the resting notional of the book by price (price-by-volume)
+type VolumeProfile struct {
+	InstrumentID string             `json:"instrument_id"`
+	BinWidth     float64            `json:"bin_width"`
+	Bins         []VolumeProfileBin `json:"bins"` // ascending by price
+	POCIndex     int                `json:"poc_index"`
+	POCPrice     float64            `json:"poc_price"` // center of the bin with the most bid+ask notional
+	Timestamp    int64              `json:"timestamp"`
+}
+
+// ComputeVolumeProfile returns the full per-bin bid/ask notional profile of the book and
+// its point of control (the bin with the largest total notional), e.g. for a heatmap.
+// 计算订单簿的价格-成交量分布（挂单名义价值）及控制点（POC）
+func (m *Manager) ComputeVolumeProfile(instID string, binCount int) (*VolumeProfile, error) {
+	asks, bids, err := m.GetTop400(instID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(asks) == 0 && len(bids) == 0 {
+		return nil, fmt.Errorf("empty order book for %s", instID)
+	}
+
+	if binCount <= 0 {
+		binCount = 50
+	}
+
+	bins, err := binBook(instID, asks, bids, binCount)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &VolumeProfile{
+		InstrumentID: instID,
+		BinWidth:     bins.BinWidth,
+		Bins:         make([]VolumeProfileBin, binCount),
+		Timestamp:    m.now().Unix(),
+	}
+
+	maxTotal := -1.0
+	for i := 0; i < binCount; i++ {
+		profile.Bins[i] = VolumeProfileBin{
+			PriceCenter: bins.center(i),
+			BidNotional: bins.BidNotional[i],
+			AskNotional: bins.AskNotional[i],
+		}
+		if total := bins.BidNotional[i] + bins.AskNotional[i]; total > maxTotal {
+			maxTotal = total
+			profile.POCIndex = i
+		}
+	}
+	profile.POCPrice = profile.Bins[profile.POCIndex].PriceCenter
+
+	return profile, nil
+}
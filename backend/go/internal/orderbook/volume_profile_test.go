@@ -0,0 +1,66 @@
+package orderbook
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestVolumeProfilePOCIsTheLargestWall(t *testing.T) {
+	// Flat book from 900 to 1100 with one bid wall at 950 and a smaller ask wall at 1060
+	var asks, bids [][2]string
+	totalBid, totalAsk := 0.0, 0.0
+	for p := 1; p <= 100; p++ {
+		askPrice, bidPrice := 1000+p, 1000-p
+		askSize, bidSize := 2.0, 2.0
+		if bidPrice == 950 {
+			bidSize = 400
+		}
+		if askPrice == 1060 {
+			askSize = 150
+		}
+		asks = append(asks, [2]string{strconv.Itoa(askPrice), strconv.FormatFloat(askSize, 'f', -1, 64)})
+		bids = append(bids, [2]string{strconv.Itoa(bidPrice), strconv.FormatFloat(bidSize, 'f', -1, 64)})
+		totalAsk += float64(askPrice) * askSize
+		totalBid += float64(bidPrice) * bidSize
+	}
+	m := NewManager()
+	loadSnapshot(t, m, "ETH-USDT", asks, bids)
+
+	profile, err := m.ComputeVolumeProfile("ETH-USDT", 40)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if len(profile.Bins) != 40 || profile.BinWidth != 5 {
+		t.Fatalf("%d bins of width %v, want 40 of width 5", len(profile.Bins), profile.BinWidth)
+	}
+
+	poc := profile.Bins[profile.POCIndex]
+	if low, high := poc.PriceCenter-profile.BinWidth/2, poc.PriceCenter+profile.BinWidth/2; 950 < low || 950 >= high {
+		t.Fatalf("POC bin [%v, %v) does not hold the 950 bid wall", low, high)
+	}
+	if profile.POCPrice != poc.PriceCenter || poc.BidNotional < 950*400 || poc.AskNotional != 0 {
+		t.Fatalf("POC %+v at %v, want the bid wall", poc, profile.POCPrice)
+	}
+
+	sumBid, sumAsk := 0.0, 0.0
+	for i, bin := range profile.Bins {
+		if i > 0 && bin.PriceCenter <= profile.Bins[i-1].PriceCenter {
+			t.Fatalf("bins not ascending at %d", i)
+		}
+		sumBid += bin.BidNotional
+		sumAsk += bin.AskNotional
+	}
+	if math.Abs(sumBid-totalBid) > 1e-6 || math.Abs(sumAsk-totalAsk) > 1e-6 {
+		t.Fatalf("profile holds %v bid / %v ask notional, the book %v / %v", sumBid, sumAsk, totalBid, totalAsk)
+	}
+
+	// Support/resistance bins the same way, so its strongest support is the same wall
+	supports, _, _, err := m.ComputeSupportResistance("ETH-USDT", 40, 1.5, 1, 0, 0)
+	if err != nil {
+		t.Fatalf("support/resistance: %v", err)
+	}
+	if len(supports) != 1 || supports[0] != poc.PriceCenter {
+		t.Fatalf("support %v, want the POC %v", supports, poc.PriceCenter)
+	}
+}
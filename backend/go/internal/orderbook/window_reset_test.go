@@ -0,0 +1,102 @@
+package orderbook
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// rangeBook loads a 20 level book around 500 whose spread is gap and whose sizes scale with size
+func rangeBook(t *testing.T, m *Manager, instID string, gap, size float64) {
+	t.Helper()
+
+	var asks, bids [][2]string
+	for i := 0; i < 20; i++ {
+		sz := strconv.FormatFloat(size*float64(1+i%4), 'f', -1, 64)
+		asks = append(asks, [2]string{strconv.FormatFloat(500+gap/2+float64(i), 'f', -1, 64), sz})
+		bids = append(bids, [2]string{strconv.FormatFloat(500-gap/2-float64(i), 'f', -1, 64), sz})
+	}
+	loadSnapshot(t, m, instID, asks, bids)
+}
+
+func TestResetWindowsMakesAnalysesStartFresh(t *testing.T) {
+	clock := &testClock{t: time.Unix(1717000000, 0)}
+	cfg := config.LoadFromEnv().Analysis
+	cfg.BookMaxAgeSec = 0
+
+	// Ten minutes of a wide, deep book on two instruments
+	history := func(m *Manager) {
+		for tick := 0; tick < 30; tick++ {
+			for _, instID := range []string{"BTC-USDT", "ETH-USDT"} {
+				rangeBook(t, m, instID, 8+float64(tick%3), 40)
+				if _, err := m.ComputeAll(instID, cfg); err != nil {
+					t.Fatalf("tick %d: %v", tick, err)
+				}
+			}
+			clock.t = clock.t.Add(20 * time.Second)
+		}
+	}
+	start := clock.t
+	m := NewManagerWithClock(clock.Now)
+	history(m)
+	end := clock.t
+	clock.t = start
+	kept := NewManagerWithClock(clock.Now)
+	history(kept)
+
+	ethBefore, err := m.ComputeAll("ETH-USDT", cfg)
+	if err != nil {
+		t.Fatalf("ETH before reset: %v", err)
+	}
+
+	// Resync: the book is dropped, the history reset and a tight, thin snapshot arrives
+	m.MarkAwaitingSnapshot([]string{"BTC-USDT"})
+	m.ResetWindows("BTC-USDT")
+	rangeBook(t, m, "BTC-USDT", 1, 2)
+	rangeBook(t, kept, "BTC-USDT", 1, 2)
+	after, err := m.ComputeAll("BTC-USDT", cfg)
+	if err != nil {
+		t.Fatalf("after reset: %v", err)
+	}
+
+	fresh := NewManagerWithClock(clock.Now)
+	rangeBook(t, fresh, "BTC-USDT", 1, 2)
+	want, err := fresh.ComputeAll("BTC-USDT", cfg)
+	if err != nil {
+		t.Fatalf("fresh manager: %v", err)
+	}
+
+	if clock.t != end {
+		t.Fatalf("clock at %v, want %v", clock.t, end)
+	}
+	for name, pair := range map[string][2]interface{}{
+		AnalysisSpreadZScore:      {after.SpreadZScore, want.SpreadZScore},
+		AnalysisDepthAnomaly:      {after.DepthAnomaly, want.DepthAnomaly},
+		AnalysisLiquidityShrink:   {after.LiquidityShrink, want.LiquidityShrink},
+		AnalysisLargeOrder:        {after.LargeOrder, want.LargeOrder},
+		AnalysisSupportResistance: {after.SupportResistance, want.SupportResistance},
+	} {
+		if !reflect.DeepEqual(pair[0], pair[1]) {
+			t.Errorf("%s after reset %+v, fresh manager %+v", name, pair[0], pair[1])
+		}
+	}
+	// One spread sample is not enough for a z-score, as on a fresh start
+	if err := after.Errors[AnalysisSpreadZScore]; err == nil || err.Error() != want.Errors[AnalysisSpreadZScore].Error() {
+		t.Fatalf("spread z-score after reset: %+v, err %v; want %v", after.SpreadZScore, err, want.Errors[AnalysisSpreadZScore])
+	}
+
+	// Without the reset the tight spread is an outlier against the old history
+	if stale, err := kept.ComputeAll("BTC-USDT", cfg); err != nil || stale.SpreadZScore.ZScore > -1 {
+		t.Fatalf("without reset: z-score %+v err %v, want far below the history", stale.SpreadZScore, err)
+	}
+
+	// Other instruments keep their history
+	ethAfter, err := m.ComputeAll("ETH-USDT", cfg)
+	if err != nil || ethAfter.SpreadZScore.Readiness < ethBefore.SpreadZScore.Readiness {
+		t.Fatalf("ETH readiness %v after BTC reset, was %v (err %v)",
+			ethAfter.SpreadZScore.Readiness, ethBefore.SpreadZScore.Readiness, err)
+	}
+}
@@ -0,0 +1,56 @@
+package orderbook
+
+import "sync"
+
+// defaultAnalysisWorkers is the pool size used when none is configured
+const defaultAnalysisWorkers = 10
+
+// analysisJob is one unit of work, e.g. computing an instrument's snapshot or storing one analysis
+type analysisJob struct {
+	run  func()
+	done *sync.WaitGroup
+}
+
+// AnalysisPool runs analysis jobs on a fixed set of goroutines that are reused across
+// ticks, instead of spawning goroutines per instrument and analysis every cycle.
+// 固定大小的分析协程池
+type AnalysisPool struct {
+	jobs    chan analysisJob
+	workers sync.WaitGroup
+}
+
+// NewAnalysisPool starts a pool of size workers; size <= 0 uses defaultAnalysisWorkers
+func NewAnalysisPool(size int) *AnalysisPool {
+	if size <= 0 {
+		size = defaultAnalysisWorkers
+	}
+
+	p := &AnalysisPool{jobs: make(chan analysisJob, size)}
+	p.workers.Add(size)
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// work runs queued jobs until the pool is closed
+func (p *AnalysisPool) work() {
+	defer p.workers.Done()
+	for job := range p.jobs {
+		job.run()
+		job.done.Done()
+	}
+}
+
+// Submit queues fn and marks it on done, which the caller waits on.
+// Blocks while every worker is busy and the queue is full; must not be called from a job.
+func (p *AnalysisPool) Submit(done *sync.WaitGroup, fn func()) {
+	done.Add(1)
+	p.jobs <- analysisJob{run: fn, done: done}
+}
+
+// Close stops the workers after the queued jobs have run. Submit must not be called afterwards.
+func (p *AnalysisPool) Close() {
+	close(p.jobs)
+	p.workers.Wait()
+}
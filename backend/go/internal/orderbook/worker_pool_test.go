@@ -0,0 +1,185 @@
+package orderbook
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/testutil"
+)
+
+// peakConcurrency submits jobs instruments to a pool of size workers and returns the
+// highest number that ran at the same time
+func peakConcurrency(size, jobs int) int32 {
+	pool := NewAnalysisPool(size)
+	defer pool.Close()
+
+	var running, peak int32
+	var done sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		pool.Submit(&done, func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	done.Wait()
+	return peak
+}
+
+func TestPoolLimitsConcurrentInstruments(t *testing.T) {
+	for _, tt := range []struct{ size, want int }{{1, 1}, {3, 3}, {0, defaultAnalysisWorkers}, {-4, defaultAnalysisWorkers}} {
+		t.Run(fmt.Sprintf("size=%d", tt.size), func(t *testing.T) {
+			peak := peakConcurrency(tt.size, 5*tt.want)
+			if int(peak) > tt.want {
+				t.Fatalf("%d jobs ran at once, limit %d", peak, tt.want)
+			}
+			if int(peak) < tt.want {
+				t.Logf("peak %d below the limit %d; the pool was never saturated", peak, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolSizeFromEnv(t *testing.T) {
+	tests := []struct {
+		current, former string
+		want            int
+	}{
+		{"", "", 10},
+		{"", "4", 4},
+		{"32", "4", 32},
+	}
+	for _, tt := range tests {
+		t.Setenv("ANALYSIS_WORKER_POOL_SIZE", tt.current)
+		t.Setenv("ANALYSIS_MAX_CONCURRENT_INSTRUMENTS", tt.former)
+		if got := config.LoadFromEnv().Analysis.WorkerPoolSize; got != tt.want {
+			t.Errorf("ANALYSIS_WORKER_POOL_SIZE=%q ANALYSIS_MAX_CONCURRENT_INSTRUMENTS=%q: size %d, want %d",
+				tt.current, tt.former, got, tt.want)
+		}
+	}
+}
+
+// cycleFixture loads n deep books on a manager whose clock stays at the books' timestamp.
+// The analyses without trade or spread history fail every tick, so their logs are dropped.
+func cycleFixture(tb testing.TB, n int) (*Manager, []string, config.AppConfig) {
+	previous := log.Writer()
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(previous) })
+
+	m := NewManagerWithClock((&testClock{t: time.UnixMilli(1717000000000)}).Now)
+	instIDs := make([]string, n)
+	for i := range instIDs {
+		instIDs[i] = fmt.Sprintf("INST%d-USDT", i)
+		deepSnapshot(tb, m, instIDs[i])
+	}
+	cfg := config.LoadFromEnv()
+	cfg.Analysis.BookMaxAgeSec = 0
+	return m, instIDs, cfg
+}
+
+// neverOpen is a breaker that keeps failing analyses running, so every tick does the same work
+func neverOpen() *CircuitBreaker {
+	return NewCircuitBreaker(math.MaxInt32, 0)
+}
+
+func TestEveryAnalysisRunsEachTick(t *testing.T) {
+	m, instIDs, cfg := cycleFixture(t, 4)
+	var enabled []string
+	for _, name := range []string{AnalysisSupportResistance, AnalysisSpreadZScore, AnalysisLargeOrder,
+		AnalysisDepthAnomaly, AnalysisLiquidityShrink, AnalysisIceberg, AnalysisOFI,
+		AnalysisTradePressure, AnalysisImbalanceFlip} {
+		if AnalysisEnabled(cfg.Analysis, name) {
+			enabled = append(enabled, name)
+		}
+	}
+
+	// Two workers for 4 instruments and dozens of storage jobs per tick
+	pool := NewAnalysisPool(2)
+	defer pool.Close()
+	breaker := neverOpen()
+	store := testutil.NewRecordingStore()
+
+	for tick := 1; tick <= 6; tick++ {
+		store.Reset()
+		runCycle(instIDs, m, store, cfg, breaker, pool)
+
+		for _, instID := range instIDs {
+			ran := make(map[string]int)
+			for _, call := range store.Calls() {
+				if call.InstID == instID && call.Method == "StoreAnalysisStatus" {
+					ran[call.Args[0].(string)]++
+				}
+			}
+			for _, name := range enabled {
+				if ran[name] != 1 {
+					t.Errorf("tick %d: %s ran %d times for %s, want once", tick, name, ran[name], instID)
+				}
+			}
+			if n := store.Methods(instID)["StoreOrderBookSnapshot"]; n != 1 {
+				t.Errorf("tick %d: book of %s stored %d times, want once", tick, instID, n)
+			}
+		}
+	}
+}
+
+// spawnCycle is the former model, one goroutine per instrument and per storage job
+func spawnCycle(instIDs []string, m *Manager, store *testutil.RecordingStore, cfg config.AppConfig, breaker *CircuitBreaker) {
+	var wg sync.WaitGroup
+	for _, instID := range instIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var jobs sync.WaitGroup
+			for _, job := range instrumentJobs(instID, m, store, cfg, breaker) {
+				jobs.Add(1)
+				go func() {
+					defer jobs.Done()
+					job()
+				}()
+			}
+			jobs.Wait()
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkCycleGoroutinePerJob(b *testing.B) {
+	m, instIDs, cfg := cycleFixture(b, 10)
+	breaker := neverOpen()
+	store := testutil.NewRecordingStore()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		spawnCycle(instIDs, m, store, cfg, breaker)
+		store.Reset()
+	}
+}
+
+func BenchmarkCycleWorkerPool(b *testing.B) {
+	m, instIDs, cfg := cycleFixture(b, 10)
+	breaker := neverOpen()
+	store := testutil.NewRecordingStore()
+	pool := NewAnalysisPool(defaultAnalysisWorkers)
+	defer pool.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runCycle(instIDs, m, store, cfg, breaker, pool)
+		store.Reset()
+	}
+}
@@ -0,0 +1,49 @@
+package redisclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// SaveAnalysisConfig stores the analysis config as JSON, see LoadAnalysisConfig
+func (c *Client) SaveAnalysisConfig(cfg config.AnalysisConfig) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal analysis config: %w", err)
+	}
+
+	key := c.prefixed(config.AnalysisConfigKey)
+	if err := c.withRetry(func(ctx context.Context) error {
+		return c.rdb.Set(ctx, key, data, 0).Err()
+	}); err != nil {
+		return fmt.Errorf("failed to save analysis config: %w", err)
+	}
+	return nil
+}
+
+// LoadAnalysisConfig returns base overlaid with the analysis config saved by
+// SaveAnalysisConfig, so fields added since it was saved keep their base value.
+// found is false when nothing was saved.
+func (c *Client) LoadAnalysisConfig(base config.AnalysisConfig) (cfg config.AnalysisConfig, found bool, err error) {
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	data, err := c.rdb.Get(ctx, c.prefixed(config.AnalysisConfigKey)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return base, false, nil
+	}
+	if err != nil {
+		return base, false, fmt.Errorf("failed to load analysis config: %w", err)
+	}
+
+	cfg = base
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return base, false, fmt.Errorf("failed to decode analysis config: %w", err)
+	}
+	return cfg, true, nil
+}
@@ -0,0 +1,56 @@
+package redisclient
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// analysisStatusTarget is the hash an analysis stores its results in and the prefix of its
+// status fields, needed where several analyses share a hash
+type analysisStatusTarget struct {
+	keyFormat   string
+	fieldPrefix string
+}
+
+// analysisStatusTargets maps the processor's analysis names (see orderbook.Analysis*) to their
+// result hash. Analyses stored in lists, such as the imbalance flip events, have no status.
+var analysisStatusTargets = map[string]analysisStatusTarget{
+	"support_resistance": {config.SupportResistanceKey, ""},
+	"spread_zscore":      {config.SupportResistanceKey, "spread_zscore_"},
+	"large_order":        {config.SentimentKey, ""},
+	"depth_anomaly":      {config.DepthAnomalyKey, ""},
+	"liquidity_shrink":   {config.LiquidityShrinkKey, ""},
+	"iceberg":            {config.IcebergKey, ""},
+	"ofi":                {config.OFIKey, ""},
+	"trade_pressure":     {config.TradePressureKey, ""},
+}
+
+// StoreAnalysisStatus records the outcome of an analysis run in its result hash, so that
+// consumers can tell a value that is stale because the analysis failed from one that was
+// recomputed: last_success_ts (unix seconds) on success, last_error and last_error_ts on
+// failure. last_error is cleared on success. No-op for analyses without a result hash.
+func (c *Client) StoreAnalysisStatus(instID, analysis string, lastErr error) error {
+	target, ok := analysisStatusTargets[analysis]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	fields := map[string]interface{}{
+		target.fieldPrefix + "last_error": "",
+	}
+	if lastErr != nil {
+		fields[target.fieldPrefix+"last_error"] = lastErr.Error()
+		fields[target.fieldPrefix+"last_error_ts"] = now
+	} else {
+		fields[target.fieldPrefix+"last_success_ts"] = now
+	}
+
+	if err := c.hset(c.key(target.keyFormat, instID), fields); err != nil {
+		return fmt.Errorf("failed to store %s status: %w", analysis, err)
+	}
+
+	return nil
+}
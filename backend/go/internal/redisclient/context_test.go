@@ -0,0 +1,62 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newMiniClient returns a Client connected to an in-memory miniredis server
+func newMiniClient(t *testing.T) (*Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client, err := NewClient(server.Addr(), "")
+	if err != nil {
+		t.Fatalf("connect to miniredis: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func TestCanceledContextWritesNothing(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := client.WithContext(ctx).StoreOrderBookSnapshot("BTC-USDT", [][]string{{"100", "1"}}, nil, 7)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if server.Exists("orderbook:BTC-USDT") {
+		t.Fatal("canceled store wrote the snapshot")
+	}
+
+	// The parent client is unaffected by the canceled copy
+	if err := client.StoreOrderBookSnapshot("BTC-USDT", [][]string{{"100", "1"}}, nil, 7); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if got := server.HGet("orderbook:BTC-USDT", "checksum"); got != "7" {
+		t.Fatalf("stored checksum = %q, want 7", got)
+	}
+}
+
+func TestCancelAbortsRetryBackoff(t *testing.T) {
+	client, server := newMiniClient(t)
+	client.SetRetryPolicy(5, time.Minute)
+	server.SetError("LOADING Redis is loading the dataset in memory")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := client.WithContext(ctx).StoreOFI("BTC-USDT", map[string]interface{}{"ofi": 1.5}); err == nil {
+		t.Fatal("store succeeded against a failing server")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("store returned after %v, want it aborted by the cancel", elapsed)
+	}
+}
@@ -0,0 +1,130 @@
+package redisclient
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Default retry policy for store operations, see SetRetryPolicy
+const (
+	defaultMaxRetries     = 3
+	defaultRetryBaseDelay = 100 * time.Millisecond
+	defaultOpTimeout      = 3 * time.Second
+)
+
+// IsHealthy reports the result of the latest health check (true until the first failure)
+func (c *Client) IsHealthy() bool {
+	return atomic.LoadInt32(c.healthy) == 1
+}
+
+// SetRetryPolicy configures how many times a failed write is retried and the initial
+// backoff delay, which doubles on every attempt
+func (c *Client) SetRetryPolicy(maxRetries int, baseDelay time.Duration) {
+	if maxRetries >= 0 {
+		c.maxRetries = maxRetries
+	}
+	if baseDelay > 0 {
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// SetOpTimeout bounds every Redis round trip, each retry attempt getting its own timeout;
+// 0 leaves operations bounded only by the client's context, see WithContext
+func (c *Client) SetOpTimeout(timeout time.Duration) {
+	if timeout >= 0 {
+		c.opTimeout = timeout
+	}
+}
+
+// WithContext returns a copy of the client whose operations are canceled with ctx, still
+// bounded by the op timeout. The copy shares the connection pool and health status; retry
+// and history settings are those of c at the time of the call.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// opContext returns the context of a single Redis round trip
+func (c *Client) opContext() (context.Context, context.CancelFunc) {
+	if c.opTimeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+	return context.WithTimeout(c.ctx, c.opTimeout)
+}
+
+// StartHealthPinger pings Redis every interval until ctx is done, updating IsHealthy.
+// onChange is called whenever the health status flips, e.g. to update the HTTP health endpoint.
+// go-redis reconnects at the transport level, so a successful ping after an outage means
+// writes will resume.
+func (c *Client) StartHealthPinger(ctx context.Context, interval time.Duration, onChange func(healthy bool)) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pingCtx, cancel := context.WithTimeout(ctx, interval)
+				err := c.rdb.Ping(pingCtx).Err()
+				cancel()
+
+				c.setHealthy(err == nil, err, onChange)
+			}
+		}
+	}()
+}
+
+// setHealthy updates the health status and notifies onChange if it flipped
+func (c *Client) setHealthy(healthy bool, err error, onChange func(bool)) {
+	var value int32
+	if healthy {
+		value = 1
+	}
+
+	if atomic.SwapInt32(c.healthy, value) == value {
+		return
+	}
+
+	if healthy {
+		log.Println("Redis connection recovered")
+	} else {
+		log.Printf("Redis health check failed: %v", err)
+	}
+	if onChange != nil {
+		onChange(healthy)
+	}
+}
+
+// withRetry runs fn, retrying with exponential backoff while it fails. Each attempt gets
+// its own op timeout; retries stop once the client's context is done.
+func (c *Client) withRetry(fn func(ctx context.Context) error) error {
+	delay := c.retryBaseDelay
+
+	err := c.attempt(fn)
+	for attempt := 0; err != nil && attempt < c.maxRetries; attempt++ {
+		select {
+		case <-c.ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		err = c.attempt(fn)
+	}
+	return err
+}
+
+// attempt runs fn once with an op context
+func (c *Client) attempt(fn func(ctx context.Context) error) error {
+	ctx, cancel := c.opContext()
+	defer cancel()
+	return fn(ctx)
+}
@@ -0,0 +1,125 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newHungClient returns a Client on a server that accepts connections but never replies
+func newHungClient(t *testing.T) *Client {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	rdb := redis.NewClient(Options{Addr: listener.Addr().String()}.redisOptions())
+	t.Cleanup(func() { rdb.Close() })
+
+	healthy := int32(1)
+	return &Client{
+		rdb:            rdb,
+		ctx:            context.Background(),
+		healthy:        &healthy,
+		maxRetries:     defaultMaxRetries,
+		retryBaseDelay: time.Second,
+		opTimeout:      time.Minute,
+	}
+}
+
+func TestCanceledContextAbortsStore(t *testing.T) {
+	client := newHungClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := client.WithContext(ctx).StoreOrderBookSnapshot("BTC-USDT", nil, nil, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	// No retry may be scheduled once the context is canceled
+	if elapsed := time.Since(start); elapsed >= client.retryBaseDelay {
+		t.Fatalf("store returned after %v", elapsed)
+	}
+}
+
+func TestOpTimeoutBoundsHungCall(t *testing.T) {
+	client := newHungClient(t)
+	client.SetRetryPolicy(0, 0)
+	client.SetOpTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	err := client.StoreOrderBookSnapshot("BTC-USDT", nil, nil, 0)
+	if err == nil {
+		t.Fatal("store on a hung server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("store returned after %v, want about the 100ms op timeout", elapsed)
+	}
+}
+
+func TestHealthFlipsAndWritesResumeAfterOutage(t *testing.T) {
+	client, server := newMiniClient(t)
+	client.SetRetryPolicy(10, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan bool, 10)
+	client.StartHealthPinger(ctx, 10*time.Millisecond, func(healthy bool) { changes <- healthy })
+
+	waitHealth := func(want bool) {
+		t.Helper()
+		select {
+		case healthy := <-changes:
+			if healthy != want || client.IsHealthy() != want {
+				t.Fatalf("health flipped to %v (IsHealthy %v), want %v", healthy, client.IsHealthy(), want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("health never flipped to %v", want)
+		}
+	}
+
+	server.Close()
+	waitHealth(false)
+
+	// A write during the outage is retried with backoff and lands once Redis is back
+	stored := make(chan error, 1)
+	go func() {
+		stored <- client.StoreOFI("BTC-USDT", map[string]interface{}{"ofi": 2.5})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := server.Restart(); err != nil {
+		t.Fatalf("restart miniredis: %v", err)
+	}
+	waitHealth(true)
+
+	select {
+	case err := <-stored:
+		if err != nil {
+			t.Fatalf("store after recovery: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("store did not return after recovery")
+	}
+	if !server.Exists("analysis:ofi:BTC-USDT") {
+		keys := server.Keys()
+		t.Fatalf("OFI not written after recovery, keys %v", keys)
+	}
+}
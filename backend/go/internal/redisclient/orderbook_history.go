@@ -0,0 +1,97 @@
+package redisclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// OrderBookHistoryEntry is one retained order book snapshot, stored as a sorted set member
+type OrderBookHistoryEntry struct {
+	Timestamp int64           `json:"ts"` // book timestamp (ms), also the member's score
+	Snapshot  json.RawMessage `json:"snapshot"`
+}
+
+// SetOrderBookHistorySize sets how many snapshots AppendOrderBookHistory retains per
+// instrument; 0 (the default) disables the history
+func (c *Client) SetOrderBookHistorySize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	c.historySize = size
+}
+
+// OrderBookHistorySize returns the number of snapshots retained per instrument, 0 if disabled
+func (c *Client) OrderBookHistorySize() int {
+	return c.historySize
+}
+
+// AppendOrderBookHistory adds a snapshot to the instrument's history sorted set, scored by
+// ts (ms), and trims the set to the newest OrderBookHistorySize entries. The latest-snapshot
+// hash written by StoreOrderBookSnapshot is unaffected. No-op when the history is disabled.
+func (c *Client) AppendOrderBookHistory(instID string, snapshot interface{}, ts int64) error {
+	if c.historySize <= 0 {
+		return nil
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order book snapshot: %w", err)
+	}
+	member, err := json.Marshal(OrderBookHistoryEntry{Timestamp: ts, Snapshot: snapshotJSON})
+	if err != nil {
+		return fmt.Errorf("failed to marshal order book history entry: %w", err)
+	}
+
+	zsetKey := c.key(config.OrderBookHistoryKey, instID)
+	keep := int64(c.historySize)
+
+	err = c.withRetry(func(ctx context.Context) error {
+		pipe := c.rdb.TxPipeline()
+		pipe.ZAdd(ctx, zsetKey, redis.Z{Score: float64(ts), Member: string(member)})
+		// Ranks are ascending by score, so dropping 0..-(keep+1) keeps the newest entries
+		pipe.ZRemRangeByRank(ctx, zsetKey, 0, -keep-1)
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append order book history: %w", err)
+	}
+
+	return nil
+}
+
+// GetOrderBookHistory returns the retained snapshots of an instrument with fromTs <= ts <= toTs
+// (ms), oldest first. toTs <= 0 means no upper bound.
+func (c *Client) GetOrderBookHistory(instID string, fromTs, toTs int64) ([]OrderBookHistoryEntry, error) {
+	max := "+inf"
+	if toTs > 0 {
+		max = strconv.FormatInt(toTs, 10)
+	}
+
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	members, err := c.rdb.ZRangeByScore(ctx, c.key(config.OrderBookHistoryKey, instID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(fromTs, 10),
+		Max: max,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order book history: %w", err)
+	}
+
+	entries := make([]OrderBookHistoryEntry, 0, len(members))
+	for _, member := range members {
+		var entry OrderBookHistoryEntry
+		if err := json.Unmarshal([]byte(member), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode order book history entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,57 @@
+package redisclient
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderBookHistoryTrimsAndRanges(t *testing.T) {
+	client, server := newMiniClient(t)
+	client.SetOrderBookHistorySize(3)
+
+	for i := int64(1); i <= 5; i++ {
+		snapshot := map[string]interface{}{"bids": [][]string{{"100", "1"}}, "seq": i}
+		if err := client.AppendOrderBookHistory("BTC-USDT", snapshot, 1000*i); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	members, err := server.ZMembers("orderbook:history:BTC-USDT")
+	if err != nil {
+		t.Fatalf("read sorted set: %v", err)
+	}
+	if len(members) != 3 {
+		t.Fatalf("history holds %d entries, want 3", len(members))
+	}
+
+	all, err := client.GetOrderBookHistory("BTC-USDT", 0, 0)
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+	if len(all) != 3 || all[0].Timestamp != 3000 || all[2].Timestamp != 5000 {
+		t.Fatalf("retained %+v, want the newest 3 oldest first", all)
+	}
+
+	ranged, err := client.GetOrderBookHistory("BTC-USDT", 3500, 4000)
+	if err != nil {
+		t.Fatalf("get range: %v", err)
+	}
+	if len(ranged) != 1 || ranged[0].Timestamp != 4000 {
+		t.Fatalf("range 3500..4000 = %+v, want the 4000 entry", ranged)
+	}
+	var snapshot struct{ Seq int64 }
+	if err := json.Unmarshal(ranged[0].Snapshot, &snapshot); err != nil || snapshot.Seq != 4 {
+		t.Fatalf("snapshot = %s (%v), want seq 4", ranged[0].Snapshot, err)
+	}
+}
+
+func TestOrderBookHistoryDisabledByDefault(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	if err := client.AppendOrderBookHistory("BTC-USDT", map[string]int{"seq": 1}, 1000); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if server.Exists("orderbook:history:BTC-USDT") {
+		t.Fatal("history written with the default size of 0")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,16 +14,42 @@ import (
 // Client wraps Redis operations for the system
 type Client struct {
 	rdb *redis.Client
-	ctx context.Context
+	ctx context.Context // parent of every operation, see WithContext
+
+	healthy        *int32 // 1 if the latest health check succeeded, see StartHealthPinger; shared with WithContext copies
+	maxRetries     int
+	retryBaseDelay time.Duration
+	opTimeout      time.Duration // bound on each Redis round trip, see SetOpTimeout
+
+	keyPrefix string // namespace prepended to every key, e.g. "dev:"
+
+	historySize          int // snapshots retained per instrument by AppendOrderBookHistory, 0 = disabled
+	sentimentHistorySize int // values retained per instrument by AppendSentimentHistory, 0 = disabled
+}
+
+// Options configures the Redis connection; zero values keep go-redis defaults
+type Options struct {
+	Addr         string
+	Password     string
+	DB           int
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// KeyPrefix namespaces every key written or read by the client (default empty)
+	KeyPrefix string
 }
 
-// NewClient creates a new Redis client
+// NewClient creates a new Redis client on DB 0 with default pool settings
 func NewClient(addr, password string) (*Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       0,
-	})
+	return NewClientWithOptions(Options{Addr: addr, Password: password})
+}
+
+// NewClientWithOptions creates a new Redis client with the given DB index and pool settings
+func NewClientWithOptions(opts Options) (*Client, error) {
+	rdb := redis.NewClient(opts.redisOptions())
 
 	ctx := context.Background()
 
@@ -31,21 +58,78 @@ func NewClient(addr, password string) (*Client, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	healthy := int32(1)
 	return &Client{
-		rdb: rdb,
-		ctx: ctx,
+		rdb:            rdb,
+		ctx:            ctx,
+		healthy:        &healthy,
+		maxRetries:     defaultMaxRetries,
+		retryBaseDelay: defaultRetryBaseDelay,
+		opTimeout:      defaultOpTimeout,
+		keyPrefix:      opts.KeyPrefix,
 	}, nil
 }
 
+// KeyPrefix returns the namespace prepended to every key
+func (c *Client) KeyPrefix() string {
+	return c.keyPrefix
+}
+
+// prefixed prepends the configured key prefix
+func (c *Client) prefixed(key string) string {
+	return c.keyPrefix + key
+}
+
+// key builds a prefixed key from a config key format such as config.OrderBookKey
+func (c *Client) key(format string, instID string) string {
+	return c.keyPrefix + fmt.Sprintf(format, instID)
+}
+
+// redisOptions maps Options onto the underlying go-redis options
+func (o Options) redisOptions() *redis.Options {
+	return &redis.Options{
+		Addr:         o.Addr,
+		Password:     o.Password,
+		DB:           o.DB,
+		PoolSize:     o.PoolSize,
+		MinIdleConns: o.MinIdleConns,
+		DialTimeout:  o.DialTimeout,
+		ReadTimeout:  o.ReadTimeout,
+		WriteTimeout: o.WriteTimeout,
+
+		// Without it go-redis ignores context deadlines on the socket, so neither the op
+		// timeout nor WithContext could bound a hung call
+		ContextTimeoutEnabled: true,
+	}
+}
+
 // GetTradingPairs returns the set of trading pairs from Redis
 func (c *Client) GetTradingPairs(key string) ([]string, error) {
-	members, err := c.rdb.SMembers(c.ctx, key).Result()
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	members, err := c.rdb.SMembers(ctx, c.prefixed(key)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trading pairs from Redis: %w", err)
 	}
 	return members, nil
 }
 
+// AddTradingPairs adds trading pairs to a Redis set
+func (c *Client) AddTradingPairs(key string, pairs ...string) error {
+	members := make([]interface{}, len(pairs))
+	for i, pair := range pairs {
+		members[i] = pair
+	}
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	if err := c.rdb.SAdd(ctx, c.prefixed(key), members...).Err(); err != nil {
+		return fmt.Errorf("failed to add trading pairs to Redis: %w", err)
+	}
+	return nil
+}
+
 // PublishOrderBookEvent publishes an order book event to Redis List
 func (c *Client) PublishOrderBookEvent(listKey string, event interface{}) error {
 	data, err := json.Marshal(event)
@@ -53,15 +137,22 @@ func (c *Client) PublishOrderBookEvent(listKey string, event interface{}) error
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	if err := c.rdb.LPush(c.ctx, listKey, data).Err(); err != nil {
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	if err := c.rdb.LPush(ctx, c.prefixed(listKey), data).Err(); err != nil {
 		return fmt.Errorf("failed to push to Redis list: %w", err)
 	}
 
 	return nil
 }
 
+// StoreTickerSnapshot stores the latest ticker in Redis Hash.
+// Empty fields (OKEx omits some, e.g. sodUtc8 on new listings) are skipped so that
+// readers never see empty strings, and `last` must be numeric.
+// ts_skew_ms is the local time minus the ticker's own OKEx ts, in milliseconds.
 func (c *Client) StoreTickerSnapshot(instID string, ticker interface{}) error {
-	hashKey := fmt.Sprintf(config.TickerKey, instID)
+	hashKey := c.key(config.TickerKey, instID)
 
 	// Convert ticker to map for Redis HSET
 	tickerBytes, err := json.Marshal(ticker)
@@ -74,7 +165,24 @@ func (c *Client) StoreTickerSnapshot(instID string, ticker interface{}) error {
 		return fmt.Errorf("failed to unmarshal ticker to map: %w", err)
 	}
 
-	if err := c.rdb.HSet(c.ctx, hashKey, tickerMap).Err(); err != nil {
+	for field, value := range tickerMap {
+		if value == nil || value == "" {
+			delete(tickerMap, field)
+		}
+	}
+
+	last, _ := tickerMap["last"].(string)
+	if _, err := strconv.ParseFloat(last, 64); err != nil {
+		return fmt.Errorf("invalid ticker for %s: last price %q is not numeric", instID, last)
+	}
+
+	if ts, ok := tickerMap["ts"].(string); ok {
+		if serverMs, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			tickerMap["ts_skew_ms"] = time.Now().UnixMilli() - serverMs
+		}
+	}
+
+	if err := c.hset(hashKey, tickerMap); err != nil {
 		return fmt.Errorf("failed to store ticker snapshot: %w", err)
 	}
 	return nil
@@ -82,7 +190,7 @@ func (c *Client) StoreTickerSnapshot(instID string, ticker interface{}) error {
 
 // StoreOrderBookSnapshot stores the latest order book snapshot in Redis Hash
 func (c *Client) StoreOrderBookSnapshot(instID string, asks, bids interface{}, checksum int32) error {
-	hashKey := fmt.Sprintf(config.OrderBookKey, instID)
+	hashKey := c.key(config.OrderBookKey, instID)
 
 	asksJSON, err := json.Marshal(asks)
 	if err != nil {
@@ -103,15 +211,40 @@ func (c *Client) StoreOrderBookSnapshot(instID string, asks, bids interface{}, c
 		"checksum":      checksum,
 	}
 
-	if err := c.rdb.HSet(c.ctx, hashKey, fields).Err(); err != nil {
+	if err := c.hset(hashKey, fields); err != nil {
 		return fmt.Errorf("failed to store order book snapshot: %w", err)
 	}
 
 	return nil
 }
 
+// StoreAggregatedBook stores the aggregated order book (cumulative totals and top levels) in Redis Hash
+func (c *Client) StoreAggregatedBook(instID string, aggregated map[string]interface{}) error {
+	hashKey := c.key(config.AggregatedBookKey, instID)
+
+	fields := make(map[string]interface{})
+	for k, v := range aggregated {
+		fields[k] = v
+	}
+	fields["instrument_id"] = instID
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store aggregated order book: %w", err)
+	}
+
+	return nil
+}
+
+// hset writes fields to a hash with retries, tagged with the current config.SchemaVersion
+// so that consumers can branch on the shape of the hash
+func (c *Client) hset(hashKey string, fields map[string]interface{}) error {
+	fields["schema_version"] = config.SchemaVersion
+	return c.withRetry(func(ctx context.Context) error { return c.rdb.HSet(ctx, hashKey, fields).Err() })
+}
+
 func (c *Client) HashSave(hashKey string, fields map[string]interface{}) error {
-	if err := c.rdb.HSet(c.ctx, hashKey, fields).Err(); err != nil {
+	hashKey = c.prefixed(hashKey)
+	if err := c.hset(hashKey, fields); err != nil {
 		return fmt.Errorf("failed to store hash fields: %w", err)
 	}
 	return nil
@@ -120,11 +253,13 @@ func (c *Client) HashSave(hashKey string, fields map[string]interface{}) error {
 
 // StoreSupportResistance stores support and resistance levels for an instrument in Redis Hash
 func (c *Client) StoreSupportResistance(instID string, supports, resistances []float64, spread float64) error {
-	hashKey := fmt.Sprintf(config.SupportResistanceKey, instID)
+	hashKey := c.key(config.SupportResistanceKey, instID)
 
 	fields := map[string]interface{}{
 		"instrument_id": instID,
 		"analysis_time": time.Now().Unix(),
+		// No level cleared the significance floor, the level fields below are absent
+		"significant": len(supports) > 0 || len(resistances) > 0,
 	}
 
 	if len(supports) > 0 {
@@ -140,19 +275,44 @@ func (c *Client) StoreSupportResistance(instID string, supports, resistances []f
 		fields["resistance_low"] = resistances[1]
 	}
 
+	// All computed levels as JSON arrays, beyond the two legacy named fields
+	supportsJSON, err := json.Marshal(append([]float64{}, supports...))
+	if err != nil {
+		return fmt.Errorf("failed to marshal supports: %w", err)
+	}
+	resistancesJSON, err := json.Marshal(append([]float64{}, resistances...))
+	if err != nil {
+		return fmt.Errorf("failed to marshal resistances: %w", err)
+	}
+	fields["supports"] = string(supportsJSON)
+	fields["resistances"] = string(resistancesJSON)
+
 	// Store the spread between highest support and lowest resistance
 	fields["spread"] = spread
 
-	if err := c.rdb.HSet(c.ctx, hashKey, fields).Err(); err != nil {
+	if err := c.hset(hashKey, fields); err != nil {
 		return fmt.Errorf("failed to store support/resistance levels: %w", err)
 	}
 
+	// Drop named levels left over from a previous result that had more of them
+	var stale []string
+	for _, name := range []string{"support_high", "support_low", "resistance_high", "resistance_low"} {
+		if _, ok := fields[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) > 0 {
+		if err := c.withRetry(func(ctx context.Context) error { return c.rdb.HDel(ctx, hashKey, stale...).Err() }); err != nil {
+			return fmt.Errorf("failed to clear stale support/resistance levels: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // StoreSpreadVolatility stores the spread volatility metric for an instrument in Redis Hash
 func (c *Client) StoreSpreadVolatility(instID string, volatilityMetric float64, currentSpread float64) error {
-	hashKey := fmt.Sprintf(config.SupportResistanceKey, instID) // Use the same key space
+	hashKey := c.key(config.SupportResistanceKey, instID) // Use the same key space
 
 	fields := map[string]interface{}{
 		"instrument_id":     instID,
@@ -161,7 +321,7 @@ func (c *Client) StoreSpreadVolatility(instID string, volatilityMetric float64,
 		"current_spread":    currentSpread,    // Current spread value
 	}
 
-	if err := c.rdb.HSet(c.ctx, hashKey, fields).Err(); err != nil {
+	if err := c.hset(hashKey, fields); err != nil {
 		return fmt.Errorf("failed to store spread volatility: %w", err)
 	}
 
@@ -169,26 +329,73 @@ func (c *Client) StoreSpreadVolatility(instID string, volatilityMetric float64,
 }
 
 // StoreSpreadZScore stores the spread Z-score for an instrument in Redis Hash
-func (c *Client) StoreSpreadZScore(instID string, zScore float64, currentSpread float64) error {
-	hashKey := fmt.Sprintf(config.SupportResistanceKey, instID) // Use the same key space
+// readiness (0..1) tells how much of the look-back window was filled.
+func (c *Client) StoreSpreadZScore(instID string, zScore float64, currentSpread float64, readiness float64) error {
+	hashKey := c.key(config.SupportResistanceKey, instID) // Use the same key space
 
 	fields := map[string]interface{}{
 		"instrument_id":  instID,
 		"analysis_time":  time.Now().Unix(),
 		"spread_zscore":  zScore,        // Z-score of current spread vs historical
 		"current_spread": currentSpread, // Current spread value
+
+		"spread_zscore_readiness": readiness,
 	}
 
-	if err := c.rdb.HSet(c.ctx, hashKey, fields).Err(); err != nil {
+	if err := c.hset(hashKey, fields); err != nil {
 		return fmt.Errorf("failed to store spread Z-score: %w", err)
 	}
 
 	return nil
 }
 
+// StoreSentiment stores large order distribution and sentiment for an instrument in Redis Hash
+// readiness (0..1) tells how much of the smoothing window was filled.
+func (c *Client) StoreSentiment(instID string, largeBuyNotional, largeSellNotional, sentiment, readiness float64) error {
+	hashKey := c.key(config.SentimentKey, instID)
+
+	fields := map[string]interface{}{
+		"instrument_id":       instID,
+		"analysis_time":       time.Now().Unix(),
+		"large_buy_notional":  largeBuyNotional,
+		"large_sell_notional": largeSellNotional,
+		"sentiment":           sentiment,
+		"readiness":           readiness,
+	}
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store sentiment: %w", err)
+	}
+
+	return nil
+}
+
+// StoreLargeOrders stores the largest levels above the large order threshold (the walls) in the
+// instrument's sentiment hash, next to the large order notionals they add up to
+func (c *Client) StoreLargeOrders(instID string, levels interface{}, count int) error {
+	hashKey := c.key(config.SentimentKey, instID)
+
+	levelsJSON, err := json.Marshal(levels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal large order levels: %w", err)
+	}
+
+	fields := map[string]interface{}{
+		"instrument_id":     instID,
+		"large_order_count": count,
+		"large_orders":      string(levelsJSON),
+	}
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store large order levels: %w", err)
+	}
+
+	return nil
+}
+
 // StoreDepthAnomaly stores depth anomaly detection results for an instrument in Redis Hash
 func (c *Client) StoreDepthAnomaly(instID string, anomalyData map[string]interface{}) error {
-	hashKey := fmt.Sprintf(config.DepthAnomalyKey, instID)
+	hashKey := c.key(config.DepthAnomalyKey, instID)
 
 	// Add instrument ID and timestamp to the data
 	fields := make(map[string]interface{})
@@ -198,7 +405,7 @@ func (c *Client) StoreDepthAnomaly(instID string, anomalyData map[string]interfa
 	fields["instrument_id"] = instID
 	fields["timestamp"] = time.Now().Unix()
 
-	if err := c.rdb.HSet(c.ctx, hashKey, fields).Err(); err != nil {
+	if err := c.hset(hashKey, fields); err != nil {
 		return fmt.Errorf("failed to store depth anomaly data: %w", err)
 	}
 
@@ -207,7 +414,7 @@ func (c *Client) StoreDepthAnomaly(instID string, anomalyData map[string]interfa
 
 // StoreLiquidityShrink stores liquidity shrinkage warning results for an instrument in Redis Hash
 func (c *Client) StoreLiquidityShrink(instID string, shrinkData map[string]interface{}) error {
-	hashKey := fmt.Sprintf(config.LiquidityShrinkKey, instID)
+	hashKey := c.key(config.LiquidityShrinkKey, instID)
 
 	// Add instrument ID and timestamp to the data
 	fields := make(map[string]interface{})
@@ -217,16 +424,137 @@ func (c *Client) StoreLiquidityShrink(instID string, shrinkData map[string]inter
 	fields["instrument_id"] = instID
 	fields["timestamp"] = time.Now().Unix()
 
-	if err := c.rdb.HSet(c.ctx, hashKey, fields).Err(); err != nil {
+	if err := c.hset(hashKey, fields); err != nil {
 		return fmt.Errorf("failed to store liquidity shrinkage data: %w", err)
 	}
 
 	return nil
 }
 
+// StoreIceberg stores iceberg order candidates for an instrument in Redis Hash
+func (c *Client) StoreIceberg(instID string, candidates interface{}, count int) error {
+	hashKey := c.key(config.IcebergKey, instID)
+
+	candidatesJSON, err := json.Marshal(candidates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal iceberg candidates: %w", err)
+	}
+
+	fields := map[string]interface{}{
+		"instrument_id": instID,
+		"timestamp":     time.Now().Unix(),
+		"count":         count,
+		"candidates":    string(candidatesJSON),
+	}
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store iceberg candidates: %w", err)
+	}
+
+	return nil
+}
+
+// StoreOFI stores the windowed order flow imbalance for an instrument in Redis Hash
+func (c *Client) StoreOFI(instID string, ofiData map[string]interface{}) error {
+	hashKey := c.key(config.OFIKey, instID)
+
+	fields := make(map[string]interface{})
+	for k, v := range ofiData {
+		fields[k] = v
+	}
+	fields["instrument_id"] = instID
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store OFI data: %w", err)
+	}
+
+	return nil
+}
+
+// StoreTradePressure stores the buy/sell aggressor ratio of recent trades for an instrument in Redis Hash
+func (c *Client) StoreTradePressure(instID string, pressureData map[string]interface{}) error {
+	hashKey := c.key(config.TradePressureKey, instID)
+
+	fields := make(map[string]interface{})
+	for k, v := range pressureData {
+		fields[k] = v
+	}
+	fields["instrument_id"] = instID
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store trade pressure data: %w", err)
+	}
+
+	return nil
+}
+
+// StoreFundingRate stores the latest funding rate of a perpetual swap in a Redis Hash
+func (c *Client) StoreFundingRate(instID string, fundingData map[string]interface{}) error {
+	hashKey := c.key(config.FundingRateKey, instID)
+
+	fields := make(map[string]interface{})
+	for k, v := range fundingData {
+		fields[k] = v
+	}
+	fields["instrument_id"] = instID
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store funding rate: %w", err)
+	}
+
+	return nil
+}
+
+// StoreOpenInterest stores the latest open interest of a derivative in a Redis Hash
+func (c *Client) StoreOpenInterest(instID string, oiData map[string]interface{}) error {
+	hashKey := c.key(config.OpenInterestKey, instID)
+
+	fields := make(map[string]interface{})
+	for k, v := range oiData {
+		fields[k] = v
+	}
+	fields["instrument_id"] = instID
+
+	if err := c.hset(hashKey, fields); err != nil {
+		return fmt.Errorf("failed to store open interest: %w", err)
+	}
+
+	return nil
+}
+
+// maxImbalanceFlipEvents is the number of imbalance flip events kept per instrument
+const maxImbalanceFlipEvents = 100
+
+// StoreImbalanceFlip pushes an imbalance flip event onto the instrument's Redis List,
+// newest first, keeping the latest maxImbalanceFlipEvents
+func (c *Client) StoreImbalanceFlip(instID string, event interface{}) error {
+	listKey := c.key(config.ImbalanceFlipKey, instID)
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal imbalance flip event: %w", err)
+	}
+
+	err = c.withRetry(func(ctx context.Context) error {
+		pipe := c.rdb.TxPipeline()
+		pipe.LPush(ctx, listKey, data)
+		pipe.LTrim(ctx, listKey, 0, maxImbalanceFlipEvents-1)
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store imbalance flip event: %w", err)
+	}
+
+	return nil
+}
+
 // GetHash returns all fields of a Redis hash as a map
 func (c *Client) GetHash(key string) (map[string]string, error) {
-	result, err := c.rdb.HGetAll(c.ctx, key).Result()
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	result, err := c.rdb.HGetAll(ctx, c.prefixed(key)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get hash %s: %w", key, err)
 	}
@@ -235,7 +563,7 @@ func (c *Client) GetHash(key string) (map[string]string, error) {
 
 // UpdateSystemMonitoring updates system monitoring metrics in Redis
 func (c *Client) UpdateSystemMonitoring(fields map[string]interface{}) error {
-	if err := c.rdb.HSet(c.ctx, "system:monitoring", fields).Err(); err != nil {
+	if err := c.hset(c.prefixed("system:monitoring"), fields); err != nil {
 		return fmt.Errorf("failed to update system monitoring: %w", err)
 	}
 	return nil
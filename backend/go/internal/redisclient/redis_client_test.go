@@ -0,0 +1,110 @@
+package redisclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestOptionsAppliedToRedisOptions(t *testing.T) {
+	got := Options{
+		Addr:         "redis:6380",
+		Password:     "secret",
+		DB:           5,
+		PoolSize:     64,
+		MinIdleConns: 8,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  750 * time.Millisecond,
+		WriteTimeout: 500 * time.Millisecond,
+	}.redisOptions()
+
+	if got.Addr != "redis:6380" || got.Password != "secret" || got.DB != 5 {
+		t.Errorf("connection options = %s / %s / db %d", got.Addr, got.Password, got.DB)
+	}
+	if got.PoolSize != 64 || got.MinIdleConns != 8 {
+		t.Errorf("pool = %d, min idle %d, want 64 and 8", got.PoolSize, got.MinIdleConns)
+	}
+	if got.DialTimeout != 2*time.Second || got.ReadTimeout != 750*time.Millisecond || got.WriteTimeout != 500*time.Millisecond {
+		t.Errorf("timeouts = dial %v read %v write %v", got.DialTimeout, got.ReadTimeout, got.WriteTimeout)
+	}
+	if !got.ContextTimeoutEnabled {
+		t.Error("context deadlines are not applied to the socket")
+	}
+
+	// The simple constructor keeps go-redis defaults: DB 0, zero means default pool and timeouts
+	defaults := Options{Addr: "localhost:6379"}.redisOptions()
+	if defaults.DB != 0 || defaults.PoolSize != 0 || defaults.ReadTimeout != 0 {
+		t.Errorf("zero Options = db %d pool %d read timeout %v", defaults.DB, defaults.PoolSize, defaults.ReadTimeout)
+	}
+}
+
+func TestClientWritesToConfiguredDB(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := NewClientWithOptions(Options{Addr: server.Addr(), DB: 3, PoolSize: 2})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if err := client.AddTradingPairs("config:trading_pairs", "BTC-USDT"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if members, _ := server.DB(3).Members("config:trading_pairs"); len(members) != 1 || members[0] != "BTC-USDT" {
+		t.Fatal("pair not written to DB 3")
+	}
+	if server.DB(0).Exists("config:trading_pairs") {
+		t.Fatal("pair written to DB 0")
+	}
+	if pairs, err := client.GetTradingPairs("config:trading_pairs"); err != nil || len(pairs) != 1 {
+		t.Fatalf("read back = %v, %v", pairs, err)
+	}
+}
+
+func TestStoreSupportResistanceKeepsAllLevels(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	supports := []float64{990, 975, 960, 945, 930}
+	resistances := []float64{1010, 1025, 1040, 1055, 1070}
+	if err := client.StoreSupportResistance("BTC-USDT", supports, resistances, 20); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	key := fmt.Sprintf(config.SupportResistanceKey, "BTC-USDT")
+	for field, want := range map[string][]float64{"supports": supports, "resistances": resistances} {
+		var got []float64
+		if err := json.Unmarshal([]byte(server.HGet(key, field)), &got); err != nil {
+			t.Fatalf("%s = %q: %v", field, server.HGet(key, field), err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("%s = %v, want %v", field, got, want)
+		}
+	}
+	if server.HGet(key, "support_low") != "975" || server.HGet(key, "resistance_high") != "1010" {
+		t.Fatalf("legacy fields support_low=%s resistance_high=%s", server.HGet(key, "support_low"), server.HGet(key, "resistance_high"))
+	}
+}
+
+func TestStoreTradePressureHash(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	err := client.StoreTradePressure("ETH-USDT", map[string]interface{}{
+		"buy_ratio": 0.375, "buy_notional": 600.0, "sell_notional": 1000.0, "trades": 3,
+	})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	key := fmt.Sprintf(config.TradePressureKey, "ETH-USDT")
+	for field, want := range map[string]string{
+		"buy_ratio": "0.375", "buy_notional": "600", "sell_notional": "1000", "trades": "3", "instrument_id": "ETH-USDT",
+	} {
+		if got := server.HGet(key, field); got != want {
+			t.Errorf("%s %s = %q, want %q", key, field, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package redisclient
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestEveryStoredHashCarriesSchemaVersion(t *testing.T) {
+	client, server := newMiniClient(t)
+	const instID = "BTC-USDT-SWAP"
+	fields := func() map[string]interface{} { return map[string]interface{}{"value": 1.5} }
+
+	// Methods that append to lists or sorted sets map to nil, they write no hash
+	writes := map[string]func() error{
+		"StoreOrderBookSnapshot": func() error {
+			return client.StoreOrderBookSnapshot(instID, [][]string{{"101", "1"}}, [][]string{{"100", "2"}}, -42)
+		},
+		"AppendOrderBookHistory": nil,
+		"StoreAggregatedBook":    func() error { return client.StoreAggregatedBook(instID, fields()) },
+		"StoreTickerSnapshot": func() error {
+			return client.StoreTickerSnapshot(instID, map[string]string{"last": "100.5"})
+		},
+		"StoreSupportResistance": func() error {
+			return client.StoreSupportResistance(instID, []float64{99}, []float64{102}, 0.5)
+		},
+		"StoreSpreadZScore":      func() error { return client.StoreSpreadZScore(instID, 2.1, 0.5, 1) },
+		"StoreSentiment":         func() error { return client.StoreSentiment(instID, 5000, 2000, 0.43, 1) },
+		"AppendSentimentHistory": nil,
+		"StoreLargeOrders":       func() error { return client.StoreLargeOrders(instID, []string{"101"}, 1) },
+		"StoreDepthAnomaly":      func() error { return client.StoreDepthAnomaly(instID, fields()) },
+		"StoreLiquidityShrink":   func() error { return client.StoreLiquidityShrink(instID, fields()) },
+		"StoreIceberg":           func() error { return client.StoreIceberg(instID, []string{"100"}, 1) },
+		"StoreOFI":               func() error { return client.StoreOFI(instID, fields()) },
+		"StoreTradePressure":     func() error { return client.StoreTradePressure(instID, fields()) },
+		"StoreImbalanceFlip":     nil,
+		"StoreFundingRate":       func() error { return client.StoreFundingRate(instID, fields()) },
+		"StoreOpenInterest":      func() error { return client.StoreOpenInterest(instID, fields()) },
+		"StoreAnalysisStatus": func() error {
+			return client.StoreAnalysisStatus(instID, "large_order", errors.New("stale book"))
+		},
+	}
+
+	// A new store method must be listed above, so it cannot skip the version by accident
+	store := reflect.TypeOf((*RedisStore)(nil)).Elem()
+	for i := 0; i < store.NumMethod(); i++ {
+		if _, listed := writes[store.Method(i).Name]; !listed {
+			t.Errorf("RedisStore.%s is not covered", store.Method(i).Name)
+		}
+	}
+
+	want := strconv.Itoa(config.SchemaVersion)
+	for name, write := range writes {
+		if write == nil {
+			continue
+		}
+		server.FlushAll()
+		if err := write(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		hashes := hashKeys(server.Keys(), server.Type)
+		if len(hashes) == 0 {
+			t.Errorf("%s wrote no hash", name)
+		}
+		for _, key := range hashes {
+			if got := server.HGet(key, "schema_version"); got != want {
+				t.Errorf("%s wrote hash %s with schema_version %q, want %q", name, key, got, want)
+			}
+		}
+	}
+}
+
+// hashKeys returns the keys holding a hash
+func hashKeys(keys []string, keyType func(string) string) []string {
+	var hashes []string
+	for _, key := range keys {
+		if keyType(key) == "hash" {
+			hashes = append(hashes, key)
+		}
+	}
+	return hashes
+}
@@ -0,0 +1,89 @@
+package redisclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// SentimentHistoryPoint is one retained sentiment value, stored as a sorted set member
+type SentimentHistoryPoint struct {
+	Timestamp int64   `json:"ts"` // analysis time (unix seconds), also the member's score
+	Sentiment float64 `json:"sentiment"`
+}
+
+// SetSentimentHistorySize sets how many sentiment values AppendSentimentHistory retains per
+// instrument; 0 disables the history
+func (c *Client) SetSentimentHistorySize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	c.sentimentHistorySize = size
+}
+
+// AppendSentimentHistory adds a sentiment value to the instrument's history sorted set,
+// scored by ts (unix seconds), and trims the set to the newest SetSentimentHistorySize
+// entries. The latest-value hash written by StoreSentiment is unaffected. No-op when the
+// history is disabled.
+func (c *Client) AppendSentimentHistory(instID string, sentiment float64, ts int64) error {
+	if c.sentimentHistorySize <= 0 {
+		return nil
+	}
+
+	member, err := json.Marshal(SentimentHistoryPoint{Timestamp: ts, Sentiment: sentiment})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sentiment history entry: %w", err)
+	}
+
+	zsetKey := c.key(config.SentimentHistoryKey, instID)
+	keep := int64(c.sentimentHistorySize)
+
+	err = c.withRetry(func(ctx context.Context) error {
+		pipe := c.rdb.TxPipeline()
+		pipe.ZAdd(ctx, zsetKey, redis.Z{Score: float64(ts), Member: string(member)})
+		// Ranks are ascending by score, so dropping 0..-(keep+1) keeps the newest entries
+		pipe.ZRemRangeByRank(ctx, zsetKey, 0, -keep-1)
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append sentiment history: %w", err)
+	}
+
+	return nil
+}
+
+// GetSentimentHistory returns the retained sentiment values of an instrument with
+// fromTs <= ts <= toTs (unix seconds), oldest first. toTs <= 0 means no upper bound.
+func (c *Client) GetSentimentHistory(instID string, fromTs, toTs int64) ([]SentimentHistoryPoint, error) {
+	max := "+inf"
+	if toTs > 0 {
+		max = strconv.FormatInt(toTs, 10)
+	}
+
+	ctx, cancel := c.opContext()
+	defer cancel()
+
+	members, err := c.rdb.ZRangeByScore(ctx, c.key(config.SentimentHistoryKey, instID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(fromTs, 10),
+		Max: max,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sentiment history: %w", err)
+	}
+
+	points := make([]SentimentHistoryPoint, 0, len(members))
+	for _, member := range members {
+		var point SentimentHistoryPoint
+		if err := json.Unmarshal([]byte(member), &point); err != nil {
+			return nil, fmt.Errorf("failed to decode sentiment history entry: %w", err)
+		}
+		points = append(points, point)
+	}
+
+	return points, nil
+}
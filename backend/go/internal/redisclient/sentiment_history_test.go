@@ -0,0 +1,61 @@
+package redisclient
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestSentimentHistoryTrimsAndKeepsTimeOrder(t *testing.T) {
+	client, server := newMiniClient(t)
+	client.SetSentimentHistorySize(4)
+
+	// Appended out of order, with a repeated sentiment value at different times
+	for _, point := range []SentimentHistoryPoint{
+		{1717000005, 0.5}, {1717000001, -0.2}, {1717000003, 0.5}, {1717000002, 0.1},
+		{1717000006, -0.7}, {1717000004, 0.5},
+	} {
+		if err := client.StoreSentiment("BTC-USDT", 100, 50, point.Sentiment, 1); err != nil {
+			t.Fatalf("store latest: %v", err)
+		}
+		if err := client.AppendSentimentHistory("BTC-USDT", point.Sentiment, point.Timestamp); err != nil {
+			t.Fatalf("append %d: %v", point.Timestamp, err)
+		}
+	}
+
+	// The four newest by time survive, whatever the order they arrived in
+	all, err := client.GetSentimentHistory("BTC-USDT", 0, 0)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := []SentimentHistoryPoint{{1717000003, 0.5}, {1717000004, 0.5}, {1717000005, 0.5}, {1717000006, -0.7}}
+	if !reflect.DeepEqual(all, want) {
+		t.Fatalf("history = %v, want %v", all, want)
+	}
+
+	ranged, err := client.GetSentimentHistory("BTC-USDT", 1717000004, 1717000005)
+	if err != nil || !reflect.DeepEqual(ranged, want[1:3]) {
+		t.Fatalf("range = %v (%v), want %v", ranged, err, want[1:3])
+	}
+
+	// The latest-value hash is still written alongside
+	if got := server.HGet(fmt.Sprintf(config.SentimentKey, "BTC-USDT"), "sentiment"); got != "0.5" {
+		t.Fatalf("latest sentiment = %q, want the last stored 0.5", got)
+	}
+}
+
+func TestSentimentHistoryDisabledWritesNothing(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	if err := client.AppendSentimentHistory("ETH-USDT", 0.3, 1717000000); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Fatalf("disabled history wrote %v", keys)
+	}
+	if points, err := client.GetSentimentHistory("ETH-USDT", 0, 0); err != nil || len(points) != 0 {
+		t.Fatalf("history = %v (%v), want empty", points, err)
+	}
+}
@@ -0,0 +1,52 @@
+package redisclient
+
+// RedisStore is the write side of Client used by the order book processing loop.
+// Processors depend on it instead of *Client so tests can inject a recording mock,
+// see internal/testutil.
+type RedisStore interface {
+	StoreOrderBookSnapshot(instID string, asks, bids interface{}, checksum int32) error
+	AppendOrderBookHistory(instID string, snapshot interface{}, ts int64) error
+	StoreAggregatedBook(instID string, aggregated map[string]interface{}) error
+	StoreTickerSnapshot(instID string, ticker interface{}) error
+	StoreSupportResistance(instID string, supports, resistances []float64, spread float64) error
+	StoreSpreadZScore(instID string, zScore float64, currentSpread float64, readiness float64) error
+	StoreSentiment(instID string, largeBuyNotional, largeSellNotional, sentiment, readiness float64) error
+	AppendSentimentHistory(instID string, sentiment float64, ts int64) error
+	StoreLargeOrders(instID string, levels interface{}, count int) error
+	StoreDepthAnomaly(instID string, anomalyData map[string]interface{}) error
+	StoreLiquidityShrink(instID string, shrinkData map[string]interface{}) error
+	StoreIceberg(instID string, candidates interface{}, count int) error
+	StoreOFI(instID string, ofiData map[string]interface{}) error
+	StoreTradePressure(instID string, pressureData map[string]interface{}) error
+	StoreImbalanceFlip(instID string, event interface{}) error
+	StoreFundingRate(instID string, fundingData map[string]interface{}) error
+	StoreOpenInterest(instID string, oiData map[string]interface{}) error
+	StoreAnalysisStatus(instID, analysis string, lastErr error) error
+}
+
+var _ RedisStore = (*Client)(nil)
+
+// NopStore is a RedisStore that discards every write, used when the service runs without
+// Redis (REDIS_REQUIRED=false) so analyses still run in memory and are served over HTTP
+type NopStore struct{}
+
+var _ RedisStore = NopStore{}
+
+func (NopStore) StoreOrderBookSnapshot(string, interface{}, interface{}, int32) error { return nil }
+func (NopStore) AppendOrderBookHistory(string, interface{}, int64) error              { return nil }
+func (NopStore) StoreAggregatedBook(string, map[string]interface{}) error             { return nil }
+func (NopStore) StoreTickerSnapshot(string, interface{}) error                        { return nil }
+func (NopStore) StoreSupportResistance(string, []float64, []float64, float64) error   { return nil }
+func (NopStore) StoreSpreadZScore(string, float64, float64, float64) error            { return nil }
+func (NopStore) StoreSentiment(string, float64, float64, float64, float64) error      { return nil }
+func (NopStore) AppendSentimentHistory(string, float64, int64) error                  { return nil }
+func (NopStore) StoreLargeOrders(string, interface{}, int) error                      { return nil }
+func (NopStore) StoreDepthAnomaly(string, map[string]interface{}) error               { return nil }
+func (NopStore) StoreLiquidityShrink(string, map[string]interface{}) error            { return nil }
+func (NopStore) StoreIceberg(string, interface{}, int) error                          { return nil }
+func (NopStore) StoreOFI(string, map[string]interface{}) error                        { return nil }
+func (NopStore) StoreTradePressure(string, map[string]interface{}) error              { return nil }
+func (NopStore) StoreImbalanceFlip(string, interface{}) error                         { return nil }
+func (NopStore) StoreFundingRate(string, map[string]interface{}) error                { return nil }
+func (NopStore) StoreOpenInterest(string, map[string]interface{}) error               { return nil }
+func (NopStore) StoreAnalysisStatus(string, string, error) error                      { return nil }
@@ -0,0 +1,80 @@
+package redisclient
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// storeArgs builds arguments for a RedisStore method from its parameter types
+func storeArgs(method reflect.Type) []reflect.Value {
+	args := make([]reflect.Value, method.NumIn())
+	names := []string{"BTC-USDT", "ofi"} // instID, then the analysis name
+	for i := range args {
+		in := method.In(i)
+		switch {
+		case in.Kind() == reflect.String:
+			args[i] = reflect.ValueOf(names[0])
+			names = names[1:]
+		case in.Kind() == reflect.Map:
+			args[i] = reflect.ValueOf(map[string]interface{}{"value": 1.5})
+		case in.Kind() == reflect.Slice:
+			args[i] = reflect.ValueOf([]float64{99, 101})
+		case in.Kind() == reflect.Interface && in.Name() == "error":
+			args[i] = reflect.Zero(in)
+		case in.Kind() == reflect.Interface:
+			// Valid as a ticker too, which must carry a numeric last price
+			args[i] = reflect.ValueOf(map[string]string{"last": "100.5", "ts": "1717000000000"})
+		default:
+			args[i] = reflect.ValueOf(1).Convert(in)
+		}
+	}
+	return args
+}
+
+func TestEveryStoreMethodHonorsKeyPrefix(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := NewClientWithOptions(Options{Addr: server.Addr(), KeyPrefix: "staging:"})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	store := reflect.ValueOf(RedisStore(client))
+	storeType := reflect.TypeOf((*RedisStore)(nil)).Elem()
+	for i := 0; i < storeType.NumMethod(); i++ {
+		method := storeType.Method(i)
+
+		out := store.MethodByName(method.Name).Call(storeArgs(method.Type))
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			t.Errorf("%s: %v", method.Name, err)
+			continue
+		}
+
+		for _, key := range server.Keys() {
+			if !strings.HasPrefix(key, "staging:") {
+				t.Fatalf("%s wrote unprefixed key %q", method.Name, key)
+			}
+		}
+	}
+
+	if len(server.Keys()) == 0 {
+		t.Fatal("no key written")
+	}
+
+	// Another environment on the same Redis sees none of it
+	prod, err := NewClientWithOptions(Options{Addr: server.Addr(), KeyPrefix: "prod:"})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { prod.Close() })
+	server.SAdd("staging:config:trading_pairs", "BTC-USDT")
+	if pairs, err := prod.GetTradingPairs("config:trading_pairs"); err != nil || len(pairs) != 0 {
+		t.Fatalf("prod read staging pairs: %v, %v", pairs, err)
+	}
+	if pairs, err := client.GetTradingPairs("config:trading_pairs"); err != nil || len(pairs) != 1 {
+		t.Fatalf("staging pairs = %v, %v", pairs, err)
+	}
+}
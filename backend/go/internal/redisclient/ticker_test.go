@@ -0,0 +1,102 @@
+package redisclient
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreTickerSnapshotSkew(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	serverTs := time.Now().Add(-1500 * time.Millisecond).UnixMilli()
+	ticker := map[string]string{
+		"instId":  "BTC-USDT",
+		"last":    "100.5",
+		"sodUtc8": "",
+		"ts":      strconv.FormatInt(serverTs, 10),
+	}
+	if err := client.StoreTickerSnapshot("BTC-USDT", ticker); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	skew, err := strconv.ParseInt(server.HGet("ticker:BTC-USDT", "ts_skew_ms"), 10, 64)
+	if err != nil {
+		t.Fatalf("ts_skew_ms: %v", err)
+	}
+	if skew < 1500 || skew > 3000 {
+		t.Fatalf("ts_skew_ms = %d, want about 1500", skew)
+	}
+	if fields, _ := server.HKeys("ticker:BTC-USDT"); strings.Contains(strings.Join(fields, ","), "sodUtc8") {
+		t.Fatalf("empty sodUtc8 stored: %v", fields)
+	}
+
+	if err := client.StoreTickerSnapshot("BTC-USDT", map[string]string{"last": ""}); err == nil {
+		t.Fatal("stored a ticker without a last price")
+	}
+}
+
+// newListingTicker mirrors orderbook.TickerData, which this package cannot import
+type newListingTicker struct {
+	InstType  string `json:"instType"`
+	InstID    string `json:"instId"`
+	Last      string `json:"last"`
+	LastSz    string `json:"lastSz"`
+	AskPx     string `json:"askPx"`
+	BidPx     string `json:"bidPx"`
+	Open24h   string `json:"open24h"`
+	High24h   string `json:"high24h"`
+	Low24h    string `json:"low24h"`
+	SodUtc0   string `json:"sodUtc0"`
+	SodUtc8   string `json:"sodUtc8"`
+	Timestamp string `json:"ts"`
+}
+
+func TestStoreTickerWithMissingFields(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	// A fresh listing: no 24h stats and no start-of-day prices yet, and an empty bid
+	payload := `{"instType":"SPOT","instId":"NEW-USDT","last":"0.0421","lastSz":"120","askPx":"0.0422","bidPx":"","ts":"1717000000000"}`
+	var ticker newListingTicker
+	if err := json.Unmarshal([]byte(payload), &ticker); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StoreTickerSnapshot("NEW-USDT", ticker); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	stored := map[string]string{}
+	fields, _ := server.HKeys("ticker:NEW-USDT")
+	for _, field := range fields {
+		stored[field] = server.HGet("ticker:NEW-USDT", field)
+	}
+	for _, missing := range []string{"bidPx", "open24h", "high24h", "low24h", "sodUtc0", "sodUtc8"} {
+		if value, ok := stored[missing]; ok {
+			t.Errorf("absent %s stored as %q", missing, value)
+		}
+	}
+	for field, value := range stored {
+		if value == "" || value == "0" {
+			t.Errorf("%s stored as bogus %q", field, value)
+		}
+	}
+	if stored["last"] != "0.0421" || stored["askPx"] != "0.0422" {
+		t.Fatalf("prices not stored verbatim: %v", stored)
+	}
+}
+
+func TestStoreTickerRejectsNonNumericLast(t *testing.T) {
+	client, server := newMiniClient(t)
+
+	for _, last := range []string{"", "n/a", "1,5"} {
+		err := client.StoreTickerSnapshot("BTC-USDT", map[string]string{"instId": "BTC-USDT", "last": last})
+		if err == nil || !strings.Contains(err.Error(), "BTC-USDT") {
+			t.Errorf("last %q: err = %v, want an error naming the instrument", last, err)
+		}
+	}
+	if server.Exists("ticker:BTC-USDT") {
+		t.Fatal("rejected ticker was written")
+	}
+}
@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/ws"
+)
+
+const (
+	placeOrderPath  = "/api/v5/trade/order"
+	cancelOrderPath = "/api/v5/trade/cancel-order"
+)
+
+// Client places and cancels orders through the OKEx REST API using signed requests.
+// It is used as a fallback when the private WebSocket is unavailable.
+type Client struct {
+	baseURL    string
+	config     ws.OKExConfig
+	httpClient *http.Client
+
+	simulatedTrading bool // demo trading, see SetSimulatedTrading
+}
+
+// OrderResult represents a single item of the OKEx order/cancel-order response data
+type OrderResult struct {
+	OrdID   string `json:"ordId"`
+	ClOrdID string `json:"clOrdId"`
+	Tag     string `json:"tag"`
+	SCode   string `json:"sCode"`
+	SMsg    string `json:"sMsg"`
+}
+
+// apiResponse represents the common OKEx REST response envelope
+type apiResponse struct {
+	Code string        `json:"code"`
+	Msg  string        `json:"msg"`
+	Data []OrderResult `json:"data"`
+}
+
+// NewClient creates a new REST client
+func NewClient(baseURL string, config ws.OKExConfig) *Client {
+	return NewClientWithProxy(baseURL, config, "")
+}
+
+// NewClientWithProxy creates a new REST client with optional HTTP proxy
+func NewClientWithProxy(baseURL string, config ws.OKExConfig, proxyAddr string) *Client {
+	transport := &http.Transport{}
+
+	if proxyAddr != "" {
+		proxyURL, err := url.Parse("http://" + proxyAddr)
+		if err != nil {
+			log.Printf("Failed to parse REST proxy URL %s: %v, connecting directly", proxyAddr, err)
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+			log.Printf("Using HTTP proxy for REST: %s", proxyAddr)
+		}
+	}
+
+	return &Client{
+		baseURL: baseURL,
+		config:  config,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+// PlaceOrder places a single order. args uses the same fields as the WebSocket order op.
+func (c *Client) PlaceOrder(args map[string]interface{}) (*OrderResult, error) {
+	return c.doOrderRequest(placeOrderPath, args)
+}
+
+// CancelOrder cancels an order by ordId or clOrdId
+func (c *Client) CancelOrder(instID, ordID, clOrdID string) (*OrderResult, error) {
+	if ordID == "" && clOrdID == "" {
+		return nil, fmt.Errorf("either ordId or clOrdId is required")
+	}
+
+	args := map[string]string{"instId": instID}
+	if ordID != "" {
+		args["ordId"] = ordID
+	}
+	if clOrdID != "" {
+		args["clOrdId"] = clOrdID
+	}
+	return c.doOrderRequest(cancelOrderPath, args)
+}
+
+// SetSimulatedTrading sends the demo trading header with every request so orders
+// are placed in the OKEx paper trading environment
+func (c *Client) SetSimulatedTrading(enabled bool) {
+	c.simulatedTrading = enabled
+}
+
+// doOrderRequest sends a signed POST request and returns the first result item
+func (c *Client) doOrderRequest(path string, args interface{}) (*OrderResult, error) {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	respBody, err := c.do(http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp apiResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(resp.Data) > 0 && resp.Data[0].SCode != "" && resp.Data[0].SCode != "0" {
+		return &resp.Data[0], fmt.Errorf("order rejected: %s - %s", resp.Data[0].SCode, resp.Data[0].SMsg)
+	}
+	if resp.Code != "0" {
+		return nil, fmt.Errorf("server returned error: %s - %s", resp.Code, resp.Msg)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no data in response")
+	}
+
+	return &resp.Data[0], nil
+}
+
+// do sends a signed request and returns the raw response body
+func (c *Client) do(method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// REST signatures use an ISO 8601 millisecond timestamp, e.g. 2020-12-08T09:08:57.715Z
+	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	signature := ws.BuildRequestSign(c.config.SecretKey, timestamp, method, path, string(body))
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("OK-ACCESS-KEY", c.config.APIKey)
+	req.Header.Set("OK-ACCESS-SIGN", signature)
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", c.config.Passphrase)
+	if c.simulatedTrading {
+		req.Header.Set(ws.SimulatedTradingHeader, "1")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/ws"
+)
+
+func TestDemoTradingOrderRequest(t *testing.T) {
+	type request struct{ path, simulated string }
+	endpoint := func(requests chan<- request) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests <- request{r.URL.Path, r.Header.Get(ws.SimulatedTradingHeader)}
+			w.Write([]byte(`{"code":"0","msg":"","data":[{"ordId":"1","sCode":"0"}]}`))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	for _, demo := range []bool{false, true} {
+		live, paper := make(chan request, 1), make(chan request, 1)
+		t.Setenv("OKEX_REST_URL", endpoint(live).URL)
+		t.Setenv("OKEX_REST_URL_DEMO", endpoint(paper).URL)
+		if demo {
+			t.Setenv("OKEX_DEMO_TRADING", "true")
+		} else {
+			t.Setenv("OKEX_DEMO_TRADING", "false")
+		}
+
+		// Wired like the order connector
+		okex := config.LoadFromEnv().OKEX
+		client := NewClient(okex.TradingRESTURL(), ws.OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"})
+		client.SetSimulatedTrading(okex.DemoTrading)
+		if _, err := client.PlaceOrder(map[string]interface{}{"instId": "BTC-USDT", "side": "buy"}); err != nil {
+			t.Fatalf("demo=%v: place order: %v", demo, err)
+		}
+
+		var got request
+		select {
+		case got = <-live:
+			if demo {
+				t.Fatal("demo order was sent to the live endpoint")
+			}
+		case got = <-paper:
+			if !demo {
+				t.Fatal("live order was sent to the demo endpoint")
+			}
+		}
+		want := ""
+		if demo {
+			want = "1"
+		}
+		if got.path != placeOrderPath || got.simulated != want {
+			t.Fatalf("demo=%v: %s with %s %q, want %s with %q", demo, got.path, ws.SimulatedTradingHeader, got.simulated, placeOrderPath, want)
+		}
+	}
+}
+
+func TestOrderRequestIsSigned(t *testing.T) {
+	type signed struct{ sign, want string }
+	requests := make(chan signed, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(r.Header.Get("OK-ACCESS-TIMESTAMP") + r.Method + r.URL.RequestURI() + string(body)))
+		requests <- signed{r.Header.Get("OK-ACCESS-SIGN"), base64.StdEncoding.EncodeToString(mac.Sum(nil))}
+		w.Write([]byte(`{"code":"0","msg":"","data":[{"ordId":"7","sCode":"0"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, ws.OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"})
+	if _, err := client.CancelOrder("BTC-USDT", "7", ""); err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+	if got := <-requests; got.sign != got.want {
+		t.Fatalf("OK-ACCESS-SIGN %s, want %s", got.sign, got.want)
+	}
+}
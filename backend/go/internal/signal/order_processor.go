@@ -5,16 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/supermancell/okex-buddy/internal/common"
 	"github.com/supermancell/okex-buddy/internal/mongodb"
+	"github.com/supermancell/okex-buddy/internal/rest"
 	"github.com/supermancell/okex-buddy/internal/ws"
 )
 
 // OrderProcessor handles placing orders based on trading signals
 type OrderProcessor struct {
 	privateClient *ws.PrivateClient
+	restClient    *rest.Client // fallback when the private socket is unauthenticated
 	mongoClient   *mongodb.Client
 	ctx           context.Context
 	cancel        context.CancelFunc
@@ -33,6 +37,11 @@ func NewOrderProcessor(privateClient *ws.PrivateClient, mongoClient *mongodb.Cli
 	}
 }
 
+// SetRESTClient sets the REST client used as a fallback for placing orders
+func (p *OrderProcessor) SetRESTClient(restClient *rest.Client) {
+	p.restClient = restClient
+}
+
 // Start starts the order processor
 func (p *OrderProcessor) Start() {
 	log.Println("Order processor started")
@@ -45,15 +54,24 @@ func (p *OrderProcessor) Stop() {
 	p.cancel()
 }
 
-// PlaceOrder places an order based on trading signal
+// errProcessorStopped is returned by PlaceOrder once the processor is stopped
+var errProcessorStopped = fmt.Errorf("order processor stopped")
+
+// PlaceOrder places an order based on trading signal.
+// It refuses to place orders once the processor is stopped.
 func (p *OrderProcessor) PlaceOrder(signal *Signal) (clOrdID, ordID string, err error) {
-	if !p.privateClient.IsAuthenticated() {
+	if p.ctx.Err() != nil {
+		return "", "", errProcessorStopped
+	}
+
+	useREST := p.privateClient == nil || !p.privateClient.IsAuthenticated()
+	if useREST && p.restClient == nil {
 		return "", "", fmt.Errorf("private client not authenticated")
 	}
 
 	clOrdID = fmt.Sprintf("%d", time.Now().UnixMilli())
 
-	args := []map[string]string{
+	args := []map[string]interface{}{
 		{
 			"instId":     signal.InstID,
 			"tdMode":     "cross",
@@ -70,6 +88,24 @@ func (p *OrderProcessor) PlaceOrder(signal *Signal) (clOrdID, ordID string, err
 		args[0]["px"] = signal.Px
 	}
 
+	algoOrds, err := buildAttachAlgoOrds(signal)
+	if err != nil {
+		return "", "", err
+	}
+	if algoOrds != nil {
+		args[0]["attachAlgoOrds"] = algoOrds
+	}
+
+	if useREST {
+		log.Printf("Private WebSocket not authenticated, placing order for signal %s via REST", signal.SignalID)
+		result, err := p.restClient.PlaceOrder(args[0])
+		if err != nil {
+			return "", "", err
+		}
+		p.clOrdIDMap.Store(signal.SignalID, clOrdID)
+		return clOrdID, result.OrdID, nil
+	}
+
 	if err := p.privateClient.PlaceOrder(args); err != nil {
 		return "", "", err
 	}
@@ -79,6 +115,58 @@ func (p *OrderProcessor) PlaceOrder(signal *Signal) (clOrdID, ordID string, err
 	return clOrdID, "", nil
 }
 
+// buildAttachAlgoOrds builds the attachAlgoOrds field for the signal's take-profit and
+// stop-loss. Returns nil when neither is set. TP/SL execute at market (ordPx -1).
+// For limit orders the trigger prices must be on the profitable/losing side of px.
+func buildAttachAlgoOrds(signal *Signal) ([]map[string]string, error) {
+	if signal.TPTriggerPx == "" && signal.SlTriggerPx == "" {
+		return nil, nil
+	}
+
+	var entry float64
+	if signal.OrdType == "limit" && signal.Px != "" {
+		px, err := strconv.ParseFloat(signal.Px, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid px %q: %w", signal.Px, err)
+		}
+		entry = px
+	}
+
+	algo := map[string]string{}
+
+	if signal.TPTriggerPx != "" {
+		tp, err := strconv.ParseFloat(signal.TPTriggerPx, 64)
+		if err != nil || tp <= 0 {
+			return nil, fmt.Errorf("tp_trigger_px must be a positive number, got %q", signal.TPTriggerPx)
+		}
+		if entry > 0 && ((signal.Side == "buy" && tp <= entry) || (signal.Side == "sell" && tp >= entry)) {
+			return nil, fmt.Errorf("tp_trigger_px %s is on the wrong side of entry %s for a %s order", signal.TPTriggerPx, signal.Px, signal.Side)
+		}
+		algo["tpTriggerPx"] = signal.TPTriggerPx
+		algo["tpOrdPx"] = "-1"
+		if signal.TPTriggerPxType != "" {
+			algo["tpTriggerPxType"] = signal.TPTriggerPxType
+		}
+	}
+
+	if signal.SlTriggerPx != "" {
+		sl, err := strconv.ParseFloat(signal.SlTriggerPx, 64)
+		if err != nil || sl <= 0 {
+			return nil, fmt.Errorf("sl_trigger_px must be a positive number, got %q", signal.SlTriggerPx)
+		}
+		if entry > 0 && ((signal.Side == "buy" && sl >= entry) || (signal.Side == "sell" && sl <= entry)) {
+			return nil, fmt.Errorf("sl_trigger_px %s is on the wrong side of entry %s for a %s order", signal.SlTriggerPx, signal.Px, signal.Side)
+		}
+		algo["slTriggerPx"] = signal.SlTriggerPx
+		algo["slOrdPx"] = "-1"
+		if signal.SlTriggerPxType != "" {
+			algo["slTriggerPxType"] = signal.SlTriggerPxType
+		}
+	}
+
+	return []map[string]string{algo}, nil
+}
+
 // HandleOrderResponse handles order response from WebSocket
 func (p *OrderProcessor) HandleOrderResponse(message []byte) error {
 	log.Printf("[DEBUG] Received order response: %s", string(message))
@@ -103,7 +191,7 @@ func (p *OrderProcessor) HandleOrderResponse(message []byte) error {
 		log.Printf("[DEBUG] Found %d items in data array", len(data))
 		if order, ok := data[0].(map[string]interface{}); ok {
 			log.Printf("[DEBUG] Processing order data: %+v", order)
-			if clOrdID, ok := order["clOrdId"].(string); ok {
+			if clOrdID := common.AsString(order["clOrdId"]); clOrdID != "" {
 				log.Printf("[DEBUG] Found client order ID: %s", clOrdID)
 				signalID := p.findSignalIDByClOrdID(clOrdID)
 				if signalID != "" {
@@ -0,0 +1,129 @@
+package signal
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/rest"
+	"github.com/supermancell/okex-buddy/internal/ws"
+)
+
+func TestPlaceOrderFallsBackToRESTWithoutPrivateSocket(t *testing.T) {
+	config := ws.OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"}
+
+	var order map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sign := ws.BuildRequestSign(config.SecretKey, r.Header.Get("OK-ACCESS-TIMESTAMP"), r.Method, r.URL.Path, string(body))
+		if r.Header.Get("OK-ACCESS-SIGN") != sign || r.Header.Get("OK-ACCESS-KEY") != "key" {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &order)
+		w.Write([]byte(`{"code":"0","msg":"","data":[{"ordId":"12345","clOrdId":"","sCode":"0","sMsg":""}]}`))
+	}))
+	defer server.Close()
+
+	// ConnectPrivateWebSocket returns nil when the socket cannot connect or log in
+	processor := NewOrderProcessor(nil, nil)
+	processor.SetRESTClient(rest.NewClient(server.URL, config))
+
+	clOrdID, ordID, err := processor.PlaceOrder(&Signal{
+		SignalID: "s1", InstID: "BTC-USDT-SWAP", Side: "buy", OrdType: "market", PosSide: "long", Sz: "1",
+	})
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+	if ordID != "12345" || clOrdID == "" {
+		t.Fatalf("clOrdID=%q ordID=%q, want the REST order", clOrdID, ordID)
+	}
+	if order["instId"] != "BTC-USDT-SWAP" || order["clOrdId"] != clOrdID {
+		t.Fatalf("REST order body = %v", order)
+	}
+}
+
+func TestPlaceOrderWithoutAnyClient(t *testing.T) {
+	processor := NewOrderProcessor(nil, nil)
+	if _, _, err := processor.PlaceOrder(&Signal{SignalID: "s1", InstID: "BTC-USDT-SWAP", Side: "buy", OrdType: "market", PosSide: "long", Sz: "1"}); err == nil {
+		t.Fatal("placed an order without a private socket or REST client")
+	}
+}
+
+func TestPlaceOrderAttachesTPSL(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"code":"0","msg":"","data":[{"ordId":"1","sCode":"0"}]}`))
+	}))
+	defer server.Close()
+
+	processor := NewOrderProcessor(nil, nil)
+	processor.SetRESTClient(rest.NewClient(server.URL, ws.OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"}))
+
+	tests := []struct {
+		name   string
+		signal Signal
+		want   string // attachAlgoOrds as JSON, empty when it must be omitted
+	}{
+		{"no TP/SL", Signal{Side: "buy", OrdType: "market"}, ""},
+		{"TP and SL on a limit buy",
+			Signal{Side: "buy", OrdType: "limit", Px: "60000", TPTriggerPx: "66000", TPTriggerPxType: "mark", SlTriggerPx: "57000"},
+			`[{"slOrdPx":"-1","slTriggerPx":"57000","tpOrdPx":"-1","tpTriggerPx":"66000","tpTriggerPxType":"mark"}]`},
+		{"SL only on a market sell",
+			Signal{Side: "sell", OrdType: "market", SlTriggerPx: "63000", SlTriggerPxType: "last"},
+			`[{"slOrdPx":"-1","slTriggerPx":"63000","slTriggerPxType":"last"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			tt.signal.SignalID, tt.signal.InstID, tt.signal.PosSide, tt.signal.Sz = tt.name, "BTC-USDT-SWAP", "net", "1"
+			if _, _, err := processor.PlaceOrder(&tt.signal); err != nil {
+				t.Fatalf("place order: %v", err)
+			}
+			if len(bodies) != 1 {
+				t.Fatalf("%d requests, want 1", len(bodies))
+			}
+
+			var order map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(bodies[0]), &order); err != nil {
+				t.Fatalf("order body %s: %v", bodies[0], err)
+			}
+			algo, attached := order["attachAlgoOrds"]
+			if tt.want == "" {
+				if attached {
+					t.Fatalf("attachAlgoOrds sent without TP/SL: %s", algo)
+				}
+				return
+			}
+			if string(algo) != tt.want {
+				t.Fatalf("attachAlgoOrds = %s, want %s", algo, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceOrderRejectsInvalidTPSL(t *testing.T) {
+	processor := NewOrderProcessor(nil, nil)
+	// No request may reach the exchange
+	processor.SetRESTClient(rest.NewClient("http://127.0.0.1:1", ws.OKExConfig{}))
+
+	for name, signal := range map[string]Signal{
+		"non-numeric TP":         {Side: "buy", OrdType: "market", TPTriggerPx: "high"},
+		"negative SL":            {Side: "buy", OrdType: "market", SlTriggerPx: "-5"},
+		"buy TP below entry":     {Side: "buy", OrdType: "limit", Px: "100", TPTriggerPx: "95"},
+		"buy SL above entry":     {Side: "buy", OrdType: "limit", Px: "100", SlTriggerPx: "101"},
+		"sell TP above entry":    {Side: "sell", OrdType: "limit", Px: "100", TPTriggerPx: "110"},
+		"sell SL at entry price": {Side: "sell", OrdType: "limit", Px: "100", SlTriggerPx: "100"},
+	} {
+		signal.SignalID, signal.InstID, signal.Sz = name, "BTC-USDT-SWAP", "1"
+		_, _, err := processor.PlaceOrder(&signal)
+		if err == nil || !strings.Contains(err.Error(), "trigger_px") {
+			t.Errorf("%s: err = %v, want a trigger price validation error", name, err)
+		}
+	}
+}
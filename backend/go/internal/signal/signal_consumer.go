@@ -12,6 +12,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/supermancell/okex-buddy/internal/mongodb"
 	"github.com/supermancell/okex-buddy/internal/redisclient"
+	"github.com/supermancell/okex-buddy/internal/rest"
 	"github.com/supermancell/okex-buddy/internal/ws"
 )
 
@@ -35,19 +36,37 @@ type Signal struct {
 	Timestamp       int64  `json:"timestamp"`
 }
 
+// seenSignalKeyFormat marks a signal_id as claimed so a redelivered signal is not traded twice
+const seenSignalKeyFormat = "trading_signals:seen:%s"
+
+// seenSignalTTL is how long a processed signal_id is remembered in Redis;
+// older duplicates are still caught by the MongoDB upsert
+const seenSignalTTL = 24 * time.Hour
+
+// SignalStore records trading signals and the state of their orders, implemented by mongodb.Client
+type SignalStore interface {
+	InsertTradingSignal(signal *mongodb.TradingSignal) (inserted bool, err error)
+	UpdateSignalWithOrderID(signalID, ordID, clOrdID, status string) error
+	UpdateSignalStatusWithError(signalID, status, errorMsg string) error
+}
+
 // SignalConsumer consumes trading signals from Redis List
 type SignalConsumer struct {
-	redisClient   *redis.Client
-	mongoClient   *mongodb.Client
+	redisClient   redis.Cmdable
+	mongoClient   SignalStore
 	strategies    []string
 	timeout       time.Duration
 	ctx           context.Context
 	cancel        context.CancelFunc
 	orderCallback func(*Signal) (string, string, error)
+	wg            sync.WaitGroup // consumeSignals goroutines, waited for by Stop
 }
 
+// consumeErrorBackoff delays the next BRPOP after a Redis error, instead of spinning
+const consumeErrorBackoff = time.Second
+
 // NewSignalConsumer creates a new signal consumer
-func NewSignalConsumer(redisClient *redis.Client, mongoClient *mongodb.Client, strategies []string) *SignalConsumer {
+func NewSignalConsumer(redisClient redis.Cmdable, mongoClient SignalStore, strategies []string) *SignalConsumer {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &SignalConsumer{
 		redisClient: redisClient,
@@ -64,26 +83,27 @@ func (c *SignalConsumer) SetOrderCallback(callback func(*Signal) (string, string
 	c.orderCallback = callback
 }
 
-// Start starts consuming signals from Redis
+// Start starts one consumer goroutine per strategy and returns. The goroutines are
+// registered before Start returns, so a following Stop always waits for them.
 func (c *SignalConsumer) Start() {
 	log.Printf("Signal consumer started, watching strategies: %v", c.strategies)
 
-	var wg sync.WaitGroup
+	c.wg.Add(len(c.strategies))
 	for _, strategy := range c.strategies {
-		wg.Add(1)
 		go func(strategyName string) {
-			defer wg.Done()
+			defer c.wg.Done()
 			c.consumeSignals(strategyName)
 		}(strategy)
 	}
-
-	<-c.ctx.Done()
-	log.Println("Signal consumer stopping...")
 }
 
-// Stop stops the signal consumer
+// Stop stops the signal consumer and blocks until every consumer goroutine has returned,
+// so that no signal is processed after Stop returns
 func (c *SignalConsumer) Stop() {
+	log.Println("Signal consumer stopping...")
 	c.cancel()
+	c.wg.Wait()
+	log.Println("Signal consumer stopped")
 }
 
 // consumeSignals consumes signals from a specific strategy
@@ -97,8 +117,16 @@ func (c *SignalConsumer) consumeSignals(strategyName string) {
 		default:
 			result, err := c.redisClient.BRPop(c.ctx, c.timeout, key).Result()
 			if err != nil {
+				if c.ctx.Err() != nil {
+					return
+				}
 				if err != redis.Nil {
 					log.Printf("Error consuming signal from %s: %v", key, err)
+					select {
+					case <-time.After(consumeErrorBackoff):
+					case <-c.ctx.Done():
+						return
+					}
 				}
 				continue
 			}
@@ -109,6 +137,15 @@ func (c *SignalConsumer) consumeSignals(strategyName string) {
 			}
 
 			signalData := result[1]
+
+			// Popped while shutting down: put it back for the next run instead of trading now
+			if c.ctx.Err() != nil {
+				if err := c.redisClient.RPush(context.Background(), key, signalData).Err(); err != nil {
+					log.Printf("Failed to requeue signal to %s during shutdown: %v", key, err)
+				}
+				return
+			}
+
 			if err := c.processSignal(signalData); err != nil {
 				log.Printf("Error processing signal: %v", err)
 			}
@@ -127,6 +164,17 @@ func (c *SignalConsumer) processSignal(signalData string) error {
 		return fmt.Errorf("signal validation failed: %w", err)
 	}
 
+	// Claim the signal_id atomically so concurrent or redelivered copies are skipped
+	seenKey := fmt.Sprintf(seenSignalKeyFormat, signal.SignalID)
+	claimed, err := c.redisClient.SetNX(c.ctx, seenKey, time.Now().UnixMilli(), seenSignalTTL).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check signal %s for duplicates: %w", signal.SignalID, err)
+	}
+	if !claimed {
+		log.Printf("Duplicate signal %s skipped", signal.SignalID)
+		return nil
+	}
+
 	tradingSignal := &mongodb.TradingSignal{
 		ID:               fmt.Sprintf("signal_%s", signal.SignalID),
 		SignalID:         signal.SignalID,
@@ -145,9 +193,16 @@ func (c *SignalConsumer) processSignal(signalData string) error {
 		UpdatedAt:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 	}
 
-	if err := c.mongoClient.InsertTradingSignal(tradingSignal); err != nil {
+	inserted, err := c.mongoClient.InsertTradingSignal(tradingSignal)
+	if err != nil {
+		// Release the claim so a redelivery can be recorded
+		c.redisClient.Del(c.ctx, seenKey)
 		return fmt.Errorf("failed to insert trading signal: %w", err)
 	}
+	if !inserted {
+		log.Printf("Duplicate signal %s already recorded, skipped", signal.SignalID)
+		return nil
+	}
 
 	log.Printf("Signal recorded: %s (inst=%s, side=%s, type=%s)",
 		signal.SignalID, signal.InstID, signal.Side, signal.OrdType)
@@ -220,18 +275,29 @@ func (c *SignalConsumer) GetSignalStatus(signalID string) (string, error) {
 	return "", nil
 }
 
-// StartSignalConsumer starts the trading signal consumer
-func StartSignalConsumer(redisClient *redisclient.Client, mongoClient *mongodb.Client, privateClient *ws.PrivateClient) {
+// StartSignalConsumer starts the trading signal consumer.
+// restClient is optional and used as a fallback when the private WebSocket is unauthenticated.
+// The returned function stops the consumer and the order processor, and returns once no
+// more orders can be placed.
+func StartSignalConsumer(redisClient *redisclient.Client, mongoClient *mongodb.Client, privateClient *ws.PrivateClient, restClient *rest.Client) (stop func()) {
 	strategies := []string{"momentum_strategy"}
 	consumer := NewSignalConsumer(redisClient.Client(), mongoClient, strategies)
 
 	orderProcessor := NewOrderProcessor(privateClient, mongoClient)
+	if restClient != nil {
+		orderProcessor.SetRESTClient(restClient)
+	}
 	consumer.SetOrderCallback(func(sig *Signal) (string, string, error) {
 		return orderProcessor.PlaceOrder(sig)
 	})
 
-	go consumer.Start()
+	consumer.Start()
 	go orderProcessor.Start()
 
 	log.Println("Signal consumer started")
+
+	return func() {
+		consumer.Stop()
+		orderProcessor.Stop()
+	}
 }
@@ -0,0 +1,206 @@
+package signal
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// claimRedis implements the SETNX/DEL claims of SignalConsumer in memory; any other
+// Redis command panics on the nil embedded Cmdable
+type claimRedis struct {
+	redis.Cmdable
+
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func newClaimRedis() *claimRedis {
+	return &claimRedis{keys: make(map[string]bool)}
+}
+
+func (r *claimRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmd := redis.NewBoolCmd(ctx)
+	cmd.SetVal(!r.keys[key])
+	r.keys[key] = true
+	return cmd
+}
+
+func (r *claimRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmd := redis.NewIntCmd(ctx)
+	for _, key := range keys {
+		if r.keys[key] {
+			delete(r.keys, key)
+			cmd.SetVal(cmd.Val() + 1)
+		}
+	}
+	return cmd
+}
+
+// memorySignalStore is a SignalStore upserting on signal_id like mongodb.Client
+type memorySignalStore struct {
+	mu      sync.Mutex
+	signals map[string]*mongodb.TradingSignal
+}
+
+func newMemorySignalStore() *memorySignalStore {
+	return &memorySignalStore{signals: make(map[string]*mongodb.TradingSignal)}
+}
+
+func (s *memorySignalStore) InsertTradingSignal(signal *mongodb.TradingSignal) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.signals[signal.SignalID]; exists {
+		return false, nil
+	}
+	s.signals[signal.SignalID] = signal
+	return true, nil
+}
+
+func (s *memorySignalStore) UpdateSignalWithOrderID(signalID, ordID, clOrdID, status string) error {
+	return nil
+}
+
+func (s *memorySignalStore) UpdateSignalStatusWithError(signalID, status, errorMsg string) error {
+	return nil
+}
+
+const testSignal = `{"signal_id":"sig-1","strategy_name":"momentum_strategy","inst_id":"BTC-USDT-SWAP",
+"side":"buy","ord_type":"market","pos_side":"long","sz":"1","timestamp":1717000000000}`
+
+// countOrders sets an order callback on consumer and returns the number of orders placed
+func countOrders(consumer *SignalConsumer) *int {
+	orders := 0
+	consumer.SetOrderCallback(func(*Signal) (string, string, error) {
+		orders++
+		return "cl-1", "ord-1", nil
+	})
+	return &orders
+}
+
+func TestDuplicateSignalPlacesOneOrder(t *testing.T) {
+	consumer := NewSignalConsumer(newClaimRedis(), newMemorySignalStore(), nil)
+	orders := countOrders(consumer)
+
+	for i := 0; i < 2; i++ {
+		if err := consumer.processSignal(testSignal); err != nil {
+			t.Fatalf("delivery %d: %v", i, err)
+		}
+	}
+	if *orders != 1 {
+		t.Fatalf("%d orders placed for a signal delivered twice, want 1", *orders)
+	}
+}
+
+func TestDuplicateSignalAfterClaimExpired(t *testing.T) {
+	store := newMemorySignalStore()
+
+	first := NewSignalConsumer(newClaimRedis(), store, nil)
+	firstOrders := countOrders(first)
+	if err := first.processSignal(testSignal); err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+
+	// The Redis claim is gone (TTL, flushed Redis); the MongoDB upsert still catches it
+	second := NewSignalConsumer(newClaimRedis(), store, nil)
+	secondOrders := countOrders(second)
+	if err := second.processSignal(testSignal); err != nil {
+		t.Fatalf("second delivery: %v", err)
+	}
+
+	if *firstOrders+*secondOrders != 1 {
+		t.Fatalf("%d orders placed, want 1", *firstOrders+*secondOrders)
+	}
+}
+
+// queueRedis serves queued signals to BRPOP and blocks on an empty queue until the
+// context is done, like a BRPOP waiting on Redis
+type queueRedis struct {
+	*claimRedis
+	queue chan string
+}
+
+func (r *queueRedis) BRPop(ctx context.Context, timeout time.Duration, keys ...string) *redis.StringSliceCmd {
+	cmd := redis.NewStringSliceCmd(ctx)
+	select {
+	case data := <-r.queue:
+		cmd.SetVal([]string{keys[0], data})
+	case <-ctx.Done():
+		cmd.SetErr(ctx.Err())
+	}
+	return cmd
+}
+
+func TestStopWaitsForInFlightConsumers(t *testing.T) {
+	rdb := &queueRedis{claimRedis: newClaimRedis(), queue: make(chan string, 1)}
+	rdb.queue <- testSignal
+
+	consumer := NewSignalConsumer(rdb, newMemorySignalStore(), []string{"momentum_strategy"})
+	placing := make(chan struct{})
+	release := make(chan struct{})
+	var placed int32
+	consumer.SetOrderCallback(func(*Signal) (string, string, error) {
+		close(placing)
+		<-release
+		atomic.StoreInt32(&placed, 1)
+		return "cl-1", "ord-1", nil
+	})
+	consumer.Start()
+
+	select {
+	case <-placing:
+	case <-time.After(2 * time.Second):
+		t.Fatal("signal was not consumed")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		consumer.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while an order was being placed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return after the consumer exited")
+	}
+	if atomic.LoadInt32(&placed) != 1 {
+		t.Fatal("Stop returned before the in-flight order finished")
+	}
+}
+
+func TestStopRightAfterStart(t *testing.T) {
+	rdb := &queueRedis{claimRedis: newClaimRedis(), queue: make(chan string)}
+	consumer := NewSignalConsumer(rdb, newMemorySignalStore(), []string{"a", "b", "c"})
+	consumer.Start()
+
+	stopped := make(chan struct{})
+	go func() {
+		consumer.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
@@ -1,12 +1,18 @@
 package subscription
 
 import (
+	"fmt"
 	"log"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/supermancell/okex-buddy/internal/common"
 )
 
+// defaultMaxPairs is the default cap on subscribed trading pairs, see SetMaxPairs
+const defaultMaxPairs = 10
+
 // SubscriptionManager manages dynamic subscription changes based on Redis config
 type SubscriptionManager struct {
 	client       common.WSClientInterface
@@ -14,6 +20,18 @@ type SubscriptionManager struct {
 	configKey    string
 	pollInterval time.Duration
 	stopChan     chan struct{}
+
+	maxPairs     int            // cap on subscribed pairs, see SetMaxPairs
+	priority     map[string]int // rank of pairs kept first under the cap, see SetPairPriority
+	droppedPairs []string       // configured pairs beyond maxPairs at the last sync
+	droppedMu    sync.Mutex
+
+	failedPairs []string // pairs whose subscribe or unsubscribe failed at the last sync
+	failedMu    sync.Mutex
+
+	invalidKey string          // Redis set of pairs rejected by OKEx
+	invalid    map[string]bool // known bad pairs, never requested again
+	invalidMu  sync.Mutex
 }
 
 // RedisConfigReader interface for reading trading pairs from Redis
@@ -21,6 +39,22 @@ type RedisConfigReader interface {
 	GetTradingPairs(key string) ([]string, error)
 }
 
+// RedisConfigWriter is optionally implemented by the Redis client to persist known bad pairs
+type RedisConfigWriter interface {
+	AddTradingPairs(key string, pairs ...string) error
+}
+
+// subscribeErrorNotifier is implemented by ws clients that report rejected subscriptions
+type subscribeErrorNotifier interface {
+	SetSubscribeErrorHandler(handler func(instID string, err error))
+}
+
+// requestTracker is implemented by ws clients whose GetSubscribed only lists acknowledged
+// instruments; GetRequestedInstruments also lists those still awaiting an acknowledgement
+type requestTracker interface {
+	GetRequestedInstruments() []string
+}
+
 // NewSubscriptionManager creates a new subscription manager
 func NewSubscriptionManager(client common.WSClientInterface, redisClient RedisConfigReader, configKey string, pollInterval int) *SubscriptionManager {
 	return &SubscriptionManager{
@@ -29,11 +63,65 @@ func NewSubscriptionManager(client common.WSClientInterface, redisClient RedisCo
 		configKey:    configKey,
 		pollInterval: time.Duration(pollInterval) * time.Second,
 		stopChan:     make(chan struct{}),
+		maxPairs:     defaultMaxPairs,
+		invalidKey:   configKey + ":invalid",
+		invalid:      make(map[string]bool),
+	}
+}
+
+// SetMaxPairs sets how many configured pairs are subscribed at most; pairs beyond the
+// cap are not subscribed and reported by DroppedPairs. Must be called before Start.
+func (sm *SubscriptionManager) SetMaxPairs(maxPairs int) {
+	if maxPairs > 0 {
+		sm.maxPairs = maxPairs
 	}
 }
 
+// SetPairPriority sets the order in which configured pairs are kept when there are more than
+// the max pairs cap: listed pairs come first in list order, unlisted pairs follow in config
+// order. Without a priority list the first configured pairs are kept. Must be called before Start.
+func (sm *SubscriptionManager) SetPairPriority(pairs []string) {
+	sm.priority = make(map[string]int, len(pairs))
+	for i, pair := range pairs {
+		if _, exists := sm.priority[pair]; !exists {
+			sm.priority[pair] = i
+		}
+	}
+}
+
+// DroppedPairs returns the configured pairs left out by the max pairs cap at the last sync
+func (sm *SubscriptionManager) DroppedPairs() []string {
+	sm.droppedMu.Lock()
+	defer sm.droppedMu.Unlock()
+	return append([]string(nil), sm.droppedPairs...)
+}
+
+// FailedPairs returns the pairs whose subscribe or unsubscribe request could not be sent at
+// the last sync. They are retried on the next poll.
+func (sm *SubscriptionManager) FailedPairs() []string {
+	sm.failedMu.Lock()
+	defer sm.failedMu.Unlock()
+	return append([]string(nil), sm.failedPairs...)
+}
+
 // Start initializes subscriptions and starts polling for config changes
 func (sm *SubscriptionManager) Start() error {
+	// Load pairs previously rejected by OKEx
+	if invalid, err := sm.redisClient.GetTradingPairs(sm.invalidKey); err != nil {
+		log.Printf("Failed to read invalid trading pairs from Redis: %v", err)
+	} else if len(invalid) > 0 {
+		log.Printf("Skipping %d known invalid trading pairs: %v", len(invalid), invalid)
+		sm.invalidMu.Lock()
+		for _, pair := range invalid {
+			sm.invalid[pair] = true
+		}
+		sm.invalidMu.Unlock()
+	}
+
+	if notifier, ok := sm.client.(subscribeErrorNotifier); ok {
+		notifier.SetSubscribeErrorHandler(sm.markInvalid)
+	}
+
 	// Initial subscription from Redis config
 	if err := sm.syncSubscriptions(); err != nil {
 		return err
@@ -67,7 +155,10 @@ func (sm *SubscriptionManager) pollConfigChanges() {
 	}
 }
 
-// syncSubscriptions synchronizes current subscriptions with Redis config
+// syncSubscriptions synchronizes current subscriptions with Redis config. Pairs whose
+// subscribe could not be sent are requested again on the next sync; pairs already requested
+// are not, even while OKEx has not acknowledged them, so a channel that never acks doesn't
+// make every poll resend the subscribe.
 func (sm *SubscriptionManager) syncSubscriptions() error {
 	// Get latest config from Redis
 	latestPairs, err := sm.redisClient.GetTradingPairs(sm.configKey)
@@ -76,44 +167,130 @@ func (sm *SubscriptionManager) syncSubscriptions() error {
 		return err
 	}
 
-	// Enforce max 10 pairs limit
-	if len(latestPairs) > 10 {
-		log.Printf("WARNING: Config has %d trading pairs, limiting to 10", len(latestPairs))
-		latestPairs = latestPairs[:10]
+	// Enforce the max pairs limit, dropping the lowest-priority pairs
+	var dropped []string
+	if len(latestPairs) > sm.maxPairs {
+		latestPairs = sm.byPriority(latestPairs)
+		dropped = latestPairs[sm.maxPairs:]
+		latestPairs = latestPairs[:sm.maxPairs]
 	}
+	sm.setDroppedPairs(dropped)
 
-	// Get current subscriptions
-	currentPairs := sm.client.GetSubscribed()
+	// Get current subscriptions, acknowledged or still pending
+	var currentPairs []string
+	if tracker, ok := sm.client.(requestTracker); ok {
+		currentPairs = tracker.GetRequestedInstruments()
+	} else {
+		currentPairs = sm.client.GetSubscribed()
+	}
 
-	// Calculate differences
-	toSubscribe := difference(latestPairs, currentPairs)
+	// Calculate differences, never re-requesting pairs OKEx already rejected
+	toSubscribe := difference(difference(latestPairs, sm.InvalidPairs()), currentPairs)
 	toUnsubscribe := difference(currentPairs, latestPairs)
 
 	// No changes needed
 	if len(toSubscribe) == 0 && len(toUnsubscribe) == 0 {
+		sm.setFailedPairs(nil)
 		return nil
 	}
 
 	log.Printf("Config changed: subscribing to %d pairs, unsubscribing from %d pairs", len(toSubscribe), len(toUnsubscribe))
 
 	// Unsubscribe first
+	var failedUnsubscribe []string
 	if len(toUnsubscribe) > 0 {
 		if err := sm.client.Unsubscribe(toUnsubscribe); err != nil {
 			log.Printf("Failed to unsubscribe: %v", err)
+			failedUnsubscribe = toUnsubscribe
 		}
 	}
 
-	// Then subscribe to new ones
-	if len(toSubscribe) > 0 {
-		if err := sm.client.Subscribe(toSubscribe); err != nil {
-			log.Printf("Failed to subscribe: %v", err)
-			return err
+	// Then subscribe to new ones, one pair per message so that a single invalid
+	// symbol cannot make OKEx reject the valid ones
+	var failed []string
+	for _, pair := range toSubscribe {
+		if err := sm.client.Subscribe([]string{pair}); err != nil {
+			log.Printf("Failed to subscribe to %s: %v", pair, err)
+			failed = append(failed, pair)
 		}
 	}
+	sm.setFailedPairs(append(append([]string(nil), failed...), failedUnsubscribe...))
+
+	if len(failed) > 0 || len(failedUnsubscribe) > 0 {
+		return fmt.Errorf("failed to subscribe to %d of %d pairs %v and unsubscribe from %d of %d pairs %v, retrying on the next poll",
+			len(failed), len(toSubscribe), failed, len(failedUnsubscribe), len(toUnsubscribe), failedUnsubscribe)
+	}
 
 	return nil
 }
 
+// setFailedPairs records the pairs whose requests failed at the last sync
+func (sm *SubscriptionManager) setFailedPairs(failed []string) {
+	sm.failedMu.Lock()
+	defer sm.failedMu.Unlock()
+	sm.failedPairs = failed
+}
+
+// byPriority returns pairs ordered by SetPairPriority, unlisted pairs last in their original order
+func (sm *SubscriptionManager) byPriority(pairs []string) []string {
+	if len(sm.priority) == 0 {
+		return pairs
+	}
+
+	sorted := append([]string(nil), pairs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		rankI, listedI := sm.priority[sorted[i]]
+		rankJ, listedJ := sm.priority[sorted[j]]
+		if listedI != listedJ {
+			return listedI
+		}
+		return listedI && rankI < rankJ
+	})
+	return sorted
+}
+
+// setDroppedPairs records the pairs left out by the cap, logging only when they change
+func (sm *SubscriptionManager) setDroppedPairs(dropped []string) {
+	sm.droppedMu.Lock()
+	defer sm.droppedMu.Unlock()
+
+	if len(difference(dropped, sm.droppedPairs)) == 0 && len(difference(sm.droppedPairs, dropped)) == 0 {
+		return
+	}
+	if len(dropped) > 0 {
+		log.Printf("WARNING: Config has %d trading pairs, limit is %d, not subscribing: %v",
+			sm.maxPairs+len(dropped), sm.maxPairs, dropped)
+	}
+	sm.droppedPairs = dropped
+}
+
+// markInvalid records a pair OKEx reported as nonexistent and persists it to Redis
+func (sm *SubscriptionManager) markInvalid(instID string, err error) {
+	log.Printf("Trading pair %s marked invalid: %v", instID, err)
+
+	sm.invalidMu.Lock()
+	sm.invalid[instID] = true
+	sm.invalidMu.Unlock()
+
+	if writer, ok := sm.redisClient.(RedisConfigWriter); ok {
+		if err := writer.AddTradingPairs(sm.invalidKey, instID); err != nil {
+			log.Printf("Failed to persist invalid trading pair %s: %v", instID, err)
+		}
+	}
+}
+
+// InvalidPairs returns the pairs that OKEx rejected and that are no longer requested
+func (sm *SubscriptionManager) InvalidPairs() []string {
+	sm.invalidMu.Lock()
+	defer sm.invalidMu.Unlock()
+
+	pairs := make([]string, 0, len(sm.invalid))
+	for pair := range sm.invalid {
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
 // difference returns elements in a that are not in b
 func difference(a, b []string) []string {
 	mb := make(map[string]bool, len(b))
@@ -0,0 +1,190 @@
+package subscription
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFailedSubscribeIsNotTrackedAndRetried(t *testing.T) {
+	client := newFakeWSClient("ETH-USDT")
+	sm := NewSubscriptionManager(client, StaticPairs{Key: "pairs", Pairs: []string{"BTC-USDT", "ETH-USDT"}}, "pairs", 60)
+
+	if err := sm.syncSubscriptions(); err == nil {
+		t.Fatal("sync with a failed subscribe returned no error")
+	}
+	if got := client.GetSubscribed(); len(got) != 1 || got[0] != "BTC-USDT" {
+		t.Fatalf("subscribed = %v, want only BTC-USDT", got)
+	}
+	if failed := sm.FailedPairs(); len(failed) != 1 || failed[0] != "ETH-USDT" {
+		t.Fatalf("failed pairs = %v, want ETH-USDT", failed)
+	}
+
+	// The next poll retries only the failed pair
+	client.mu.Lock()
+	delete(client.failing, "ETH-USDT")
+	client.mu.Unlock()
+	if err := sm.syncSubscriptions(); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got := client.GetSubscribed(); len(got) != 2 {
+		t.Fatalf("subscribed after retry = %v, want both pairs", got)
+	}
+	if failed := sm.FailedPairs(); len(failed) != 0 {
+		t.Fatalf("failed pairs after retry = %v", failed)
+	}
+}
+
+// pairList builds n pairs named P00-USDT, P01-USDT, ...
+func pairList(n int) []string {
+	pairs := make([]string, n)
+	for i := range pairs {
+		pairs[i] = fmt.Sprintf("P%02d-USDT", i)
+	}
+	return pairs
+}
+
+func TestMaxPairsCapReportsDropped(t *testing.T) {
+	client := newFakeWSClient()
+	pairs := pairList(15)
+	sm := NewSubscriptionManager(client, StaticPairs{Key: "pairs", Pairs: pairs}, "pairs", 60)
+	sm.SetMaxPairs(5)
+
+	if err := sm.syncSubscriptions(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got := client.GetSubscribed(); len(got) != 5 || got[0] != "P00-USDT" || got[4] != "P04-USDT" {
+		t.Fatalf("subscribed = %v, want the first 5 pairs", got)
+	}
+	dropped := sm.DroppedPairs()
+	if len(dropped) != 10 {
+		t.Fatalf("dropped %d pairs %v, want 10", len(dropped), dropped)
+	}
+	for i, pair := range dropped {
+		if pair != pairs[5+i] {
+			t.Fatalf("dropped = %v, want P05-USDT..P14-USDT", dropped)
+		}
+	}
+}
+
+func TestPriorityKeepsHighestRankedPairsUnderCap(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority []string
+		want     []string
+	}{
+		{"listed pairs in priority order", []string{"P06-USDT", "P02-USDT", "P07-USDT", "P01-USDT"}, []string{"P02-USDT", "P06-USDT", "P07-USDT"}},
+		{"unlisted pairs fill up in config order", []string{"P05-USDT", "NOT-CONFIGURED"}, []string{"P00-USDT", "P01-USDT", "P05-USDT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeWSClient()
+			sm := NewSubscriptionManager(client, StaticPairs{Key: "pairs", Pairs: pairList(8)}, "pairs", 60)
+			sm.SetMaxPairs(3)
+			sm.SetPairPriority(tt.priority)
+
+			if err := sm.syncSubscriptions(); err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+			got := client.GetSubscribed()
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("subscribed = %v, want %v", got, tt.want)
+			}
+			if dropped := sm.DroppedPairs(); len(dropped) != 5 {
+				t.Fatalf("dropped = %v, want the 5 lowest-priority pairs", dropped)
+			}
+		})
+	}
+}
+
+// okexLikeClient mimics the public client: it acknowledges valid pairs and reports
+// nonexistent ones through the subscribe error handler, like a 60018 error event
+type okexLikeClient struct {
+	*fakeWSClient
+	exists   map[string]bool
+	messages [][]string // pairs of every subscribe message, in order
+	onError  func(instID string, err error)
+}
+
+func (c *okexLikeClient) SetSubscribeErrorHandler(handler func(instID string, err error)) {
+	c.onError = handler
+}
+
+func (c *okexLikeClient) Subscribe(params interface{}) error {
+	pairs := params.([]string)
+	c.messages = append(c.messages, pairs)
+	for _, pair := range pairs {
+		if !c.exists[pair] {
+			// OKEx rejects the whole message when one of its args doesn't exist
+			c.onError(pair, fmt.Errorf("code=60018, msg=instId:%s doesn't exist", pair))
+			return nil
+		}
+	}
+	return c.fakeWSClient.Subscribe(pairs)
+}
+
+// pairStore is an in-memory Redis for trading pair sets
+type pairStore map[string][]string
+
+func (s pairStore) GetTradingPairs(key string) ([]string, error) { return s[key], nil }
+
+func (s pairStore) AddTradingPairs(key string, pairs ...string) error {
+	s[key] = append(s[key], pairs...)
+	return nil
+}
+
+func TestInvalidSymbolDoesNotBlockValidPairs(t *testing.T) {
+	store := pairStore{"pairs": {"BTC-USDT", "NOPE-USDT", "ETH-USDT", "SOL-USDT"}}
+	newClient := func() *okexLikeClient {
+		return &okexLikeClient{
+			fakeWSClient: newFakeWSClient(),
+			exists:       map[string]bool{"BTC-USDT": true, "ETH-USDT": true, "SOL-USDT": true},
+		}
+	}
+
+	client := newClient()
+	sm := NewSubscriptionManager(client, store, "pairs", 60)
+	if err := sm.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer sm.Stop()
+
+	for _, msg := range client.messages {
+		if len(msg) != 1 {
+			t.Fatalf("subscribe message with %d pairs %v, want one pair per message", len(msg), msg)
+		}
+	}
+	if got := client.GetSubscribed(); fmt.Sprint(got) != "[BTC-USDT ETH-USDT SOL-USDT]" {
+		t.Fatalf("subscribed = %v, want every valid pair", got)
+	}
+	if invalid := sm.InvalidPairs(); len(invalid) != 1 || invalid[0] != "NOPE-USDT" {
+		t.Fatalf("invalid pairs = %v", invalid)
+	}
+	if persisted := store["pairs:invalid"]; len(persisted) != 1 || persisted[0] != "NOPE-USDT" {
+		t.Fatalf("persisted invalid pairs = %v", persisted)
+	}
+
+	// Later polls don't request the bad pair again
+	requested := len(client.messages)
+	if err := sm.syncSubscriptions(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(client.messages) != requested {
+		t.Fatalf("poll resent %v", client.messages[requested:])
+	}
+
+	// Neither does a restarted process, which loads the persisted set
+	restarted := newClient()
+	sm2 := NewSubscriptionManager(restarted, store, "pairs", 60)
+	if err := sm2.Start(); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	defer sm2.Stop()
+	for _, msg := range restarted.messages {
+		if msg[0] == "NOPE-USDT" {
+			t.Fatal("known bad pair requested after a restart")
+		}
+	}
+	if got := restarted.GetSubscribed(); len(got) != 3 {
+		t.Fatalf("subscribed after restart = %v", got)
+	}
+}
@@ -0,0 +1,17 @@
+package subscription
+
+// StaticPairs is a RedisConfigReader serving a fixed list of trading pairs under the trading
+// pairs key, used when the service runs without Redis
+type StaticPairs struct {
+	Key   string   // trading pairs key the pairs are served under
+	Pairs []string // configured trading pairs
+}
+
+// GetTradingPairs returns the configured pairs for the trading pairs key and nothing for
+// any other key, such as the set of invalid pairs
+func (p StaticPairs) GetTradingPairs(key string) ([]string, error) {
+	if key != p.Key {
+		return nil, nil
+	}
+	return append([]string(nil), p.Pairs...), nil
+}
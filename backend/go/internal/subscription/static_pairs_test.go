@@ -0,0 +1,85 @@
+package subscription
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// fakeWSClient is a common.WSClientInterface that tracks subscriptions in memory.
+// Subscribe fails for pairs in failing.
+type fakeWSClient struct {
+	mu         sync.Mutex
+	subscribed map[string]bool
+	failing    map[string]bool
+}
+
+func newFakeWSClient(failing ...string) *fakeWSClient {
+	c := &fakeWSClient{subscribed: make(map[string]bool), failing: make(map[string]bool)}
+	for _, pair := range failing {
+		c.failing[pair] = true
+	}
+	return c
+}
+
+func (c *fakeWSClient) Subscribe(params interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pair := range params.([]string) {
+		if c.failing[pair] {
+			return fmt.Errorf("subscribe %s: connection reset", pair)
+		}
+		c.subscribed[pair] = true
+	}
+	return nil
+}
+
+func (c *fakeWSClient) Unsubscribe(params interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pair := range params.([]string) {
+		delete(c.subscribed, pair)
+	}
+	return nil
+}
+
+func (c *fakeWSClient) GetSubscribed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pairs := make([]string, 0, len(c.subscribed))
+	for pair := range c.subscribed {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+func TestStaticPairsServeOnlyTheTradingPairsKey(t *testing.T) {
+	pairs := StaticPairs{Key: "trading_pairs", Pairs: []string{"BTC-USDT", "ETH-USDT"}}
+
+	got, err := pairs.GetTradingPairs("trading_pairs")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("trading pairs = %v, %v", got, err)
+	}
+	if got, _ := pairs.GetTradingPairs("trading_pairs:invalid"); len(got) != 0 {
+		t.Fatalf("invalid pairs key returned %v, want nothing", got)
+	}
+}
+
+func TestStaticPairsSubscribedWithoutRedis(t *testing.T) {
+	client := newFakeWSClient()
+	pairs := StaticPairs{Key: "trading_pairs", Pairs: []string{"BTC-USDT", "ETH-USDT"}}
+	sm := NewSubscriptionManager(client, pairs, "trading_pairs", 60)
+	if err := sm.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer sm.Stop()
+
+	if invalid := sm.InvalidPairs(); len(invalid) != 0 {
+		t.Fatalf("static pairs marked invalid: %v", invalid)
+	}
+	if got := client.GetSubscribed(); len(got) != 2 || got[0] != "BTC-USDT" || got[1] != "ETH-USDT" {
+		t.Fatalf("subscribed = %v, want both static pairs", got)
+	}
+}
@@ -0,0 +1,138 @@
+// Package testutil provides test doubles for the service's external dependencies
+package testutil
+
+import (
+	"sync"
+
+	"github.com/supermancell/okex-buddy/internal/redisclient"
+)
+
+// StoreCall is one recorded call on RecordingStore
+type StoreCall struct {
+	Method string
+	InstID string
+	Args   []interface{} // remaining arguments after instID
+}
+
+// RecordingStore is a redisclient.RedisStore that records every call instead of writing
+// to Redis. Err, when set, is returned by every method.
+type RecordingStore struct {
+	Err error
+
+	mu    sync.Mutex
+	calls []StoreCall
+}
+
+var _ redisclient.RedisStore = (*RecordingStore)(nil)
+
+// NewRecordingStore creates an empty recording store
+func NewRecordingStore() *RecordingStore {
+	return &RecordingStore{}
+}
+
+// Calls returns a copy of the recorded calls in call order
+func (s *RecordingStore) Calls() []StoreCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StoreCall(nil), s.calls...)
+}
+
+// Methods returns how many times each method was called for instID
+func (s *RecordingStore) Methods(instID string) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, call := range s.calls {
+		if call.InstID == instID {
+			counts[call.Method]++
+		}
+	}
+	return counts
+}
+
+// Reset drops the recorded calls
+func (s *RecordingStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+func (s *RecordingStore) record(method, instID string, args ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, StoreCall{Method: method, InstID: instID, Args: args})
+	return s.Err
+}
+
+func (s *RecordingStore) StoreOrderBookSnapshot(instID string, asks, bids interface{}, checksum int32) error {
+	return s.record("StoreOrderBookSnapshot", instID, asks, bids, checksum)
+}
+
+func (s *RecordingStore) AppendOrderBookHistory(instID string, snapshot interface{}, ts int64) error {
+	return s.record("AppendOrderBookHistory", instID, snapshot, ts)
+}
+
+func (s *RecordingStore) StoreAggregatedBook(instID string, aggregated map[string]interface{}) error {
+	return s.record("StoreAggregatedBook", instID, aggregated)
+}
+
+func (s *RecordingStore) StoreTickerSnapshot(instID string, ticker interface{}) error {
+	return s.record("StoreTickerSnapshot", instID, ticker)
+}
+
+func (s *RecordingStore) StoreSupportResistance(instID string, supports, resistances []float64, spread float64) error {
+	return s.record("StoreSupportResistance", instID, supports, resistances, spread)
+}
+
+func (s *RecordingStore) StoreSpreadZScore(instID string, zScore float64, currentSpread float64, readiness float64) error {
+	return s.record("StoreSpreadZScore", instID, zScore, currentSpread, readiness)
+}
+
+func (s *RecordingStore) StoreSentiment(instID string, largeBuyNotional, largeSellNotional, sentiment, readiness float64) error {
+	return s.record("StoreSentiment", instID, largeBuyNotional, largeSellNotional, sentiment, readiness)
+}
+
+func (s *RecordingStore) AppendSentimentHistory(instID string, sentiment float64, ts int64) error {
+	return s.record("AppendSentimentHistory", instID, sentiment, ts)
+}
+
+func (s *RecordingStore) StoreLargeOrders(instID string, levels interface{}, count int) error {
+	return s.record("StoreLargeOrders", instID, levels, count)
+}
+
+func (s *RecordingStore) StoreDepthAnomaly(instID string, anomalyData map[string]interface{}) error {
+	return s.record("StoreDepthAnomaly", instID, anomalyData)
+}
+
+func (s *RecordingStore) StoreLiquidityShrink(instID string, shrinkData map[string]interface{}) error {
+	return s.record("StoreLiquidityShrink", instID, shrinkData)
+}
+
+func (s *RecordingStore) StoreIceberg(instID string, candidates interface{}, count int) error {
+	return s.record("StoreIceberg", instID, candidates, count)
+}
+
+func (s *RecordingStore) StoreOFI(instID string, ofiData map[string]interface{}) error {
+	return s.record("StoreOFI", instID, ofiData)
+}
+
+func (s *RecordingStore) StoreTradePressure(instID string, pressureData map[string]interface{}) error {
+	return s.record("StoreTradePressure", instID, pressureData)
+}
+
+func (s *RecordingStore) StoreImbalanceFlip(instID string, event interface{}) error {
+	return s.record("StoreImbalanceFlip", instID, event)
+}
+
+func (s *RecordingStore) StoreFundingRate(instID string, fundingData map[string]interface{}) error {
+	return s.record("StoreFundingRate", instID, fundingData)
+}
+
+func (s *RecordingStore) StoreOpenInterest(instID string, oiData map[string]interface{}) error {
+	return s.record("StoreOpenInterest", instID, oiData)
+}
+
+func (s *RecordingStore) StoreAnalysisStatus(instID, analysis string, lastErr error) error {
+	return s.record("StoreAnalysisStatus", instID, analysis, lastErr)
+}
@@ -0,0 +1,94 @@
+package trade
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// Store persists batches of trades
+type Store interface {
+	InsertTrades(trades []mongodb.Trade) error
+}
+
+// Batcher buffers trades and writes them with InsertMany, either when the batch is
+// full or when the flush interval elapses, so the read loop never waits on MongoDB
+type Batcher struct {
+	store         Store
+	batchSize     int
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	pending []mongodb.Trade
+	full    chan struct{}
+}
+
+// NewBatcher creates a trade batcher
+func NewBatcher(store Store, batchSize int, flushInterval time.Duration) *Batcher {
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	return &Batcher{
+		store:         store,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		full:          make(chan struct{}, 1),
+	}
+}
+
+// Add buffers trades for the next flush
+func (b *Batcher) Add(trades ...mongodb.Trade) {
+	b.mu.Lock()
+	b.pending = append(b.pending, trades...)
+	full := len(b.pending) >= b.batchSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run flushes buffered trades until ctx is cancelled, then flushes what is left
+func (b *Batcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.flush()
+			return
+		case <-ticker.C:
+			b.flush()
+		case <-b.full:
+			b.flush()
+		}
+	}
+}
+
+// flush writes all buffered trades in batches of batchSize
+func (b *Batcher) flush() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	for start := 0; start < len(pending); start += b.batchSize {
+		end := start + b.batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		if err := b.store.InsertTrades(pending[start:end]); err != nil {
+			log.Printf("Failed to insert %d trades: %v", end-start, err)
+		}
+	}
+}
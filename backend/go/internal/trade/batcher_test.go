@@ -0,0 +1,70 @@
+package trade
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// recordingStore records every InsertTrades batch
+type recordingStore struct {
+	mu      sync.Mutex
+	batches [][]mongodb.Trade
+}
+
+func (s *recordingStore) InsertTrades(trades []mongodb.Trade) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]mongodb.Trade(nil), trades...))
+	return nil
+}
+
+const tradesPayload = `{"arg":{"channel":"trades","instId":"BTC-USDT"},"data":[
+{"instId":"BTC-USDT","tradeId":"1","px":"60000.1","sz":"0.5","side":"buy","ts":"1717000000001"},
+{"instId":"BTC-USDT","tradeId":"2","px":"60000.2","sz":"0.1","side":"sell","ts":"1717000000002"},
+{"instId":"BTC-USDT","tradeId":"3","px":"60000.3","sz":"1.2","side":"buy","ts":"1717000000003"},
+{"instId":"BTC-USDT","tradeId":"4","px":"60000.4","sz":"0.3","side":"sell","ts":"1717000000004"},
+{"instId":"BTC-USDT","tradeId":"5","px":"60000.5","sz":"2","side":"buy","ts":"1717000000005"}]}`
+
+func TestParseTrades(t *testing.T) {
+	if !IsTradesMessage([]byte(tradesPayload)) {
+		t.Fatal("trades payload not recognized")
+	}
+
+	trades, err := ParseTrades([]byte(tradesPayload))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(trades) != 5 {
+		t.Fatalf("parsed %d trades, want 5", len(trades))
+	}
+	want := mongodb.Trade{InstID: "BTC-USDT", TradeID: "1", Px: 60000.1, Sz: 0.5, Side: "buy", Timestamp: 1717000000001}
+	if trades[0] != want {
+		t.Fatalf("first trade = %+v, want %+v", trades[0], want)
+	}
+}
+
+func TestBatcherInsertsInBatches(t *testing.T) {
+	trades, err := ParseTrades([]byte(tradesPayload))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	store := &recordingStore{}
+	batcher := NewBatcher(store, 2, time.Hour)
+	batcher.Add(trades...)
+
+	// Cancelled right away: Run flushes what is buffered and returns
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	batcher.Run(ctx)
+
+	want := [][]mongodb.Trade{trades[0:2], trades[2:4], trades[4:5]}
+	if !reflect.DeepEqual(store.batches, want) {
+		t.Fatalf("batches = %+v, want %+v", store.batches, want)
+	}
+}
@@ -0,0 +1,93 @@
+package trade
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+	"github.com/supermancell/okex-buddy/internal/mongodb"
+)
+
+// Message represents an incoming trades channel message
+type Message struct {
+	Arg struct {
+		Channel string `json:"channel"`
+		InstID  string `json:"instId"`
+	} `json:"arg"`
+	Data []TradeData `json:"data"`
+}
+
+// TradeData represents a single trade in OKEx format
+type TradeData struct {
+	InstID  string `json:"instId"`
+	TradeID string `json:"tradeId"`
+	Px      string `json:"px"`
+	Sz      string `json:"sz"`
+	Side    string `json:"side"`
+	Ts      string `json:"ts"`
+}
+
+// IsTradesMessage reports whether a raw message is a trades channel push
+func IsTradesMessage(msg []byte) bool {
+	var message struct {
+		Event string `json:"event"`
+		Arg   struct {
+			Channel string `json:"channel"`
+		} `json:"arg"`
+	}
+	if err := json.Unmarshal(msg, &message); err != nil {
+		return false
+	}
+	return message.Event == "" && message.Arg.Channel == config.TradesChannel
+}
+
+// ParseTrades parses a trades message and converts it to MongoDB format
+func ParseTrades(msg []byte) ([]mongodb.Trade, error) {
+	var message Message
+	if err := json.Unmarshal(msg, &message); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	trades := make([]mongodb.Trade, 0, len(message.Data))
+	for _, data := range message.Data {
+		trade, err := convertToTrade(message.Arg.InstID, data)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+
+	return trades, nil
+}
+
+// convertToTrade converts raw trade data to MongoDB format
+func convertToTrade(instID string, data TradeData) (mongodb.Trade, error) {
+	if data.InstID != "" {
+		instID = data.InstID
+	}
+
+	px, err := strconv.ParseFloat(data.Px, 64)
+	if err != nil {
+		return mongodb.Trade{}, fmt.Errorf("failed to parse px: %w", err)
+	}
+
+	sz, err := strconv.ParseFloat(data.Sz, 64)
+	if err != nil {
+		return mongodb.Trade{}, fmt.Errorf("failed to parse sz: %w", err)
+	}
+
+	ts, err := strconv.ParseInt(data.Ts, 10, 64)
+	if err != nil {
+		return mongodb.Trade{}, fmt.Errorf("failed to parse ts: %w", err)
+	}
+
+	return mongodb.Trade{
+		InstID:    instID,
+		TradeID:   data.TradeID,
+		Px:        px,
+		Sz:        sz,
+		Side:      data.Side,
+		Timestamp: ts,
+	}, nil
+}
@@ -133,21 +133,24 @@ func PerformLinearRegression(x, y []float64) (slope, intercept float64) {
 		return 0, 0
 	}
 
-	var sumX, sumY, sumXY, sumX2 float64
+	// Center on the means first: with x in unix seconds, n*Σx² - (Σx)² cancels
+	// catastrophically in float64 and yields slopes of the wrong sign
+	// 先减去均值再求和，避免时间戳量级的 x 造成精度损失
+	meanX, meanY := CalculateMean(x), CalculateMean(y)
+
+	var sumXY, sumX2 float64
 	for i := 0; i < n; i++ {
-		sumX += x[i]
-		sumY += y[i]
-		sumXY += x[i] * y[i]
-		sumX2 += x[i] * x[i]
+		dx := x[i] - meanX
+		sumXY += dx * (y[i] - meanY)
+		sumX2 += dx * dx
 	}
 
-	denominator := float64(n)*sumX2 - sumX*sumX
-	if denominator == 0 {
+	if sumX2 == 0 {
 		return 0, 0
 	}
 
-	slope = (float64(n)*sumXY - sumX*sumY) / denominator
-	intercept = (sumY - slope*sumX) / float64(n)
+	slope = sumXY / sumX2
+	intercept = meanY - slope*meanX
 
 	return slope, intercept
 }
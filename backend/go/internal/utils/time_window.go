@@ -14,23 +14,62 @@ type TimeWindowItem interface {
 type GenericTimeWindow struct {
 	items    []TimeWindowItem
 	duration int64 // window duration in seconds
+	maxItems int   // 0 means unbounded (time-based eviction only)
 	mutex    sync.RWMutex
+	now      func() time.Time // clock used for expiry, time.Now by default
+
+	evictedByTime int64 // number of items dropped because they expired
+	evictedByCap  int64 // number of items dropped because the window was full
+
+	// coveredSince is the timestamp of the newest expired item while the retained items
+	// continue its history, 0 otherwise; see Coverage
+	coveredSince int64
+}
+
+// TimeWindowStats holds eviction metrics of a time window
+type TimeWindowStats struct {
+	ItemCount     int   `json:"item_count"`
+	MaxItems      int   `json:"max_items"`
+	EvictedByTime int64 `json:"evicted_by_time"`
+	EvictedByCap  int64 `json:"evicted_by_cap"`
 }
 
 // NewGenericTimeWindow creates a new time window with specified duration
 func NewGenericTimeWindow(durationSeconds int64) *GenericTimeWindow {
+	return NewGenericTimeWindowWithCap(durationSeconds, 0)
+}
+
+// NewGenericTimeWindowWithCap creates a new time window that additionally keeps at most
+// maxItems items, dropping the oldest ones even if they have not expired yet.
+// This bounds memory when items arrive faster than expected. maxItems <= 0 disables the cap.
+func NewGenericTimeWindowWithCap(durationSeconds int64, maxItems int) *GenericTimeWindow {
+	if maxItems < 0 {
+		maxItems = 0
+	}
 	return &GenericTimeWindow{
 		items:    make([]TimeWindowItem, 0),
 		duration: durationSeconds,
+		maxItems: maxItems,
+		now:      time.Now,
 	}
 }
 
+// SetClock replaces the clock used to expire items, e.g. with a fake clock in replays
+func (tw *GenericTimeWindow) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	tw.now = now
+}
+
 // Add adds an item to the time window and automatically removes expired items
 func (tw *GenericTimeWindow) Add(item TimeWindowItem) {
 	tw.mutex.Lock()
 	defer tw.mutex.Unlock()
 
-	currentTime := time.Now().Unix()
+	currentTime := tw.now().Unix()
 	cutoffTime := currentTime - tw.duration
 
 	// Add new item
@@ -44,7 +83,84 @@ func (tw *GenericTimeWindow) Add(item TimeWindowItem) {
 			break
 		}
 	}
+	tw.evictedByTime += int64(startIndex)
+	if startIndex > 0 {
+		// When every earlier item expired, the history restarts with the new item
+		tw.coveredSince = 0
+		if startIndex < len(tw.items)-1 {
+			tw.coveredSince = tw.items[startIndex-1].GetTimestamp()
+		}
+	}
 	tw.items = tw.items[startIndex:]
+
+	// Enforce the count cap by dropping the oldest items
+	if tw.maxItems > 0 && len(tw.items) > tw.maxItems {
+		overflow := len(tw.items) - tw.maxItems
+		tw.evictedByCap += int64(overflow)
+		// Copy into a fresh slice so the dropped items can be garbage collected
+		kept := make([]TimeWindowItem, tw.maxItems)
+		copy(kept, tw.items[overflow:])
+		tw.items = kept
+	}
+}
+
+// GetOldest returns the oldest item in the window
+func (tw *GenericTimeWindow) GetOldest() (TimeWindowItem, bool) {
+	tw.mutex.RLock()
+	defer tw.mutex.RUnlock()
+	if len(tw.items) == 0 {
+		return nil, false
+	}
+	return tw.items[0], true
+}
+
+// GetNewest returns the most recently added item in the window
+func (tw *GenericTimeWindow) GetNewest() (TimeWindowItem, bool) {
+	tw.mutex.RLock()
+	defer tw.mutex.RUnlock()
+	if len(tw.items) == 0 {
+		return nil, false
+	}
+	return tw.items[len(tw.items)-1], true
+}
+
+// GetStats returns the item count and eviction metrics of the window
+func (tw *GenericTimeWindow) GetStats() TimeWindowStats {
+	tw.mutex.RLock()
+	defer tw.mutex.RUnlock()
+	return TimeWindowStats{
+		ItemCount:     len(tw.items),
+		MaxItems:      tw.maxItems,
+		EvictedByTime: tw.evictedByTime,
+		EvictedByCap:  tw.evictedByCap,
+	}
+}
+
+// Coverage returns how much of neededSeconds the items in the window span, from 0 (empty or
+// a single item) to 1 (fully covered). neededSeconds <= 0 means the window duration. Analyses
+// use it as a readiness indicator while the window is still filling after startup.
+// Expired items count toward the span: items sampled every few seconds never span the full
+// duration on their own, since the oldest one expires as the window fills up.
+func (tw *GenericTimeWindow) Coverage(neededSeconds int64) float64 {
+	if neededSeconds <= 0 {
+		neededSeconds = tw.duration
+	}
+
+	tw.mutex.RLock()
+	defer tw.mutex.RUnlock()
+	if len(tw.items) == 0 || neededSeconds <= 0 {
+		return 0
+	}
+
+	oldest := tw.items[0].GetTimestamp()
+	if tw.coveredSince > 0 {
+		oldest = tw.coveredSince
+	}
+	span := tw.items[len(tw.items)-1].GetTimestamp() - oldest
+	if span >= neededSeconds {
+		return 1
+	}
+	return float64(span) / float64(neededSeconds)
 }
 
 // GetItems returns all items currently in the window
@@ -70,6 +186,7 @@ func (tw *GenericTimeWindow) Clear() {
 	tw.mutex.Lock()
 	defer tw.mutex.Unlock()
 	tw.items = tw.items[:0]
+	tw.coveredSince = 0
 }
 
 // GetDuration returns the window duration in seconds
@@ -77,6 +194,11 @@ func (tw *GenericTimeWindow) GetDuration() int64 {
 	return tw.duration
 }
 
+// GetMaxItems returns the count cap of the window (0 means unbounded)
+func (tw *GenericTimeWindow) GetMaxItems() int {
+	return tw.maxItems
+}
+
 // TimeWindowWithValue is a convenience wrapper for simple value-based time windows
 type TimeWindowWithValue struct {
 	window *GenericTimeWindow
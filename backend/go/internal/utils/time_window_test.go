@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// windowAt returns a window whose clock reads *now (unix seconds)
+func windowAt(now *int64, duration int64, maxItems int) *GenericTimeWindow {
+	tw := NewGenericTimeWindowWithCap(duration, maxItems)
+	tw.SetClock(func() time.Time { return time.Unix(*now, 0) })
+	return tw
+}
+
+func timestamps(items []TimeWindowItem) []int64 {
+	out := make([]int64, len(items))
+	for i, item := range items {
+		out[i] = item.GetTimestamp()
+	}
+	return out
+}
+
+func TestTimeWindowEvictsExpiredItems(t *testing.T) {
+	now := int64(1000)
+	tw := windowAt(&now, 10, 0)
+
+	for _, ts := range []int64{985, 992, 995} {
+		now = ts
+		tw.Add(&TimeWindowValueItem{Value: 1, Timestamp: ts})
+	}
+	// At 995 the cutoff is 985, which is not inside the window
+	if got := timestamps(tw.GetItems()); len(got) != 2 || got[0] != 992 {
+		t.Fatalf("items = %v, want [992 995]", got)
+	}
+
+	now = 1003
+	tw.Add(&TimeWindowValueItem{Value: 1, Timestamp: 1003})
+	if got := timestamps(tw.GetItems()); len(got) != 2 || got[0] != 995 || got[1] != 1003 {
+		t.Fatalf("items = %v, want [995 1003]", got)
+	}
+	if stats := tw.GetStats(); stats.EvictedByTime != 2 || stats.EvictedByCap != 0 {
+		t.Fatalf("stats = %+v, want 2 evicted by time", stats)
+	}
+}
+
+func TestTimeWindowCapDropsOldestBeforeExpiry(t *testing.T) {
+	now := int64(1000)
+	tw := windowAt(&now, 1800, 3)
+
+	for i := int64(0); i < 10; i++ {
+		tw.Add(&TimeWindowValueItem{Value: float64(i), Timestamp: now})
+		if n := len(tw.GetItems()); n > 3 {
+			t.Fatalf("after %d adds the window holds %d items, cap 3", i+1, n)
+		}
+	}
+
+	oldest, _ := tw.GetOldest()
+	newest, _ := tw.GetNewest()
+	if oldest.(*TimeWindowValueItem).Value != 7 || newest.(*TimeWindowValueItem).Value != 9 {
+		t.Fatalf("oldest=%v newest=%v, want values 7 and 9", oldest, newest)
+	}
+	if stats := tw.GetStats(); stats.EvictedByCap != 7 || stats.EvictedByTime != 0 || stats.MaxItems != 3 {
+		t.Fatalf("stats = %+v, want 7 evicted by cap", stats)
+	}
+}
+
+func TestTimeWindowEmptyAccessors(t *testing.T) {
+	tw := NewGenericTimeWindow(60)
+	if _, ok := tw.GetOldest(); ok {
+		t.Fatal("GetOldest on an empty window returned an item")
+	}
+	if _, ok := tw.GetNewest(); ok {
+		t.Fatal("GetNewest on an empty window returned an item")
+	}
+	if tw.GetMaxItems() != 0 {
+		t.Fatalf("default cap = %d, want unbounded", tw.GetMaxItems())
+	}
+}
+
+func TestTimeWindowCoverageReachesOneOnceFull(t *testing.T) {
+	now := int64(1000)
+	tw := windowAt(&now, 40, 0)
+
+	// Sampled every 5s, the retained items never span more than 35s
+	var coverage []float64
+	for ts := int64(1000); ts <= 1060; ts += 5 {
+		now = ts
+		tw.Add(&TimeWindowValueItem{Value: 1, Timestamp: ts})
+		coverage = append(coverage, tw.Coverage(0))
+	}
+	for i, c := range coverage {
+		want := float64(i*5) / 40
+		if want > 1 {
+			want = 1
+		}
+		if c != want {
+			t.Fatalf("coverage after %ds = %v, want %v (all: %v)", i*5, c, want, coverage)
+		}
+	}
+
+	// After a gap that expires all earlier items the window fills up again
+	now = 2000
+	tw.Add(&TimeWindowValueItem{Value: 1, Timestamp: 2000})
+	if c := tw.Coverage(0); c != 0 {
+		t.Fatalf("coverage after a gap = %v, want 0", c)
+	}
+	now = 2010
+	tw.Add(&TimeWindowValueItem{Value: 1, Timestamp: 2010})
+	if c := tw.Coverage(0); c != 0.25 {
+		t.Fatalf("coverage 10s after a gap = %v, want 0.25", c)
+	}
+
+	tw.Clear()
+	tw.Add(&TimeWindowValueItem{Value: 1, Timestamp: 2010})
+	if c := tw.Coverage(0); c != 0 {
+		t.Fatalf("coverage after Clear = %v, want 0", c)
+	}
+}
@@ -0,0 +1,139 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// capture is the optional tap writing every received raw message as one line, see SetCapture
+type capture struct {
+	mu sync.Mutex
+	w  io.Writer // nil disables the tap
+}
+
+// SetCapture writes every raw message received on the public socket to w as one JSON line
+// (the NDJSON format read by cmd/replay), before it is handed to the message handler.
+// nil disables the tap. The client does not close w. A failed write disables the tap.
+func (c *PublicClient) SetCapture(w io.Writer) {
+	c.capture.mu.Lock()
+	defer c.capture.mu.Unlock()
+	c.capture.w = w
+}
+
+// write appends message as one line. OKEx frames are compact JSON; the rare frame containing
+// a newline is compacted so that it still takes exactly one line.
+func (t *capture) write(message []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.w == nil {
+		return
+	}
+
+	line := message
+	if bytes.IndexByte(message, '\n') >= 0 {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, message); err == nil {
+			line = compact.Bytes()
+		}
+	}
+
+	buf := make([]byte, 0, len(line)+1)
+	buf = append(append(buf, line...), '\n')
+	if _, err := t.w.Write(buf); err != nil {
+		log.Printf("Failed to write capture, disabling it: %v", err)
+		t.w = nil
+	}
+}
+
+// CaptureFile is a size-rotated file for SetCapture. Once a write would exceed maxBytes the
+// file is renamed with a timestamp suffix (capture.ndjson -> capture-20240101T120000.ndjson)
+// and a new one is started.
+type CaptureFile struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64 // <= 0 disables rotation
+	file     *os.File
+	size     int64
+}
+
+// NewCaptureFile opens path for appending, creating it and its directory if needed
+func NewCaptureFile(path string, maxBytes int64) (*CaptureFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create capture directory: %w", err)
+	}
+	f := &CaptureFile{path: path, maxBytes: maxBytes}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the capture path for appending. Caller holds mu (or owns f exclusively).
+func (f *CaptureFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open capture file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat capture file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when it would exceed the size limit
+func (f *CaptureFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file with a timestamp suffix and opens a new one. Caller holds mu.
+func (f *CaptureFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close capture file: %w", err)
+	}
+	f.file = nil
+
+	ext := filepath.Ext(f.path)
+	rotated := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, ext), time.Now().Format("20060102T150405"), ext)
+	if err := os.Rename(f.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate capture file: %w", err)
+	}
+	return f.open()
+}
+
+// Close closes the current file
+func (f *CaptureFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,172 @@
+package ws
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// lockedBuffer is a bytes.Buffer safe for the read loop and the test to share
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// pushingClient connects a PublicClient to a server that sends every frame put on the
+// returned channel, and returns the messages handed to the handler
+func pushingClient(t *testing.T) (*PublicClient, chan<- string, <-chan string) {
+	t.Helper()
+
+	push := make(chan string)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for frame := range push {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	handled := make(chan string, 10)
+	client := NewPublicClient("ws"+strings.TrimPrefix(server.URL, "http"), func(message []byte) error {
+		handled <- string(message)
+		return nil
+	})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		close(push)
+		client.Close()
+	})
+	return client, push, handled
+}
+
+func TestCaptureWritesEachFrameAsALine(t *testing.T) {
+	client, push, handled := pushingClient(t)
+	var captured lockedBuffer
+	client.SetCapture(&captured)
+
+	frames := []string{
+		`{"arg":{"channel":"books","instId":"BTC-USDT"},"action":"snapshot","data":[{"asks":[["43250.1","0.5","0","3"]],"bids":[],"ts":"1717000000000","checksum":-855196043}]}`,
+		`{"event":"subscribe","arg":{"channel":"tickers","instId":"BTC-USDT"},"connId":"a4d3ae55"}`,
+		`{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":[{"last":"43250","ts":"1717000000100"}]}`,
+	}
+	for _, frame := range frames {
+		push <- frame
+		// Processing goes on alongside the capture
+		select {
+		case got := <-handled:
+			if got != frame {
+				t.Fatalf("handler got %s, want %s", got, frame)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("frame never handled: %s", frame)
+		}
+	}
+	if want := strings.Join(frames, "\n") + "\n"; captured.String() != want {
+		t.Fatalf("capture:\n%s\nwant:\n%s", captured.String(), want)
+	}
+
+	// A pretty-printed frame still takes one line
+	push <- "{\n  \"event\": \"notice\",\n  \"msg\": \"reconnect\"\n}"
+	<-handled
+	lines := strings.Split(strings.TrimSuffix(captured.String(), "\n"), "\n")
+	if len(lines) != 4 || lines[3] != `{"event":"notice","msg":"reconnect"}` {
+		t.Fatalf("last line %q of %d", lines[len(lines)-1], len(lines))
+	}
+
+	// Disabled, frames are still handled but no longer written
+	client.SetCapture(nil)
+	push <- frames[2]
+	<-handled
+	if got := strings.Count(captured.String(), "\n"); got != 4 {
+		t.Fatalf("%d lines after disabling the capture, want 4", got)
+	}
+}
+
+// failingWriter fails every write and counts them
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.writes++
+	return 0, errors.New("disk full")
+}
+
+func TestFailedCaptureWriteDisablesTheTap(t *testing.T) {
+	var tap capture
+	w := &failingWriter{}
+	tap.w = w
+
+	tap.write([]byte(`{"event":"subscribe"}`))
+	tap.write([]byte(`{"event":"subscribe"}`))
+	if w.writes != 1 || tap.w != nil {
+		t.Fatalf("%d writes, tap %v; want one write and the tap disabled", w.writes, tap.w)
+	}
+}
+
+func TestCaptureFileRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "captures", "public.ndjson")
+	file, err := NewCaptureFile(path, 64)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer file.Close()
+
+	line := strings.Repeat("x", 39) + "\n" // 40 bytes, two do not fit in 64
+	for i := 0; i < 2; i++ {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "public-*.ndjson"))
+	if len(rotated) != 1 {
+		t.Fatalf("rotated files %v, want one", rotated)
+	}
+	for _, name := range []string{path, rotated[0]} {
+		if data, err := os.ReadFile(name); err != nil || string(data) != line {
+			t.Fatalf("%s holds %q (%v), want one line", name, data, err)
+		}
+	}
+
+	// Rotation off: the file grows past the limit
+	unbounded, err := NewCaptureFile(filepath.Join(t.TempDir(), "all.ndjson"), 0)
+	if err != nil {
+		t.Fatalf("open unbounded: %v", err)
+	}
+	defer unbounded.Close()
+	for i := 0; i < 3; i++ {
+		unbounded.Write([]byte(line))
+	}
+	if info, err := os.Stat(filepath.Join(filepath.Dir(unbounded.path), "all.ndjson")); err != nil || info.Size() != 120 {
+		t.Fatalf("unbounded capture: %v, %v", info, err)
+	}
+}
@@ -19,6 +19,8 @@ import (
 type BusinessClient struct {
 	url            string
 	conn           *websocket.Conn
+	writer         connWriter        // serializes writes on conn, see writer.go
+	throttle       subscribeThrottle // spaces subscribe requests, see throttle.go
 	mu             sync.RWMutex
 	msgHandler     common.MessageHandler
 	reconnectDelay time.Duration
@@ -31,12 +33,17 @@ type BusinessClient struct {
 	proxyAddr      string
 	pingInterval   time.Duration
 	pongTimeout    time.Duration
+
+	// enableCompression requests permessage-deflate when dialing
+	enableCompression bool
 }
 
 // NewBusinessClient creates a new business WebSocket client
 func NewBusinessClient(url string, msgHandler common.MessageHandler) *BusinessClient {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &BusinessClient{
+		writer:         connWriter{timeout: DefaultWriteTimeout},
+		throttle:       subscribeThrottle{interval: DefaultSubscribeInterval},
 		url:            url,
 		msgHandler:     msgHandler,
 		reconnectDelay: 5 * time.Second,
@@ -53,6 +60,8 @@ func NewBusinessClient(url string, msgHandler common.MessageHandler) *BusinessCl
 func NewBusinessClientWithProxy(url string, msgHandler common.MessageHandler, useProxy bool, proxyAddr string) *BusinessClient {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &BusinessClient{
+		writer:         connWriter{timeout: DefaultWriteTimeout},
+		throttle:       subscribeThrottle{interval: DefaultSubscribeInterval},
 		url:            url,
 		msgHandler:     msgHandler,
 		reconnectDelay: 5 * time.Second,
@@ -67,13 +76,22 @@ func NewBusinessClientWithProxy(url string, msgHandler common.MessageHandler, us
 	}
 }
 
+// SetCompression enables or disables permessage-deflate for subsequent connections
+func (c *BusinessClient) SetCompression(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enableCompression = enabled
+}
+
 // Connect establishes the WebSocket connection
 func (c *BusinessClient) Connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	dialer := websocket.DefaultDialer
+	// Copy the default dialer so settings don't leak into other clients
+	dialer := *websocket.DefaultDialer
 	dialer.HandshakeTimeout = 10 * time.Second
+	dialer.EnableCompression = c.enableCompression
 
 	if c.useProxy && c.proxyAddr != "" {
 		log.Printf("Using SOCKS5 proxy for business: %s", c.proxyAddr)
@@ -86,10 +104,11 @@ func (c *BusinessClient) Connect() error {
 		}
 	}
 
-	conn, _, err := dialer.Dial(c.url, nil)
+	conn, resp, err := dialer.Dial(c.url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to %s: %w", c.url, err)
 	}
+	logCompression(c.url, c.enableCompression, resp)
 
 	c.conn = conn
 	log.Printf("Business WebSocket connected to %s", c.url)
@@ -201,11 +220,10 @@ func (c *BusinessClient) Subscribe(params interface{}) error {
 		return fmt.Errorf("failed to marshal subscribe message: %w", err)
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
-
-	if err != nil {
+	if err := c.throttle.wait(c.ctx); err != nil {
+		return fmt.Errorf("subscribe interrupted: %w", err)
+	}
+	if err := c.writeText(data); err != nil {
 		return fmt.Errorf("failed to send subscribe message: %w", err)
 	}
 
@@ -256,11 +274,7 @@ func (c *BusinessClient) Unsubscribe(params interface{}) error {
 		return fmt.Errorf("failed to marshal unsubscribe message: %w", err)
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
-
-	if err != nil {
+	if err := c.writeText(data); err != nil {
 		return fmt.Errorf("failed to send unsubscribe message: %w", err)
 	}
 
@@ -315,7 +329,7 @@ func (c *BusinessClient) startPingPong() {
 				return
 			}
 
-			err := conn.WriteMessage(websocket.PingMessage, nil)
+			err := c.writer.write(conn, websocket.PingMessage, nil)
 			if err != nil {
 				log.Printf("Failed to send ping on business WebSocket: %v", err)
 				return
@@ -333,7 +347,7 @@ func (c *BusinessClient) Close() error {
 	defer c.mu.Unlock()
 
 	if c.conn != nil {
-		err := c.conn.WriteMessage(
+		err := c.writer.write(c.conn,
 			websocket.CloseMessage,
 			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 		)
@@ -1,10 +1,8 @@
 package ws
 
 import (
+	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -26,13 +24,12 @@ type OKExConfig struct {
 	Passphrase string
 }
 
-// timeOffset stores the time offset from server
-var timeOffset int64 = 0
-
 // PrivateClient manages the WebSocket connection to OKEx private channel
 type PrivateClient struct {
 	url            string
 	conn           *websocket.Conn
+	writer         connWriter        // serializes writes on conn, see writer.go
+	throttle       subscribeThrottle // spaces subscribe requests, see throttle.go
 	mu             sync.RWMutex
 	msgHandler     common.MessageHandler
 	reconnectDelay time.Duration
@@ -49,6 +46,17 @@ type PrivateClient struct {
 	config         OKExConfig
 	authenticated  bool
 	loginSuccess   chan bool
+
+	// enableCompression requests permessage-deflate when dialing
+	enableCompression bool
+
+	// simulatedTrading targets the demo trading environment, see SetSimulatedTrading
+	simulatedTrading bool
+
+	// timeSyncInterval is the period of the background server time re-sync, started after the first login
+	timeSyncInterval time.Duration
+	timeSyncOnce     sync.Once
+	clock            serverClock
 }
 
 // NewPrivateClient creates a new private WebSocket client
@@ -65,6 +73,8 @@ func NewPrivateClientWithProxy(url string, msgHandler common.MessageHandler, use
 func NewPrivateClientWithDualProxy(url string, msgHandler common.MessageHandler, useProxy bool, proxyAddr string, httpProxyAddr string, config OKExConfig) *PrivateClient {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &PrivateClient{
+		writer:         connWriter{timeout: DefaultWriteTimeout},
+		throttle:       subscribeThrottle{interval: DefaultSubscribeInterval},
 		url:            url,
 		msgHandler:     msgHandler,
 		reconnectDelay: 5 * time.Second,
@@ -80,16 +90,64 @@ func NewPrivateClientWithDualProxy(url string, msgHandler common.MessageHandler,
 		config:         config,
 		authenticated:  false,
 		loginSuccess:   make(chan bool, 1),
+
+		timeSyncInterval: DefaultTimeSyncInterval,
 	}
 }
 
+// SetTimeSyncInterval sets how often the server time offset is re-synced; 0 disables the re-sync.
+// Must be called before the first Login.
+func (c *PrivateClient) SetTimeSyncInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeSyncInterval = interval
+}
+
+// runTimeSync periodically re-syncs the server time offset so that re-logins after a
+// reconnect on a long-lived connection stay within OKEx's timestamp tolerance
+func (c *PrivateClient) runTimeSync(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			offset, err := SyncServerTime(c.httpProxyAddr, c.timeSyncSOCKS5Addr())
+			if err != nil {
+				log.Printf("Warning: Periodic time sync failed: %v, keeping offset %d ms", err, c.clock.Offset())
+				continue
+			}
+			c.clock.SetOffset(offset)
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// timeSyncSOCKS5Addr returns the SOCKS5 proxy the time sync falls back to without an HTTP proxy
+func (c *PrivateClient) timeSyncSOCKS5Addr() string {
+	if !c.useProxy {
+		return ""
+	}
+	return c.proxyAddr
+}
+
+// SetCompression enables or disables permessage-deflate for subsequent connections
+func (c *PrivateClient) SetCompression(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enableCompression = enabled
+}
+
 // Connect establishes the WebSocket connection
 func (c *PrivateClient) Connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	dialer := websocket.DefaultDialer
+	// Copy the default dialer so settings don't leak into other clients
+	dialer := *websocket.DefaultDialer
 	dialer.HandshakeTimeout = 10 * time.Second
+	dialer.EnableCompression = c.enableCompression
 
 	if c.useProxy && c.proxyAddr != "" {
 		log.Printf("Using SOCKS5 proxy for private: %s", c.proxyAddr)
@@ -102,10 +160,11 @@ func (c *PrivateClient) Connect() error {
 		}
 	}
 
-	conn, _, err := dialer.Dial(c.url, nil)
+	conn, resp, err := dialer.Dial(c.url, c.handshakeHeader())
 	if err != nil {
 		return fmt.Errorf("failed to connect to %s: %w", c.url, err)
 	}
+	logCompression(c.url, c.enableCompression, resp)
 
 	c.conn = conn
 	c.authenticated = false
@@ -120,19 +179,23 @@ func (c *PrivateClient) Connect() error {
 // Login authenticates with OKEx using API credentials
 func (c *PrivateClient) Login() error {
 	log.Printf("Syncing time with OKEx server...")
-	offset, err := SyncServerTime(c.httpProxyAddr)
+	offset, err := SyncServerTime(c.httpProxyAddr, c.timeSyncSOCKS5Addr())
 	if err != nil {
 		log.Printf("Warning: Failed to sync time: %v, using local time", err)
 	} else {
-		timeOffset = offset
+		c.clock.SetOffset(offset)
+	}
+
+	c.mu.RLock()
+	interval := c.timeSyncInterval
+	c.mu.RUnlock()
+	if interval > 0 {
+		c.timeSyncOnce.Do(func() { go c.runTimeSync(interval) })
 	}
 
-	timestamp := strconv.FormatInt((time.Now().UnixMilli()+timeOffset)/1000, 10)
+	timestamp := c.clock.TimestampSeconds()
 
-	signStr := timestamp + "GET" + "/users/self/verify"
-	h := hmac.New(sha256.New, []byte(c.config.SecretKey))
-	h.Write([]byte(signStr))
-	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	signature := BuildLoginSign(c.config.SecretKey, timestamp)
 
 	loginMsg := map[string]interface{}{
 		"op": "login",
@@ -146,7 +209,7 @@ func (c *PrivateClient) Login() error {
 		},
 	}
 
-	log.Printf("Login timestamp: %s (local: %d, offset: %d ms)", timestamp, time.Now().UnixMilli(), timeOffset)
+	log.Printf("Login timestamp: %s (local: %d, offset: %d ms)", timestamp, time.Now().UnixMilli(), c.clock.Offset())
 
 	data, err := json.Marshal(loginMsg)
 	if err != nil {
@@ -161,7 +224,7 @@ func (c *PrivateClient) Login() error {
 		return fmt.Errorf("websocket not connected")
 	}
 
-	err = conn.WriteMessage(websocket.TextMessage, data)
+	err = c.writer.write(conn, websocket.TextMessage, data)
 	if err != nil {
 		return fmt.Errorf("failed to send login message: %w", err)
 	}
@@ -324,11 +387,10 @@ func (c *PrivateClient) Subscribe(params interface{}) error {
 		return fmt.Errorf("failed to marshal subscribe message: %w", err)
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
-
-	if err != nil {
+	if err := c.throttle.wait(c.ctx); err != nil {
+		return fmt.Errorf("subscribe interrupted: %w", err)
+	}
+	if err := c.writeText(data); err != nil {
 		return fmt.Errorf("failed to send subscribe message: %w", err)
 	}
 
@@ -368,11 +430,7 @@ func (c *PrivateClient) Unsubscribe(params interface{}) error {
 		return fmt.Errorf("failed to marshal unsubscribe message: %w", err)
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
-
-	if err != nil {
+	if err := c.writeText(data); err != nil {
 		return fmt.Errorf("failed to send unsubscribe message: %w", err)
 	}
 
@@ -388,7 +446,7 @@ func (c *PrivateClient) Unsubscribe(params interface{}) error {
 }
 
 // PlaceOrder sends an order request via WebSocket
-func (c *PrivateClient) PlaceOrder(args []map[string]string) error {
+func (c *PrivateClient) PlaceOrder(args []map[string]interface{}) error {
 	c.mu.RLock()
 	conn := c.conn
 	authenticated := c.authenticated
@@ -413,11 +471,7 @@ func (c *PrivateClient) PlaceOrder(args []map[string]string) error {
 		return fmt.Errorf("failed to marshal order message: %w", err)
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
-
-	if err != nil {
+	if err := c.writeText(data); err != nil {
 		return fmt.Errorf("failed to send order message: %w", err)
 	}
 
@@ -475,7 +529,7 @@ func (c *PrivateClient) startPingPong() {
 				return
 			}
 
-			err := conn.WriteMessage(websocket.PingMessage, nil)
+			err := c.writer.write(conn, websocket.PingMessage, nil)
 			if err != nil {
 				log.Printf("Failed to send ping on private WebSocket: %v", err)
 				return
@@ -500,7 +554,7 @@ func (c *PrivateClient) Close() error {
 	defer c.mu.Unlock()
 
 	if c.conn != nil {
-		err := c.conn.WriteMessage(
+		err := c.writer.write(c.conn,
 			websocket.CloseMessage,
 			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 		)
@@ -520,19 +574,18 @@ func (c *PrivateClient) Close() error {
 // ParseOrderID extracts ordId from order channel response
 func ParseOrderID(message []byte) (string, error) {
 	var msg map[string]interface{}
-	if err := json.Unmarshal(message, &msg); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+	if err := decoder.Decode(&msg); err != nil {
 		return "", err
 	}
 
 	if event, ok := msg["event"].(string); ok && event == "order" {
 		if data, ok := msg["data"].([]interface{}); ok && len(data) > 0 {
 			if order, ok := data[0].(map[string]interface{}); ok {
-				if ordId, ok := order["ordId"].(string); ok {
+				if ordId := common.AsString(order["ordId"]); ordId != "" {
 					return ordId, nil
 				}
-				if ordIdFloat, ok := order["ordId"].(float64); ok {
-					return strconv.FormatFloat(ordIdFloat, 'f', -1, 64), nil
-				}
 			}
 		}
 	}
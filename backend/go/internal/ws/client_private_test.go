@@ -0,0 +1,72 @@
+package ws
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentLoginsKeepTheirOwnOffset(t *testing.T) {
+	atomic.StoreInt64(fakeServerTime(), 7000)
+	credentials := OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"}
+
+	// synced reaches the time endpoint, unsynced only sees a refusing proxy
+	syncedURL, syncedFrames := newLoginServer(t, "0")
+	unsyncedURL, unsyncedFrames := newLoginServer(t, "0")
+	synced := NewPrivateClient(syncedURL, func([]byte) error { return nil }, credentials)
+	unsynced := NewPrivateClientWithDualProxy(unsyncedURL, func([]byte) error { return nil }, false, "", refusingProxy(t), credentials)
+
+	var wg sync.WaitGroup
+	for _, client := range []*PrivateClient{synced, unsynced} {
+		if err := client.Connect(); err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		t.Cleanup(func() { client.Close() })
+
+		wg.Add(1)
+		go func(client *PrivateClient) {
+			defer wg.Done()
+			for i := 0; i < 3; i++ {
+				if err := client.Login(); err != nil {
+					t.Errorf("login %d: %v", i, err)
+					return
+				}
+			}
+		}(client)
+	}
+	wg.Wait()
+
+	if offset := synced.clock.Offset(); !near(offset, 7000) {
+		t.Fatalf("synced client offset %d ms, want about 7000", offset)
+	}
+	if offset := unsynced.clock.Offset(); offset != 0 {
+		t.Fatalf("unsynced client offset %d ms, another client's sync leaked into it", offset)
+	}
+
+	// The signed login timestamps reflect each client's own offset
+	timestamp := func(frames <-chan wsFrame) int64 {
+		var last int64
+		for _, frame := range nextFrames(t, frames, 3) {
+			last, _ = strconv.ParseInt(frame.Args[0]["timestamp"], 10, 64)
+		}
+		return last
+	}
+	local := time.Now().Unix()
+	if ahead := timestamp(syncedFrames) - local; ahead < 6 || ahead > 8 {
+		t.Fatalf("synced login signed %ds ahead of local time, want about 7", ahead)
+	}
+	if ahead := timestamp(unsyncedFrames) - local; ahead < -1 || ahead > 1 {
+		t.Fatalf("unsynced login signed %ds off local time, want local time", ahead)
+	}
+}
+
+func TestParseOrderIDStringOrNumber(t *testing.T) {
+	for _, ordID := range []string{`"1234567890123456789"`, `1234567890123456789`} {
+		got, err := ParseOrderID([]byte(`{"id":"1","op":"order","event":"order","code":"0","data":[{"ordId":` + ordID + `,"sCode":"0"}]}`))
+		if err != nil || got != "1234567890123456789" {
+			t.Fatalf("ordId %s parsed as %q (%v)", ordID, got, err)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,39 +15,82 @@ import (
 	"golang.org/x/net/proxy"
 
 	"github.com/supermancell/okex-buddy/internal/common"
+	"github.com/supermancell/okex-buddy/internal/config"
 )
 
 // PublicClient manages the WebSocket connection to OKEx public channel
 type PublicClient struct {
 	url            string
 	conn           *websocket.Conn
+	writer         connWriter        // serializes writes on conn, see writer.go
+	throttle       subscribeThrottle // spaces subscribe requests, see throttle.go
 	mu             sync.RWMutex
 	msgHandler     common.MessageHandler
 	reconnectDelay time.Duration
 	maxReconnect   int
 	ctx            context.Context
 	cancel         context.CancelFunc
-	subscribed     map[string]bool // track subscribed instruments
+	subscribed     map[string]map[string]bool // instrument -> subscribed channels
+	confirmed      map[string]map[string]bool // instrument -> channels acknowledged by OKEx, guarded by subscribedMu
 	subscribedMu   sync.RWMutex
 	useProxy       bool
 	proxyAddr      string
 	pingInterval   time.Duration
 	pongTimeout    time.Duration
+
+	// defaultChannels are subscribed for instruments without an instType override
+	defaultChannels  []string
+	instTypeChannels map[string][]string // instType -> channels
+
+	// enableCompression requests permessage-deflate when dialing
+	enableCompression bool
+
+	// loginConfig enables login on connect (books-l2-tbt), see SetLogin
+	loginConfig   *OKExConfig
+	httpProxyAddr string
+	clock         serverClock
+
+	subscribeErrorHandler func(instID string, err error) // guarded by subscribedMu
+
+	// reconnectHandler is called after a reconnect, before resubscribing, see SetReconnectHandler
+	reconnectHandler func(instIDs []string)
+
+	// messages decouples the socket reader from msgHandler, see message_queue.go
+	messages         chan []byte
+	messageQueueSize int
+	dispatchOnce     sync.Once
+	droppedMessages  int64 // messages dropped on a full queue, atomic
+
+	// subscribeBatchSize caps the args per subscribe/unsubscribe frame, see SetSubscribeBatchSize
+	subscribeBatchSize int
+
+	// capture writes received messages to disk, see SetCapture
+	capture capture
 }
 
+// DefaultSubscribeBatchSize is the default number of channel args per subscribe frame.
+// OKEx rejects requests whose args exceed 64 KB in total; 100 args stay well below it.
+const DefaultSubscribeBatchSize = 100
+
 // NewPublicClient creates a new WebSocket client
 func NewPublicClient(url string, msgHandler common.MessageHandler) *PublicClient {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &PublicClient{
+		writer:         connWriter{timeout: DefaultWriteTimeout},
+		throttle:       subscribeThrottle{interval: DefaultSubscribeInterval},
 		url:            url,
 		msgHandler:     msgHandler,
 		reconnectDelay: 5 * time.Second,
 		maxReconnect:   3,
 		ctx:            ctx,
 		cancel:         cancel,
-		subscribed:     make(map[string]bool),
+		subscribed:     make(map[string]map[string]bool),
+		confirmed:      make(map[string]map[string]bool),
 		pingInterval:   25 * time.Second,
 		pongTimeout:    30 * time.Second,
+
+		defaultChannels:  []string{config.BooksChannel, config.TickerChannel},
+		instTypeChannels: make(map[string][]string),
 	}
 }
 
@@ -53,27 +98,52 @@ func NewPublicClient(url string, msgHandler common.MessageHandler) *PublicClient
 func NewPublicClientWithProxy(url string, msgHandler common.MessageHandler, useProxy bool, proxyAddr string) *PublicClient {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &PublicClient{
+		writer:         connWriter{timeout: DefaultWriteTimeout},
+		throttle:       subscribeThrottle{interval: DefaultSubscribeInterval},
 		url:            url,
 		msgHandler:     msgHandler,
 		reconnectDelay: 5 * time.Second,
 		maxReconnect:   3,
 		ctx:            ctx,
 		cancel:         cancel,
-		subscribed:     make(map[string]bool),
+		subscribed:     make(map[string]map[string]bool),
+		confirmed:      make(map[string]map[string]bool),
 		useProxy:       useProxy,
 		proxyAddr:      proxyAddr,
 		pingInterval:   25 * time.Second,
 		pongTimeout:    30 * time.Second,
+
+		defaultChannels:  []string{config.BooksChannel, config.TickerChannel},
+		instTypeChannels: make(map[string][]string),
 	}
 }
 
+// SetSubscribeBatchSize sets the maximum number of channel args sent in one
+// subscribe/unsubscribe frame; larger requests are split into several frames
+func (c *PublicClient) SetSubscribeBatchSize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if size > 0 {
+		c.subscribeBatchSize = size
+	}
+}
+
+// SetCompression enables or disables permessage-deflate for subsequent connections
+func (c *PublicClient) SetCompression(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enableCompression = enabled
+}
+
 // Connect establishes the WebSocket connection
 func (c *PublicClient) Connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	dialer := websocket.DefaultDialer
+	// Copy the default dialer so settings don't leak into other clients
+	dialer := *websocket.DefaultDialer
 	dialer.HandshakeTimeout = 10 * time.Second
+	dialer.EnableCompression = c.enableCompression
 
 	// Configure SOCKS5 proxy if enabled
 	if c.useProxy && c.proxyAddr != "" {
@@ -88,15 +158,27 @@ func (c *PublicClient) Connect() error {
 		}
 	}
 
-	conn, _, err := dialer.Dial(c.url, nil)
+	conn, resp, err := dialer.Dial(c.url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to %s: %w", c.url, err)
 	}
+	logCompression(c.url, c.enableCompression, resp)
+
+	// Log in before the reader starts so that no subscribe can race ahead of it
+	if c.loginConfig != nil {
+		if err := c.login(conn); err != nil {
+			conn.Close()
+			return err
+		}
+	}
 
 	c.conn = conn
 	log.Printf("WebSocket connected to %s", c.url)
+	// Acknowledgements belong to the previous connection
+	c.resetConfirmed()
 
-	// Start message reader in goroutine
+	// Start message reader in goroutine, handing messages to the dispatcher
+	c.startDispatcher()
 	go c.readMessages()
 	go c.startPingPong()
 
@@ -134,11 +216,13 @@ func (c *PublicClient) readMessages() {
 				return
 			}
 
-			// Handle message
-			if c.msgHandler != nil {
-				if err := c.msgHandler(message); err != nil {
-					log.Printf("Error handling message: %v", err)
-				}
+			c.capture.write(message)
+			c.handleSubscribeError(message)
+			c.handleSubscribeEvent(message)
+
+			// Handle message on the dispatcher goroutine so a slow handler doesn't block reads
+			if !c.enqueueMessage(message) {
+				return
 			}
 		}
 	}
@@ -161,6 +245,8 @@ func (c *PublicClient) reconnect() {
 			}
 
 			log.Println("Reconnected successfully")
+			// Let the consumer reset its state before the fresh snapshots arrive
+			c.notifyReconnect()
 			// Resubscribe to all instruments
 			c.resubscribeAll()
 			return
@@ -170,11 +256,13 @@ func (c *PublicClient) reconnect() {
 	log.Printf("Failed to reconnect after %d attempts", c.maxReconnect)
 }
 
-// Subscribe subscribes to both order book and ticker data for trading pairs
+// Subscribe subscribes to the configured channels for trading pairs.
+// params is either []string (instrument IDs, channels resolved per instType and
+// defaulting to books+tickers) or map[string][]string (instrument ID -> channels).
 func (c *PublicClient) Subscribe(params interface{}) error {
-	instruments, ok := params.([]string)
-	if !ok {
-		return fmt.Errorf("invalid params type for PublicClient Subscribe, expected []string")
+	subs, err := c.resolveChannels(params, "Subscribe")
+	if err != nil {
+		return err
 	}
 
 	c.mu.RLock()
@@ -185,120 +273,341 @@ func (c *PublicClient) Subscribe(params interface{}) error {
 		return fmt.Errorf("websocket not connected")
 	}
 
-	// Build subscription message for both channels according to OKEx API
-	args := make([]map[string]string, 0, len(instruments)*2)
+	// Track subscribed channels per instrument so resubscribe is faithful; only batches
+	// that were sent are tracked
+	err = c.sendBatched("subscribe", buildChannelArgs(subs), func(batch []map[string]string) {
+		c.subscribedMu.Lock()
+		defer c.subscribedMu.Unlock()
+		for _, arg := range batch {
+			inst := arg["instId"]
+			if c.subscribed[inst] == nil {
+				c.subscribed[inst] = make(map[string]bool)
+			}
+			c.subscribed[inst][arg["channel"]] = true
+		}
+	})
+	if err != nil {
+		return err
+	}
 
-	// Subscribe to both channels for each instrument
-	for _, inst := range instruments {
-		// Subscribe to books channel (order book data)
-		args = append(args, map[string]string{
-			"channel": "books",
-			"instId":  inst,
-		})
+	log.Printf("Subscribed to instruments: %v", subs)
+	return nil
+}
 
-		// Subscribe to tickers channel (market data)
-		args = append(args, map[string]string{
-			"channel": "tickers",
-			"instId":  inst,
-		})
+// Unsubscribe unsubscribes from the channels of trading pairs.
+// params is either []string (all tracked channels of each instrument) or
+// map[string][]string (instrument ID -> channels to drop).
+func (c *PublicClient) Unsubscribe(params interface{}) error {
+	var subs map[string][]string
+	if instruments, ok := params.([]string); ok {
+		// Unsubscribe exactly what was subscribed for each instrument
+		subs = make(map[string][]string, len(instruments))
+		c.subscribedMu.RLock()
+		for _, inst := range instruments {
+			subs[inst] = sortedKeys(c.subscribed[inst])
+		}
+		c.subscribedMu.RUnlock()
+		for inst, channels := range subs {
+			if len(channels) == 0 {
+				subs[inst] = c.channelsFor(inst)
+			}
+		}
+	} else {
+		var err error
+		if subs, err = c.resolveChannels(params, "Unsubscribe"); err != nil {
+			return err
+		}
 	}
 
-	subMsg := map[string]interface{}{
-		"op":   "subscribe",
-		"args": args,
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+
+	if conn == nil {
+		return fmt.Errorf("websocket not connected")
 	}
 
-	data, err := json.Marshal(subMsg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal subscribe message: %w", err)
+	// index-tickers still used by another instrument is only untracked, the rest is removed
+	// from tracking batch by batch as it is sent
+	send, shared := c.splitSharedIndexArgs(buildChannelArgs(subs))
+	c.untrack(shared)
+	if err := c.sendBatched("unsubscribe", send, c.untrack); err != nil {
+		return err
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
+	log.Printf("Unsubscribed from instruments: %v", subs)
+	return nil
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to send subscribe message: %w", err)
+// untrack removes the channels of args from the subscribed tracking
+func (c *PublicClient) untrack(args []map[string]string) {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+	for _, arg := range args {
+		inst := arg["instId"]
+		delete(c.subscribed[inst], arg["channel"])
+		if len(c.subscribed[inst]) == 0 {
+			delete(c.subscribed, inst)
+		}
 	}
+}
 
-	// Track subscribed instruments
-	c.subscribedMu.Lock()
-	for _, inst := range instruments {
-		c.subscribed[inst] = true
+// splitSharedIndexArgs splits unsubscribe args into those to send and the index-tickers
+// args whose wire subscription must stay. Instruments share one index-tickers subscription
+// per index (see wireArgs), which is counted by the tracked instruments on that index: the
+// unsubscribe is sent once, and only when the last of them leaves.
+func (c *PublicClient) splitSharedIndexArgs(args []map[string]string) (send, shared []map[string]string) {
+	leaving := make(map[string]bool)
+	for _, arg := range args {
+		if arg["channel"] == config.IndexTickersChannel {
+			leaving[arg["instId"]] = true
+		}
 	}
-	c.subscribedMu.Unlock()
 
-	log.Printf("Subscribed to instruments: %v (both books and tickers channels)", instruments)
-	return nil
-}
+	c.subscribedMu.RLock()
+	staying := make(map[string]int) // index -> instruments keeping its index-tickers
+	for inst, channels := range c.subscribed {
+		if channels[config.IndexTickersChannel] && !leaving[inst] {
+			staying[IndexOf(inst)]++
+		}
+	}
+	c.subscribedMu.RUnlock()
 
-// Unsubscribe unsubscribes from both order book and ticker data
-func (c *PublicClient) Unsubscribe(params interface{}) error {
-	instruments, ok := params.([]string)
-	if !ok {
-		return fmt.Errorf("invalid params type for PublicClient Unsubscribe, expected []string")
+	released := make(map[string]bool)
+	for _, arg := range args {
+		if arg["channel"] != config.IndexTickersChannel {
+			send = append(send, arg)
+			continue
+		}
+		index := IndexOf(arg["instId"])
+		if staying[index] > 0 || released[index] {
+			shared = append(shared, arg)
+			continue
+		}
+		released[index] = true
+		send = append(send, arg)
 	}
+	return send, shared
+}
 
-	c.mu.RLock()
-	conn := c.conn
-	c.mu.RUnlock()
+// SetDefaultChannels sets the channels subscribed for instruments without an instType override
+func (c *PublicClient) SetDefaultChannels(channels []string) {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+	c.defaultChannels = append([]string(nil), channels...)
+}
 
-	if conn == nil {
-		return fmt.Errorf("websocket not connected")
+// DefaultChannels returns the channels subscribed for instruments without an instType override
+func (c *PublicClient) DefaultChannels() []string {
+	c.subscribedMu.RLock()
+	defer c.subscribedMu.RUnlock()
+	return append([]string(nil), c.defaultChannels...)
+}
+
+// SetInstTypeChannels sets the channels subscribed for all instruments of an instType (SPOT, SWAP, FUTURES, OPTION)
+func (c *PublicClient) SetInstTypeChannels(instType string, channels []string) {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+	c.instTypeChannels[instType] = append([]string(nil), channels...)
+}
+
+// GetSubscribedChannels returns the tracked channels per subscribed instrument
+func (c *PublicClient) GetSubscribedChannels() map[string][]string {
+	c.subscribedMu.RLock()
+	defer c.subscribedMu.RUnlock()
+
+	result := make(map[string][]string, len(c.subscribed))
+	for inst, channels := range c.subscribed {
+		result[inst] = sortedKeys(channels)
 	}
+	return result
+}
 
-	// Build unsubscription message for both channels
-	args := make([]map[string]string, 0, len(instruments)*2)
+// resolveChannels converts Subscribe/Unsubscribe params into an instrument -> channels map
+func (c *PublicClient) resolveChannels(params interface{}, op string) (map[string][]string, error) {
+	switch p := params.(type) {
+	case []string:
+		subs := make(map[string][]string, len(p))
+		for _, inst := range p {
+			subs[inst] = c.channelsFor(inst)
+		}
+		return subs, nil
+	case map[string][]string:
+		subs := make(map[string][]string, len(p))
+		for inst, channels := range p {
+			if len(channels) == 0 {
+				channels = c.channelsFor(inst)
+			}
+			subs[inst] = channels
+		}
+		return subs, nil
+	default:
+		return nil, fmt.Errorf("invalid params type for PublicClient %s, expected []string or map[string][]string", op)
+	}
+}
 
-	// Unsubscribe from both channels for each instrument
-	for _, inst := range instruments {
-		// Unsubscribe from books channel
-		args = append(args, map[string]string{
-			"channel": "books",
-			"instId":  inst,
-		})
+// channelsFor returns the configured channels for an instrument based on its instType
+func (c *PublicClient) channelsFor(instID string) []string {
+	c.subscribedMu.RLock()
+	defer c.subscribedMu.RUnlock()
 
-		// Unsubscribe from tickers channel
-		args = append(args, map[string]string{
-			"channel": "tickers",
-			"instId":  inst,
-		})
+	if channels, ok := c.instTypeChannels[InstTypeOf(instID)]; ok && len(channels) > 0 {
+		return append([]string(nil), channels...)
 	}
+	return append([]string(nil), c.defaultChannels...)
+}
 
-	unsubMsg := map[string]interface{}{
-		"op":   "unsubscribe",
-		"args": args,
+// sendOp marshals and writes a subscribe/unsubscribe request
+func (c *PublicClient) sendOp(op string, args []map[string]string) error {
+	msg := map[string]interface{}{
+		"op":   op,
+		"args": wireArgs(args),
 	}
 
-	data, err := json.Marshal(unsubMsg)
+	data, err := json.Marshal(msg)
 	if err != nil {
-		return fmt.Errorf("failed to marshal unsubscribe message: %w", err)
+		return fmt.Errorf("failed to marshal %s message: %w", op, err)
 	}
 
-	c.mu.Lock()
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
-	c.mu.Unlock()
+	if err := c.writeText(data); err != nil {
+		return fmt.Errorf("failed to send %s message: %w", op, err)
+	}
+	return nil
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to send unsubscribe message: %w", err)
+// sendBatched sends args in frames of at most the subscribe batch size and calls onSent,
+// if not nil, for every frame that was written. A failed frame does not stop the following ones;
+// the error lists how many args were not sent.
+func (c *PublicClient) sendBatched(op string, args []map[string]string, onSent func(batch []map[string]string)) error {
+	c.mu.RLock()
+	batchSize := c.subscribeBatchSize
+	c.mu.RUnlock()
+	if batchSize <= 0 {
+		batchSize = DefaultSubscribeBatchSize
 	}
 
-	// Remove from subscribed tracking
-	c.subscribedMu.Lock()
-	for _, inst := range instruments {
-		delete(c.subscribed, inst)
+	var failed int
+	var lastErr error
+	for start := 0; start < len(args); start += batchSize {
+		batch := args[start:min(start+batchSize, len(args))]
+		if op == "subscribe" {
+			if err := c.throttle.wait(c.ctx); err != nil {
+				return fmt.Errorf("%s interrupted: %w", op, err)
+			}
+		}
+		if err := c.sendOp(op, batch); err != nil {
+			log.Printf("Failed to send %s batch of %d args: %v", op, len(batch), err)
+			failed += len(batch)
+			lastErr = err
+			continue
+		}
+		if onSent != nil {
+			onSent(batch)
+		}
 	}
-	c.subscribedMu.Unlock()
 
-	log.Printf("Unsubscribed from instruments: %v (both books and tickers channels)", instruments)
+	if lastErr != nil {
+		return fmt.Errorf("%d of %d %s args not sent: %w", failed, len(args), op, lastErr)
+	}
 	return nil
 }
 
-// GetSubscribed returns the list of currently subscribed instruments
+// buildChannelArgs builds OKEx subscription args ordered by instrument for deterministic output
+func buildChannelArgs(subs map[string][]string) []map[string]string {
+	instruments := make([]string, 0, len(subs))
+	for inst := range subs {
+		instruments = append(instruments, inst)
+	}
+	sort.Strings(instruments)
+
+	args := make([]map[string]string, 0, len(subs)*2)
+	for _, inst := range instruments {
+		for _, ch := range subs[inst] {
+			args = append(args, map[string]string{
+				"channel": ch,
+				"instId":  inst,
+			})
+		}
+	}
+	return args
+}
+
+// wireArgs returns args as sent to OKEx: index-tickers is subscribed per index, so its
+// instId is replaced by the instrument's index. Subscriptions stay tracked under the
+// instrument; instruments sharing an index share the index-tickers subscription, see
+// splitSharedIndexArgs.
+func wireArgs(args []map[string]string) []map[string]string {
+	wire := make([]map[string]string, len(args))
+	for i, arg := range args {
+		if arg["channel"] != config.IndexTickersChannel {
+			wire[i] = arg
+			continue
+		}
+		wire[i] = map[string]string{
+			"channel": arg["channel"],
+			"instId":  IndexOf(arg["instId"]),
+		}
+	}
+	return wire
+}
+
+// IndexOf returns the index an instrument tracks, its base and quote currency
+// e.g. BTC-USDT-SWAP -> BTC-USDT, BTC-USD-240628 -> BTC-USD, BTC-USDT -> BTC-USDT
+func IndexOf(instID string) string {
+	parts := strings.SplitN(instID, "-", 3)
+	if len(parts) < 2 {
+		return instID
+	}
+	return parts[0] + "-" + parts[1]
+}
+
+// InstTypeOf infers the OKEx instType from an instrument ID
+// e.g. BTC-USDT -> SPOT, BTC-USDT-SWAP -> SWAP, BTC-USD-240628 -> FUTURES, BTC-USD-240628-60000-C -> OPTION
+func InstTypeOf(instID string) string {
+	parts := strings.Split(instID, "-")
+	switch {
+	case len(parts) == 3 && parts[2] == "SWAP":
+		return "SWAP"
+	case len(parts) == 3:
+		return "FUTURES"
+	case len(parts) == 5:
+		return "OPTION"
+	default:
+		return "SPOT"
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetSubscribed returns the instruments whose channels OKEx has all acknowledged on the
+// current connection. Instruments whose subscribe was not sent, was rejected or is still
+// waiting for its acknowledgement are left out, so the subscription manager requests them
+// again on its next sync; GetSubscribedChannels lists everything that was requested.
 func (c *PublicClient) GetSubscribed() []string {
 	c.subscribedMu.RLock()
 	defer c.subscribedMu.RUnlock()
 
+	instruments := make([]string, 0, len(c.subscribed))
+	for inst, channels := range c.subscribed {
+		if c.isConfirmed(inst, channels) {
+			instruments = append(instruments, inst)
+		}
+	}
+	return instruments
+}
+
+// GetRequestedInstruments returns every instrument with tracked channels, acknowledged or not
+func (c *PublicClient) GetRequestedInstruments() []string {
+	c.subscribedMu.RLock()
+	defer c.subscribedMu.RUnlock()
+
 	instruments := make([]string, 0, len(c.subscribed))
 	for inst := range c.subscribed {
 		instruments = append(instruments, inst)
@@ -306,12 +615,32 @@ func (c *PublicClient) GetSubscribed() []string {
 	return instruments
 }
 
-// resubscribeAll resubscribes to all previously subscribed instruments
+// SetReconnectHandler sets the callback invoked after a successful reconnect and before the
+// instruments are resubscribed, e.g. to discard order books that missed updates while disconnected
+func (c *PublicClient) SetReconnectHandler(handler func(instIDs []string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnectHandler = handler
+}
+
+// notifyReconnect calls the reconnect handler with the subscribed instruments
+func (c *PublicClient) notifyReconnect() {
+	c.mu.RLock()
+	handler := c.reconnectHandler
+	c.mu.RUnlock()
+
+	if handler != nil {
+		// Acknowledgements were reset with the connection, report what was requested
+		handler(c.GetRequestedInstruments())
+	}
+}
+
+// resubscribeAll resubscribes to all previously subscribed instruments and channels
 func (c *PublicClient) resubscribeAll() {
-	instruments := c.GetSubscribed()
-	if len(instruments) > 0 {
-		log.Printf("Resubscribing to %d instruments", len(instruments))
-		if err := c.Subscribe(instruments); err != nil {
+	subs := c.GetSubscribedChannels()
+	if len(subs) > 0 {
+		log.Printf("Resubscribing to %d instruments", len(subs))
+		if err := c.Subscribe(subs); err != nil {
 			log.Printf("Failed to resubscribe: %v", err)
 		}
 	}
@@ -335,7 +664,7 @@ func (c *PublicClient) startPingPong() {
 				return
 			}
 
-			err := conn.WriteMessage(websocket.PingMessage, nil)
+			err := c.writer.write(conn, websocket.PingMessage, nil)
 			if err != nil {
 				log.Printf("Failed to send ping on WebSocket: %v", err)
 				return
@@ -353,7 +682,7 @@ func (c *PublicClient) Close() error {
 	defer c.mu.Unlock()
 
 	if c.conn != nil {
-		err := c.conn.WriteMessage(
+		err := c.writer.write(c.conn,
 			websocket.CloseMessage,
 			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 		)
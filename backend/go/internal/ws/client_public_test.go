@@ -0,0 +1,215 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// wsFrame is a subscribe/unsubscribe request as received by the test server
+type wsFrame struct {
+	Op   string              `json:"op"`
+	Args []map[string]string `json:"args"`
+}
+
+// newTestPublicClient connects a PublicClient without throttle to a server that records
+// every request it receives
+func newTestPublicClient(t *testing.T) (*PublicClient, <-chan wsFrame) {
+	t.Helper()
+
+	frames := make(chan wsFrame, 100)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var frame wsFrame
+			if json.Unmarshal(data, &frame) == nil && frame.Op != "" {
+				frames <- frame
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPublicClient("ws"+strings.TrimPrefix(server.URL, "http"), func([]byte) error { return nil })
+	client.SetSubscribeInterval(0)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, frames
+}
+
+// nextFrames returns the next n requests received by the test server
+func nextFrames(t *testing.T, frames <-chan wsFrame, n int) []wsFrame {
+	t.Helper()
+
+	var got []wsFrame
+	for len(got) < n {
+		select {
+		case frame := <-frames:
+			got = append(got, frame)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d of %d frames: %+v", len(got), n, got)
+		}
+	}
+	return got
+}
+
+// noMoreFrames fails if the test server receives another request shortly
+func noMoreFrames(t *testing.T, frames <-chan wsFrame) {
+	t.Helper()
+
+	select {
+	case frame := <-frames:
+		t.Fatalf("unexpected frame %+v", frame)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestResubscribeIsBatched(t *testing.T) {
+	client, frames := newTestPublicClient(t)
+	client.SetSubscribeBatchSize(1)
+
+	if err := client.Subscribe([]string{"BTC-USDT"}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	nextFrames(t, frames, 2)
+
+	if err := client.Resubscribe("BTC-USDT"); err != nil {
+		t.Fatalf("resubscribe: %v", err)
+	}
+	got := nextFrames(t, frames, 4)
+	for i, op := range []string{"unsubscribe", "unsubscribe", "subscribe", "subscribe"} {
+		if got[i].Op != op || len(got[i].Args) != 1 {
+			t.Fatalf("frame %d = %+v, want %s with 1 arg", i, got[i], op)
+		}
+	}
+	noMoreFrames(t, frames)
+
+	if channels := client.GetSubscribedChannels()["BTC-USDT"]; len(channels) != 2 {
+		t.Fatalf("tracked channels after resubscribe = %v", channels)
+	}
+}
+
+// indexTickersArgs returns the index-tickers instIds of a frame
+func indexTickersArgs(frame wsFrame) []string {
+	var indexes []string
+	for _, arg := range frame.Args {
+		if arg["channel"] == config.IndexTickersChannel {
+			indexes = append(indexes, arg["instId"])
+		}
+	}
+	return indexes
+}
+
+func TestSharedIndexTickersUnsubscribedWithLastInstrument(t *testing.T) {
+	client, frames := newTestPublicClient(t)
+
+	channels := []string{config.BooksChannel, config.IndexTickersChannel}
+	if err := client.Subscribe(map[string][]string{"BTC-USDT": channels, "BTC-USDT-SWAP": channels}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	nextFrames(t, frames, 1)
+
+	// Still used by BTC-USDT-SWAP
+	if err := client.Resubscribe("BTC-USDT"); err != nil {
+		t.Fatalf("resubscribe: %v", err)
+	}
+	for _, frame := range nextFrames(t, frames, 2) {
+		if indexes := indexTickersArgs(frame); len(indexes) != 0 {
+			t.Fatalf("resubscribe sent %s for shared index %v", frame.Op, indexes)
+		}
+	}
+	if err := client.Unsubscribe([]string{"BTC-USDT"}); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	if frame := nextFrames(t, frames, 1)[0]; len(frame.Args) != 1 || len(indexTickersArgs(frame)) != 0 {
+		t.Fatalf("unsubscribe of BTC-USDT sent %+v, want books only", frame)
+	}
+	if _, tracked := client.GetSubscribedChannels()["BTC-USDT"]; tracked {
+		t.Fatal("BTC-USDT still tracked")
+	}
+
+	// Last instrument on the index
+	if err := client.Unsubscribe([]string{"BTC-USDT-SWAP"}); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	frame := nextFrames(t, frames, 1)[0]
+	if indexes := indexTickersArgs(frame); len(indexes) != 1 || indexes[0] != "BTC-USDT" {
+		t.Fatalf("unsubscribe of the last instrument sent %+v, want index-tickers of BTC-USDT", frame)
+	}
+	noMoreFrames(t, frames)
+}
+
+func TestSharedIndexTickersUnsubscribedOnce(t *testing.T) {
+	client, frames := newTestPublicClient(t)
+
+	channels := []string{config.IndexTickersChannel}
+	instruments := map[string][]string{"BTC-USDT": channels, "BTC-USDT-SWAP": channels}
+	if err := client.Subscribe(instruments); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	nextFrames(t, frames, 1)
+
+	if err := client.Unsubscribe(instruments); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	if frame := nextFrames(t, frames, 1)[0]; len(frame.Args) != 1 {
+		t.Fatalf("unsubscribe sent %+v, want a single index-tickers arg", frame)
+	}
+	if subs := client.GetSubscribedChannels(); len(subs) != 0 {
+		t.Fatalf("still tracked: %v", subs)
+	}
+}
+
+func TestSubscribeArgsPerChannelConfiguration(t *testing.T) {
+	client := NewPublicClient("ws://unused", func([]byte) error { return nil })
+	client.SetInstTypeChannels("SWAP", []string{config.BooksChannel, config.MarkPriceChannel, config.OpenInterestChannel})
+
+	tests := []struct {
+		name   string
+		params interface{}
+		want   string
+	}{
+		{"default books and tickers", []string{"BTC-USDT"},
+			"books/BTC-USDT tickers/BTC-USDT"},
+		{"instType override for SWAP", []string{"BTC-USDT-SWAP"},
+			"books/BTC-USDT-SWAP mark-price/BTC-USDT-SWAP open-interest/BTC-USDT-SWAP"},
+		{"explicit channels per instrument", map[string][]string{"ETH-USDT": {config.BooksChannel}, "BTC-USDT": nil},
+			"books/BTC-USDT tickers/BTC-USDT books/ETH-USDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs, err := client.resolveChannels(tt.params, "Subscribe")
+			if err != nil {
+				t.Fatalf("resolve: %v", err)
+			}
+			var got []string
+			for _, arg := range buildChannelArgs(subs) {
+				got = append(got, arg["channel"]+"/"+arg["instId"])
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Fatalf("args = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := client.resolveChannels("BTC-USDT", "Subscribe"); err == nil {
+		t.Fatal("a bare string was accepted as subscribe params")
+	}
+}
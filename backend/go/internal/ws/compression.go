@@ -0,0 +1,27 @@
+package ws
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// compressionNegotiated reports whether the server accepted permessage-deflate
+func compressionNegotiated(resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+	return strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+}
+
+// logCompression logs the outcome of the permessage-deflate negotiation
+func logCompression(url string, requested bool, resp *http.Response) {
+	if !requested {
+		return
+	}
+	if compressionNegotiated(resp) {
+		log.Printf("permessage-deflate negotiated with %s", url)
+	} else {
+		log.Printf("permessage-deflate requested but not accepted by %s", url)
+	}
+}
@@ -0,0 +1,156 @@
+package ws
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/supermancell/okex-buddy/internal/common"
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// logBuffer collects log output written from any goroutine
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newCompressingServer accepts permessage-deflate when offered and sends every
+// connection one large, highly compressible books push. It reports the extensions
+// each client offered.
+func newCompressingServer(t *testing.T, push string) (string, <-chan string) {
+	t.Helper()
+
+	offers := make(chan string, 10)
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offered := r.Header.Get("Sec-WebSocket-Extensions")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		offers <- offered
+		conn.WriteMessage(websocket.TextMessage, []byte(push))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), offers
+}
+
+func TestCompressionNegotiatedPerClient(t *testing.T) {
+	push := `{"arg":{"channel":"books","instId":"BTC-USDT"},"data":[{"asks":[` +
+		strings.Repeat(`["100.5","1","0","1"],`, 400) + `["101","1","0","1"]]}]}`
+
+	type connector interface {
+		SetCompression(bool)
+		Connect() error
+		Close() error
+	}
+	clients := map[string]func(url string, handler common.MessageHandler) connector{
+		"public": func(url string, h common.MessageHandler) connector { return NewPublicClient(url, h) },
+		"business": func(url string, h common.MessageHandler) connector {
+			return NewBusinessClient(url, h)
+		},
+		"private": func(url string, h common.MessageHandler) connector {
+			return NewPrivateClient(url, h, OKExConfig{})
+		},
+	}
+
+	logs := &logBuffer{}
+	log.SetOutput(logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for name, newClient := range clients {
+		for _, enabled := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s/compression=%v", name, enabled), func(t *testing.T) {
+				url, offers := newCompressingServer(t, push)
+				received := make(chan []byte, 1)
+				client := newClient(url, func(msg []byte) error {
+					received <- msg
+					return nil
+				})
+				client.SetCompression(enabled)
+				if err := client.Connect(); err != nil {
+					t.Fatalf("connect: %v", err)
+				}
+				defer client.Close()
+
+				select {
+				case offered := <-offers:
+					if got := strings.Contains(offered, "permessage-deflate"); got != enabled {
+						t.Fatalf("client offered %q", offered)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatal("server saw no handshake")
+				}
+				// Connect logs the extension the server answered with before returning
+				if got := strings.Contains(logs.String(), "permessage-deflate negotiated with "+url); got != enabled {
+					t.Fatalf("negotiated = %v in log:\n%s", got, logs.String())
+				}
+
+				// The compressed push must arrive intact
+				select {
+				case msg := <-received:
+					if string(msg) != push {
+						t.Fatalf("received %d bytes, want the %d-byte push", len(msg), len(push))
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatal("push not delivered")
+				}
+			})
+		}
+	}
+}
+
+func TestCompressionNegotiatedFromResponse(t *testing.T) {
+	for header, want := range map[string]bool{
+		"permessage-deflate; server_no_context_takeover; client_no_context_takeover": true,
+		"": false,
+	} {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Sec-WebSocket-Extensions", header)
+		if got := compressionNegotiated(resp); got != want {
+			t.Errorf("compressionNegotiated(%q) = %v, want %v", header, got, want)
+		}
+	}
+	if compressionNegotiated(nil) {
+		t.Error("compressionNegotiated(nil) = true")
+	}
+}
+
+func TestCompressionDefaultsOnForPublicOnly(t *testing.T) {
+	for _, key := range []string{"OKEX_WS_PUBLIC_COMPRESSION", "OKEX_WS_BUSINESS_COMPRESSION", "OKEX_WS_PRIVATE_COMPRESSION"} {
+		t.Setenv(key, "")
+	}
+	okex := config.LoadFromEnv().OKEX
+	if !okex.PublicWSCompression || okex.BusinessWSCompression || okex.PrivateWSCompression {
+		t.Fatalf("defaults public=%v business=%v private=%v, want only public on",
+			okex.PublicWSCompression, okex.BusinessWSCompression, okex.PrivateWSCompression)
+	}
+}
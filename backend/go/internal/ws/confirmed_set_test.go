@@ -0,0 +1,55 @@
+package ws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestConfirmedSetFollowsAcknowledgements(t *testing.T) {
+	client := NewPublicClient("ws://127.0.0.1:1", func([]byte) error { return nil })
+
+	// What was sent, as Subscribe records it
+	client.subscribedMu.Lock()
+	client.subscribed = map[string]map[string]bool{
+		"ETH-USDT-SWAP": {"books": true, config.IndexTickersChannel: true},
+		"XYZ-USDT":      {"books": true},
+	}
+	client.subscribedMu.Unlock()
+
+	for _, frame := range []string{
+		`{"event":"subscribe","arg":{"channel":"books","instId":"ETH-USDT-SWAP"},"connId":"7f1"}`,
+		// index-tickers is acknowledged under the index
+		`{"event":"subscribe","arg":{"channel":"index-tickers","instId":"ETH-USDT"},"connId":"7f1"}`,
+		// Not acknowledgements
+		`{"event":"error","code":"60018","msg":"Wrong URL or channel:books,instId:XYZ-USDT doesn't exist.","arg":{"channel":"books","instId":"XYZ-USDT"}}`,
+		`{"arg":{"channel":"books","instId":"XYZ-USDT"},"data":[]}`,
+		`{"event":"subscribe","arg":{"channel":"books"}}`,
+		`{"event":"subscribe",`,
+	} {
+		client.handleSubscribeEvent([]byte(frame))
+	}
+
+	want := map[string][]string{"ETH-USDT-SWAP": {"books"}, "ETH-USDT": {config.IndexTickersChannel}}
+	if got := client.GetConfirmedSubscriptions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("confirmed = %v, want %v", got, want)
+	}
+	if got := client.GetUnconfirmedSubscriptions(); !reflect.DeepEqual(got, map[string][]string{"XYZ-USDT": {"books"}}) {
+		t.Fatalf("unconfirmed = %v, want only XYZ-USDT books", got)
+	}
+
+	client.handleSubscribeEvent([]byte(`{"event":"unsubscribe","arg":{"channel":"books","instId":"ETH-USDT-SWAP"}}`))
+	if got := client.GetConfirmedSubscriptions(); !reflect.DeepEqual(got, map[string][]string{"ETH-USDT": {config.IndexTickersChannel}}) {
+		t.Fatalf("confirmed after unsubscribe = %v", got)
+	}
+
+	// A new connection starts without acknowledgements
+	client.resetConfirmed()
+	if got := client.GetConfirmedSubscriptions(); len(got) != 0 {
+		t.Fatalf("confirmed after reconnect = %v", got)
+	}
+	if got := client.GetUnconfirmedSubscriptions(); len(got["ETH-USDT-SWAP"]) != 2 {
+		t.Fatalf("unconfirmed after reconnect = %v, want both ETH-USDT-SWAP channels", got)
+	}
+}
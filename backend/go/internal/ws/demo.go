@@ -0,0 +1,24 @@
+package ws
+
+import "net/http"
+
+// SimulatedTradingHeader routes requests to the OKEx demo trading (paper) environment
+const SimulatedTradingHeader = "x-simulated-trading"
+
+// SetSimulatedTrading sends the demo trading header on subsequent handshakes.
+// The URL must point at the demo endpoint (wspap.okx.com) as well.
+func (c *PrivateClient) SetSimulatedTrading(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.simulatedTrading = enabled
+}
+
+// handshakeHeader returns the extra handshake headers. Caller holds c.mu.
+func (c *PrivateClient) handshakeHeader() http.Header {
+	if !c.simulatedTrading {
+		return nil
+	}
+	header := http.Header{}
+	header.Set(SimulatedTradingHeader, "1")
+	return header
+}
@@ -0,0 +1,73 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// handshakeRecorder is a WebSocket endpoint that reports the simulated trading header of
+// every handshake it accepts
+func handshakeRecorder(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	headers := make(chan string, 10)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get(SimulatedTradingHeader)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), headers
+}
+
+func TestDemoTradingPrivateHandshake(t *testing.T) {
+	for _, demo := range []bool{false, true} {
+		prodURL, prod := handshakeRecorder(t)
+		demoURL, paper := handshakeRecorder(t)
+		t.Setenv("OKEX_WS_PRIVATE", prodURL)
+		t.Setenv("OKEX_WS_PRIVATE_DEMO", demoURL)
+		t.Setenv("OKEX_DEMO_TRADING", map[bool]string{false: "false", true: "true"}[demo])
+
+		// Wired like the private connector
+		okex := config.LoadFromEnv().OKEX
+		client := NewPrivateClient(okex.TradingPrivateWSURL(), func([]byte) error { return nil }, OKExConfig{})
+		client.SetSimulatedTrading(okex.DemoTrading)
+		if err := client.Connect(); err != nil {
+			t.Fatalf("demo=%v: connect: %v", demo, err)
+		}
+		client.Close()
+
+		used, other := prod, paper
+		if demo {
+			used, other = paper, prod
+		}
+		select {
+		case header := <-used:
+			if want := map[bool]string{false: "", true: "1"}[demo]; header != want {
+				t.Fatalf("demo=%v: %s = %q, want %q", demo, SimulatedTradingHeader, header, want)
+			}
+		default:
+			t.Fatalf("demo=%v: the expected endpoint saw no handshake", demo)
+		}
+		select {
+		case <-other:
+			t.Fatalf("demo=%v: connected to the wrong environment", demo)
+		default:
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package ws
+
+import "time"
+
+// SetReconnectDelay shortens the reconnect backoff for tests outside the package
+func (c *PublicClient) SetReconnectDelay(delay time.Duration) {
+	c.reconnectDelay = delay
+}
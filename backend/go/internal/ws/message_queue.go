@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// defaultMessageQueueSize is the number of raw messages buffered between the socket
+// reader and the message handler
+const defaultMessageQueueSize = 1024
+
+// SetMessageQueueSize sets the buffer between the socket reader and the message handler.
+// Must be called before Connect; values <= 0 keep the default.
+func (c *PublicClient) SetMessageQueueSize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messageQueueSize = size
+}
+
+// startDispatcher creates the message queue and starts the goroutine that drains it.
+// Runs once per client so the queue survives reconnects. Caller holds c.mu.
+func (c *PublicClient) startDispatcher() {
+	c.dispatchOnce.Do(func() {
+		size := c.messageQueueSize
+		if size <= 0 {
+			size = defaultMessageQueueSize
+		}
+		c.messages = make(chan []byte, size)
+		go c.dispatchMessages()
+	})
+}
+
+// dispatchMessages hands queued messages to msgHandler until the client is closed.
+// A slow handler fills the queue instead of stalling ReadMessage.
+func (c *PublicClient) dispatchMessages() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case message := <-c.messages:
+			if c.msgHandler == nil {
+				continue
+			}
+			if err := c.msgHandler(message); err != nil {
+				log.Printf("Error handling message: %v", err)
+			}
+		}
+	}
+}
+
+// droppedLogInterval logs every Nth dropped message after the first one
+const droppedLogInterval = 1000
+
+// enqueueMessage queues a message for the dispatcher without blocking the reader. When the
+// queue is full the message is dropped and counted, see DroppedMessages; a dropped books
+// message shows up as a sequence gap and the book is resubscribed.
+// Returns false when the client is closed.
+func (c *PublicClient) enqueueMessage(message []byte) bool {
+	select {
+	case <-c.ctx.Done():
+		return false
+	default:
+	}
+
+	select {
+	case c.messages <- message:
+	default:
+		if dropped := atomic.AddInt64(&c.droppedMessages, 1); dropped == 1 || dropped%droppedLogInterval == 0 {
+			log.Printf("Message queue full (%d), dropped %d messages so far", cap(c.messages), dropped)
+		}
+	}
+	return true
+}
+
+// DroppedMessages returns how many messages were dropped because the message queue was full
+func (c *PublicClient) DroppedMessages() int64 {
+	return atomic.LoadInt64(&c.droppedMessages)
+}
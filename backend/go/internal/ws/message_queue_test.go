@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestReaderKeepsReadingWhileHandlerIsSlow(t *testing.T) {
+	const pushed = 50
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for i := 0; i < pushed; i++ {
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"arg":{"channel":"trades","instId":"BTC-USDT"},"data":[{"tradeId":"%d"}]}`, i)))
+		}
+		// Read by the reader goroutine itself, not the handler
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event":"subscribe","arg":{"channel":"books","instId":"BTC-USDT"}}`))
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	release := make(chan struct{})
+	var handled int32
+	client := NewPublicClient("ws"+strings.TrimPrefix(server.URL, "http"), func([]byte) error {
+		<-release
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	client.SetMessageQueueSize(2)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+	defer close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.GetConfirmedSubscriptions()["BTC-USDT"]) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("reader stalled behind the blocked handler")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if atomic.LoadInt32(&handled) != 0 {
+		t.Fatal("handler was not blocked")
+	}
+	// One message is held by the blocked handler and two fill the queue
+	if dropped := client.DroppedMessages(); dropped < pushed-3 {
+		t.Fatalf("dropped %d messages, want at least %d", dropped, pushed-3)
+	}
+}
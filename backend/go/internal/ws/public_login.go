@@ -0,0 +1,109 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// loginTimeout bounds the wait for the login response on the public connection
+const loginTimeout = 10 * time.Second
+
+// SetLogin makes the client log in right after every (re)connect, before any subscribe
+// is sent. Required for VIP channels such as books-l2-tbt on the public endpoint.
+// httpProxyAddr is used for the server time sync, as for the private client.
+func (c *PublicClient) SetLogin(config OKExConfig, httpProxyAddr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loginConfig = &config
+	c.httpProxyAddr = httpProxyAddr
+}
+
+// timeSyncSOCKS5Addr returns the SOCKS5 proxy the time sync falls back to without an HTTP proxy
+func (c *PublicClient) timeSyncSOCKS5Addr() string {
+	if !c.useProxy {
+		return ""
+	}
+	return c.proxyAddr
+}
+
+// login sends the login op on conn and blocks until OKEx answers.
+// It runs before the reader goroutine starts, so it reads the response itself.
+func (c *PublicClient) login(conn *websocket.Conn) error {
+	if offset, err := SyncServerTime(c.httpProxyAddr, c.timeSyncSOCKS5Addr()); err != nil {
+		log.Printf("Warning: Failed to sync time: %v, using local time", err)
+	} else {
+		c.clock.SetOffset(offset)
+	}
+
+	timestamp := c.clock.TimestampSeconds()
+	loginMsg := map[string]interface{}{
+		"op": "login",
+		"args": []map[string]string{
+			{
+				"apiKey":     c.loginConfig.APIKey,
+				"passphrase": c.loginConfig.Passphrase,
+				"timestamp":  timestamp,
+				"sign":       BuildLoginSign(c.loginConfig.SecretKey, timestamp),
+			},
+		},
+	}
+
+	data, err := json.Marshal(loginMsg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal login message: %w", err)
+	}
+	if err := c.writer.write(conn, websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("failed to send login message: %w", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(loginTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("failed to read login response: %w", err)
+		}
+
+		var resp struct {
+			Event string `json:"event"`
+			Code  string `json:"code"`
+			Msg   string `json:"msg"`
+		}
+		if err := json.Unmarshal(message, &resp); err != nil {
+			continue
+		}
+
+		switch resp.Event {
+		case "login":
+			if resp.Code != "" && resp.Code != "0" {
+				return fmt.Errorf("login failed: code=%s, msg=%s", resp.Code, resp.Msg)
+			}
+			log.Println("Public WebSocket login successful")
+			return nil
+		case "error":
+			return fmt.Errorf("login failed: code=%s, msg=%s", resp.Code, resp.Msg)
+		}
+	}
+}
+
+// Resubscribe unsubscribes and subscribes again the tracked channels of an instrument,
+// which makes OKEx send a fresh books snapshot (e.g. after a sequence gap)
+func (c *PublicClient) Resubscribe(instID string) error {
+	channels := c.GetSubscribedChannels()[instID]
+	if len(channels) == 0 {
+		return fmt.Errorf("instrument %s is not subscribed", instID)
+	}
+
+	// Tracking is unchanged, only the frames are sent, throttled and batched like Subscribe.
+	// An index-tickers subscription shared with other instruments is left alone.
+	args, _ := c.splitSharedIndexArgs(buildChannelArgs(map[string][]string{instID: channels}))
+	if err := c.sendBatched("unsubscribe", args, nil); err != nil {
+		return err
+	}
+	return c.sendBatched("subscribe", args, nil)
+}
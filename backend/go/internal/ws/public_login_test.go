@@ -0,0 +1,98 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// newLoginServer answers login with loginCode and records every op in arrival order
+func newLoginServer(t *testing.T, loginCode string) (string, <-chan wsFrame) {
+	t.Helper()
+
+	frames := make(chan wsFrame, 100)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var frame wsFrame
+			if json.Unmarshal(data, &frame) != nil || frame.Op == "" {
+				continue
+			}
+			frames <- frame
+			if frame.Op == "login" {
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"event":"login","code":"`+loginCode+`","msg":""}`))
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), frames
+}
+
+// refusingProxy is an HTTP proxy that rejects every request, so the server time sync
+// done before login fails fast instead of reaching OKEx
+func refusingProxy(t *testing.T) string {
+	t.Helper()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no upstream in tests", http.StatusForbidden)
+	}))
+	t.Cleanup(proxy.Close)
+	return strings.TrimPrefix(proxy.URL, "http://")
+}
+
+func TestBooksL2TBTLogsInBeforeSubscribing(t *testing.T) {
+	url, frames := newLoginServer(t, "0")
+	client := NewPublicClient(url, func([]byte) error { return nil })
+	client.SetSubscribeInterval(0)
+	client.SetLogin(OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"}, refusingProxy(t))
+	client.SetDefaultChannels([]string{config.BooksL2TBTChannel, config.TickerChannel})
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.Subscribe([]string{"BTC-USDT"}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	got := nextFrames(t, frames, 2)
+	if got[0].Op != "login" || got[0].Args[0]["apiKey"] != "key" || got[0].Args[0]["sign"] == "" {
+		t.Fatalf("first frame = %+v, want a signed login", got[0])
+	}
+	if got[1].Op != "subscribe" || len(got[1].Args) != 2 || got[1].Args[0]["channel"] != config.BooksL2TBTChannel {
+		t.Fatalf("second frame = %+v, want books-l2-tbt and tickers", got[1])
+	}
+	noMoreFrames(t, frames)
+}
+
+func TestBooksL2TBTFailedLoginSendsNoSubscribe(t *testing.T) {
+	url, frames := newLoginServer(t, "60009")
+	client := NewPublicClient(url, func([]byte) error { return nil })
+	client.SetLogin(OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"}, refusingProxy(t))
+
+	err := client.Connect()
+	if err == nil || !strings.Contains(err.Error(), "60009") {
+		t.Fatalf("connect err = %v, want the login failure", err)
+	}
+	if frame := nextFrames(t, frames, 1)[0]; frame.Op != "login" {
+		t.Fatalf("frame = %+v, want login", frame)
+	}
+	if err := client.Subscribe([]string{"BTC-USDT"}); err == nil {
+		t.Fatal("subscribe succeeded without a logged-in connection")
+	}
+	noMoreFrames(t, frames)
+}
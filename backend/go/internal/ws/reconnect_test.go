@@ -0,0 +1,128 @@
+package ws_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+	"github.com/supermancell/okex-buddy/internal/ws"
+)
+
+// booksPush is a books message for BTC-USDT with the given ask and bid [price, size] levels
+func booksPush(action string, asks, bids [][2]string) []byte {
+	levels := func(in [][2]string) string {
+		var out []string
+		for _, l := range in {
+			out = append(out, fmt.Sprintf(`[%q,%q,"0","1"]`, l[0], l[1]))
+		}
+		return "[" + strings.Join(out, ",") + "]"
+	}
+	return []byte(fmt.Sprintf(`{"action":%q,"arg":{"channel":"books","instId":"BTC-USDT"},"data":[{"asks":%s,"bids":%s,"ts":"1717000000000"}]}`,
+		action, levels(asks), levels(bids)))
+}
+
+func TestReconnectDoesNotMergeOntoStaleBook(t *testing.T) {
+	m := orderbook.NewManager()
+	m.SetVerifyChecksum(false)
+
+	// Each server-side step waits until the client has processed the previous push
+	processed := make(chan error, 10)
+	next := make(chan struct{})
+	sessions := [][][]byte{
+		{
+			booksPush("snapshot", [][2]string{{"101", "1"}}, [][2]string{{"99", "1"}}),
+			booksPush("update", [][2]string{{"102", "7"}}, nil),
+		},
+		{
+			// Sent before the fresh snapshot, it belongs to neither book
+			booksPush("update", [][2]string{{"103", "4"}}, nil),
+			booksPush("snapshot", [][2]string{{"200", "2"}}, [][2]string{{"198", "2"}}),
+			booksPush("update", [][2]string{{"201", "1"}}, nil),
+		},
+	}
+	connections := make(chan int, 2)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		session := len(connections)
+		connections <- session
+
+		// Wait for the (re)subscribe before pushing
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		for _, push := range sessions[session] {
+			if conn.WriteMessage(websocket.TextMessage, push) != nil {
+				return
+			}
+			<-next
+		}
+		// Dropping the first connection makes the client reconnect
+		if session > 0 {
+			conn.ReadMessage()
+		}
+	}))
+	defer server.Close()
+
+	client := ws.NewPublicClient("ws"+strings.TrimPrefix(server.URL, "http"), func(msg []byte) error {
+		processed <- m.ProcessMessage(msg)
+		return nil
+	})
+	client.SetSubscribeInterval(0)
+	client.SetReconnectDelay(time.Millisecond)
+	client.SetReconnectHandler(m.MarkAwaitingSnapshot)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+	if err := client.Subscribe([]string{"BTC-USDT"}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	asks := func() string {
+		book, ok := m.GetOrderBook("BTC-USDT")
+		if !ok {
+			return "none"
+		}
+		var prices []string
+		for _, l := range book.Asks {
+			prices = append(prices, l.Price)
+		}
+		return strings.Join(prices, " ")
+	}
+	step := func(want string) {
+		t.Helper()
+		select {
+		case err := <-processed:
+			if err != nil {
+				t.Fatalf("process: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no push processed, waiting for asks %q", want)
+		}
+		if got := asks(); got != want {
+			t.Fatalf("asks %q, want %q", got, want)
+		}
+		next <- struct{}{}
+	}
+
+	step("101")
+	step("101 102")
+	step("none") // reset on reconnect, the stray update is dropped
+	step("200")
+	step("200 201")
+
+	if len(connections) != 2 {
+		t.Fatalf("%d connections, want a reconnect", len(connections))
+	}
+}
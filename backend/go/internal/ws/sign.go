@@ -0,0 +1,26 @@
+package ws
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// loginVerifyPath is the request path signed by the WebSocket login
+const loginVerifyPath = "/users/self/verify"
+
+// BuildRequestSign builds the OKEx request signature: Base64(HMAC-SHA256(timestamp + method + requestPath + body, secret)).
+// It is shared by the WebSocket login and the REST client.
+// Reference: https://www.okx.com/docs-v5/en/#overview-rest-authentication-making-requests
+func BuildRequestSign(secret, timestamp, method, path, body string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + method + path + body))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// BuildLoginSign builds the WebSocket login signature, i.e. the request signature of
+// GET /users/self/verify with an empty body. timestamp is in unix seconds.
+// Reference: https://www.okx.com/docs-v5/en/#overview-websocket-login
+func BuildLoginSign(secret, timestamp string) string {
+	return BuildRequestSign(secret, timestamp, "GET", loginVerifyPath, "")
+}
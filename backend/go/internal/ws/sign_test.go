@@ -0,0 +1,80 @@
+package ws
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+// referenceSign is the signature as OKEx documents it:
+// Base64(HMAC-SHA256(timestamp + method + requestPath + body, SecretKey))
+func referenceSign(secret, prehash string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(prehash))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestBuildRequestSign(t *testing.T) {
+	// RFC 4231 test case 2: HMAC-SHA256("Jefe", "what do ya want for nothing?"), split
+	// into the timestamp, method and path parts that OKEx concatenates
+	got := BuildRequestSign("Jefe", "what do ya ", "want", " for nothing?", "")
+	if want := "W9zBRr9gdU5qBCQmCJV1x1oAPwidJzmDnexYuWTsOEM="; got != want {
+		t.Fatalf("sign = %s, want %s", got, want)
+	}
+}
+
+func TestBuildRequestSignDocumentedExamples(t *testing.T) {
+	secret := "22582BD0CFF14C41EDBF1AB98506286D"
+	tests := []struct {
+		name                    string
+		timestamp, method, path string
+		body                    string
+	}{
+		{"GET with query", "2020-12-08T09:08:57.715Z", "GET", "/api/v5/account/balance?ccy=BTC", ""},
+		{"POST with body", "2020-12-08T09:08:57.715Z", "POST", "/api/v5/trade/order",
+			`{"instId":"BTC-USDT","tdMode":"cash","side":"buy","ordType":"market","sz":"100"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildRequestSign(secret, tt.timestamp, tt.method, tt.path, tt.body)
+			if want := referenceSign(secret, tt.timestamp+tt.method+tt.path+tt.body); got != want {
+				t.Fatalf("sign = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestBuildLoginSignSignsVerifyPath(t *testing.T) {
+	if got, want := BuildLoginSign("secret", "1538054050"), BuildRequestSign("secret", "1538054050", "GET", "/users/self/verify", ""); got != want {
+		t.Fatalf("login sign = %s, want the signature of GET /users/self/verify %s", got, want)
+	}
+	if got, want := BuildLoginSign("secret", "1538054050"), referenceSign("secret", "1538054050GET/users/self/verify"); got != want {
+		t.Fatalf("login sign = %s, documented prehash gives %s", got, want)
+	}
+}
+
+func TestPrivateLoginFrameIsSigned(t *testing.T) {
+	url, frames := newLoginServer(t, "0")
+	client := NewPrivateClientWithDualProxy(url, func([]byte) error { return nil }, false, "", refusingProxy(t),
+		OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.Login(); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	frame := nextFrames(t, frames, 1)[0]
+	if frame.Op != "login" || len(frame.Args) != 1 {
+		t.Fatalf("frame = %+v, want login", frame)
+	}
+	args := frame.Args[0]
+	if want := referenceSign("secret", args["timestamp"]+"GET/users/self/verify"); args["sign"] != want {
+		t.Fatalf("login sign %s for timestamp %s, want %s", args["sign"], args["timestamp"], want)
+	}
+	if args["apiKey"] != "key" || args["passphrase"] != "pass" {
+		t.Fatalf("login args = %v", args)
+	}
+}
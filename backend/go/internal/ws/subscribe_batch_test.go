@@ -0,0 +1,56 @@
+package ws
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSubscribeHundredPairsInBatches(t *testing.T) {
+	pairs := make([]string, 100)
+	for i := range pairs {
+		pairs[i] = fmt.Sprintf("COIN%03d-USDT", i)
+	}
+
+	// books and tickers per pair: 200 args
+	for _, tt := range []struct {
+		batchSize, frames, last int
+	}{
+		{0, 2, DefaultSubscribeBatchSize}, // OKEx default
+		{30, 7, 20},
+		{200, 1, 200},
+	} {
+		t.Run(fmt.Sprintf("batch=%d", tt.batchSize), func(t *testing.T) {
+			client, frames := newTestPublicClient(t)
+			client.SetSubscribeBatchSize(tt.batchSize)
+			if err := client.Subscribe(pairs); err != nil {
+				t.Fatalf("subscribe: %v", err)
+			}
+
+			limit := tt.batchSize
+			if limit == 0 {
+				limit = DefaultSubscribeBatchSize
+			}
+			seen := make(map[string]bool)
+			got := nextFrames(t, frames, tt.frames)
+			for i, frame := range got {
+				if frame.Op != "subscribe" || len(frame.Args) > limit {
+					t.Fatalf("frame %d: %s with %d args, limit %d", i, frame.Op, len(frame.Args), limit)
+				}
+				for _, arg := range frame.Args {
+					seen[arg["channel"]+"/"+arg["instId"]] = true
+				}
+			}
+			if n := len(got[len(got)-1].Args); n != tt.last {
+				t.Fatalf("last frame has %d args, want %d", n, tt.last)
+			}
+			noMoreFrames(t, frames)
+
+			if len(seen) != 200 {
+				t.Fatalf("%d distinct args subscribed, want 200", len(seen))
+			}
+			if subscribed := client.GetSubscribedChannels(); len(subscribed) != 100 || len(subscribed["COIN099-USDT"]) != 2 {
+				t.Fatalf("%d pairs tracked, last with %v", len(subscribed), subscribed["COIN099-USDT"])
+			}
+		})
+	}
+}
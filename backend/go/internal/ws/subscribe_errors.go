@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// errorInstIDPattern extracts the instrument from OKEx error messages such as
+// "Wrong URL or channel:books,instId:FOO-USDT doesn't exist."
+var errorInstIDPattern = regexp.MustCompile(`instId:([A-Za-z0-9\-]+)`)
+
+// errorChannelPattern extracts the channel from the same messages
+var errorChannelPattern = regexp.MustCompile(`channel:([A-Za-z0-9\-]+)`)
+
+// codeInstrumentNotFound is the OKEx error code for a subscription to an instId that doesn't exist
+const codeInstrumentNotFound = "60018"
+
+// SetSubscribeErrorHandler sets the callback invoked when OKEx rejects the subscription
+// of an instrument because it doesn't exist. The instrument is removed from the subscribed
+// set before the call.
+func (c *PublicClient) SetSubscribeErrorHandler(handler func(instID string, err error)) {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+	c.subscribeErrorHandler = handler
+}
+
+// handleSubscribeError inspects error events. An instrument that doesn't exist is dropped
+// with all its channels and reported to the subscribe error handler, so that a single
+// invalid symbol doesn't stay tracked forever. Any other rejection (e.g. books-l2-tbt
+// without VIP permission) only drops the failing channel, the instrument stays valid.
+func (c *PublicClient) handleSubscribeError(message []byte) {
+	if !bytes.Contains(message, []byte(`"error"`)) {
+		return
+	}
+
+	var event struct {
+		Event string `json:"event"`
+		Code  string `json:"code"`
+		Msg   string `json:"msg"`
+		Arg   struct {
+			Channel string `json:"channel"`
+			InstID  string `json:"instId"`
+		} `json:"arg"`
+	}
+	if err := json.Unmarshal(message, &event); err != nil || event.Event != "error" {
+		return
+	}
+
+	instID, channel := event.Arg.InstID, event.Arg.Channel
+	if instID == "" {
+		if match := errorInstIDPattern.FindStringSubmatch(event.Msg); match != nil {
+			instID = match[1]
+		}
+	}
+	if channel == "" {
+		if match := errorChannelPattern.FindStringSubmatch(event.Msg); match != nil {
+			channel = match[1]
+		}
+	}
+	if instID == "" {
+		return
+	}
+	rejection := fmt.Errorf("OKEx rejected subscription: code=%s, msg=%s", event.Code, event.Msg)
+
+	// index-tickers are subscribed per index, whose instId is not a tradable instrument
+	if event.Code != codeInstrumentNotFound || channel == config.IndexTickersChannel {
+		if channel == "" {
+			log.Printf("Subscription error for %s: %v", instID, rejection)
+			return
+		}
+		c.dropRejectedChannel(instID, channel)
+		log.Printf("Dropped channel %s of %s: %v", channel, instID, rejection)
+		return
+	}
+
+	c.subscribedMu.Lock()
+	_, tracked := c.subscribed[instID]
+	delete(c.subscribed, instID)
+	delete(c.confirmed, instID)
+	handler := c.subscribeErrorHandler
+	c.subscribedMu.Unlock()
+
+	if tracked && handler != nil {
+		handler(instID, rejection)
+	}
+}
+
+// dropRejectedChannel stops tracking one channel that OKEx rejected. For index-tickers,
+// instID is the index and the channel is dropped from every instrument tracking it.
+func (c *PublicClient) dropRejectedChannel(instID, channel string) {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+
+	for inst, channels := range c.subscribed {
+		if ackInstrument(inst, channel) != instID || !channels[channel] {
+			continue
+		}
+		delete(channels, channel)
+		if len(channels) == 0 {
+			delete(c.subscribed, inst)
+		}
+	}
+	delete(c.confirmed[instID], channel)
+	if len(c.confirmed[instID]) == 0 {
+		delete(c.confirmed, instID)
+	}
+}
@@ -0,0 +1,101 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+// handleSubscribeEvent records the subscribe and unsubscribe acknowledgements of OKEx, so
+// that subscriptions that were sent but never confirmed can be told apart
+func (c *PublicClient) handleSubscribeEvent(message []byte) {
+	if !bytes.Contains(message, []byte(`"event"`)) {
+		return
+	}
+
+	var event struct {
+		Event string `json:"event"`
+		Arg   struct {
+			Channel string `json:"channel"`
+			InstID  string `json:"instId"`
+		} `json:"arg"`
+	}
+	if err := json.Unmarshal(message, &event); err != nil || event.Arg.Channel == "" || event.Arg.InstID == "" {
+		return
+	}
+
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+
+	inst, channel := event.Arg.InstID, event.Arg.Channel
+	switch event.Event {
+	case "subscribe":
+		if c.confirmed[inst] == nil {
+			c.confirmed[inst] = make(map[string]bool)
+		}
+		c.confirmed[inst][channel] = true
+	case "unsubscribe":
+		delete(c.confirmed[inst], channel)
+		if len(c.confirmed[inst]) == 0 {
+			delete(c.confirmed, inst)
+		}
+	}
+}
+
+// resetConfirmed forgets all acknowledgements, e.g. on a new connection
+func (c *PublicClient) resetConfirmed() {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+	c.confirmed = make(map[string]map[string]bool)
+}
+
+// GetConfirmedSubscriptions returns the channels per instrument that OKEx acknowledged on the
+// current connection. index-tickers acknowledgements are listed under the index (e.g. BTC-USDT).
+func (c *PublicClient) GetConfirmedSubscriptions() map[string][]string {
+	c.subscribedMu.RLock()
+	defer c.subscribedMu.RUnlock()
+
+	result := make(map[string][]string, len(c.confirmed))
+	for inst, channels := range c.confirmed {
+		result[inst] = sortedKeys(channels)
+	}
+	return result
+}
+
+// GetUnconfirmedSubscriptions returns the channels per instrument that were sent but not yet
+// acknowledged by OKEx. Entries that stay here point to a bad symbol or a silent rejection.
+func (c *PublicClient) GetUnconfirmedSubscriptions() map[string][]string {
+	c.subscribedMu.RLock()
+	defer c.subscribedMu.RUnlock()
+
+	result := make(map[string][]string)
+	for inst, channels := range c.subscribed {
+		for _, channel := range sortedKeys(channels) {
+			if !c.confirmed[ackInstrument(inst, channel)][channel] {
+				result[inst] = append(result[inst], channel)
+			}
+		}
+	}
+	return result
+}
+
+// isConfirmed reports whether OKEx acknowledged every channel of an instrument.
+// Must be called with subscribedMu held.
+func (c *PublicClient) isConfirmed(inst string, channels map[string]bool) bool {
+	for channel := range channels {
+		if !c.confirmed[ackInstrument(inst, channel)][channel] {
+			return false
+		}
+	}
+	return true
+}
+
+// ackInstrument returns the instId OKEx acknowledges a channel of an instrument under
+func ackInstrument(inst, channel string) string {
+	if channel == config.IndexTickersChannel {
+		// Subscribed per index, see wireArgs
+		return IndexOf(inst)
+	}
+	return inst
+}
@@ -0,0 +1,114 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newAckingPublicClient connects a PublicClient to a server that acknowledges every
+// subscribe arg, rejects those of the instruments in reject and ignores those in silent
+func newAckingPublicClient(t *testing.T, reject, silent string) *PublicClient {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var frame struct {
+				Op   string              `json:"op"`
+				Args []map[string]string `json:"args"`
+			}
+			if err := conn.ReadJSON(&frame); err != nil {
+				return
+			}
+			for _, arg := range frame.Args {
+				switch arg["instId"] {
+				case silent:
+				case reject:
+					conn.WriteJSON(map[string]interface{}{
+						"event": "error", "code": "60012", "arg": arg,
+						"msg": "Invalid request: {\"op\": \"subscribe\"}",
+					})
+				default:
+					conn.WriteJSON(map[string]interface{}{"event": frame.Op, "arg": arg})
+				}
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPublicClient("ws"+strings.TrimPrefix(server.URL, "http"), func([]byte) error { return nil })
+	client.SetSubscribeInterval(0)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// waitSubscribed waits until GetSubscribed lists exactly want
+func waitSubscribed(t *testing.T, client *PublicClient, want ...string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := client.GetSubscribed()
+		sort.Strings(got)
+		if strings.Join(got, ",") == strings.Join(want, ",") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribed = %v, want %v", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOnlyAcknowledgedPairsAreSubscribed(t *testing.T) {
+	client := newAckingPublicClient(t, "BAD-USDT", "SLOW-USDT")
+
+	for _, pair := range []string{"BTC-USDT", "BAD-USDT", "SLOW-USDT"} {
+		if err := client.Subscribe([]string{pair}); err != nil {
+			t.Fatalf("subscribe %s: %v", pair, err)
+		}
+	}
+	waitSubscribed(t, client, "BTC-USDT")
+
+	// The unacknowledged pair is still requested, so it is not sent again, but it is not
+	// reported as subscribed
+	unconfirmed := client.GetUnconfirmedSubscriptions()
+	if len(unconfirmed["SLOW-USDT"]) == 0 {
+		t.Fatalf("unconfirmed = %v, want SLOW-USDT", unconfirmed)
+	}
+	if channels := client.GetSubscribedChannels()["BAD-USDT"]; len(channels) != 0 {
+		t.Fatalf("rejected pair still tracked with %v", channels)
+	}
+
+	if err := client.Unsubscribe([]string{"BTC-USDT"}); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	waitSubscribed(t, client)
+}
+
+func TestSubscribeWithoutConnectionTracksNothing(t *testing.T) {
+	client := NewPublicClient("ws://127.0.0.1:1", func([]byte) error { return nil })
+	if err := client.Subscribe([]string{"BTC-USDT"}); err == nil {
+		t.Fatal("subscribe without a connection succeeded")
+	}
+	if requested := client.GetRequestedInstruments(); len(requested) != 0 {
+		t.Fatalf("requested = %v after a failed subscribe", requested)
+	}
+	if subscribed := client.GetSubscribed(); len(subscribed) != 0 {
+		t.Fatalf("subscribed = %v after a failed subscribe", subscribed)
+	}
+}
@@ -0,0 +1,60 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultSubscribeInterval is the default minimum spacing of subscribe requests, see SetSubscribeInterval.
+// OKEx limits subscribe/unsubscribe/login requests to 3 per second per IP.
+const DefaultSubscribeInterval = 400 * time.Millisecond
+
+// subscribeThrottle enforces a minimum interval between consecutive subscribe requests of
+// a client, so a burst of reconnects and resubscribes does not trip OKEx's rate limits
+type subscribeThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration // 0 disables the throttle
+	last     time.Time     // send time of the previous request
+}
+
+// setInterval changes the spacing applied to subsequent requests
+func (t *subscribeThrottle) setInterval(interval time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interval = interval
+}
+
+// wait blocks until interval has passed since the previous request, then records the
+// current one. Callers are served one at a time, each spaced from the previous one.
+func (t *subscribeThrottle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if delay := time.Until(t.last.Add(t.interval)); t.interval > 0 && delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	t.last = time.Now()
+	return nil
+}
+
+// SetSubscribeInterval sets the minimum time between two subscribe requests; 0 disables it
+func (c *PublicClient) SetSubscribeInterval(interval time.Duration) {
+	c.throttle.setInterval(interval)
+}
+
+// SetSubscribeInterval sets the minimum time between two subscribe requests; 0 disables it
+func (c *BusinessClient) SetSubscribeInterval(interval time.Duration) {
+	c.throttle.setInterval(interval)
+}
+
+// SetSubscribeInterval sets the minimum time between two subscribe requests; 0 disables it
+func (c *PrivateClient) SetSubscribeInterval(interval time.Duration) {
+	c.throttle.setInterval(interval)
+}
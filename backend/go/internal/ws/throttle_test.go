@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// timedFrame is a request and when the test server received it
+type timedFrame struct {
+	op string
+	at time.Time
+}
+
+// timedServer records the op and arrival time of every request
+func timedServer(t *testing.T) (string, <-chan timedFrame) {
+	t.Helper()
+
+	frames := make(chan timedFrame, 100)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var frame wsFrame
+			if json.Unmarshal(data, &frame) == nil && frame.Op != "" {
+				frames <- timedFrame{op: frame.Op, at: time.Now()}
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), frames
+}
+
+func TestRapidSubscribesAreSpaced(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	url, frames := timedServer(t)
+	client := NewPublicClient(url, func([]byte) error { return nil })
+	client.SetSubscribeInterval(interval)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+
+	// A burst of five subscribes, as after several quick reconnects
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Subscribe([]string{fmt.Sprintf("COIN%d-USDT", i)}); err != nil {
+				t.Errorf("subscribe %d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var times []time.Time
+	for len(times) < 5 {
+		select {
+		case frame := <-frames:
+			times = append(times, frame.at)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d of 5 subscribes", len(times))
+		}
+	}
+	// Allow some network jitter on the receiving side
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < interval*8/10 {
+			t.Errorf("subscribes %d and %d arrived %v apart, want at least %v", i-1, i, gap, interval)
+		}
+	}
+
+	// Unsubscribes are not throttled
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := client.Unsubscribe([]string{fmt.Sprintf("COIN%d-USDT", i)}); err != nil {
+			t.Fatalf("unsubscribe: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > interval {
+		t.Fatalf("three unsubscribes took %v", elapsed)
+	}
+}
+
+func TestSubscribeWaitEndsOnClose(t *testing.T) {
+	url, _ := timedServer(t)
+	client := NewPublicClient(url, func([]byte) error { return nil })
+	client.SetSubscribeInterval(time.Hour)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := client.Subscribe([]string{"BTC-USDT"}); err != nil {
+		t.Fatalf("first subscribe: %v", err)
+	}
+
+	// The second subscribe would wait an hour; closing the client releases it
+	done := make(chan error, 1)
+	go func() { done <- client.Subscribe([]string{"ETH-USDT"}) }()
+	time.Sleep(50 * time.Millisecond)
+	client.Close()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "interrupted") {
+			t.Fatalf("subscribe after close = %v, want it interrupted", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscribe still waiting after close")
+	}
+
+	// Without an interval the throttle never waits, even on a canceled context
+	var throttle subscribeThrottle
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		if err := throttle.wait(ctx); err != nil {
+			t.Fatalf("unthrottled wait %d: %v", i, err)
+		}
+	}
+}
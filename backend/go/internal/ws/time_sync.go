@@ -8,9 +8,39 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/net/proxy"
 )
 
+// DefaultTimeSyncInterval is how often a logged-in client re-syncs the server time offset
+const DefaultTimeSyncInterval = 5 * time.Minute
+
+// serverTimeURL is the OKEx public endpoint returning the server time
+var serverTimeURL = "https://www.okx.com/api/v5/public/time"
+
+// serverClock holds one client's offset from the OKEx server time. Each client syncs its own,
+// so one client's sync never changes the timestamps another client signs with.
+type serverClock struct {
+	offset int64 // ms, accessed atomically
+}
+
+// Offset returns the last synced server time offset in ms
+func (s *serverClock) Offset() int64 {
+	return atomic.LoadInt64(&s.offset)
+}
+
+// SetOffset records a freshly synced server time offset in ms
+func (s *serverClock) SetOffset(offset int64) {
+	atomic.StoreInt64(&s.offset, offset)
+}
+
+// TimestampSeconds returns the local time corrected by the server offset, in unix seconds
+func (s *serverClock) TimestampSeconds() string {
+	return strconv.FormatInt((time.Now().UnixMilli()+s.Offset())/1000, 10)
+}
+
 // ServerTimeResponse represents OKEx server time response
 type ServerTimeResponse struct {
 	Code string `json:"code"`
@@ -20,17 +50,42 @@ type ServerTimeResponse struct {
 	} `json:"data"`
 }
 
-// GetServerTime fetches current server time from OKEx with optional HTTP proxy
-func GetServerTime(proxyAddr string) (int64, error) {
+// timeSyncTransport builds the transport for the time sync request: through the HTTP proxy
+// when httpProxyAddr is set, otherwise dialing over the SOCKS5 proxy when socksProxyAddr is
+// set, so a single SOCKS5 config covers both the WebSocket and the time sync
+func timeSyncTransport(httpProxyAddr, socksProxyAddr string) (*http.Transport, error) {
 	transport := &http.Transport{}
 
-	if proxyAddr != "" {
-		proxyURL, err := url.Parse("http://" + proxyAddr)
+	switch {
+	case httpProxyAddr != "":
+		proxyURL, err := url.Parse("http://" + httpProxyAddr)
 		if err != nil {
-			return 0, fmt.Errorf("failed to parse proxy URL: %w", err)
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
 		}
 		transport.Proxy = http.ProxyURL(proxyURL)
-		log.Printf("Using HTTP proxy for time sync: %s", proxyAddr)
+		log.Printf("Using HTTP proxy for time sync: %s", httpProxyAddr)
+	case socksProxyAddr != "":
+		proxyDialer, err := proxy.SOCKS5("tcp", socksProxyAddr, nil, proxy.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SOCKS5 proxy: %w", err)
+		}
+		contextDialer, ok := proxyDialer.(proxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("SOCKS5 dialer does not support contexts")
+		}
+		transport.DialContext = contextDialer.DialContext
+		log.Printf("Using SOCKS5 proxy for time sync: %s", socksProxyAddr)
+	}
+
+	return transport, nil
+}
+
+// GetServerTime fetches current server time from OKEx, through the HTTP proxy if set,
+// else through the SOCKS5 proxy if set, else directly
+func GetServerTime(httpProxyAddr, socksProxyAddr string) (int64, error) {
+	transport, err := timeSyncTransport(httpProxyAddr, socksProxyAddr)
+	if err != nil {
+		return 0, err
 	}
 
 	client := &http.Client{
@@ -38,7 +93,7 @@ func GetServerTime(proxyAddr string) (int64, error) {
 		Transport: transport,
 	}
 
-	resp, err := client.Get("https://www.okx.com/api/v5/public/time")
+	resp, err := client.Get(serverTimeURL)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch server time: %w", err)
 	}
@@ -71,9 +126,9 @@ func GetServerTime(proxyAddr string) (int64, error) {
 	return serverTimeMs, nil
 }
 
-// SyncServerTime calculates time offset from server with optional HTTP proxy
-func SyncServerTime(proxyAddr string) (int64, error) {
-	serverTime, err := GetServerTime(proxyAddr)
+// SyncServerTime calculates time offset from server, see GetServerTime for the proxies
+func SyncServerTime(httpProxyAddr, socksProxyAddr string) (int64, error) {
+	serverTime, err := GetServerTime(httpProxyAddr, socksProxyAddr)
 	if err != nil {
 		return 0, err
 	}
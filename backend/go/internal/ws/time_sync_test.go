@@ -0,0 +1,230 @@
+package ws
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	fakeTimeOnce  sync.Once
+	fakeTimeAhead int64 // ms, accessed atomically
+)
+
+// fakeServerTime points the time sync at a local OKEx time endpoint running ahead of the
+// local clock by the returned offset, in ms. It stays installed for the rest of the package
+// tests, since a stopped client's last sync may still be reading serverTimeURL.
+func fakeServerTime() *int64 {
+	fakeTimeOnce.Do(func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ts := time.Now().UnixMilli() + atomic.LoadInt64(&fakeTimeAhead)
+			fmt.Fprintf(w, `{"code":"0","msg":"","data":[{"ts":"%d"}]}`, ts)
+		}))
+		serverTimeURL = server.URL
+	})
+	return &fakeTimeAhead
+}
+
+// near reports whether an offset matches want within the request round trip
+func near(offset, want int64) bool {
+	return offset >= want-200 && offset <= want+200
+}
+
+func TestPeriodicTimeSyncUpdatesOffset(t *testing.T) {
+	ahead := fakeServerTime()
+	atomic.StoreInt64(ahead, 5000)
+
+	url, _ := newLoginServer(t, "0")
+	client := NewPrivateClient(url, func([]byte) error { return nil }, OKExConfig{APIKey: "key", SecretKey: "secret", Passphrase: "pass"})
+	client.SetTimeSyncInterval(10 * time.Millisecond)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.Login(); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if offset := client.clock.Offset(); !near(offset, 5000) {
+		t.Fatalf("offset after login %d ms, want about 5000", offset)
+	}
+
+	// The local clock drifts; the next background sync picks it up without a re-login
+	atomic.StoreInt64(ahead, -3000)
+
+	// Signing reads the offset while the background sync writes it
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					if _, err := strconv.ParseInt(client.clock.TimestampSeconds(), 10, 64); err != nil {
+						t.Errorf("timestamp: %v", err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !near(client.clock.Offset(), -3000) {
+		if time.Now().After(deadline) {
+			close(stop)
+			readers.Wait()
+			t.Fatalf("offset still %d ms, want about -3000 after a periodic sync", client.clock.Offset())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	readers.Wait()
+
+	local := time.Now().Unix()
+	ts, _ := strconv.ParseInt(client.clock.TimestampSeconds(), 10, 64)
+	if diff := local - ts; diff < 2 || diff > 4 {
+		t.Fatalf("signed timestamp %d is %ds behind local %d, want about 3", ts, diff, local)
+	}
+}
+
+func TestTimeSyncFailureKeepsOffset(t *testing.T) {
+	client := NewPrivateClientWithDualProxy("ws://unused", func([]byte) error { return nil }, false, "", refusingProxy(t), OKExConfig{})
+	client.clock.SetOffset(1234)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.runTimeSync(5 * time.Millisecond)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	client.Close()
+	<-done
+
+	if offset := client.clock.Offset(); offset != 1234 {
+		t.Fatalf("offset %d ms after failed syncs, want the last good 1234", offset)
+	}
+}
+
+// socks5Server is a minimal no-auth SOCKS5 proxy that records the CONNECT targets it relays
+type socks5Server struct {
+	addr    string
+	mu      sync.Mutex
+	targets []string
+}
+
+func newSOCKS5Server(t *testing.T) *socks5Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &socks5Server{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.relay(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socks5Server) relay(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, method count, methods; answer "no authentication"
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 0})
+
+	// CONNECT request: version, command, reserved, address type, address, port
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		length := make([]byte, 1)
+		io.ReadFull(conn, length)
+		name := make([]byte, length[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+	target := net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1])))
+
+	s.mu.Lock()
+	s.targets = append(s.targets, target)
+	s.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func (s *socks5Server) Targets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.targets...)
+}
+
+func TestTimeSyncFallsBackToSOCKS5(t *testing.T) {
+	ahead := fakeServerTime()
+	atomic.StoreInt64(ahead, 3000)
+	timeHost := strings.TrimPrefix(serverTimeURL, "http://")
+	socks := newSOCKS5Server(t)
+
+	serverTime, err := GetServerTime("", socks.addr)
+	if err != nil {
+		t.Fatalf("time sync over SOCKS5: %v", err)
+	}
+	if offset := serverTime - time.Now().UnixMilli(); !near(offset, 3000) {
+		t.Fatalf("offset %dms, want about 3000ms", offset)
+	}
+	if targets := socks.Targets(); len(targets) != 1 || targets[0] != timeHost {
+		t.Fatalf("SOCKS5 proxy relayed %v, want one connection to %s", targets, timeHost)
+	}
+
+	// An HTTP proxy, when set, takes precedence and the SOCKS5 proxy is not dialed
+	if _, err := GetServerTime(refusingProxy(t), socks.addr); err == nil {
+		t.Fatal("time sync through the refusing HTTP proxy succeeded")
+	}
+	if n := len(socks.Targets()); n != 1 {
+		t.Fatalf("SOCKS5 proxy saw %d connections with an HTTP proxy configured, want no new one", n)
+	}
+}
@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultWriteTimeout bounds a single WebSocket write, see SetWriteTimeout
+const DefaultWriteTimeout = 10 * time.Second
+
+// errNotConnected is returned when writing before Connect or after Close
+var errNotConnected = errors.New("websocket not connected")
+
+// connWriter serializes every write on a client's connection (gorilla allows only one
+// concurrent writer) and bounds each with a write deadline, so a stuck TCP send fails
+// with a timeout instead of blocking subscribe, ping and order paths forever
+type connWriter struct {
+	mu      sync.Mutex
+	timeout time.Duration // 0 disables the deadline
+}
+
+// setTimeout changes the deadline applied to subsequent writes
+func (w *connWriter) setTimeout(timeout time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timeout = timeout
+}
+
+// write sends one message on conn. A write that hits the deadline leaves the connection
+// unusable; the read loop then fails as well and triggers the reconnect.
+func (w *connWriter) write(conn *websocket.Conn, messageType int, data []byte) error {
+	if conn == nil {
+		return errNotConnected
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timeout > 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+			return err
+		}
+	}
+	return conn.WriteMessage(messageType, data)
+}
+
+// SetWriteTimeout sets the deadline of every write on the connection; 0 disables it
+func (c *PublicClient) SetWriteTimeout(timeout time.Duration) {
+	c.writer.setTimeout(timeout)
+}
+
+// SetWriteTimeout sets the deadline of every write on the connection; 0 disables it
+func (c *BusinessClient) SetWriteTimeout(timeout time.Duration) {
+	c.writer.setTimeout(timeout)
+}
+
+// SetWriteTimeout sets the deadline of every write on the connection; 0 disables it
+func (c *PrivateClient) SetWriteTimeout(timeout time.Duration) {
+	c.writer.setTimeout(timeout)
+}
+
+// writeText sends a text frame on the current connection without holding c.mu
+func (c *PublicClient) writeText(data []byte) error {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	return c.writer.write(conn, websocket.TextMessage, data)
+}
+
+// writeText sends a text frame on the current connection without holding c.mu
+func (c *BusinessClient) writeText(data []byte) error {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	return c.writer.write(conn, websocket.TextMessage, data)
+}
+
+// writeText sends a text frame on the current connection without holding c.mu
+func (c *PrivateClient) writeText(data []byte) error {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	return c.writer.write(conn, websocket.TextMessage, data)
+}
@@ -0,0 +1,81 @@
+package ws
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// stalledServer accepts a WebSocket connection and then never reads from it, so the
+// client's writes pile up in the socket buffers until they block
+func stalledServer(t *testing.T) string {
+	t.Helper()
+
+	release := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestWritesToStalledServerTimeOut(t *testing.T) {
+	client := NewPublicClient(stalledServer(t), func([]byte) error { return nil })
+	client.SetSubscribeInterval(0)
+	client.SetWriteTimeout(200 * time.Millisecond)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+
+	// Fill the socket buffers; without a deadline one of these writes blocks forever
+	payload := make([]byte, 1<<20)
+	start := time.Now()
+	var err error
+	for i := 0; i < 256 && err == nil; i++ {
+		err = client.writeText(payload)
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("write to a stalled server returned %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("writes took %v to time out", elapsed)
+	}
+
+	// Concurrent subscribes queue on the one writer and fail too, each within its deadline
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	start = time.Now()
+	for _, instID := range []string{"BTC-USDT", "ETH-USDT", "SOL-USDT"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.Subscribe([]string{instID})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err == nil {
+			t.Fatal("subscribe on a connection whose write timed out succeeded")
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("subscribes took %v after the timeout", elapsed)
+	}
+}
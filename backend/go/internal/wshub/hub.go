@@ -5,6 +5,7 @@ package wshub
 它确保了前端监控界面能够实时接收最新的订单簿分析结果，同时支持动态订阅和资源优化。
 */
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/supermancell/okex-buddy/internal/config"
 )
 
 // Message types sent to clients
@@ -31,6 +33,9 @@ type Message struct {
 	Data         map[string]interface{} `json:"data,omitempty"`
 	Error        string                 `json:"error,omitempty"`
 	Timestamp    int64                  `json:"timestamp"`
+
+	// SchemaVersion is config.SchemaVersion on analysis_update messages, see there
+	SchemaVersion int `json:"schema_version,omitempty"`
 }
 
 // Client represents a WebSocket client connection
@@ -49,15 +54,46 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
+
+	closing  bool          // set by Shutdown, rejects new registrations
+	quit     chan struct{} // closed by Shutdown to stop Run
+	quitOnce sync.Once
+	done     chan struct{}  // closed when Run returns
+	writers  sync.WaitGroup // tracks client writePumps so Shutdown can wait for close frames
+
+	upgrader websocket.Upgrader // used by ServeWs, see HubOptions
 }
 
-// NewHub creates a new WebSocket hub
+// HubOptions configures the WebSocket upgrade of client connections; zero values keep
+// gorilla's defaults (4 KB buffers, no handshake timeout)
+type HubOptions struct {
+	ReadBufferSize   int           // I/O read buffer per connection in bytes
+	WriteBufferSize  int           // I/O write buffer per connection in bytes
+	HandshakeTimeout time.Duration // upper bound of the upgrade handshake
+}
+
+// NewHub creates a new WebSocket hub with the default upgrader options
 func NewHub() *Hub {
+	return NewHubWithOptions(HubOptions{})
+}
+
+// NewHubWithOptions creates a new WebSocket hub whose upgrader uses opts
+func NewHubWithOptions(opts HubOptions) *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan []byte, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:   opts.ReadBufferSize,
+			WriteBufferSize:  opts.WriteBufferSize,
+			HandshakeTimeout: opts.HandshakeTimeout,
+			CheckOrigin: func(r *http.Request) bool {
+				return true // Allow all origins in development
+			},
+		},
 	}
 }
 
@@ -72,10 +108,14 @@ Run() 函数启动主循环：
 func (h *Hub) Run() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
+	defer close(h.done)
 
 	for {
 		select {
 		case client := <-h.register:
+			// Counted here rather than in ServeWs, so every Add happens before done is
+			// closed and Shutdown's Wait never races with it
+			h.writers.Add(1)
 			h.mu.Lock()
 			h.clients[client] = true
 			h.mu.Unlock()
@@ -118,17 +158,75 @@ func (h *Hub) Run() {
 				}
 			}
 			h.mu.RUnlock()
+
+		case <-h.quit:
+			h.drain()
+			return
 		}
 	}
 }
 
+// drain delivers pending broadcasts, then closes every client's send channel so that
+// writePump sends a close frame and exits
+func (h *Hub) drain() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for {
+		select {
+		case message := <-h.broadcast:
+			for client := range h.clients {
+				select {
+				case client.send <- message:
+				default:
+				}
+			}
+			continue
+		default:
+		}
+		break
+	}
+
+	for client := range h.clients {
+		close(client.send)
+		delete(h.clients, client)
+	}
+	log.Println("WebSocket hub drained, all clients closed")
+}
+
+// Shutdown stops accepting new clients, sends a close frame to all connected clients,
+// drains the broadcast channel and waits for Run to return.
+// Returns ctx.Err() if ctx expires first.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closing = true
+	h.mu.Unlock()
+
+	h.quitOnce.Do(func() { close(h.quit) })
+
+	finished := make(chan struct{})
+	go func() {
+		<-h.done
+		h.writers.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // BroadcastAnalysisUpdate sends analysis update to all subscribed clients
 func (h *Hub) BroadcastAnalysisUpdate(instrumentID string, data map[string]interface{}) {
 	msg := Message{
-		Type:         MessageTypeAnalysisUpdate,
-		InstrumentID: instrumentID,
-		Data:         data,
-		Timestamp:    time.Now().Unix(),
+		Type:          MessageTypeAnalysisUpdate,
+		InstrumentID:  instrumentID,
+		Data:          data,
+		Timestamp:     time.Now().Unix(),
+		SchemaVersion: config.SchemaVersion,
 	}
 
 	jsonData, err := json.Marshal(msg)
@@ -155,6 +253,16 @@ func (h *Hub) BroadcastAnalysisUpdate(instrumentID string, data map[string]inter
 	}
 }
 
+// BroadcastAnalysis sends a typed analysis update to all subscribed clients
+func (h *Hub) BroadcastAnalysis(instrumentID string, data AnalysisUpdateData) {
+	payload, err := data.ToMap()
+	if err != nil {
+		log.Printf("Failed to encode analysis update: %v", err)
+		return
+	}
+	h.BroadcastAnalysisUpdate(instrumentID, payload)
+}
+
 // Subscribe adds instrument to client's subscription list
 func (c *Client) Subscribe(instrumentID string) {
 	c.mu.Lock()
@@ -182,7 +290,11 @@ readPump() 函数负责从 WebSocket 连接读取消息：
 */
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+			// Hub already stopped and closed the send channel
+		}
 		c.conn.Close()
 	}()
 
@@ -235,6 +347,7 @@ func (c *Client) writePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.hub.writers.Done()
 	}()
 
 	for {
@@ -262,13 +375,15 @@ func (c *Client) writePump() {
 
 // ServeWs handles WebSocket upgrade and client management
 func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request) {
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true // Allow all origins in development
-		},
+	h.mu.RLock()
+	closing := h.closing
+	h.mu.RUnlock()
+	if closing {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
 		return
@@ -281,9 +396,14 @@ func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request) {
 		subscribed: make(map[string]bool),
 	}
 
-	h.register <- client
+	select {
+	case h.register <- client:
+	case <-h.done:
+		conn.Close()
+		return
+	}
 
-	// Start read and write pumps in separate goroutines
+	// Start read and write pumps in separate goroutines; Run counted the writer
 	go client.writePump()
 	go client.readPump()
 }
@@ -0,0 +1,86 @@
+package wshub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestShutdownClosesClientsAndStopsRun(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	server := httptest.NewServer(http.HandlerFunc(hub.ServeWs))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	var conns []*websocket.Conn
+	for i := 0; i < 3; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("dial client %d: %v", i, err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.mu.RLock()
+		registered := len(hub.clients)
+		hub.mu.RUnlock()
+		if registered == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of 3 clients registered", registered)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A broadcast still queued at shutdown is delivered before the close frame
+	hub.broadcast <- []byte(`{"type":"analysis_update","instrument_id":"BTC-USDT"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	select {
+	case <-hub.done:
+	default:
+		t.Fatal("Run still running after Shutdown returned")
+	}
+	if len(hub.clients) != 0 {
+		t.Fatalf("%d clients left registered", len(hub.clients))
+	}
+
+	for i, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, message, err := conn.ReadMessage()
+		if err != nil || !strings.Contains(string(message), "analysis_update") {
+			t.Fatalf("client %d: message %q, err %v, want the pending broadcast", i, message, err)
+		}
+		if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNoStatusReceived) {
+			t.Fatalf("client %d: err = %v, want a close frame", i, err)
+		}
+	}
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("connection after shutdown: resp %v, err %v, want 503", resp, err)
+	}
+}
+
+func TestShutdownRespectsContext(t *testing.T) {
+	// Run was never started, so it can't finish
+	hub := NewHub()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := hub.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,52 @@
+package wshub
+
+import (
+	"encoding/json"
+
+	"github.com/supermancell/okex-buddy/internal/orderbook"
+)
+
+// AnalysisUpdateData is the Data payload of an analysis_update message.
+// Fields that were not computed in the current round are omitted.
+type AnalysisUpdateData struct {
+	Supports          []float64                      `json:"supports,omitempty"`
+	Resistances       []float64                      `json:"resistances,omitempty"`
+	Spread            *float64                       `json:"spread,omitempty"`
+	SpreadZScore      *float64                       `json:"spread_z_score,omitempty"`
+	LargeBuyNotional  *float64                       `json:"large_buy_notional,omitempty"`
+	LargeSellNotional *float64                       `json:"large_sell_notional,omitempty"`
+	Sentiment         *float64                       `json:"sentiment,omitempty"`
+	DepthAnomaly      *orderbook.DepthAnomalyData    `json:"depth_anomaly,omitempty"`
+	LiquidityShrink   *orderbook.LiquidityShrinkData `json:"liquidity_shrink,omitempty"`
+	OFI               *orderbook.OFIData             `json:"ofi,omitempty"`
+	Icebergs          []orderbook.IcebergCandidate   `json:"icebergs,omitempty"`
+}
+
+// ToMap converts the payload to the generic Message.Data map
+func (d AnalysisUpdateData) ToMap() (map[string]interface{}, error) {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MessageTypes lists every message type exchanged over the hub, with the Go type of its
+// Data payload (nil when the message carries no data). Used to generate the API spec.
+var MessageTypes = []struct {
+	Type      string
+	Direction string // "server" (hub -> client) or "client" (client -> hub)
+	Data      interface{}
+}{
+	{Type: MessageTypeAnalysisUpdate, Direction: "server", Data: AnalysisUpdateData{}},
+	{Type: MessageTypePing, Direction: "server"},
+	{Type: MessageTypeError, Direction: "server"},
+	{Type: MessageTypePong, Direction: "client"},
+	{Type: MessageTypeSubscribe, Direction: "client"},
+	{Type: MessageTypeUnsubscribe, Direction: "client"},
+}
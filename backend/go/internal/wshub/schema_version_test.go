@@ -0,0 +1,42 @@
+package wshub
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestAnalysisUpdateCarriesSchemaVersion(t *testing.T) {
+	hub := NewHub()
+	client := &Client{hub: hub, send: make(chan []byte, 1), subscribed: map[string]bool{}}
+	client.Subscribe("ETH-USDT")
+	hub.clients[client] = true
+
+	sentiment := 0.25
+	hub.BroadcastAnalysis("ETH-USDT", AnalysisUpdateData{Sentiment: &sentiment})
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(<-client.send, &msg); err != nil {
+		t.Fatalf("decode update: %v", err)
+	}
+	if msg["type"] != MessageTypeAnalysisUpdate {
+		t.Fatalf("type = %v, want %s", msg["type"], MessageTypeAnalysisUpdate)
+	}
+	if version, ok := msg["schema_version"].(float64); !ok || int(version) != config.SchemaVersion {
+		t.Fatalf("schema_version = %v, want %d", msg["schema_version"], config.SchemaVersion)
+	}
+
+	// Control messages are not versioned analysis payloads
+	raw, err := json.Marshal(Message{Type: MessageTypeSubscribe, InstrumentID: "ETH-USDT"})
+	if err != nil {
+		t.Fatalf("marshal subscribe: %v", err)
+	}
+	var control map[string]interface{}
+	if err := json.Unmarshal(raw, &control); err != nil {
+		t.Fatalf("decode subscribe: %v", err)
+	}
+	if _, ok := control["schema_version"]; ok {
+		t.Fatalf("subscribe message carries schema_version: %s", raw)
+	}
+}
@@ -0,0 +1,94 @@
+package wshub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/supermancell/okex-buddy/internal/config"
+)
+
+func TestHubUpgraderUsesConfiguredOptions(t *testing.T) {
+	t.Setenv("WSHUB_READ_BUFFER_SIZE", "1024")
+	t.Setenv("WSHUB_WRITE_BUFFER_SIZE", "16384")
+	t.Setenv("WSHUB_HANDSHAKE_TIMEOUT_MS", "2500")
+	cfg := config.LoadFromEnv().WSHub
+
+	// Wired the way main builds the hub
+	hub := NewHubWithOptions(HubOptions{
+		ReadBufferSize:   cfg.ReadBufferSize,
+		WriteBufferSize:  cfg.WriteBufferSize,
+		HandshakeTimeout: time.Duration(cfg.HandshakeTimeoutMs) * time.Millisecond,
+	})
+	if u := hub.upgrader; u.ReadBufferSize != 1024 || u.WriteBufferSize != 16384 || u.HandshakeTimeout != 2500*time.Millisecond {
+		t.Fatalf("upgrader buffers %d/%d, handshake timeout %v; want 1024/16384 and 2.5s",
+			u.ReadBufferSize, u.WriteBufferSize, u.HandshakeTimeout)
+	}
+	if hub.upgrader.CheckOrigin == nil {
+		t.Fatal("configured upgrader lost the origin check")
+	}
+
+	// NewHub keeps gorilla's defaults
+	if u := NewHub().upgrader; u.ReadBufferSize != 0 || u.WriteBufferSize != 0 || u.HandshakeTimeout != 0 {
+		t.Fatalf("default upgrader %d/%d %v, want gorilla's defaults", u.ReadBufferSize, u.WriteBufferSize, u.HandshakeTimeout)
+	}
+}
+
+func TestSmallBuffersCarryLargeMessages(t *testing.T) {
+	// Buffers far smaller than the messages in both directions
+	hub := NewHubWithOptions(HubOptions{ReadBufferSize: 64, WriteBufferSize: 64, HandshakeTimeout: time.Second})
+	go hub.Run()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		hub.Shutdown(ctx)
+	}()
+	server := httptest.NewServer(http.HandlerFunc(hub.ServeWs))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	instID := "BTC-USDT-" + strings.Repeat("X", 200)
+	if err := conn.WriteJSON(Message{Type: MessageTypeSubscribe, InstrumentID: instID}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !subscribedTo(hub, instID) {
+		if time.Now().After(deadline) {
+			t.Fatal("the 200+ byte subscribe never reached the hub")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	payload := fmt.Sprintf(`{"type":"analysis_update","data":"%s"}`, strings.Repeat("y", 10000))
+	hub.broadcast <- []byte(payload)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, message, err := conn.ReadMessage(); err != nil || string(message) != payload {
+		t.Fatalf("broadcast of %d bytes: got %d bytes, err %v", len(payload), len(message), err)
+	}
+}
+
+// subscribedTo reports whether any client of hub subscribed to instID
+func subscribedTo(hub *Hub, instID string) bool {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	for client := range hub.clients {
+		client.mu.RLock()
+		ok := client.subscribed[instID]
+		client.mu.RUnlock()
+		if ok {
+			return true
+		}
+	}
+	return false
+}